@@ -45,8 +45,18 @@ func main() {
 		log.Println("Example: API_KEYS=key1,key2,key3")
 	}
 
+	// Admin keys may also create and edit teams
+	adminKeys := os.Getenv("ADMIN_API_KEYS")
+	if adminKeys == "" {
+		log.Println("Admin endpoints disabled. Set ADMIN_API_KEYS to enable them.")
+		log.Println("Example: ADMIN_API_KEYS=alice:key1,bob:key2")
+	}
+
 	// Create API server
-	server := api.NewServer(db, apiKeys)
+	server, err := api.NewServer(db, apiKeys, adminKeys)
+	if err != nil {
+		log.Fatalf("Failed to create API server: %v", err)
+	}
 
 	// Get port from environment or use default
 	port := os.Getenv("PORT")
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/dothanhlam/go-github-tracker/internal/collector"
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/dothanhlam/go-github-tracker/internal/jobs"
+)
+
+// deadlineMargin is how long before the Lambda deadline collection stops,
+// leaving time for the request in flight and the response
+const deadlineMargin = 30 * time.Second
+
+// Event actions
+const (
+	ActionCollect = "collect" // Collect the repositories in this invocation (default)
+	ActionPlan    = "plan"    // Queue a collection job per repository (and window)
+	ActionWork    = "work"    // Run queued jobs until none is ready or the deadline
+)
+
+// Event is the payload the collector is invoked with: the options themselves
+// (manual invocations), or an EventBridge event carrying them in its detail
+type Event struct {
+	Action       string   `json:"action"`       // ActionCollect if empty
+	Repositories []string `json:"repositories"` // Tracked names; all active repositories if empty
+	Since        string   `json:"since"`        // Backfill start, YYYY-MM-DD or RFC 3339
+	Until        string   `json:"until"`        // Backfill end; a date includes the whole day
+	WindowDays   int      `json:"window_days"`  // Days per planned backfill job; one job per repository if 0
+	DryRun       bool     `json:"dry_run"`
+	Migrate      bool     `json:"migrate"` // Run database migrations first
+
+	// Detail is set on EventBridge events; scheduled ones have an empty one
+	Detail *Event `json:"detail"`
+}
+
+// Response is what an invocation returns
+type Response struct {
+	*collector.Result                  // Collect
+	Migrated          bool             `json:"migrated"`
+	Planned           *int             `json:"planned,omitempty"` // Plan: jobs added
+	Worked            *jobs.WorkResult `json:"worked,omitempty"`  // Work: jobs run
+	Jobs              map[string]int   `json:"jobs,omitempty"`    // Plan and work: jobs in each status
+}
+
+// request returns the event carrying the options: the event itself, or an
+// EventBridge event's detail
+func (e Event) request() Event {
+	if e.Detail != nil {
+		return e.Detail.request()
+	}
+	return e
+}
+
+// options returns the run options an event asks for, and whether it asks for migrations
+func (e Event) options() (collector.RunOptions, bool, error) {
+	e = e.request()
+	switch e.Action {
+	case "", ActionCollect:
+	case ActionPlan, ActionWork:
+		if e.DryRun {
+			return collector.RunOptions{}, false, fmt.Errorf("dry_run only applies to the %s action", ActionCollect)
+		}
+	default:
+		return collector.RunOptions{}, false, fmt.Errorf("action must be '%s', '%s' or '%s', got: %s", ActionCollect, ActionPlan, ActionWork, e.Action)
+	}
+	if e.WindowDays < 0 {
+		return collector.RunOptions{}, false, fmt.Errorf("window_days must not be negative, got: %d", e.WindowDays)
+	}
+
+	options := collector.RunOptions{Repositories: e.Repositories, DryRun: e.DryRun}
+	if e.Since != "" {
+		since, err := parseEventTime(e.Since, false)
+		if err != nil {
+			return options, false, fmt.Errorf("invalid since: %w", err)
+		}
+		options.Since = &since
+	}
+	if e.Until != "" {
+		until, err := parseEventTime(e.Until, true)
+		if err != nil {
+			return options, false, fmt.Errorf("invalid until: %w", err)
+		}
+		options.Until = &until
+	}
+	if options.Since != nil && options.Until != nil && options.Until.Before(*options.Since) {
+		return options, false, fmt.Errorf("until (%s) is before since (%s)", e.Until, e.Since)
+	}
+	return options, e.Migrate, nil
+}
+
+// parseEventTime parses an RFC 3339 time or a date, which is taken as the
+// start of the day (UTC), or its last moment for endOfDay
+func parseEventTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%s' is neither a date (YYYY-MM-DD) nor an RFC 3339 time", value)
+	}
+	if endOfDay {
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return day, nil
+}
+
+// lambdaHandler handles invocations. The configuration and database
+// connection are set up on the first one and reused by later ones.
+type lambdaHandler struct {
+	cfg *config.Config
+	db  *database.DB
+}
+
+// Handle collects, plans jobs or runs them as the event asks, stopping
+// deadlineMargin before the invocation's deadline
+func (h *lambdaHandler) Handle(ctx context.Context, event Event) (*Response, error) {
+	request := event.request()
+	options, migrate, err := event.options()
+	if err != nil {
+		return nil, err
+	}
+	if err := h.connect(); err != nil {
+		return nil, err
+	}
+
+	response := &Response{}
+	if migrate {
+		fmt.Println("📦 Running database migrations...")
+		if err := h.db.RunMigrations(); err != nil {
+			return nil, fmt.Errorf("failed to run migrations: %w", err)
+		}
+		response.Migrated = true
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-deadlineMargin))
+		defer cancel()
+	}
+
+	// Dry runs don't sync teams or repositories, so they leave the database untouched
+	newCollector := collector.New
+	if options.DryRun {
+		newCollector = collector.NewDryRun
+	}
+	c, err := newCollector(h.cfg, h.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create collector: %w", err)
+	}
+	if request.Action == "" || request.Action == ActionCollect {
+		response.Result, err = c.RunContext(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		return response, nil
+	}
+
+	queue := jobs.NewQueue(h.db, time.Duration(h.cfg.JobLeaseSeconds)*time.Second, h.cfg.JobMaxAttempts)
+	if request.Action == ActionPlan {
+		repos, err := c.Repositories(options.Repositories)
+		if err != nil {
+			return nil, err
+		}
+		planned, err := queue.Plan(repos, options.Since, options.Until, request.WindowDays)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("🗓️  Queued %d collection jobs for %d repositories\n", planned, len(repos))
+		response.Planned = &planned
+	} else {
+		worked, err := jobs.NewWorker(queue, workerID(ctx), collectJob(c)).Work(ctx)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("✅ Jobs done: %d succeeded, %d retried, %d dead, %d released\n", worked.Succeeded, worked.Retried, worked.Dead, worked.Released)
+		response.Worked = &worked
+	}
+
+	if response.Jobs, err = queue.Counts(); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// collectJob returns a job RunFunc collecting the job's repository, over its
+// window if it has one
+func collectJob(c *collector.Collector) jobs.RunFunc {
+	return func(ctx context.Context, job *database.CollectionJob) error {
+		result, err := c.RunContext(ctx, collector.RunOptions{
+			Repositories: []string{job.Repository},
+			Since:        job.WindowStart,
+			Until:        job.WindowEnd,
+		})
+		if err != nil {
+			return err
+		}
+		if result.TimedOut {
+			return jobs.ErrInterrupted
+		}
+		// Another run holds the repository; retry the window once it is done
+		for _, repo := range result.Repositories {
+			if repo.Status == collector.StatusLocked {
+				return errors.New(repo.Error)
+			}
+		}
+		return nil
+	}
+}
+
+// workerID identifies a job worker: by its Lambda request, or by host and
+// process when run locally
+func workerID(ctx context.Context) string {
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		return lc.AwsRequestID
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// connect loads the configuration and connects to the database, unless
+// an earlier invocation already did
+func (h *lambdaHandler) connect() error {
+	if h.db != nil {
+		return nil
+	}
+	if h.cfg == nil {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		h.cfg = cfg
+	}
+	db, err := database.Connect(h.cfg.DBDriver, h.cfg.DBURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	h.db = db
+	return nil
+}
+
+// invoke runs the Lambda handler locally with a JSON event read from a file,
+// or from stdin without one, and prints the response.
+// Usage: collector invoke [-timeout 15m] [event.json]
+func invoke(args []string) int {
+	flags := flag.NewFlagSet("invoke", flag.ContinueOnError)
+	timeout := flags.Duration("timeout", 0, "deadline to run with, like the Lambda timeout (none if 0)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var input io.Reader = os.Stdin
+	if flags.NArg() > 0 {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		input = file
+	}
+	var event Event
+	if err := json.NewDecoder(input).Decode(&event); err != nil {
+		fmt.Printf("❌ Invalid event: %v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	handler := &lambdaHandler{}
+	response, err := handler.Handle(ctx, event)
+	if handler.db != nil {
+		handler.db.Close()
+	}
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+
+	output, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	fmt.Println(string(output))
+	return 0
+}
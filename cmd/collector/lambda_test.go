@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/collector"
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+	"github.com/dothanhlam/go-github-tracker/internal/jobs"
+	"github.com/dothanhlam/go-github-tracker/internal/lock"
+)
+
+// TestEventOptions tests reading run options from manual and EventBridge events
+func TestEventOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     string
+		wantRepos   int
+		wantSince   string
+		wantUntil   string
+		wantDryRun  bool
+		wantMigrate bool
+		wantErr     bool
+	}{
+		{
+			name:    "scheduled EventBridge event",
+			payload: `{"version":"0","id":"53dc4d37","detail-type":"Scheduled Event","source":"aws.events","time":"2026-03-02T06:00:00Z","detail":{}}`,
+		},
+		{
+			name:        "EventBridge event with options in its detail",
+			payload:     `{"detail-type":"Backfill","source":"dora.collector","detail":{"repositories":["acme/api"],"since":"2026-01-01","migrate":true}}`,
+			wantRepos:   1,
+			wantSince:   "2026-01-01T00:00:00Z",
+			wantMigrate: true,
+		},
+		{
+			name:       "manual backfill",
+			payload:    `{"repositories":["acme/api","acme/web"],"since":"2026-01-01","until":"2026-01-31","dry_run":true}`,
+			wantRepos:  2,
+			wantSince:  "2026-01-01T00:00:00Z",
+			wantUntil:  "2026-01-31T23:59:59.999999999Z",
+			wantDryRun: true,
+		},
+		{
+			name:      "RFC 3339 times",
+			payload:   `{"since":"2026-01-01T08:00:00+07:00","until":"2026-01-02T08:00:00Z"}`,
+			wantSince: "2026-01-01T01:00:00Z",
+			wantUntil: "2026-01-02T08:00:00Z",
+		},
+		{
+			name:    "invalid date",
+			payload: `{"since":"01/02/2026"}`,
+			wantErr: true,
+		},
+		{
+			name:      "plan windowed backfill jobs",
+			payload:   `{"action":"plan","since":"2026-01-01","window_days":7}`,
+			wantSince: "2026-01-01T00:00:00Z",
+		},
+		{
+			name:    "unknown action",
+			payload: `{"action":"collect-all"}`,
+			wantErr: true,
+		},
+		{
+			name:    "dry run of jobs",
+			payload: `{"action":"work","dry_run":true}`,
+			wantErr: true,
+		},
+		{
+			name:    "until before since",
+			payload: `{"since":"2026-02-01","until":"2026-01-01"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var event Event
+			if err := json.Unmarshal([]byte(tt.payload), &event); err != nil {
+				t.Fatal(err)
+			}
+			options, migrate, err := event.options()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("options() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(options.Repositories) != tt.wantRepos || options.DryRun != tt.wantDryRun || migrate != tt.wantMigrate {
+				t.Errorf("options() = %+v, migrate %v", options, migrate)
+			}
+			if got := formatOptionalTime(options.Since); got != tt.wantSince {
+				t.Errorf("Since = %s, want %s", got, tt.wantSince)
+			}
+			if got := formatOptionalTime(options.Until); got != tt.wantUntil {
+				t.Errorf("Until = %s, want %s", got, tt.wantUntil)
+			}
+		})
+	}
+}
+
+// formatOptionalTime formats a time in UTC, or "" for nil
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// newTestHandler returns a handler with a fresh SQLite database tracking acme/api
+func newTestHandler(t *testing.T) *lambdaHandler {
+	t.Helper()
+
+	// Migrations are read relative to the repository root
+	originalDir, _ := os.Getwd()
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatalf("failed to change to repository root: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(originalDir) })
+
+	handler := &lambdaHandler{cfg: &config.Config{
+		DBDriver:     "sqlite3",
+		DBURL:        filepath.Join(t.TempDir(), "collector.db"),
+		LookbackDays: 7,
+		TeamSource:   "config",
+		Teams: []config.TeamConfig{{
+			Name:    "Payments",
+			Members: []config.TeamMemberConfig{{Username: "alice", Allocation: 1}},
+		}},
+		RepositorySettings: []config.RepositoryConfig{{Name: "acme/api"}},
+	}}
+	t.Cleanup(func() {
+		if handler.db != nil {
+			handler.db.Close()
+		}
+	})
+	return handler
+}
+
+// TestHandleDryRun tests a dry run that migrates first, without calling GitHub
+func TestHandleDryRun(t *testing.T) {
+	handler := newTestHandler(t)
+
+	response, err := handler.Handle(context.Background(), Event{Migrate: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if !response.Migrated || !response.DryRun || response.TimedOut {
+		t.Errorf("Handle() = %+v", response)
+	}
+	if len(response.Repositories) != 1 || response.Repositories[0].Status != collector.StatusPlanned || response.Repositories[0].Since == nil {
+		t.Errorf("Repositories = %+v, want acme/api planned", response.Repositories)
+	}
+}
+
+// TestHandleDryRunWritesNothing tests that a dry run plans the configured
+// teams and repositories without syncing them
+func TestHandleDryRunWritesNothing(t *testing.T) {
+	handler := newTestHandler(t)
+
+	// Sync teams and repositories by planning jobs
+	if _, err := handler.Handle(context.Background(), Event{Migrate: true, Action: ActionPlan, Since: "2026-01-01", WindowDays: 7}); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	tables := []string{"teams", "team_memberships", "team_audit_log", "repositories", "repository_tags", "collection_metadata", "collection_locks", "collection_jobs"}
+	counts := func() map[string]int {
+		counts := make(map[string]int, len(tables))
+		for _, table := range tables {
+			var count int
+			if err := handler.db.Get(&count, "SELECT COUNT(*) FROM "+table); err != nil {
+				t.Fatalf("failed to count %s: %v", table, err)
+			}
+			counts[table] = count
+		}
+		return counts
+	}
+	before := counts()
+
+	handler.cfg.Teams = append(handler.cfg.Teams, config.TeamConfig{
+		Name:    "Platform",
+		Members: []config.TeamMemberConfig{{Username: "bob", Allocation: 1}},
+	})
+	handler.cfg.RepositorySettings = append(handler.cfg.RepositorySettings, config.RepositoryConfig{Name: "acme/web", Team: "Platform", Tags: []string{"frontend"}})
+	response, err := handler.Handle(context.Background(), Event{DryRun: true})
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if len(response.Repositories) != 2 || response.Repositories[1].Name != "acme/web" || response.Repositories[1].Status != collector.StatusPlanned {
+		t.Errorf("Repositories = %+v, want acme/api and acme/web planned", response.Repositories)
+	}
+	after := counts()
+	for _, table := range tables {
+		if after[table] != before[table] {
+			t.Errorf("%s has %d rows after the dry run, want %d", table, after[table], before[table])
+		}
+	}
+}
+
+// TestHandleDeadline tests that repositories aren't started within the margin before the deadline
+func TestHandleDeadline(t *testing.T) {
+	handler := newTestHandler(t)
+	if _, err := handler.Handle(context.Background(), Event{Migrate: true, DryRun: true}); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deadlineMargin/2)
+	defer cancel()
+	response, err := handler.Handle(ctx, Event{})
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if response.Migrated || !response.TimedOut {
+		t.Errorf("Handle() = %+v, want timed out without migrating", response)
+	}
+	if len(response.Repositories) != 1 || response.Repositories[0].Status != collector.StatusNotStarted {
+		t.Errorf("Repositories = %+v, want acme/api not started", response.Repositories)
+	}
+}
+
+// TestHandleLockedRepository tests skipping a repository another run is collecting
+func TestHandleLockedRepository(t *testing.T) {
+	handler := newTestHandler(t)
+	if _, err := handler.Handle(context.Background(), Event{Migrate: true, DryRun: true}); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	held, err := lock.NewLocker(handler.db, "host-a-1", time.Minute).Acquire(context.Background(), "repository:acme/api")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	defer held.Release()
+
+	response, err := handler.Handle(context.Background(), Event{})
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if len(response.Repositories) != 1 || response.Repositories[0].Status != collector.StatusLocked || !strings.Contains(response.Repositories[0].Error, "host-a-1") {
+		t.Errorf("Repositories = %+v, want acme/api locked by host-a-1", response.Repositories)
+	}
+}
+
+// TestHandleUnknownRepository tests rejecting repositories that aren't tracked
+func TestHandleUnknownRepository(t *testing.T) {
+	handler := newTestHandler(t)
+
+	if _, err := handler.Handle(context.Background(), Event{Migrate: true, Repositories: []string{"acme/unknown"}}); err == nil {
+		t.Error("Handle() error = nil, want an error for an untracked repository")
+	}
+}
+
+// TestHandlePlanAndWork tests planning backfill jobs, and a worker stopping at the deadline
+func TestHandlePlanAndWork(t *testing.T) {
+	handler := newTestHandler(t)
+
+	event := Event{Migrate: true, Action: ActionPlan, Since: "2026-01-01", Until: "2026-01-14", WindowDays: 7}
+	for _, want := range []int{2, 0} {
+		response, err := handler.Handle(context.Background(), event)
+		if err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+		if response.Planned == nil || *response.Planned != want || response.Jobs[jobs.StatusQueued] != 2 {
+			t.Errorf("Handle() planned %v, jobs %v, want %d added and 2 queued", response.Planned, response.Jobs, want)
+		}
+		event.Migrate = false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deadlineMargin/2)
+	defer cancel()
+	response, err := handler.Handle(ctx, Event{Action: ActionWork})
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if response.Worked == nil || *response.Worked != (jobs.WorkResult{}) || response.Jobs[jobs.StatusQueued] != 2 {
+		t.Errorf("Handle() worked %+v, jobs %v, want nothing leased", response.Worked, response.Jobs)
+	}
+}
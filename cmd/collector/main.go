@@ -5,12 +5,29 @@ import (
 	"log"
 	"os"
 
+	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/dothanhlam/go-github-tracker/internal/collector"
 	"github.com/dothanhlam/go-github-tracker/internal/config"
 	"github.com/dothanhlam/go-github-tracker/internal/database"
 )
 
 func main() {
+	// On Lambda (handler bootstrap), serve invocations instead of running once
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") != "" {
+		lambda.Start((&lambdaHandler{}).Handle)
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "validate-config" {
+		os.Exit(validateConfig(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "invoke" {
+		os.Exit(invoke(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		os.Exit(serve(os.Args[2:]))
+	}
+
 	fmt.Println("🚀 DORA Metrics Collector - Phase 1 MVP")
 	fmt.Println("========================================")
 
@@ -79,6 +96,36 @@ func runCollector(cfg *config.Config, db *database.DB) error {
 	return collector.Run()
 }
 
+// validateConfig checks a team config file without touching the database.
+// Usage: collector validate-config [path] (defaults to TEAM_CONFIG_FILE)
+func validateConfig(args []string) int {
+	path := os.Getenv("TEAM_CONFIG_FILE")
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if path == "" {
+		fmt.Println("Usage: collector validate-config <team-config.yaml>  (or set TEAM_CONFIG_FILE)")
+		return 2
+	}
+
+	// Parents may name GitHub teams when teams are also synced from GitHub
+	source := os.Getenv("TEAM_SOURCE")
+	checkParents := source != "github" && source != "merged"
+
+	file, err := config.LoadTeamFile(path, checkParents)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+
+	members := 0
+	for _, team := range file.Teams {
+		members += len(team.Members)
+	}
+	fmt.Printf("✓ %s is valid: %d teams, %d memberships, %d aliases\n", path, len(file.Teams), members, len(file.Aliases))
+	return 0
+}
+
 // verifySchema checks that all required tables exist
 func verifySchema(db *database.DB) error {
 	tables := []string{"teams", "team_memberships", "pr_metrics"}
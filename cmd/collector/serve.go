@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/api/response"
+	"github.com/dothanhlam/go-github-tracker/internal/collector"
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/dothanhlam/go-github-tracker/internal/scheduler"
+	"github.com/dothanhlam/go-github-tracker/internal/service"
+)
+
+// serve collects repositories on their schedules until interrupted, with a
+// control endpoint on COLLECTOR_CONTROL_ADDR for status, health and run triggers.
+// Usage: collector serve
+func serve(args []string) int {
+	if len(args) > 0 {
+		fmt.Println("Usage: collector serve  (configure with COLLECTOR_SCHEDULE, COLLECTOR_CONTROL_ADDR, COLLECTOR_MAX_CONCURRENT_RUNS)")
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("❌ Failed to load configuration: %v\n", err)
+		return 1
+	}
+	if cfg.DBDriver == "sqlite3" {
+		if err := os.MkdirAll("./data", 0755); err != nil {
+			fmt.Printf("❌ Failed to create data directory: %v\n", err)
+			return 1
+		}
+	}
+	db, err := database.Connect(cfg.DBDriver, cfg.DBURL)
+	if err != nil {
+		fmt.Printf("❌ Failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+	fmt.Println("📦 Running database migrations...")
+	if err := db.RunMigrations(); err != nil {
+		fmt.Printf("❌ Failed to run migrations: %v\n", err)
+		return 1
+	}
+
+	runner := &scheduleRunner{cfg: cfg, db: db}
+	sched, err := scheduler.New(schedules(cfg), cfg.MaxConcurrentRuns, runner.run)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	for _, status := range sched.Status() {
+		fmt.Printf("⏰ Schedule %s: %s\n", status.Name, status.Cron)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{
+		Addr:         cfg.ControlAddr,
+		Handler:      controlHandler(sched, db),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		fmt.Printf("🎛️  Control endpoint listening on %s\n", cfg.ControlAddr)
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		sched.Run(ctx)
+		close(done)
+	}()
+
+	code := 0
+	select {
+	case <-ctx.Done():
+		fmt.Println("\n🛑 Stopping; runs in progress stop at their next pull request...")
+	case err := <-serverErr:
+		fmt.Printf("❌ Control endpoint failed: %v\n", err)
+		stop()
+		code = 1
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	server.Shutdown(shutdownCtx)
+	<-done
+	return code
+}
+
+// schedules returns the configured schedules, and the default one for the
+// repositories matching none unless COLLECTOR_SCHEDULE is empty
+func schedules(cfg *config.Config) []scheduler.Schedule {
+	var result []scheduler.Schedule
+	for _, schedule := range cfg.Schedules {
+		result = append(result, scheduler.Schedule{Name: schedule.Name, Cron: schedule.Cron})
+	}
+	if cfg.DefaultSchedule != "" {
+		result = append(result, scheduler.Schedule{Name: config.DefaultScheduleName, Cron: cfg.DefaultSchedule})
+	}
+	return result
+}
+
+// scheduleRunner collects the repositories of a schedule
+type scheduleRunner struct {
+	cfg *config.Config
+	db  *database.DB
+
+	// mu serializes creating collectors, which sync teams and repositories
+	mu sync.Mutex
+}
+
+// run collects a schedule's active repositories with a collector of its own,
+// so schedules can run side by side
+func (r *scheduleRunner) run(ctx context.Context, schedule string) error {
+	r.mu.Lock()
+	c, err := collector.New(r.cfg, r.db)
+	var repos []string
+	if err == nil {
+		repos, err = c.ScheduledRepositories(schedule)
+	}
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to prepare collection: %w", err)
+	}
+	if len(repos) == 0 {
+		fmt.Printf("  ⚠️  Schedule %s has no active repositories\n", schedule)
+		return nil
+	}
+
+	_, err = c.RunContext(ctx, collector.RunOptions{Repositories: repos})
+	return err
+}
+
+// ServeHealth is the control endpoint's health response
+type ServeHealth struct {
+	Status     string                    `json:"status"` // healthy, degraded (failing runs) or unhealthy (no database)
+	Database   string                    `json:"database"`
+	Collection *service.CollectionHealth `json:"collection,omitempty"`
+	Schedules  []scheduler.Status        `json:"schedules"`
+}
+
+// controlHandler serves the control endpoint:
+//
+//	GET  /status                  schedules and their last runs
+//	GET  /health                  run health (503 without a database)
+//	POST /schedules/{name}/run    run a schedule now (409 if it is already going)
+func controlHandler(sched *scheduler.Scheduler, db *database.DB) http.Handler {
+	metrics := service.NewMetricsService(db)
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		response.JSON(w, http.StatusOK, sched.Status())
+	})
+
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		health := ServeHealth{Status: "healthy", Database: "connected", Schedules: sched.Status()}
+		if err := db.Ping(); err != nil {
+			health.Status, health.Database = "unhealthy", "disconnected"
+			response.JSON(w, http.StatusServiceUnavailable, health)
+			return
+		}
+		collection, err := metrics.CollectionHealth()
+		if err != nil {
+			response.InternalError(w, err.Error())
+			return
+		}
+		health.Collection = collection
+		if len(collection.Failing) > 0 {
+			health.Status = "degraded"
+		}
+		for _, status := range health.Schedules {
+			if status.LastRun != nil && status.LastRun.Error != "" {
+				health.Status = "degraded"
+			}
+		}
+		response.JSON(w, http.StatusOK, health)
+	})
+
+	mux.HandleFunc("POST /schedules/{name}/run", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		err := sched.Trigger(name)
+		switch {
+		case errors.Is(err, scheduler.ErrUnknownSchedule):
+			response.NotFound(w, err.Error())
+		case errors.Is(err, scheduler.ErrBusy):
+			response.Conflict(w, fmt.Sprintf("schedule %s is already waiting or running", name))
+		case err != nil:
+			response.Error(w, http.StatusServiceUnavailable, "UNAVAILABLE", err.Error())
+		default:
+			response.JSON(w, http.StatusAccepted, map[string]string{"triggered": name})
+		}
+	})
+
+	return mux
+}
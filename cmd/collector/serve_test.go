@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/collector"
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+	"github.com/dothanhlam/go-github-tracker/internal/scheduler"
+	"github.com/dothanhlam/go-github-tracker/internal/store"
+)
+
+// TestScheduledRepositories tests splitting the tracked repositories between schedules
+func TestScheduledRepositories(t *testing.T) {
+	handler := newTestHandler(t)
+	handler.cfg.RepositorySettings = []config.RepositoryConfig{
+		{Name: "acme/api", ServiceGroup: "payments"},
+		{Name: "acme/web", Tags: []string{"web"}},
+		{Name: "acme/docs"},
+	}
+	handler.cfg.Schedules = []config.ScheduleConfig{
+		{Name: "payments", Cron: "*/15 * * * *", ServiceGroups: []string{"payments"}},
+		{Name: "frontend", Cron: "@daily", Tags: []string{"web"}, Repositories: []string{"acme/api"}},
+	}
+	handler.cfg.DefaultSchedule = "@hourly"
+	if _, err := handler.Handle(context.Background(), Event{Migrate: true, DryRun: true}); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	names := []string{}
+	for _, schedule := range schedules(handler.cfg) {
+		names = append(names, schedule.Name)
+	}
+	if want := []string{"payments", "frontend", config.DefaultScheduleName}; !reflect.DeepEqual(names, want) {
+		t.Errorf("schedules() = %v, want %v", names, want)
+	}
+
+	c, err := collector.New(handler.cfg, handler.db)
+	if err != nil {
+		t.Fatalf("collector.New() error = %v", err)
+	}
+	for schedule, want := range map[string][]string{
+		"payments":                 {"acme/api"},
+		"frontend":                 {"acme/web"},
+		config.DefaultScheduleName: {"acme/docs"},
+	} {
+		got, err := c.ScheduledRepositories(schedule)
+		if err != nil {
+			t.Fatalf("ScheduledRepositories() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ScheduledRepositories(%s) = %v, want %v", schedule, got, want)
+		}
+	}
+}
+
+// TestControlHandler tests the control endpoint's status, health and triggers
+func TestControlHandler(t *testing.T) {
+	handler := newTestHandler(t)
+	if _, err := handler.Handle(context.Background(), Event{Migrate: true, DryRun: true}); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	// Dry runs don't sync, so track acme/api by creating a collector
+	if _, err := collector.New(handler.cfg, handler.db); err != nil {
+		t.Fatalf("collector.New() error = %v", err)
+	}
+
+	ran := make(chan string, 1)
+	sched, err := scheduler.New([]scheduler.Schedule{{Name: "default", Cron: "@daily"}}, 1, func(ctx context.Context, schedule string) error {
+		ran <- schedule
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("scheduler.New() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		sched.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	server := httptest.NewServer(controlHandler(sched, handler.db))
+	defer server.Close()
+
+	// Wait for the scheduler to start before triggering runs
+	deadline := time.Now().Add(2 * time.Second)
+	for sched.Status()[0].NextRunAt.IsZero() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	for _, tt := range []struct {
+		path string
+		want int
+	}{
+		{"/schedules/nightly/run", http.StatusNotFound},
+		{"/schedules/default/run", http.StatusAccepted},
+	} {
+		resp, err := http.Post(server.URL+tt.path, "application/json", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("POST %s = %d, want %d", tt.path, resp.StatusCode, tt.want)
+		}
+	}
+	select {
+	case schedule := <-ran:
+		if schedule != "default" {
+			t.Errorf("ran %s, want default", schedule)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the triggered schedule never ran")
+	}
+
+	var health ServeHealth
+	getJSON(t, server.URL+"/health", &health)
+	if health.Status != "healthy" || health.Collection == nil || health.Collection.Repositories != 1 || len(health.Schedules) != 1 {
+		t.Errorf("GET /health = %+v, want healthy with one repository and schedule", health)
+	}
+
+	// A failed run degrades the health
+	started := time.Now()
+	if err := store.New(handler.db).RecordRun("acme/api", collector.StatusFailed, "bad credentials", started, started.Add(time.Second)); err != nil {
+		t.Fatalf("RecordRun() error = %v", err)
+	}
+	getJSON(t, server.URL+"/health", &health)
+	if health.Status != "degraded" || !reflect.DeepEqual(health.Collection.Failing, []string{"acme/api"}) {
+		t.Errorf("GET /health = %+v, want degraded by acme/api", health)
+	}
+
+	var statuses []scheduler.Status
+	getJSON(t, server.URL+"/status", &statuses)
+	if len(statuses) != 1 || statuses[0].Name != "default" {
+		t.Errorf("GET /status = %+v, want the default schedule", statuses)
+	}
+}
+
+// getJSON decodes the JSON response to a GET request
+func getJSON(t *testing.T, url string, v interface{}) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("failed to decode %s: %v", url, err)
+	}
+}
@@ -0,0 +1,402 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/api/middleware"
+	"github.com/dothanhlam/go-github-tracker/internal/api/response"
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/dothanhlam/go-github-tracker/internal/repository"
+	"github.com/dothanhlam/go-github-tracker/internal/team"
+	"github.com/go-chi/chi/v5"
+)
+
+// AdminHandler handles team, membership and repository admin requests
+type AdminHandler struct {
+	teams *team.Manager
+	repos *repository.Registry
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(teams *team.Manager, repos *repository.Registry) *AdminHandler {
+	return &AdminHandler{
+		teams: teams,
+		repos: repos,
+	}
+}
+
+// AdminTeam is a team as returned by the admin endpoints
+type AdminTeam struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description *string   `json:"description"`
+	ParentID    *int      `json:"parent_id"`
+	Source      string    `json:"source"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// AdminRepository is a repository as returned by the admin endpoints
+type AdminRepository struct {
+	ID                    int       `json:"id"`
+	Name                  string    `json:"name"`
+	DefaultBranch         *string   `json:"default_branch"`
+	TeamID                *int      `json:"team_id"`
+	Tags                  []string  `json:"tags"`
+	BaseBranches          []string  `json:"base_branches"`
+	ServiceGroup          *string   `json:"service_group"`
+	Active                bool      `json:"active"`
+	LookbackDays          *int      `json:"lookback_days"`
+	DeploymentSource      *string   `json:"deployment_source"`
+	DeploymentEnvironment *string   `json:"deployment_environment"`
+	GitPath               *string   `json:"git_path"`
+	Source                string    `json:"source"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+// AuditEntry is an audit log entry as returned by the admin endpoints
+type AuditEntry struct {
+	ID         int             `json:"id"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	TeamID     *int            `json:"team_id"`
+	Username   *string         `json:"username"`
+	Repository *string         `json:"repository"`
+	Details    json.RawMessage `json:"details"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// createTeamRequest is the body of POST /api/v1/admin/teams
+type createTeamRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	ParentID    *int    `json:"parent_id"`
+}
+
+// updateTeamRequest is the body of PATCH /api/v1/admin/teams/{id}
+type updateTeamRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	ParentID    *int    `json:"parent_id"` // 0 makes the team top-level
+}
+
+// addMemberRequest is the body of POST /api/v1/admin/teams/{id}/members
+type addMemberRequest struct {
+	Username   string       `json:"username"`
+	Allocation *float64     `json:"allocation"`
+	JoinedAt   *config.Date `json:"joined_at"`
+}
+
+// updateMemberRequest is the body of PATCH /api/v1/admin/teams/{id}/members/{username}
+type updateMemberRequest struct {
+	Allocation  *float64     `json:"allocation"`
+	EffectiveAt *config.Date `json:"effective_at"`
+}
+
+// repositoryRequest is the body of POST /api/v1/admin/repositories (which
+// also requires a name) and PATCH /api/v1/admin/repositories/{id}
+type repositoryRequest struct {
+	Name                  string    `json:"name,omitempty"`
+	DefaultBranch         *string   `json:"default_branch"`
+	TeamID                *int      `json:"team_id"` // 0 clears the owning team
+	Tags                  *[]string `json:"tags"`
+	BaseBranches          *[]string `json:"base_branches"` // [] counts PRs into every base
+	ServiceGroup          *string   `json:"service_group"`
+	Active                *bool     `json:"active"`
+	LookbackDays          *int      `json:"lookback_days"` // 0 falls back to COLLECTION_LOOKBACK_DAYS
+	DeploymentSource      *string   `json:"deployment_source"`
+	DeploymentEnvironment *string   `json:"deployment_environment"`
+}
+
+// changes converts the request to repository changes
+func (req repositoryRequest) changes() repository.Changes {
+	return repository.Changes{
+		DefaultBranch:         req.DefaultBranch,
+		TeamID:                req.TeamID,
+		Tags:                  req.Tags,
+		BaseBranches:          req.BaseBranches,
+		ServiceGroup:          req.ServiceGroup,
+		Active:                req.Active,
+		LookbackDays:          req.LookbackDays,
+		DeploymentSource:      req.DeploymentSource,
+		DeploymentEnvironment: req.DeploymentEnvironment,
+	}
+}
+
+// CreateTeam handles POST /api/v1/admin/teams
+func (h *AdminHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
+	var req createTeamRequest
+	if err := decodeBody(r, &req); err != nil {
+		response.BadRequest(w, "Invalid request body")
+		return
+	}
+
+	created, err := h.teams.CreateTeam(middleware.Actor(r.Context()), req.Name, req.Description, req.ParentID)
+	if err != nil {
+		adminError(w, err, "Failed to create team")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, toAdminTeam(created))
+}
+
+// UpdateTeam handles PATCH /api/v1/admin/teams/{id}
+func (h *AdminHandler) UpdateTeam(w http.ResponseWriter, r *http.Request) {
+	teamID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid team ID")
+		return
+	}
+
+	var req updateTeamRequest
+	if err := decodeBody(r, &req); err != nil {
+		response.BadRequest(w, "Invalid request body")
+		return
+	}
+
+	changes := team.TeamChanges{Name: req.Name, Description: req.Description, ParentID: req.ParentID}
+	updated, err := h.teams.UpdateTeam(middleware.Actor(r.Context()), teamID, changes)
+	if err != nil {
+		adminError(w, err, "Failed to update team")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, toAdminTeam(updated))
+}
+
+// AddMember handles POST /api/v1/admin/teams/{id}/members
+func (h *AdminHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	teamID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid team ID")
+		return
+	}
+
+	var req addMemberRequest
+	if err := decodeBody(r, &req); err != nil {
+		response.BadRequest(w, "Invalid request body")
+		return
+	}
+
+	allocation := 1.0
+	if req.Allocation != nil {
+		allocation = *req.Allocation
+	}
+
+	err = h.teams.AddMember(middleware.Actor(r.Context()), teamID, req.Username, allocation, dateValue(req.JoinedAt))
+	if err != nil {
+		adminError(w, err, "Failed to add member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateMember handles PATCH /api/v1/admin/teams/{id}/members/{username}
+func (h *AdminHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	teamID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid team ID")
+		return
+	}
+
+	var req updateMemberRequest
+	if err := decodeBody(r, &req); err != nil {
+		response.BadRequest(w, "Invalid request body")
+		return
+	}
+	if req.Allocation == nil {
+		response.BadRequest(w, "allocation is required")
+		return
+	}
+
+	username := chi.URLParam(r, "username")
+	err = h.teams.SetAllocation(middleware.Actor(r.Context()), teamID, username, *req.Allocation, dateValue(req.EffectiveAt))
+	if err != nil {
+		adminError(w, err, "Failed to update member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveMember handles DELETE /api/v1/admin/teams/{id}/members/{username}
+func (h *AdminHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	teamID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid team ID")
+		return
+	}
+
+	var leftAt time.Time
+	if leftStr := r.URL.Query().Get("left_at"); leftStr != "" {
+		leftAt, err = time.Parse("2006-01-02", leftStr)
+		if err != nil {
+			response.BadRequest(w, "Invalid left_at date, expected YYYY-MM-DD")
+			return
+		}
+	}
+
+	username := chi.URLParam(r, "username")
+	if err := h.teams.RemoveMember(middleware.Actor(r.Context()), teamID, username, leftAt); err != nil {
+		adminError(w, err, "Failed to remove member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateRepository handles POST /api/v1/admin/repositories
+func (h *AdminHandler) CreateRepository(w http.ResponseWriter, r *http.Request) {
+	var req repositoryRequest
+	if err := decodeBody(r, &req); err != nil {
+		response.BadRequest(w, "Invalid request body")
+		return
+	}
+
+	created, err := h.repos.Create(middleware.Actor(r.Context()), req.Name, req.changes())
+	if err != nil {
+		adminError(w, err, "Failed to create repository")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, toAdminRepository(created))
+}
+
+// UpdateRepository handles PATCH /api/v1/admin/repositories/{id}
+func (h *AdminHandler) UpdateRepository(w http.ResponseWriter, r *http.Request) {
+	repositoryID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid repository ID")
+		return
+	}
+
+	var req repositoryRequest
+	if err := decodeBody(r, &req); err != nil || req.Name != "" {
+		response.BadRequest(w, "Invalid request body")
+		return
+	}
+
+	updated, err := h.repos.Update(middleware.Actor(r.Context()), repositoryID, req.changes())
+	if err != nil {
+		adminError(w, err, "Failed to update repository")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, toAdminRepository(updated))
+}
+
+// ListAudit handles GET /api/v1/admin/audit
+func (h *AdminHandler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit < 1 {
+			response.BadRequest(w, "Invalid limit")
+			return
+		}
+	}
+
+	entries, err := h.teams.ListAudit(limit)
+	if err != nil {
+		response.InternalError(w, "Failed to fetch audit log")
+		return
+	}
+
+	result := make([]AuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		var details json.RawMessage
+		if entry.Details != nil {
+			details = json.RawMessage(*entry.Details)
+		}
+		result = append(result, AuditEntry{
+			ID:         entry.ID,
+			Actor:      entry.Actor,
+			Action:     entry.Action,
+			TeamID:     entry.TeamID,
+			Username:   entry.GitHubUsername,
+			Repository: entry.Repository,
+			Details:    details,
+			CreatedAt:  entry.CreatedAt,
+		})
+	}
+
+	resp := map[string]interface{}{
+		"entries": result,
+	}
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// adminError maps errors from the team manager and repository registry to API error responses
+func adminError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, team.ErrTeamNotFound):
+		response.NotFound(w, "Team not found")
+	case errors.Is(err, team.ErrMemberNotFound):
+		response.NotFound(w, "Member not found")
+	case errors.Is(err, repository.ErrNotFound):
+		response.NotFound(w, "Repository not found")
+	case errors.Is(err, team.ErrTeamExists), errors.Is(err, team.ErrAlreadyMember), errors.Is(err, team.ErrConfigManaged),
+		errors.Is(err, repository.ErrExists), errors.Is(err, repository.ErrConfigManaged):
+		response.Conflict(w, err.Error())
+	case errors.Is(err, team.ErrInvalid), errors.Is(err, repository.ErrInvalid):
+		response.BadRequest(w, err.Error())
+	default:
+		response.InternalError(w, message)
+	}
+}
+
+// decodeBody decodes a JSON request body, rejecting unknown fields
+func decodeBody(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// dateValue returns the time of an optional date, or the zero time
+func dateValue(d *config.Date) time.Time {
+	if d == nil {
+		return time.Time{}
+	}
+	return d.Time
+}
+
+// toAdminTeam converts a database team to its API representation
+func toAdminTeam(t *database.Team) AdminTeam {
+	return AdminTeam{
+		ID:          t.ID,
+		Name:        t.Name,
+		Description: t.Description,
+		ParentID:    t.ParentID,
+		Source:      t.Source,
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+}
+
+// toAdminRepository converts a database repository to its API representation
+func toAdminRepository(repo *database.Repository) AdminRepository {
+	return AdminRepository{
+		ID:                    repo.ID,
+		Name:                  repo.Name,
+		DefaultBranch:         repo.DefaultBranch,
+		TeamID:                repo.TeamID,
+		Tags:                  repo.Tags,
+		BaseBranches:          repo.BaseBranches,
+		ServiceGroup:          repo.ServiceGroup,
+		Active:                repo.Active,
+		LookbackDays:          repo.LookbackDays,
+		DeploymentSource:      repo.DeploymentSource,
+		DeploymentEnvironment: repo.DeploymentEnvironment,
+		GitPath:               repo.GitPath,
+		Source:                repo.Source,
+		CreatedAt:             repo.CreatedAt,
+		UpdatedAt:             repo.UpdatedAt,
+	}
+}
@@ -5,23 +5,26 @@ import (
 
 	"github.com/dothanhlam/go-github-tracker/internal/api/response"
 	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/dothanhlam/go-github-tracker/internal/service"
 )
 
 // HealthHandler handles health check requests
 type HealthHandler struct {
-	db *database.DB
+	db      *database.DB
+	metrics *service.MetricsService
 }
 
 // NewHealthHandler creates a new health handler
 func NewHealthHandler(db *database.DB) *HealthHandler {
-	return &HealthHandler{db: db}
+	return &HealthHandler{db: db, metrics: service.NewMetricsService(db)}
 }
 
 // HealthResponse represents the health check response
 type HealthResponse struct {
-	Status   string `json:"status"`
-	Database string `json:"database"`
-	Version  string `json:"version"`
+	Status     string                    `json:"status"` // "degraded" while repositories are failing to collect
+	Database   string                    `json:"database"`
+	Version    string                    `json:"version"`
+	Collection *service.CollectionHealth `json:"collection,omitempty"`
 }
 
 // Handle processes health check requests
@@ -38,5 +41,16 @@ func (h *HealthHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		Version:  "1.0.0",
 	}
 
+	// Collection failures degrade the status without failing the check, so
+	// the API stays up while they are fixed
+	if dbStatus == "connected" {
+		if collection, err := h.metrics.CollectionHealth(); err == nil {
+			resp.Collection = collection
+			if len(collection.Failing) > 0 {
+				resp.Status = "degraded"
+			}
+		}
+	}
+
 	response.JSON(w, http.StatusOK, resp)
 }
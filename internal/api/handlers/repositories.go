@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dothanhlam/go-github-tracker/internal/api/response"
+	"github.com/dothanhlam/go-github-tracker/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// RepositoriesHandler handles repository-related requests
+type RepositoriesHandler struct {
+	metricsService *service.MetricsService
+}
+
+// NewRepositoriesHandler creates a new repositories handler
+func NewRepositoriesHandler(metricsService *service.MetricsService) *RepositoriesHandler {
+	return &RepositoriesHandler{
+		metricsService: metricsService,
+	}
+}
+
+// ListRepositories handles GET /api/v1/repositories
+func (h *RepositoriesHandler) ListRepositories(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := service.RepositoryFilter{
+		Tag:          query.Get("tag"),
+		ServiceGroup: query.Get("service_group"),
+	}
+	if teamStr := query.Get("team_id"); teamStr != "" {
+		teamID, err := strconv.Atoi(teamStr)
+		if err != nil {
+			response.BadRequest(w, "Invalid team_id")
+			return
+		}
+		filter.TeamID = &teamID
+	}
+	if activeStr := query.Get("active"); activeStr != "" {
+		active, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			response.BadRequest(w, "Invalid active (expected true or false)")
+			return
+		}
+		filter.Active = &active
+	}
+
+	repos, err := h.metricsService.ListRepositories(filter)
+	if err != nil {
+		response.InternalError(w, "Failed to fetch repositories")
+		return
+	}
+
+	resp := map[string]interface{}{
+		"repositories": repos,
+	}
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// GetRepository handles GET /api/v1/repositories/{id}
+func (h *RepositoriesHandler) GetRepository(w http.ResponseWriter, r *http.Request) {
+	repositoryID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid repository ID")
+		return
+	}
+
+	repo, err := h.metricsService.GetRepository(repositoryID)
+	if err != nil {
+		if err.Error() == "repository not found" {
+			response.NotFound(w, "Repository not found")
+			return
+		}
+		response.InternalError(w, "Failed to fetch repository")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, repo)
+}
+
+// GetVelocity handles GET /api/v1/repositories/{id}/velocity
+func (h *RepositoriesHandler) GetVelocity(w http.ResponseWriter, r *http.Request) {
+	repositoryID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid repository ID")
+		return
+	}
+
+	startDate, endDate, _ := parseDateParams(r)
+
+	byWorkType, ok := parseBreakdownParam(r)
+	if !ok {
+		response.BadRequest(w, "Invalid breakdown (expected work_type)")
+		return
+	}
+
+	metrics, err := h.metricsService.GetRepositoryVelocity(repositoryID, startDate, endDate, labelFilter(r), byWorkType)
+	if err != nil {
+		if err.Error() == "repository not found" {
+			response.NotFound(w, "Repository not found")
+			return
+		}
+		response.InternalError(w, "Failed to fetch velocity metrics")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, metrics)
+}
+
+// GetLeadTime handles GET /api/v1/repositories/{id}/lead-time
+func (h *RepositoriesHandler) GetLeadTime(w http.ResponseWriter, r *http.Request) {
+	repositoryID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid repository ID")
+		return
+	}
+
+	startDate, endDate, _ := parseDateParams(r)
+
+	metrics, err := h.metricsService.GetRepositoryLeadTime(repositoryID, startDate, endDate, labelFilter(r))
+	if err != nil {
+		if err.Error() == "repository not found" {
+			response.NotFound(w, "Repository not found")
+			return
+		}
+		response.InternalError(w, "Failed to fetch lead time metrics")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, metrics)
+}
+
+// GetReviewTurnaround handles GET /api/v1/repositories/{id}/review-turnaround
+func (h *RepositoriesHandler) GetReviewTurnaround(w http.ResponseWriter, r *http.Request) {
+	repositoryID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid repository ID")
+		return
+	}
+
+	startDate, endDate, _ := parseDateParams(r)
+
+	metrics, err := h.metricsService.GetRepositoryReviewTurnaround(repositoryID, startDate, endDate, labelFilter(r))
+	if err != nil {
+		if err.Error() == "repository not found" {
+			response.NotFound(w, "Repository not found")
+			return
+		}
+		response.InternalError(w, "Failed to fetch review turnaround metrics")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, metrics)
+}
+
+// labelFilter reads the optional label filters of the repository metrics endpoints
+func labelFilter(r *http.Request) service.PRFilter {
+	query := r.URL.Query()
+	return service.PRFilter{
+		Labels:        parseListParam(query.Get("labels")),
+		ExcludeLabels: parseListParam(query.Get("exclude_labels")),
+	}
+}
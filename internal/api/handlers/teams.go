@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dothanhlam/go-github-tracker/internal/api/response"
@@ -44,9 +45,27 @@ func (h *TeamsHandler) GetVelocity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	startDate, endDate, granularity := h.parseDateParams(r)
+	startDate, endDate, granularity := parseDateParams(r)
 
-	metrics, err := h.metricsService.GetTeamVelocity(teamID, startDate, endDate, granularity)
+	filter, ok := parsePRFilter(r)
+	if !ok {
+		response.BadRequest(w, "Invalid role (expected authored, reviewed, owned or all, optionally comma-separated)")
+		return
+	}
+
+	byWorkType, ok := parseBreakdownParam(r)
+	if !ok {
+		response.BadRequest(w, "Invalid breakdown (expected work_type)")
+		return
+	}
+
+	weighted, ok := parseBoolParam(r, "weighted")
+	if !ok {
+		response.BadRequest(w, "Invalid weighted (expected true or false)")
+		return
+	}
+
+	metrics, err := h.metricsService.GetTeamVelocity(teamID, startDate, endDate, granularity, weighted, filter, byWorkType)
 	if err != nil {
 		if err.Error() == "team not found" {
 			response.NotFound(w, "Team not found")
@@ -67,9 +86,15 @@ func (h *TeamsHandler) GetLeadTime(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	startDate, endDate, granularity := h.parseDateParams(r)
+	startDate, endDate, granularity := parseDateParams(r)
+
+	filter, ok := parsePRFilter(r)
+	if !ok {
+		response.BadRequest(w, "Invalid role (expected authored, reviewed, owned or all, optionally comma-separated)")
+		return
+	}
 
-	metrics, err := h.metricsService.GetTeamLeadTime(teamID, startDate, endDate, granularity)
+	metrics, err := h.metricsService.GetTeamLeadTime(teamID, startDate, endDate, granularity, filter)
 	if err != nil {
 		if err.Error() == "team not found" {
 			response.NotFound(w, "Team not found")
@@ -90,9 +115,15 @@ func (h *TeamsHandler) GetReviewTurnaround(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	startDate, endDate, _ := h.parseDateParams(r)
+	startDate, endDate, _ := parseDateParams(r)
 
-	metrics, err := h.metricsService.GetReviewTurnaround(teamID, startDate, endDate)
+	filter, ok := parsePRFilter(r)
+	if !ok {
+		response.BadRequest(w, "Invalid role (expected authored, reviewed, owned or all, optionally comma-separated)")
+		return
+	}
+
+	metrics, err := h.metricsService.GetReviewTurnaround(teamID, startDate, endDate, filter)
 	if err != nil {
 		if err.Error() == "team not found" {
 			response.NotFound(w, "Team not found")
@@ -113,9 +144,15 @@ func (h *TeamsHandler) GetReviewEngagement(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	startDate, endDate, _ := h.parseDateParams(r)
+	startDate, endDate, _ := parseDateParams(r)
 
-	metrics, err := h.metricsService.GetReviewEngagement(teamID, startDate, endDate)
+	filter, ok := parsePRFilter(r)
+	if !ok {
+		response.BadRequest(w, "Invalid role (expected authored, reviewed, owned or all, optionally comma-separated)")
+		return
+	}
+
+	metrics, err := h.metricsService.GetReviewEngagement(teamID, startDate, endDate, filter)
 	if err != nil {
 		if err.Error() == "team not found" {
 			response.NotFound(w, "Team not found")
@@ -136,9 +173,15 @@ func (h *TeamsHandler) GetKnowledgeSharing(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	startDate, endDate, _ := h.parseDateParams(r)
+	startDate, endDate, _ := parseDateParams(r)
+
+	filter, ok := parsePRFilter(r)
+	if !ok {
+		response.BadRequest(w, "Invalid role (expected authored, reviewed, owned or all, optionally comma-separated)")
+		return
+	}
 
-	metrics, err := h.metricsService.GetKnowledgeSharing(teamID, startDate, endDate)
+	metrics, err := h.metricsService.GetKnowledgeSharing(teamID, startDate, endDate, filter)
 	if err != nil {
 		if err.Error() == "team not found" {
 			response.NotFound(w, "Team not found")
@@ -151,6 +194,104 @@ func (h *TeamsHandler) GetKnowledgeSharing(w http.ResponseWriter, r *http.Reques
 	response.JSON(w, http.StatusOK, metrics)
 }
 
+// GetWorkMix handles GET /api/v1/teams/{id}/work-mix
+func (h *TeamsHandler) GetWorkMix(w http.ResponseWriter, r *http.Request) {
+	teamID, err := h.getTeamID(r)
+	if err != nil {
+		response.BadRequest(w, "Invalid team ID")
+		return
+	}
+
+	startDate, endDate, _ := parseDateParams(r)
+
+	filter, ok := parsePRFilter(r)
+	if !ok {
+		response.BadRequest(w, "Invalid role (expected authored, reviewed, owned or all, optionally comma-separated)")
+		return
+	}
+
+	metrics, err := h.metricsService.GetWorkMix(teamID, startDate, endDate, filter)
+	if err != nil {
+		if err.Error() == "team not found" {
+			response.NotFound(w, "Team not found")
+			return
+		}
+		response.InternalError(w, "Failed to fetch work mix metrics")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, metrics)
+}
+
+// GetBugFlow handles GET /api/v1/teams/{id}/bug-flow
+func (h *TeamsHandler) GetBugFlow(w http.ResponseWriter, r *http.Request) {
+	teamID, err := h.getTeamID(r)
+	if err != nil {
+		response.BadRequest(w, "Invalid team ID")
+		return
+	}
+
+	startDate, endDate, _ := parseDateParams(r)
+
+	metrics, err := h.metricsService.GetBugFlow(teamID, startDate, endDate, r.URL.Query().Get("work_type"), parseLabelFilter(r))
+	if err != nil {
+		if err.Error() == "team not found" {
+			response.NotFound(w, "Team not found")
+			return
+		}
+		response.InternalError(w, "Failed to fetch bug flow metrics")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, metrics)
+}
+
+// GetBugAge handles GET /api/v1/teams/{id}/bug-age
+func (h *TeamsHandler) GetBugAge(w http.ResponseWriter, r *http.Request) {
+	teamID, err := h.getTeamID(r)
+	if err != nil {
+		response.BadRequest(w, "Invalid team ID")
+		return
+	}
+
+	_, endDate, _ := parseDateParams(r)
+
+	metrics, err := h.metricsService.GetBugAge(teamID, endDate, r.URL.Query().Get("work_type"), parseLabelFilter(r))
+	if err != nil {
+		if err.Error() == "team not found" {
+			response.NotFound(w, "Team not found")
+			return
+		}
+		response.InternalError(w, "Failed to fetch bug age metrics")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, metrics)
+}
+
+// GetBugTimeToClose handles GET /api/v1/teams/{id}/bug-time-to-close
+func (h *TeamsHandler) GetBugTimeToClose(w http.ResponseWriter, r *http.Request) {
+	teamID, err := h.getTeamID(r)
+	if err != nil {
+		response.BadRequest(w, "Invalid team ID")
+		return
+	}
+
+	startDate, endDate, _ := parseDateParams(r)
+
+	metrics, err := h.metricsService.GetBugTimeToClose(teamID, startDate, endDate, r.URL.Query().Get("work_type"), parseLabelFilter(r))
+	if err != nil {
+		if err.Error() == "team not found" {
+			response.NotFound(w, "Team not found")
+			return
+		}
+		response.InternalError(w, "Failed to fetch bug time to close metrics")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, metrics)
+}
+
 // GetCommits handles GET /api/v1/teams/{id}/commits
 func (h *TeamsHandler) GetCommits(w http.ResponseWriter, r *http.Request) {
 	teamID, err := h.getTeamID(r)
@@ -159,9 +300,15 @@ func (h *TeamsHandler) GetCommits(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	startDate, endDate, _ := h.parseDateParams(r)
+	startDate, endDate, _ := parseDateParams(r)
+
+	weighted, ok := parseBoolParam(r, "weighted")
+	if !ok {
+		response.BadRequest(w, "Invalid weighted (expected true or false)")
+		return
+	}
 
-	metrics, err := h.metricsService.GetTeamCommits(teamID, startDate, endDate)
+	metrics, err := h.metricsService.GetTeamCommits(teamID, startDate, endDate, weighted, parseLabelFilter(r))
 	if err != nil {
 		if err.Error() == "team not found" {
 			response.NotFound(w, "Team not found")
@@ -182,9 +329,15 @@ func (h *TeamsHandler) GetComments(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	startDate, endDate, _ := h.parseDateParams(r)
+	startDate, endDate, _ := parseDateParams(r)
 
-	metrics, err := h.metricsService.GetTeamComments(teamID, startDate, endDate)
+	weighted, ok := parseBoolParam(r, "weighted")
+	if !ok {
+		response.BadRequest(w, "Invalid weighted (expected true or false)")
+		return
+	}
+
+	metrics, err := h.metricsService.GetTeamComments(teamID, startDate, endDate, weighted, parseLabelFilter(r))
 	if err != nil {
 		if err.Error() == "team not found" {
 			response.NotFound(w, "Team not found")
@@ -211,9 +364,15 @@ func (h *TeamsHandler) GetMemberCommits(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	startDate, endDate, _ := h.parseDateParams(r)
+	startDate, endDate, _ := parseDateParams(r)
 
-	metrics, err := h.metricsService.GetMemberCommits(teamID, username, startDate, endDate)
+	includeCoAuthored, ok := parseBoolParam(r, "include_coauthored")
+	if !ok {
+		response.BadRequest(w, "Invalid include_coauthored (expected true or false)")
+		return
+	}
+
+	metrics, err := h.metricsService.GetMemberCommits(teamID, username, startDate, endDate, includeCoAuthored, parseLabelFilter(r))
 	if err != nil {
 		if err.Error() == "team not found" {
 			response.NotFound(w, "Team not found")
@@ -240,9 +399,9 @@ func (h *TeamsHandler) GetMemberComments(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	startDate, endDate, _ := h.parseDateParams(r)
+	startDate, endDate, _ := parseDateParams(r)
 
-	metrics, err := h.metricsService.GetMemberComments(teamID, username, startDate, endDate)
+	metrics, err := h.metricsService.GetMemberComments(teamID, username, startDate, endDate, parseLabelFilter(r))
 	if err != nil {
 		if err.Error() == "team not found" {
 			response.NotFound(w, "Team not found")
@@ -262,7 +421,7 @@ func (h *TeamsHandler) getTeamID(r *http.Request) (int, error) {
 	return strconv.Atoi(teamIDStr)
 }
 
-func (h *TeamsHandler) parseDateParams(r *http.Request) (startDate, endDate time.Time, granularity string) {
+func parseDateParams(r *http.Request) (startDate, endDate time.Time, granularity string) {
 	// Default to last 30 days
 	endDate = time.Now()
 	startDate = endDate.AddDate(0, 0, -30)
@@ -291,3 +450,57 @@ func (h *TeamsHandler) parseDateParams(r *http.Request) (startDate, endDate time
 
 	return
 }
+
+// parseBoolParam reads an optional boolean query parameter, false if absent.
+// ok is false when it is present but not a boolean.
+func parseBoolParam(r *http.Request, name string) (value, ok bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return false, true
+	}
+	value, err := strconv.ParseBool(raw)
+	return value, err == nil
+}
+
+// parsePRFilter reads the optional attribution role and label filters; an empty role means the endpoint default
+func parsePRFilter(r *http.Request) (service.PRFilter, bool) {
+	query := r.URL.Query()
+	filter := service.PRFilter{
+		Role:          query.Get("role"),
+		Labels:        parseListParam(query.Get("labels")),
+		ExcludeLabels: parseListParam(query.Get("exclude_labels")),
+	}
+	return filter, service.ValidRole(filter.Role)
+}
+
+// parseLabelFilter reads the optional label filters of the issue, commit and comment endpoints
+func parseLabelFilter(r *http.Request) service.LabelFilter {
+	query := r.URL.Query()
+	return service.LabelFilter{
+		Labels:        parseListParam(query.Get("labels")),
+		ExcludeLabels: parseListParam(query.Get("exclude_labels")),
+	}
+}
+
+// parseListParam splits a comma-separated query parameter, dropping empty entries
+func parseListParam(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseBreakdownParam reads the optional velocity breakdown; only "work_type" is supported
+func parseBreakdownParam(r *http.Request) (byWorkType bool, ok bool) {
+	switch r.URL.Query().Get("breakdown") {
+	case "":
+		return false, true
+	case "work_type":
+		return true, true
+	default:
+		return false, false
+	}
+}
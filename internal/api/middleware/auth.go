@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
@@ -45,3 +46,35 @@ func APIKeyAuth(validKeys []string) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// actorKey is the context key for the name of the admin making a request
+type actorKey struct{}
+
+// AdminKeyAuth creates middleware that only lets admin-scoped API keys through.
+// adminKeys maps each admin key to the actor name recorded in the audit log.
+func AdminKeyAuth(adminKeys map[string]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiKey := strings.TrimSpace(r.Header.Get("X-API-Key"))
+			if apiKey == "" {
+				response.Unauthorized(w, "API key is required")
+				return
+			}
+
+			actor, ok := adminKeys[apiKey]
+			if !ok {
+				response.Forbidden(w, "Admin API key is required")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), actorKey{}, actor)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Actor returns the name of the admin authenticated by AdminKeyAuth
+func Actor(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
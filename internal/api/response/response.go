@@ -58,3 +58,13 @@ func NotFound(w http.ResponseWriter, message string) {
 func InternalError(w http.ResponseWriter, message string) {
 	Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", message)
 }
+
+// Forbidden writes a 403 Forbidden error
+func Forbidden(w http.ResponseWriter, message string) {
+	Error(w, http.StatusForbidden, "FORBIDDEN", message)
+}
+
+// Conflict writes a 409 Conflict error
+func Conflict(w http.ResponseWriter, message string) {
+	Error(w, http.StatusConflict, "CONFLICT", message)
+}
@@ -1,13 +1,16 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/dothanhlam/go-github-tracker/internal/api/handlers"
 	"github.com/dothanhlam/go-github-tracker/internal/api/middleware"
 	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/dothanhlam/go-github-tracker/internal/repository"
 	"github.com/dothanhlam/go-github-tracker/internal/service"
+	"github.com/dothanhlam/go-github-tracker/internal/team"
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
@@ -18,22 +21,35 @@ type Server struct {
 	router         *chi.Mux
 	db             *database.DB
 	apiKeys        []string
+	adminKeys      map[string]string // admin key -> actor name
 	metricsService *service.MetricsService
+	teamManager    *team.Manager
+	repositories   *repository.Registry
 }
 
-// NewServer creates a new API server
-func NewServer(db *database.DB, apiKeys string) *Server {
+// NewServer creates a new API server.
+// adminKeys are comma-separated "name:key" (or bare "key") entries that may
+// also call the admin endpoints; the name is recorded in the audit log.
+func NewServer(db *database.DB, apiKeys, adminKeys string) (*Server, error) {
+	teamManager, err := team.LoadManager(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load teams: %w", err)
+	}
+
 	s := &Server{
 		router:         chi.NewRouter(),
 		db:             db,
 		apiKeys:        parseAPIKeys(apiKeys),
+		adminKeys:      parseAdminKeys(adminKeys),
 		metricsService: service.NewMetricsService(db),
+		teamManager:    teamManager,
+		repositories:   repository.NewRegistry(db),
 	}
 
 	s.setupMiddleware()
 	s.setupRoutes()
 
-	return s
+	return s, nil
 }
 
 // setupMiddleware configures middleware
@@ -47,15 +63,19 @@ func (s *Server) setupMiddleware() {
 	// CORS
 	s.router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"}, // Configure this for production
-		AllowedMethods:   []string{"GET", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Content-Type", "X-API-Key"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 
-	// API Key authentication
-	s.router.Use(middleware.APIKeyAuth(s.apiKeys))
+	// API Key authentication (admin keys can read too)
+	keys := append([]string{}, s.apiKeys...)
+	for key := range s.adminKeys {
+		keys = append(keys, key)
+	}
+	s.router.Use(middleware.APIKeyAuth(keys))
 }
 
 // setupRoutes configures routes
@@ -73,6 +93,10 @@ func (s *Server) setupRoutes() {
 		r.Get("/{id}/review-turnaround", teamsHandler.GetReviewTurnaround)
 		r.Get("/{id}/review-engagement", teamsHandler.GetReviewEngagement)
 		r.Get("/{id}/knowledge-sharing", teamsHandler.GetKnowledgeSharing)
+		r.Get("/{id}/work-mix", teamsHandler.GetWorkMix)
+		r.Get("/{id}/bug-flow", teamsHandler.GetBugFlow)
+		r.Get("/{id}/bug-age", teamsHandler.GetBugAge)
+		r.Get("/{id}/bug-time-to-close", teamsHandler.GetBugTimeToClose)
 
 		// New endpoints for commits and comments
 		r.Get("/{id}/commits", teamsHandler.GetCommits)
@@ -81,6 +105,30 @@ func (s *Server) setupRoutes() {
 		r.Get("/{id}/members/{username}/comments", teamsHandler.GetMemberComments)
 	})
 
+	// Repositories endpoints
+	repositoriesHandler := handlers.NewRepositoriesHandler(s.metricsService)
+	s.router.Route("/api/v1/repositories", func(r chi.Router) {
+		r.Get("/", repositoriesHandler.ListRepositories)
+		r.Get("/{id}", repositoriesHandler.GetRepository)
+		r.Get("/{id}/velocity", repositoriesHandler.GetVelocity)
+		r.Get("/{id}/lead-time", repositoriesHandler.GetLeadTime)
+		r.Get("/{id}/review-turnaround", repositoriesHandler.GetReviewTurnaround)
+	})
+
+	// Admin endpoints (admin-scoped API keys only)
+	adminHandler := handlers.NewAdminHandler(s.teamManager, s.repositories)
+	s.router.Route("/api/v1/admin", func(r chi.Router) {
+		r.Use(middleware.AdminKeyAuth(s.adminKeys))
+		r.Post("/teams", adminHandler.CreateTeam)
+		r.Patch("/teams/{id}", adminHandler.UpdateTeam)
+		r.Post("/teams/{id}/members", adminHandler.AddMember)
+		r.Patch("/teams/{id}/members/{username}", adminHandler.UpdateMember)
+		r.Delete("/teams/{id}/members/{username}", adminHandler.RemoveMember)
+		r.Post("/repositories", adminHandler.CreateRepository)
+		r.Patch("/repositories/{id}", adminHandler.UpdateRepository)
+		r.Get("/audit", adminHandler.ListAudit)
+	})
+
 	// Serve the dashboard static files from web/ directory
 	fs := http.FileServer(http.Dir("web"))
 	s.router.Handle("/*", fs)
@@ -106,3 +154,20 @@ func parseAPIKeys(keys string) []string {
 	}
 	return result
 }
+
+// parseAdminKeys splits comma-separated "name:key" admin keys.
+// Keys without a name are recorded in the audit log as "admin".
+func parseAdminKeys(keys string) map[string]string {
+	result := make(map[string]string)
+	for _, entry := range parseAPIKeys(keys) {
+		name, key, found := strings.Cut(entry, ":")
+		if !found {
+			name, key = "admin", entry
+		}
+		name, key = strings.TrimSpace(name), strings.TrimSpace(key)
+		if key != "" {
+			result[key] = name
+		}
+	}
+	return result
+}
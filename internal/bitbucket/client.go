@@ -0,0 +1,150 @@
+// Package bitbucket reads pull requests, their participants' approvals and
+// comments, and commits from Bitbucket Cloud (API 2.0) and Bitbucket Data
+// Center/Server (REST API 1.0), each as a source.Provider
+package bitbucket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errNotFound is returned for paths Bitbucket doesn't know, or the credentials can't see
+var errNotFound = errors.New("not found")
+
+// codeOwnersPath is where Bitbucket reads CODEOWNERS from
+const codeOwnersPath = ".bitbucket/CODEOWNERS"
+
+// maxAttempts is how many times a rate-limited request is sent
+const maxAttempts = 3
+
+// api sends authenticated requests to a Bitbucket REST API
+type api struct {
+	baseURL  string
+	username string
+	token    string
+	http     *http.Client
+}
+
+// newAPI creates an API client for baseURL. With a username the token is an
+// app password (Cloud) or password; without one it is sent as a bearer
+// access token (Cloud repository/workspace tokens, Data Center HTTP access tokens).
+func newAPI(baseURL, username, token string) api {
+	return api{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		username: username,
+		token:    token,
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// get fetches an API path, or an absolute URL such as a Cloud next page
+// link, and decodes the JSON response into out
+func (a api) get(pathOrURL string, out interface{}) error {
+	resp, err := a.do(pathOrURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode Bitbucket response: %w", err)
+	}
+	return nil
+}
+
+// raw fetches an API path's response body as is
+func (a api) raw(path string) ([]byte, error) {
+	resp, err := a.do(path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Bitbucket response: %w", err)
+	}
+	return data, nil
+}
+
+// do sends a GET request, returning the response if it succeeded.
+// Rate-limited requests are retried after the wait Bitbucket asks for.
+func (a api) do(pathOrURL string) (*http.Response, error) {
+	target := pathOrURL
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		target = a.baseURL + pathOrURL
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Bitbucket request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	switch {
+	case a.username != "":
+		req.SetBasicAuth(a.username, a.token)
+	case a.token != "":
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := a.http.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call Bitbucket: %w", err)
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			return resp, nil
+		case resp.StatusCode == http.StatusTooManyRequests && attempt < maxAttempts:
+			resp.Body.Close()
+			wait := retryAfter(resp.Header)
+			fmt.Printf("  ⏳ Bitbucket rate limit reached. Waiting %v...\n", wait)
+			time.Sleep(wait)
+		case resp.StatusCode == http.StatusNotFound:
+			resp.Body.Close()
+			return nil, errNotFound
+		default:
+			resp.Body.Close()
+			return nil, fmt.Errorf("bitbucket returned %s for %s", resp.Status, pathOrURL)
+		}
+	}
+}
+
+// retryAfter returns how long Bitbucket asks rate-limited clients to wait
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds < 1 {
+		return time.Minute
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// keepUntil filters items to those at or before until, the end of a backfill
+// window, or keeps them all if until is zero
+func keepUntil[T any](items []T, until time.Time, at func(T) time.Time) []T {
+	if until.IsZero() {
+		return items
+	}
+	kept := items[:0]
+	for _, item := range items {
+		if !at(item).After(until) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// parseAuthor splits a git author line ("Alice Example <alice@example.com>")
+// into its name and email
+func parseAuthor(raw string) (name, email string) {
+	name, rest, found := strings.Cut(raw, "<")
+	if !found {
+		return strings.TrimSpace(raw), ""
+	}
+	return strings.TrimSpace(name), strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), ">"))
+}
@@ -0,0 +1,126 @@
+package bitbucket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cloudAPI is the base URL recorded Cloud responses link to
+const cloudAPI = "https://api.bitbucket.org/2.0"
+
+// serveRecorded serves the recorded responses in testdata/<dir>, keyed by
+// escaped path (and page or start, for later pages). Links to Bitbucket
+// Cloud in the responses are rewritten to the server, and requests are
+// rejected unless authorized says otherwise.
+func serveRecorded(t *testing.T, dir string, responses map[string]string, authorized func(*http.Request) bool) string {
+	t.Helper()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		key := r.URL.EscapedPath()
+		if page := r.URL.Query().Get("page"); page != "" && page != "1" {
+			key += "?page=" + page
+		}
+		if start := r.URL.Query().Get("start"); start != "" && start != "0" {
+			key += "?start=" + start
+		}
+		file, ok := responses[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", dir, file))
+		if err != nil {
+			t.Errorf("failed to read recorded response: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(strings.ReplaceAll(string(data), cloudAPI, server.URL+"/2.0")))
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+// at parses an RFC 3339 timestamp
+func at(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+// TestAuthentication tests sending app passwords as basic auth and tokens as bearer tokens
+func TestAuthentication(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		token    string
+		want     func(*http.Request) bool
+	}{
+		{
+			name:     "app password",
+			username: "alice",
+			token:    "app-password",
+			want: func(r *http.Request) bool {
+				username, password, ok := r.BasicAuth()
+				return ok && username == "alice" && password == "app-password"
+			},
+		},
+		{
+			name:  "access token",
+			token: "access-token",
+			want: func(r *http.Request) bool {
+				return r.Header.Get("Authorization") == "Bearer access-token"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverURL := serveRecorded(t, "cloud", map[string]string{
+				"/2.0/repositories/acme/payments": "repository.json",
+			}, tt.want)
+
+			branch, err := NewCloudClient(serverURL+"/2.0/", tt.username, tt.token).DefaultBranch("acme", "payments")
+			if err != nil {
+				t.Fatalf("DefaultBranch() error = %v", err)
+			}
+			if branch != "main" {
+				t.Errorf("DefaultBranch() = %q, want main", branch)
+			}
+		})
+	}
+}
+
+// TestParseAuthor tests splitting git author lines
+func TestParseAuthor(t *testing.T) {
+	tests := []struct {
+		raw       string
+		wantName  string
+		wantEmail string
+	}{
+		{"Alice Example <alice@acme.example>", "Alice Example", "alice@acme.example"},
+		{"dave <dave@contractor.example>", "dave", "dave@contractor.example"},
+		{"build bot", "build bot", ""},
+		{"<ci@acme.example>", "", "ci@acme.example"},
+	}
+
+	for _, tt := range tests {
+		name, email := parseAuthor(tt.raw)
+		if name != tt.wantName || email != tt.wantEmail {
+			t.Errorf("parseAuthor(%q) = %q, %q, want %q, %q", tt.raw, name, email, tt.wantName, tt.wantEmail)
+		}
+	}
+}
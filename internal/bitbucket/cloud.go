@@ -0,0 +1,444 @@
+package bitbucket
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/source"
+)
+
+// Cloud is a Bitbucket Cloud API 2.0 client. Repositories are identified by
+// workspace as owner and repository slug as repo; people by their nickname.
+type Cloud struct {
+	api api
+}
+
+var _ source.Provider = (*Cloud)(nil)
+
+// NewCloudClient creates a Bitbucket Cloud client for the API at baseURL
+// (https://api.bitbucket.org/2.0/), authenticating with a username and app
+// password, or with an access token if username is empty
+func NewCloudClient(baseURL, username, token string) *Cloud {
+	return &Cloud{api: newAPI(baseURL, username, token)}
+}
+
+// cloudPage is a page of a Cloud list, linking to the next one
+type cloudPage[T any] struct {
+	Values []T    `json:"values"`
+	Next   string `json:"next"`
+}
+
+// listCloud fetches the pages of a Cloud list until one has no next link or
+// stop reports true for an item, which is left out along with the rest
+func listCloud[T any](a api, path string, stop func(T) bool) ([]T, error) {
+	var items []T
+	for next := path; next != ""; {
+		var page cloudPage[T]
+		if err := a.get(next, &page); err != nil {
+			return nil, err
+		}
+		for _, item := range page.Values {
+			if stop != nil && stop(item) {
+				return items, nil
+			}
+			items = append(items, item)
+		}
+		next = page.Next
+	}
+	return items, nil
+}
+
+// cloudUser is an account as embedded in other objects
+type cloudUser struct {
+	Nickname string `json:"nickname"`
+}
+
+// cloudRef is the branch at either end of a pull request
+type cloudRef struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+}
+
+// cloudPullRequest is a pull request as listed by the API
+type cloudPullRequest struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	State       string    `json:"state"` // OPEN, MERGED, DECLINED or SUPERSEDED
+	Author      cloudUser `json:"author"`
+	CreatedOn   time.Time `json:"created_on"`
+	UpdatedOn   time.Time `json:"updated_on"`
+	Source      cloudRef  `json:"source"`
+	Destination cloudRef  `json:"destination"`
+}
+
+// cloudActivity is an entry in a pull request's activity log; one of its fields is set
+type cloudActivity struct {
+	Approval *struct {
+		Date time.Time `json:"date"`
+		User cloudUser `json:"user"`
+	} `json:"approval"`
+	ChangesRequested *struct {
+		Date time.Time `json:"date"`
+		User cloudUser `json:"user"`
+	} `json:"changes_requested"`
+	Comment *struct {
+		User      cloudUser `json:"user"`
+		CreatedOn time.Time `json:"created_on"`
+	} `json:"comment"`
+	Update *struct {
+		State string    `json:"state"`
+		Date  time.Time `json:"date"`
+	} `json:"update"`
+}
+
+// cloudComment is a comment on a pull request, inline on its diff or general
+type cloudComment struct {
+	ID      int64 `json:"id"`
+	Content struct {
+		Raw string `json:"raw"`
+	} `json:"content"`
+	User      cloudUser `json:"user"`
+	CreatedOn time.Time `json:"created_on"`
+	Deleted   bool      `json:"deleted"`
+	Parent    *struct {
+		ID int64 `json:"id"`
+	} `json:"parent"`
+	Inline *struct {
+		Path string `json:"path"`
+	} `json:"inline"`
+}
+
+// cloudCommit is a commit as listed by the API
+type cloudCommit struct {
+	Hash   string `json:"hash"`
+	Author struct {
+		Raw  string     `json:"raw"` // Git author, "Name <email>"
+		User *cloudUser `json:"user"`
+	} `json:"author"`
+	Date    time.Time `json:"date"`
+	Message string    `json:"message"`
+	Parents []struct {
+		Hash string `json:"hash"`
+	} `json:"parents"`
+}
+
+// repoPath is the API path of a repository
+func (c *Cloud) repoPath(owner, repo string) string {
+	return "/repositories/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+}
+
+// ChangeRequests returns the pull requests updated between since and until
+func (c *Cloud) ChangeRequests(owner, repo string, since, until time.Time) ([]source.ChangeRequest, error) {
+	fmt.Printf("  📥 Fetching PRs from %s/%s (since %s)...\n", owner, repo, since.Format("2006-01-02"))
+
+	prs, err := c.pullRequests(owner, repo, since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]source.ChangeRequest, 0, len(prs))
+	for _, pr := range prs {
+		change := source.ChangeRequest{
+			Number:    pr.ID,
+			Title:     pr.Title,
+			Body:      pr.Description,
+			Author:    pr.Author.Nickname,
+			State:     "open",
+			CreatedAt: pr.CreatedOn,
+			UpdatedAt: pr.UpdatedOn,
+			BaseRef:   pr.Destination.Branch.Name,
+			HeadRef:   pr.Source.Branch.Name,
+		}
+		if pr.State != "OPEN" {
+			// Pull requests don't say when they were closed; their activity does
+			closedAt, err := c.closedAt(owner, repo, pr)
+			if err != nil {
+				return nil, err
+			}
+			change.State = "closed"
+			change.ClosedAt = &closedAt
+			if pr.State == "MERGED" {
+				change.MergedAt = &closedAt
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	fmt.Printf("  ✓ Fetched %d PRs\n", len(changes))
+	return changes, nil
+}
+
+// pullRequests lists the pull requests in any state updated between since and
+// until, or since the given time if until is zero
+func (c *Cloud) pullRequests(owner, repo string, since, until time.Time) ([]cloudPullRequest, error) {
+	path := c.repoPath(owner, repo) + "/pullrequests?state=OPEN&state=MERGED&state=DECLINED&state=SUPERSEDED&sort=-updated_on&pagelen=50"
+	if !until.IsZero() {
+		path += "&q=" + url.QueryEscape(fmt.Sprintf("updated_on <= %s", until.UTC().Format(time.RFC3339)))
+	}
+	prs, err := listCloud(c.api, path, func(pr cloudPullRequest) bool { return pr.UpdatedOn.Before(since) })
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pull requests: %w", err)
+	}
+	return prs, nil
+}
+
+// closedAt returns when a closed pull request was merged, declined or
+// superseded: the latest update to that state in its activity, which is
+// listed newest first, or its last update if the activity doesn't say
+func (c *Cloud) closedAt(owner, repo string, pr cloudPullRequest) (time.Time, error) {
+	var page cloudPage[cloudActivity]
+	path := fmt.Sprintf("%s/pullrequests/%d/activity?pagelen=50", c.repoPath(owner, repo), pr.ID)
+	if err := c.api.get(path, &page); err != nil {
+		return time.Time{}, fmt.Errorf("failed to fetch activity of PR #%d: %w", pr.ID, err)
+	}
+	for _, activity := range page.Values {
+		if activity.Update != nil && activity.Update.State == pr.State {
+			return activity.Update.Date, nil
+		}
+	}
+	return pr.UpdatedOn, nil
+}
+
+// Reviews returns a pull request's reviews from its activity: approvals,
+// requested changes, and a comment review for each comment by someone other
+// than its author
+func (c *Cloud) Reviews(owner, repo string, number int) ([]source.Review, error) {
+	var pr cloudPullRequest
+	if err := c.api.get(fmt.Sprintf("%s/pullrequests/%d", c.repoPath(owner, repo), number), &pr); err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request: %w", err)
+	}
+	path := fmt.Sprintf("%s/pullrequests/%d/activity?pagelen=50", c.repoPath(owner, repo), number)
+	activities, err := listCloud[cloudActivity](c.api, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch activity: %w", err)
+	}
+
+	var reviews []source.Review
+	for _, activity := range activities {
+		switch {
+		case activity.Approval != nil:
+			reviews = append(reviews, source.Review{Author: activity.Approval.User.Nickname, State: source.ReviewApproved, SubmittedAt: activity.Approval.Date})
+		case activity.ChangesRequested != nil:
+			reviews = append(reviews, source.Review{Author: activity.ChangesRequested.User.Nickname, State: source.ReviewChangesRequested, SubmittedAt: activity.ChangesRequested.Date})
+		case activity.Comment != nil && activity.Comment.User.Nickname != pr.Author.Nickname:
+			reviews = append(reviews, source.Review{Author: activity.Comment.User.Nickname, State: source.ReviewCommented, SubmittedAt: activity.Comment.CreatedOn})
+		}
+	}
+	return reviews, nil
+}
+
+// ReviewComments returns the inline comments on a pull request's diff and the replies to them
+func (c *Cloud) ReviewComments(owner, repo string, number int) ([]source.ReviewComment, error) {
+	comments, roots, err := c.comments(owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []source.ReviewComment
+	for _, comment := range comments {
+		root := roots[comment.ID]
+		if root.Inline == nil {
+			continue
+		}
+		reviewComment := source.ReviewComment{ID: comment.ID, Author: comment.User.Nickname, CreatedAt: comment.CreatedOn}
+		if root.ID != comment.ID {
+			reviewComment.InReplyTo = root.ID
+		}
+		result = append(result, reviewComment)
+	}
+	return result, nil
+}
+
+// comments fetches a pull request's comments that weren't deleted, and the
+// comment starting each one's thread
+func (c *Cloud) comments(owner, repo string, number int) ([]cloudComment, map[int64]cloudComment, error) {
+	path := fmt.Sprintf("%s/pullrequests/%d/comments?pagelen=100", c.repoPath(owner, repo), number)
+	all, err := listCloud[cloudComment](c.api, path, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch comments: %w", err)
+	}
+
+	byID := make(map[int64]cloudComment, len(all))
+	for _, comment := range all {
+		byID[comment.ID] = comment
+	}
+	roots := make(map[int64]cloudComment, len(all))
+	var comments []cloudComment
+	for _, comment := range all {
+		root := comment
+		for root.Parent != nil {
+			parent, ok := byID[root.Parent.ID]
+			if !ok {
+				break
+			}
+			root = parent
+		}
+		roots[comment.ID] = root
+		if !comment.Deleted {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, roots, nil
+}
+
+// ChangeRequestCommits returns a pull request's commits
+func (c *Cloud) ChangeRequestCommits(owner, repo string, number int) ([]source.Commit, error) {
+	path := fmt.Sprintf("%s/pullrequests/%d/commits?pagelen=100", c.repoPath(owner, repo), number)
+	commits, err := listCloud[cloudCommit](c.api, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request commits: %w", err)
+	}
+	return convertCloudCommits(commits), nil
+}
+
+// ChangeRequestFiles returns the paths a pull request changes, including the
+// old paths of moved files
+func (c *Cloud) ChangeRequestFiles(owner, repo string, number int) ([]string, error) {
+	type file struct {
+		Path string `json:"path"`
+	}
+	path := fmt.Sprintf("%s/pullrequests/%d/diffstat?pagelen=100", c.repoPath(owner, repo), number)
+	stats, err := listCloud[struct {
+		Old *file `json:"old"`
+		New *file `json:"new"`
+	}](c.api, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request files: %w", err)
+	}
+
+	var files []string
+	for _, stat := range stats {
+		if stat.New != nil {
+			files = append(files, stat.New.Path)
+		}
+		if stat.Old != nil && (stat.New == nil || stat.Old.Path != stat.New.Path) {
+			files = append(files, stat.Old.Path)
+		}
+	}
+	return files, nil
+}
+
+// Commits returns the commits on the main branch between since and until
+func (c *Cloud) Commits(owner, repo string, since, until time.Time) ([]source.Commit, error) {
+	fmt.Printf("  📥 Fetching Commits from %s/%s (since %s)...\n", owner, repo, since.Format("2006-01-02"))
+
+	branch, err := c.DefaultBranch(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	path := c.repoPath(owner, repo) + "/commits/" + url.PathEscape(branch) + "?pagelen=100"
+	commits, err := listCloud(c.api, path, func(commit cloudCommit) bool { return commit.Date.Before(since) })
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch commits: %w", err)
+	}
+	commits = keepUntil(commits, until, func(commit cloudCommit) time.Time { return commit.Date })
+
+	fmt.Printf("  ✓ Fetched %d commits\n", len(commits))
+	return convertCloudCommits(commits), nil
+}
+
+// Issues returns no issues: Bitbucket teams track theirs in Jira, which is
+// linked through issue keys
+func (c *Cloud) Issues(owner, repo string, since, until time.Time) ([]source.Issue, error) {
+	return nil, nil
+}
+
+// Comments returns the general (not inline) comments made between since and
+// until on the pull requests updated since then. Commenting updates a pull
+// request, so those updated after until are read too.
+func (c *Cloud) Comments(owner, repo string, since, until time.Time) ([]source.Comment, error) {
+	fmt.Printf("  📥 Fetching Comments from %s/%s (since %s)...\n", owner, repo, since.Format("2006-01-02"))
+
+	prs, err := c.pullRequests(owner, repo, since, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []source.Comment
+	for _, pr := range prs {
+		comments, roots, err := c.comments(owner, repo, pr.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, comment := range comments {
+			if roots[comment.ID].Inline != nil || comment.CreatedOn.Before(since) || (!until.IsZero() && comment.CreatedOn.After(until)) {
+				continue
+			}
+			number := pr.ID
+			result = append(result, source.Comment{
+				ID:          comment.ID,
+				Kind:        source.CommentChangeRequest,
+				Author:      comment.User.Nickname,
+				Body:        comment.Content.Raw,
+				CreatedAt:   comment.CreatedOn,
+				IssueNumber: &number,
+			})
+		}
+	}
+
+	fmt.Printf("  ✓ Fetched %d comments\n", len(result))
+	return result, nil
+}
+
+// Deployments returns no deployments: Bitbucket Pipelines deployments aren't read
+func (c *Cloud) Deployments(owner, repo, environment string, since, until time.Time) ([]source.Deployment, error) {
+	return nil, nil
+}
+
+// CodeOwners returns the repository's .bitbucket/CODEOWNERS file on its main
+// branch, or nil if it has none
+func (c *Cloud) CodeOwners(owner, repo string) ([]byte, error) {
+	branch, err := c.DefaultBranch(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.api.raw(c.repoPath(owner, repo) + "/src/" + url.PathEscape(branch) + "/" + codeOwnersPath)
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch CODEOWNERS: %w", err)
+	}
+	return data, nil
+}
+
+// DefaultBranch returns the repository's main branch
+func (c *Cloud) DefaultBranch(owner, repo string) (string, error) {
+	var repository struct {
+		MainBranch struct {
+			Name string `json:"name"`
+		} `json:"mainbranch"`
+	}
+	if err := c.api.get(c.repoPath(owner, repo), &repository); err != nil {
+		return "", fmt.Errorf("failed to fetch repository: %w", err)
+	}
+	return repository.MainBranch.Name, nil
+}
+
+// convertCloudCommits converts commits, taking the author's nickname from
+// their linked account, if any, and their name and email from git
+func convertCloudCommits(commits []cloudCommit) []source.Commit {
+	result := make([]source.Commit, 0, len(commits))
+	for _, commit := range commits {
+		name, email := parseAuthor(commit.Author.Raw)
+		converted := source.Commit{
+			SHA:         commit.Hash,
+			AuthorName:  name,
+			AuthorEmail: email,
+			AuthoredAt:  commit.Date,
+			Message:     commit.Message,
+		}
+		if commit.Author.User != nil {
+			converted.Login = commit.Author.User.Nickname
+		}
+		for _, parent := range commit.Parents {
+			converted.Parents = append(converted.Parents, parent.Hash)
+		}
+		result = append(result, converted)
+	}
+	return result
+}
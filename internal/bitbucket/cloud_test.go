@@ -0,0 +1,183 @@
+package bitbucket
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/source"
+)
+
+// cloudRepo is the API path of the repository the recorded Cloud responses are from
+const cloudRepo = "/2.0/repositories/acme/payments"
+
+// newRecordedCloud serves the recorded Cloud responses for acme/payments
+func newRecordedCloud(t *testing.T) *Cloud {
+	t.Helper()
+
+	serverURL := serveRecorded(t, "cloud", map[string]string{
+		cloudRepo:                              "repository.json",
+		cloudRepo + "/pullrequests":            "pullrequests.json",
+		cloudRepo + "/pullrequests?page=2":     "pullrequests-page2.json",
+		cloudRepo + "/pullrequests/7":          "pullrequest-7.json",
+		cloudRepo + "/pullrequests/7/activity": "activity-7.json",
+		cloudRepo + "/pullrequests/6/activity": "activity-6.json",
+		cloudRepo + "/pullrequests/7/comments": "comments-7.json",
+		cloudRepo + "/pullrequests/6/comments": "empty.json",
+		cloudRepo + "/pullrequests/8/comments": "empty.json",
+		cloudRepo + "/pullrequests/7/commits":  "commits-7.json",
+		cloudRepo + "/pullrequests/7/diffstat": "diffstat-7.json",
+		cloudRepo + "/commits/main":            "commits-main.json",
+	}, func(r *http.Request) bool {
+		username, password, ok := r.BasicAuth()
+		return ok && username == "alice" && password == "app-password"
+	})
+
+	return NewCloudClient(serverURL+"/2.0/", "alice", "app-password")
+}
+
+// TestCloudChangeRequests tests listing pull requests across pages until one
+// was last updated before since, and reading closed ones' close times from their activity
+func TestCloudChangeRequests(t *testing.T) {
+	client := newRecordedCloud(t)
+
+	changes, err := client.ChangeRequests("acme", "payments", at(t, "2026-03-01T00:00:00Z"), time.Time{})
+	if err != nil {
+		t.Fatalf("ChangeRequests() error = %v", err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("ChangeRequests() returned %d pull requests, want 3", len(changes))
+	}
+
+	merged := changes[0]
+	if merged.Number != 7 || merged.Author != "alice" || merged.State != "closed" || merged.HeadRef != "feature/PAY-12-retries" || merged.BaseRef != "main" {
+		t.Errorf("merged PR = %+v", merged)
+	}
+	if merged.MergedAt == nil || !merged.MergedAt.Equal(at(t, "2026-03-04T16:00:00Z")) {
+		t.Errorf("merged PR MergedAt = %v, want 2026-03-04T16:00:00Z", merged.MergedAt)
+	}
+
+	declined := changes[1]
+	if declined.Number != 6 || declined.State != "closed" || declined.MergedAt != nil {
+		t.Errorf("declined PR = %+v", declined)
+	}
+	if declined.ClosedAt == nil || !declined.ClosedAt.Equal(at(t, "2026-03-03T09:30:00Z")) {
+		t.Errorf("declined PR ClosedAt = %v, want 2026-03-03T09:30:00Z", declined.ClosedAt)
+	}
+
+	open := changes[2]
+	if open.Number != 8 || open.State != "open" || open.ClosedAt != nil {
+		t.Errorf("open PR = %+v", open)
+	}
+}
+
+// TestCloudReviews tests mapping approvals, requested changes and others' comments to reviews
+func TestCloudReviews(t *testing.T) {
+	client := newRecordedCloud(t)
+
+	reviews, err := client.Reviews("acme", "payments", 7)
+	if err != nil {
+		t.Fatalf("Reviews() error = %v", err)
+	}
+
+	want := []source.Review{
+		{Author: "bob", State: source.ReviewApproved, SubmittedAt: at(t, "2026-03-03T15:00:00Z")},
+		{Author: "carol", State: source.ReviewCommented, SubmittedAt: at(t, "2026-03-03T09:00:00Z")},
+		{Author: "carol", State: source.ReviewChangesRequested, SubmittedAt: at(t, "2026-03-02T14:00:00Z")},
+		{Author: "bob", State: source.ReviewCommented, SubmittedAt: at(t, "2026-03-02T11:00:00Z")},
+	}
+	if len(reviews) != len(want) {
+		t.Fatalf("Reviews() = %+v, want %+v", reviews, want)
+	}
+	for i := range want {
+		if reviews[i].Author != want[i].Author || reviews[i].State != want[i].State || !reviews[i].SubmittedAt.Equal(want[i].SubmittedAt) {
+			t.Errorf("review %d = %+v, want %+v", i, reviews[i], want[i])
+		}
+	}
+}
+
+// TestCloudComments tests splitting comments into inline threads and general
+// comments, replies following their thread and deleted comments left out
+func TestCloudComments(t *testing.T) {
+	client := newRecordedCloud(t)
+
+	reviewComments, err := client.ReviewComments("acme", "payments", 7)
+	if err != nil {
+		t.Fatalf("ReviewComments() error = %v", err)
+	}
+	var threads [][2]int64
+	for _, comment := range reviewComments {
+		threads = append(threads, [2]int64{comment.ID, comment.InReplyTo})
+	}
+	if want := [][2]int64{{101, 0}, {102, 101}, {103, 101}}; !reflect.DeepEqual(threads, want) {
+		t.Errorf("ReviewComments() IDs and threads = %v, want %v", threads, want)
+	}
+
+	comments, err := client.Comments("acme", "payments", at(t, "2026-03-01T00:00:00Z"), time.Time{})
+	if err != nil {
+		t.Fatalf("Comments() error = %v", err)
+	}
+	if len(comments) != 1 {
+		t.Fatalf("Comments() = %+v, want 1 comment", comments)
+	}
+	comment := comments[0]
+	if comment.ID != 104 || comment.Kind != source.CommentChangeRequest || comment.Author != "carol" || comment.IssueNumber == nil || *comment.IssueNumber != 7 {
+		t.Errorf("comment = %+v", comment)
+	}
+}
+
+// TestCloudCommits tests listing the main branch until a commit before since,
+// with accounts only for authors linked to one
+func TestCloudCommits(t *testing.T) {
+	client := newRecordedCloud(t)
+
+	commits, err := client.Commits("acme", "payments", at(t, "2026-03-01T00:00:00Z"), time.Time{})
+	if err != nil {
+		t.Fatalf("Commits() error = %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("Commits() returned %d commits, want 2", len(commits))
+	}
+	if merge := commits[0]; merge.SHA != "e4d5c6" || !merge.IsMerge() || merge.Login != "alice" || merge.AuthorName != "Alice Example" {
+		t.Errorf("merge commit = %+v", merge)
+	}
+	if unlinked := commits[1]; unlinked.Login != "" || unlinked.AuthorName != "dave" || unlinked.AuthorEmail != "dave@contractor.example" || unlinked.IsMerge() {
+		t.Errorf("unlinked commit = %+v", unlinked)
+	}
+
+	prCommits, err := client.ChangeRequestCommits("acme", "payments", 7)
+	if err != nil {
+		t.Fatalf("ChangeRequestCommits() error = %v", err)
+	}
+	if len(prCommits) != 1 || prCommits[0].SHA != "c7a1b2" || prCommits[0].AuthorEmail != "alice@acme.example" {
+		t.Errorf("ChangeRequestCommits() = %+v", prCommits)
+	}
+}
+
+// TestCloudChangeRequestFiles tests listing changed paths with renamed files' old paths
+func TestCloudChangeRequestFiles(t *testing.T) {
+	client := newRecordedCloud(t)
+
+	files, err := client.ChangeRequestFiles("acme", "payments", 7)
+	if err != nil {
+		t.Fatalf("ChangeRequestFiles() error = %v", err)
+	}
+	want := []string{"billing/retry.go", "billing/charges_test.go", "billing/charge_test.go", "billing/backoff.go"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("ChangeRequestFiles() = %v, want %v", files, want)
+	}
+}
+
+// TestCloudCodeOwners tests that a repository without CODEOWNERS has none
+func TestCloudCodeOwners(t *testing.T) {
+	client := newRecordedCloud(t)
+
+	data, err := client.CodeOwners("acme", "payments")
+	if err != nil {
+		t.Fatalf("CodeOwners() error = %v", err)
+	}
+	if data != nil {
+		t.Errorf("CodeOwners() = %q, want nil", data)
+	}
+}
@@ -0,0 +1,438 @@
+package bitbucket
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/source"
+)
+
+// DataCenter is a Bitbucket Data Center (or Server) REST API 1.0 client.
+// Repositories are identified by project key as owner and repository slug as
+// repo; people by their username.
+type DataCenter struct {
+	api api
+}
+
+var _ source.Provider = (*DataCenter)(nil)
+
+// NewDataCenterClient creates a Bitbucket Data Center client for the API at
+// baseURL (e.g. https://bitbucket.example.com/rest/api/1.0/), authenticating
+// with a username and password, or with an HTTP access token if username is empty
+func NewDataCenterClient(baseURL, username, token string) *DataCenter {
+	return &DataCenter{api: newAPI(baseURL, username, token)}
+}
+
+// dcPage is a page of a Data Center list
+type dcPage[T any] struct {
+	Values        []T  `json:"values"`
+	IsLastPage    bool `json:"isLastPage"`
+	NextPageStart int  `json:"nextPageStart"`
+}
+
+// listDC fetches the pages of a Data Center list until the last one or until
+// stop reports true for an item, which is left out along with the rest
+func listDC[T any](a api, path string, stop func(T) bool) ([]T, error) {
+	var items []T
+	for start := 0; ; {
+		var page dcPage[T]
+		if err := a.get(fmt.Sprintf("%s&start=%d", path, start), &page); err != nil {
+			return nil, err
+		}
+		for _, item := range page.Values {
+			if stop != nil && stop(item) {
+				return items, nil
+			}
+			items = append(items, item)
+		}
+		if page.IsLastPage || len(page.Values) == 0 {
+			return items, nil
+		}
+		start = page.NextPageStart
+	}
+}
+
+// millis is a timestamp in milliseconds since the epoch, as the API writes them
+type millis int64
+
+// Time returns the timestamp as a time
+func (m millis) Time() time.Time {
+	return time.UnixMilli(int64(m)).UTC()
+}
+
+// dcUser is a user as embedded in other objects. Commit authors not linked
+// to a user only have a name (their git name) and email.
+type dcUser struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	EmailAddress string `json:"emailAddress"`
+	DisplayName  string `json:"displayName"`
+}
+
+// dcPullRequest is a pull request as listed by the API
+type dcPullRequest struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state"` // OPEN, MERGED or DECLINED
+	Author      struct {
+		User dcUser `json:"user"`
+	} `json:"author"`
+	CreatedDate millis `json:"createdDate"`
+	UpdatedDate millis `json:"updatedDate"`
+	ClosedDate  millis `json:"closedDate"`
+	FromRef     struct {
+		DisplayID string `json:"displayId"`
+	} `json:"fromRef"`
+	ToRef struct {
+		DisplayID string `json:"displayId"`
+	} `json:"toRef"`
+}
+
+// dcComment is a comment with its replies
+type dcComment struct {
+	ID          int64       `json:"id"`
+	Text        string      `json:"text"`
+	Author      dcUser      `json:"author"`
+	CreatedDate millis      `json:"createdDate"`
+	Comments    []dcComment `json:"comments"`
+}
+
+// dcActivity is an entry in a pull request's activity
+type dcActivity struct {
+	Action        string      `json:"action"`        // APPROVED, REVIEWED (needs work), COMMENTED, ...
+	CommentAction string      `json:"commentAction"` // ADDED, EDITED, ... for COMMENTED
+	CreatedDate   millis      `json:"createdDate"`
+	User          dcUser      `json:"user"`
+	Comment       *dcComment  `json:"comment"`
+	CommentAnchor interface{} `json:"commentAnchor"` // Set for comments on the diff
+}
+
+// dcCommit is a commit as listed by the API
+type dcCommit struct {
+	ID              string `json:"id"`
+	Author          dcUser `json:"author"`
+	AuthorTimestamp millis `json:"authorTimestamp"`
+	Message         string `json:"message"`
+	Parents         []struct {
+		ID string `json:"id"`
+	} `json:"parents"`
+}
+
+// threadComment is a comment flattened out of its thread
+type threadComment struct {
+	comment dcComment
+	root    int64 // Comment starting the thread
+	inline  bool  // The thread is on the diff
+}
+
+// repoPath is the API path of a repository
+func (d *DataCenter) repoPath(owner, repo string) string {
+	return "/projects/" + url.PathEscape(owner) + "/repos/" + url.PathEscape(repo)
+}
+
+// ChangeRequests returns the pull requests updated between since and until
+func (d *DataCenter) ChangeRequests(owner, repo string, since, until time.Time) ([]source.ChangeRequest, error) {
+	fmt.Printf("  📥 Fetching PRs from %s/%s (since %s)...\n", owner, repo, since.Format("2006-01-02"))
+
+	prs, err := d.pullRequests(owner, repo, since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]source.ChangeRequest, 0, len(prs))
+	for _, pr := range prs {
+		change := source.ChangeRequest{
+			Number:    pr.ID,
+			Title:     pr.Title,
+			Body:      pr.Description,
+			Author:    pr.Author.User.Name,
+			State:     "open",
+			CreatedAt: pr.CreatedDate.Time(),
+			UpdatedAt: pr.UpdatedDate.Time(),
+			BaseRef:   pr.ToRef.DisplayID,
+			HeadRef:   pr.FromRef.DisplayID,
+		}
+		if pr.State != "OPEN" {
+			closedAt := pr.ClosedDate.Time()
+			if pr.ClosedDate == 0 {
+				closedAt = change.UpdatedAt
+			}
+			change.State = "closed"
+			change.ClosedAt = &closedAt
+			if pr.State == "MERGED" {
+				change.MergedAt = &closedAt
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	fmt.Printf("  ✓ Fetched %d PRs\n", len(changes))
+	return changes, nil
+}
+
+// pullRequests lists the pull requests in any state updated between since and
+// until, or since the given time if until is zero. The API can't filter by
+// update time, so every page is read.
+func (d *DataCenter) pullRequests(owner, repo string, since, until time.Time) ([]dcPullRequest, error) {
+	all, err := listDC[dcPullRequest](d.api, d.repoPath(owner, repo)+"/pull-requests?state=ALL&order=NEWEST&limit=100", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pull requests: %w", err)
+	}
+
+	var prs []dcPullRequest
+	for _, pr := range all {
+		if !pr.UpdatedDate.Time().Before(since) {
+			prs = append(prs, pr)
+		}
+	}
+	return keepUntil(prs, until, func(pr dcPullRequest) time.Time { return pr.UpdatedDate.Time() }), nil
+}
+
+// Reviews returns a pull request's reviews from its activity: approvals,
+// "needs work" verdicts as requested changes, and a comment review for each
+// comment by someone other than its author
+func (d *DataCenter) Reviews(owner, repo string, number int) ([]source.Review, error) {
+	var pr dcPullRequest
+	if err := d.api.get(fmt.Sprintf("%s/pull-requests/%d", d.repoPath(owner, repo), number), &pr); err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request: %w", err)
+	}
+	activities, err := d.activities(owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	var reviews []source.Review
+	for _, activity := range activities {
+		review := source.Review{Author: activity.User.Name, SubmittedAt: activity.CreatedDate.Time()}
+		switch {
+		case activity.Action == "APPROVED":
+			review.State = source.ReviewApproved
+		case activity.Action == "REVIEWED":
+			review.State = source.ReviewChangesRequested
+		case activity.Action == "COMMENTED" && activity.CommentAction == "ADDED" && activity.User.Name != pr.Author.User.Name:
+			review.State = source.ReviewCommented
+		default:
+			continue
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, nil
+}
+
+// ReviewComments returns the comments on a pull request's diff and the replies to them
+func (d *DataCenter) ReviewComments(owner, repo string, number int) ([]source.ReviewComment, error) {
+	activities, err := d.activities(owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []source.ReviewComment
+	for _, thread := range threadComments(activities) {
+		if !thread.inline {
+			continue
+		}
+		comment := source.ReviewComment{ID: thread.comment.ID, Author: thread.comment.Author.Name, CreatedAt: thread.comment.CreatedDate.Time()}
+		if thread.root != thread.comment.ID {
+			comment.InReplyTo = thread.root
+		}
+		result = append(result, comment)
+	}
+	return result, nil
+}
+
+// activities fetches a pull request's activity, newest first
+func (d *DataCenter) activities(owner, repo string, number int) ([]dcActivity, error) {
+	path := fmt.Sprintf("%s/pull-requests/%d/activities?limit=100", d.repoPath(owner, repo), number)
+	activities, err := listDC[dcActivity](d.api, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch activity: %w", err)
+	}
+	return activities, nil
+}
+
+// threadComments flattens the comment threads in a pull request's activity,
+// each comment once. A thread appears in the activity that added its first
+// comment, with the replies nested, and replies may have activities of their own.
+func threadComments(activities []dcActivity) []threadComment {
+	replies := make(map[int64]bool)
+	var markReplies func(comments []dcComment)
+	markReplies = func(comments []dcComment) {
+		for _, reply := range comments {
+			replies[reply.ID] = true
+			markReplies(reply.Comments)
+		}
+	}
+	for _, activity := range activities {
+		if activity.Comment != nil {
+			markReplies(activity.Comment.Comments)
+		}
+	}
+
+	var result []threadComment
+	var flatten func(comment dcComment, root int64, inline bool)
+	flatten = func(comment dcComment, root int64, inline bool) {
+		result = append(result, threadComment{comment: comment, root: root, inline: inline})
+		for _, reply := range comment.Comments {
+			flatten(reply, root, inline)
+		}
+	}
+	for _, activity := range activities {
+		if activity.Action != "COMMENTED" || activity.CommentAction != "ADDED" || activity.Comment == nil || replies[activity.Comment.ID] {
+			continue
+		}
+		flatten(*activity.Comment, activity.Comment.ID, activity.CommentAnchor != nil)
+	}
+	return result
+}
+
+// ChangeRequestCommits returns a pull request's commits
+func (d *DataCenter) ChangeRequestCommits(owner, repo string, number int) ([]source.Commit, error) {
+	path := fmt.Sprintf("%s/pull-requests/%d/commits?limit=100", d.repoPath(owner, repo), number)
+	commits, err := listDC[dcCommit](d.api, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request commits: %w", err)
+	}
+	return convertDCCommits(commits), nil
+}
+
+// ChangeRequestFiles returns the paths a pull request changes, including the
+// old paths of moved files
+func (d *DataCenter) ChangeRequestFiles(owner, repo string, number int) ([]string, error) {
+	type path struct {
+		ToString string `json:"toString"`
+	}
+	changes, err := listDC[struct {
+		Path    path  `json:"path"`
+		SrcPath *path `json:"srcPath"`
+	}](d.api, fmt.Sprintf("%s/pull-requests/%d/changes?limit=100", d.repoPath(owner, repo), number), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request files: %w", err)
+	}
+
+	var files []string
+	for _, change := range changes {
+		files = append(files, change.Path.ToString)
+		if change.SrcPath != nil && change.SrcPath.ToString != change.Path.ToString {
+			files = append(files, change.SrcPath.ToString)
+		}
+	}
+	return files, nil
+}
+
+// Commits returns the commits on the default branch between since and until
+func (d *DataCenter) Commits(owner, repo string, since, until time.Time) ([]source.Commit, error) {
+	fmt.Printf("  📥 Fetching Commits from %s/%s (since %s)...\n", owner, repo, since.Format("2006-01-02"))
+
+	branch, err := d.DefaultBranch(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	path := d.repoPath(owner, repo) + "/commits?until=" + url.QueryEscape(branch) + "&limit=100"
+	commits, err := listDC(d.api, path, func(commit dcCommit) bool { return commit.AuthorTimestamp.Time().Before(since) })
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch commits: %w", err)
+	}
+	commits = keepUntil(commits, until, func(commit dcCommit) time.Time { return commit.AuthorTimestamp.Time() })
+
+	fmt.Printf("  ✓ Fetched %d commits\n", len(commits))
+	return convertDCCommits(commits), nil
+}
+
+// Issues returns no issues: Bitbucket Data Center has no issue tracker
+func (d *DataCenter) Issues(owner, repo string, since, until time.Time) ([]source.Issue, error) {
+	return nil, nil
+}
+
+// Comments returns the general (not inline) comments made between since and
+// until on the pull requests updated since then. Commenting updates a pull
+// request, so those updated after until are read too.
+func (d *DataCenter) Comments(owner, repo string, since, until time.Time) ([]source.Comment, error) {
+	fmt.Printf("  📥 Fetching Comments from %s/%s (since %s)...\n", owner, repo, since.Format("2006-01-02"))
+
+	prs, err := d.pullRequests(owner, repo, since, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []source.Comment
+	for _, pr := range prs {
+		activities, err := d.activities(owner, repo, pr.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, thread := range threadComments(activities) {
+			createdAt := thread.comment.CreatedDate.Time()
+			if thread.inline || createdAt.Before(since) || (!until.IsZero() && createdAt.After(until)) {
+				continue
+			}
+			number := pr.ID
+			result = append(result, source.Comment{
+				ID:          thread.comment.ID,
+				Kind:        source.CommentChangeRequest,
+				Author:      thread.comment.Author.Name,
+				Body:        thread.comment.Text,
+				CreatedAt:   createdAt,
+				IssueNumber: &number,
+			})
+		}
+	}
+
+	fmt.Printf("  ✓ Fetched %d comments\n", len(result))
+	return result, nil
+}
+
+// Deployments returns no deployments: Bitbucket Data Center doesn't record them
+func (d *DataCenter) Deployments(owner, repo, environment string, since, until time.Time) ([]source.Deployment, error) {
+	return nil, nil
+}
+
+// CodeOwners returns the repository's .bitbucket/CODEOWNERS file on its
+// default branch, or nil if it has none
+func (d *DataCenter) CodeOwners(owner, repo string) ([]byte, error) {
+	data, err := d.api.raw(d.repoPath(owner, repo) + "/raw/" + codeOwnersPath)
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch CODEOWNERS: %w", err)
+	}
+	return data, nil
+}
+
+// DefaultBranch returns the repository's default branch
+func (d *DataCenter) DefaultBranch(owner, repo string) (string, error) {
+	var branch struct {
+		DisplayID string `json:"displayId"`
+	}
+	if err := d.api.get(d.repoPath(owner, repo)+"/branches/default", &branch); err != nil {
+		return "", fmt.Errorf("failed to fetch default branch: %w", err)
+	}
+	return branch.DisplayID, nil
+}
+
+// convertDCCommits converts commits, taking the author's username from their
+// linked user, if any, and their name and email from git
+func convertDCCommits(commits []dcCommit) []source.Commit {
+	result := make([]source.Commit, 0, len(commits))
+	for _, commit := range commits {
+		converted := source.Commit{
+			SHA:         commit.ID,
+			AuthorName:  commit.Author.Name,
+			AuthorEmail: commit.Author.EmailAddress,
+			AuthoredAt:  commit.AuthorTimestamp.Time(),
+			Message:     commit.Message,
+		}
+		if commit.Author.ID != 0 {
+			converted.Login = commit.Author.Name
+			converted.AuthorName = commit.Author.DisplayName
+		}
+		for _, parent := range commit.Parents {
+			converted.Parents = append(converted.Parents, parent.ID)
+		}
+		result = append(result, converted)
+	}
+	return result
+}
@@ -0,0 +1,199 @@
+package bitbucket
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/source"
+)
+
+// dcRepo is the API path of the repository the recorded Data Center responses are from
+const dcRepo = "/rest/api/1.0/projects/PAY/repos/payments"
+
+// newRecordedDataCenter serves the recorded Data Center responses for PAY/payments
+func newRecordedDataCenter(t *testing.T) *DataCenter {
+	t.Helper()
+
+	serverURL := serveRecorded(t, "datacenter", map[string]string{
+		dcRepo + "/branches/default":            "default-branch.json",
+		dcRepo + "/commits":                     "commits.json",
+		dcRepo + "/pull-requests":               "pull-requests.json",
+		dcRepo + "/pull-requests?start=2":       "pull-requests-start2.json",
+		dcRepo + "/pull-requests/12":            "pull-request-12.json",
+		dcRepo + "/pull-requests/12/activities": "activities-12.json",
+		dcRepo + "/pull-requests/11/activities": "empty.json",
+		dcRepo + "/pull-requests/13/activities": "empty.json",
+		dcRepo + "/pull-requests/12/changes":    "changes-12.json",
+		dcRepo + "/pull-requests/12/commits":    "commits-12.json",
+	}, func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer http-access-token"
+	})
+
+	return NewDataCenterClient(serverURL+"/rest/api/1.0/", "", "http-access-token")
+}
+
+// TestDataCenterChangeRequests tests listing pull requests across pages,
+// leaving out ones last updated before since
+func TestDataCenterChangeRequests(t *testing.T) {
+	client := newRecordedDataCenter(t)
+
+	changes, err := client.ChangeRequests("PAY", "payments", at(t, "2026-03-01T00:00:00Z"), time.Time{})
+	if err != nil {
+		t.Fatalf("ChangeRequests() error = %v", err)
+	}
+	var numbers []int
+	for _, change := range changes {
+		numbers = append(numbers, change.Number)
+	}
+	if want := []int{13, 12, 11}; !reflect.DeepEqual(numbers, want) {
+		t.Fatalf("ChangeRequests() numbers = %v, want %v", numbers, want)
+	}
+
+	if open := changes[0]; open.State != "open" || open.ClosedAt != nil || open.Author != "carol" {
+		t.Errorf("open PR = %+v", open)
+	}
+	merged := changes[1]
+	if merged.State != "closed" || merged.HeadRef != "feature/PAY-12-retries" || merged.BaseRef != "main" || merged.Body != "Retries card charges up to 5 times." {
+		t.Errorf("merged PR = %+v", merged)
+	}
+	if merged.MergedAt == nil || !merged.MergedAt.Equal(at(t, "2026-03-04T16:00:00Z")) {
+		t.Errorf("merged PR MergedAt = %v, want 2026-03-04T16:00:00Z", merged.MergedAt)
+	}
+	if declined := changes[2]; declined.State != "closed" || declined.MergedAt != nil || declined.ClosedAt == nil {
+		t.Errorf("declined PR = %+v", declined)
+	}
+
+	// A window ending before #12's last update leaves it to the next window
+	windowed, err := client.ChangeRequests("PAY", "payments", at(t, "2026-03-01T00:00:00Z"), at(t, "2026-03-04T00:00:00Z"))
+	if err != nil {
+		t.Fatalf("ChangeRequests() with until error = %v", err)
+	}
+	numbers = nil
+	for _, change := range windowed {
+		numbers = append(numbers, change.Number)
+	}
+	if want := []int{13, 11}; !reflect.DeepEqual(numbers, want) {
+		t.Errorf("ChangeRequests() with until numbers = %v, want %v", numbers, want)
+	}
+}
+
+// TestDataCenterReviews tests mapping approvals, "needs work" and others' comments to reviews
+func TestDataCenterReviews(t *testing.T) {
+	client := newRecordedDataCenter(t)
+
+	reviews, err := client.Reviews("PAY", "payments", 12)
+	if err != nil {
+		t.Fatalf("Reviews() error = %v", err)
+	}
+
+	want := []source.Review{
+		{Author: "bob", State: source.ReviewApproved, SubmittedAt: at(t, "2026-03-03T15:00:00Z")},
+		{Author: "carol", State: source.ReviewCommented, SubmittedAt: at(t, "2026-03-03T09:00:00Z")},
+		{Author: "bob", State: source.ReviewCommented, SubmittedAt: at(t, "2026-03-02T16:00:00Z")},
+		{Author: "carol", State: source.ReviewChangesRequested, SubmittedAt: at(t, "2026-03-02T14:00:00Z")},
+		{Author: "bob", State: source.ReviewCommented, SubmittedAt: at(t, "2026-03-02T11:00:00Z")},
+	}
+	if len(reviews) != len(want) {
+		t.Fatalf("Reviews() = %+v, want %+v", reviews, want)
+	}
+	for i := range want {
+		if reviews[i].Author != want[i].Author || reviews[i].State != want[i].State || !reviews[i].SubmittedAt.Equal(want[i].SubmittedAt) {
+			t.Errorf("review %d = %+v, want %+v", i, reviews[i], want[i])
+		}
+	}
+}
+
+// TestDataCenterComments tests flattening comment threads once each, with
+// threads anchored to the diff as review comments
+func TestDataCenterComments(t *testing.T) {
+	client := newRecordedDataCenter(t)
+
+	reviewComments, err := client.ReviewComments("PAY", "payments", 12)
+	if err != nil {
+		t.Fatalf("ReviewComments() error = %v", err)
+	}
+	var threads [][2]int64
+	for _, comment := range reviewComments {
+		threads = append(threads, [2]int64{comment.ID, comment.InReplyTo})
+	}
+	if want := [][2]int64{{201, 0}, {202, 201}, {203, 201}}; !reflect.DeepEqual(threads, want) {
+		t.Errorf("ReviewComments() IDs and threads = %v, want %v", threads, want)
+	}
+
+	comments, err := client.Comments("PAY", "payments", at(t, "2026-03-01T00:00:00Z"), time.Time{})
+	if err != nil {
+		t.Fatalf("Comments() error = %v", err)
+	}
+	if len(comments) != 1 {
+		t.Fatalf("Comments() = %+v, want 1 comment", comments)
+	}
+	comment := comments[0]
+	if comment.ID != 204 || comment.Kind != source.CommentChangeRequest || comment.Author != "carol" || comment.IssueNumber == nil || *comment.IssueNumber != 12 {
+		t.Errorf("comment = %+v", comment)
+	}
+}
+
+// TestDataCenterCommits tests listing the default branch until a commit
+// before since, with usernames only for authors linked to a user
+func TestDataCenterCommits(t *testing.T) {
+	client := newRecordedDataCenter(t)
+
+	commits, err := client.Commits("PAY", "payments", at(t, "2026-03-01T00:00:00Z"), time.Time{})
+	if err != nil {
+		t.Fatalf("Commits() error = %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("Commits() returned %d commits, want 2", len(commits))
+	}
+	if merge := commits[0]; !merge.IsMerge() || merge.Login != "alice" || merge.AuthorName != "Alice Example" || merge.AuthorEmail != "alice@acme.example" {
+		t.Errorf("merge commit = %+v", merge)
+	}
+	if unlinked := commits[1]; unlinked.Login != "" || unlinked.AuthorName != "dave" || unlinked.AuthorEmail != "dave@contractor.example" {
+		t.Errorf("unlinked commit = %+v", unlinked)
+	}
+
+	windowed, err := client.Commits("PAY", "payments", at(t, "2026-03-01T00:00:00Z"), at(t, "2026-03-02T00:00:00Z"))
+	if err != nil {
+		t.Fatalf("Commits() with until error = %v", err)
+	}
+	if len(windowed) != 1 || windowed[0].AuthorName != "dave" {
+		t.Errorf("Commits() with until = %+v, want only dave's commit", windowed)
+	}
+
+	prCommits, err := client.ChangeRequestCommits("PAY", "payments", 12)
+	if err != nil {
+		t.Fatalf("ChangeRequestCommits() error = %v", err)
+	}
+	if len(prCommits) != 1 || prCommits[0].SHA != "c7a1b2c7a1b2" {
+		t.Errorf("ChangeRequestCommits() = %+v", prCommits)
+	}
+}
+
+// TestDataCenterChangeRequestFiles tests listing changed paths with moved files' old paths
+func TestDataCenterChangeRequestFiles(t *testing.T) {
+	client := newRecordedDataCenter(t)
+
+	files, err := client.ChangeRequestFiles("PAY", "payments", 12)
+	if err != nil {
+		t.Fatalf("ChangeRequestFiles() error = %v", err)
+	}
+	want := []string{"billing/retry.go", "billing/charges_test.go", "billing/charge_test.go", "billing/backoff.go"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("ChangeRequestFiles() = %v, want %v", files, want)
+	}
+}
+
+// TestDataCenterCodeOwners tests that a repository without CODEOWNERS has none
+func TestDataCenterCodeOwners(t *testing.T) {
+	client := newRecordedDataCenter(t)
+
+	data, err := client.CodeOwners("PAY", "payments")
+	if err != nil {
+		t.Fatalf("CodeOwners() error = %v", err)
+	}
+	if data != nil {
+		t.Errorf("CodeOwners() = %q, want nil", data)
+	}
+}
@@ -0,0 +1,121 @@
+// Package codeowners parses GitHub CODEOWNERS files and matches paths to their owners.
+package codeowners
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rule is one CODEOWNERS line: a path pattern and the owners of matching files
+type Rule struct {
+	Pattern string
+	Owners  []string // @user, @org/team-slug or email
+
+	regex *regexp.Regexp
+}
+
+// Ruleset is a parsed CODEOWNERS file
+type Ruleset struct {
+	Rules []Rule
+}
+
+// Parse parses a CODEOWNERS file. Lines that can't be parsed are reported
+// together; the remaining rules are still returned.
+func Parse(data []byte) (*Ruleset, error) {
+	rs := &Ruleset{}
+	var invalid []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		regex, err := compilePattern(fields[0])
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("line %d: %v", lineNumber, err))
+			continue
+		}
+		rs.Rules = append(rs.Rules, Rule{Pattern: fields[0], Owners: fields[1:], regex: regex})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read CODEOWNERS: %w", err)
+	}
+
+	if len(invalid) > 0 {
+		return rs, fmt.Errorf("invalid CODEOWNERS patterns: %s", strings.Join(invalid, "; "))
+	}
+	return rs, nil
+}
+
+// Owners returns the owners of a path. As on GitHub, the last matching rule
+// wins, and a matching rule without owners leaves the path unowned.
+func (rs *Ruleset) Owners(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	for i := len(rs.Rules) - 1; i >= 0; i-- {
+		if rs.Rules[i].regex.MatchString(path) {
+			return rs.Rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// compilePattern translates a CODEOWNERS pattern (gitignore syntax) to a regex.
+// A pattern with a leading or inner slash is relative to the repository root,
+// otherwise it matches at any depth. A pattern matches files and everything
+// under matching directories, except that a trailing "/*" only matches the
+// files directly in the directory.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, "!") || strings.Contains(pattern, "[") {
+		return nil, fmt.Errorf("unsupported pattern %q", pattern)
+	}
+
+	dirOnly := strings.HasSuffix(pattern, "/")
+	trimmed := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(trimmed, "/")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	if !anchored {
+		expr.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(trimmed); i++ {
+		switch {
+		case strings.HasPrefix(trimmed[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(trimmed[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case trimmed[i] == '*':
+			expr.WriteString("[^/]*")
+		case trimmed[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(trimmed[i : i+1]))
+		}
+	}
+
+	switch {
+	case dirOnly:
+		expr.WriteString("/.*$")
+	case strings.HasSuffix(trimmed, "/*"):
+		expr.WriteString("$")
+	default:
+		expr.WriteString("(?:/.*)?$")
+	}
+
+	return regexp.Compile(expr.String())
+}
@@ -0,0 +1,70 @@
+package codeowners
+
+import (
+	"reflect"
+	"testing"
+)
+
+const sampleCodeOwners = `
+# Default owners
+*       @acme/platform
+
+*.js    @acme/frontend   # trailing comment
+/docs/  docs@acme.com
+apps/*  @acme/apps
+/services/payments/ @acme/payments @alice
+**/migrations @acme/dba
+/vendor/
+`
+
+// TestOwners tests matching paths to owners with last-match-wins rules
+func TestOwners(t *testing.T) {
+	rs, err := Parse([]byte(sampleCodeOwners))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{"default owner", "README.md", []string{"@acme/platform"}},
+		{"extension at any depth", "web/src/app.js", []string{"@acme/frontend"}},
+		{"anchored directory", "docs/guide/intro.md", []string{"docs@acme.com"}},
+		{"anchored directory is not matched deeper", "web/docs/intro.md", []string{"@acme/platform"}},
+		{"single level wildcard", "apps/main.go", []string{"@acme/apps"}},
+		{"single level wildcard skips subdirectories", "apps/cli/main.go", []string{"@acme/platform"}},
+		{"multiple owners", "services/payments/api.go", []string{"@acme/payments", "@alice"}},
+		{"double star directory", "services/payments/migrations/001.sql", []string{"@acme/dba"}},
+		{"rule without owners", "vendor/lib/lib.go", []string{}},
+		{"leading slash in path", "/web/app.js", []string{"@acme/frontend"}},
+		{"later rule wins", "services/payments/web.js", []string{"@acme/payments", "@alice"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rs.Owners(tt.path)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Owners(%s) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseInvalid tests that unsupported patterns are reported but other rules still apply
+func TestParseInvalid(t *testing.T) {
+	rs, err := Parse([]byte("!keep.txt @acme/a\n[ab].go @acme/b\n*.go @acme/go\n"))
+	if err == nil {
+		t.Error("Parse() should report unsupported patterns")
+	}
+	if rs == nil || len(rs.Rules) != 1 {
+		t.Fatalf("Parse() kept %v, want the one valid rule", rs)
+	}
+	if got := rs.Owners("main.go"); !reflect.DeepEqual(got, []string{"@acme/go"}) {
+		t.Errorf("Owners(main.go) = %v, want [@acme/go]", got)
+	}
+}
@@ -0,0 +1,83 @@
+package collector
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+)
+
+// baseBranchRule decides which base branches a repository's PRs count toward
+// delivery metrics for. Patterns are globs as in path.Match, so "release/*"
+// matches "release/1.2" but not "release/1.2/hotfix"; config.DefaultBranchPattern
+// matches the default branch. Without patterns every base matches.
+type baseBranchRule struct {
+	patterns      []string
+	defaultBranch string
+}
+
+// matches reports whether PRs merged into base count
+func (r baseBranchRule) matches(base string) bool {
+	if len(r.patterns) == 0 {
+		return true
+	}
+	for _, pattern := range r.patterns {
+		if pattern == config.DefaultBranchPattern {
+			if base == r.defaultBranch {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// usesDefaultBranch reports whether the rule needs the repository's default branch
+func (r baseBranchRule) usesDefaultBranch() bool {
+	for _, pattern := range r.patterns {
+		if pattern == config.DefaultBranchPattern {
+			return true
+		}
+	}
+	return false
+}
+
+// matchBaseBranches loads a repository's base branch rule and queues stored
+// PRs to be re-checked against it. If the default branch the rule needs can't
+// be fetched, this run's PRs count into every base, but stored PRs keep the
+// matches they had rather than all being marked as matching.
+func (c *Collector) matchBaseBranches(owner, repo string, tracked database.Repository) baseBranchRule {
+	rule, err := c.loadBaseBranchRule(owner, repo, tracked)
+	if err != nil {
+		// Better to count too much for one run than to drop the default branch
+		fmt.Printf("  ⚠️  Failed to fetch default branch, counting this run's PRs into every base: %v\n", err)
+		return rule
+	}
+	c.batch.MatchBaseRefs(tracked.Name, rule.matches)
+	return rule
+}
+
+// loadBaseBranchRule builds a repository's base branch rule, looking up its
+// default branch from the host if the rule needs it and none is configured.
+// If that lookup fails it returns a rule matching every base with the error.
+func (c *Collector) loadBaseBranchRule(owner, repo string, tracked database.Repository) (baseBranchRule, error) {
+	rule := baseBranchRule{patterns: tracked.BaseBranches}
+	if !rule.usesDefaultBranch() {
+		return rule, nil
+	}
+
+	if tracked.DefaultBranch != nil {
+		rule.defaultBranch = *tracked.DefaultBranch
+		return rule, nil
+	}
+	defaultBranch, err := c.provider.DefaultBranch(owner, repo)
+	if err != nil {
+		return baseBranchRule{}, err
+	}
+	rule.defaultBranch = defaultBranch
+	return rule, nil
+}
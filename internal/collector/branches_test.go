@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/dothanhlam/go-github-tracker/internal/source"
+)
+
+// TestBaseBranchRuleMatches tests which base branches count toward delivery metrics
+func TestBaseBranchRuleMatches(t *testing.T) {
+	releases := baseBranchRule{patterns: []string{"default", "release/*"}, defaultBranch: "main"}
+
+	tests := []struct {
+		name string
+		rule baseBranchRule
+		base string
+		want bool
+	}{
+		{"no rules", baseBranchRule{}, "feature/login", true},
+		{"default branch", releases, "main", true},
+		{"release branch", releases, "release/1.2", true},
+		{"nested release branch", releases, "release/1.2/hotfix", false},
+		{"feature branch", releases, "feature/login", false},
+		{"stacked PR", releases, "alice/part-1", false},
+		{"default branch unknown", baseBranchRule{patterns: []string{"default"}}, "main", false},
+		{"literal branch", baseBranchRule{patterns: []string{"develop"}}, "develop", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.matches(tt.base); got != tt.want {
+				t.Errorf("matches(%q) = %v, want %v", tt.base, got, tt.want)
+			}
+		})
+	}
+}
+
+// branchProvider returns a fixed default branch, or fails if it has none
+type branchProvider struct {
+	source.Provider
+	defaultBranch string
+}
+
+func (p branchProvider) DefaultBranch(owner, repo string) (string, error) {
+	if p.defaultBranch == "" {
+		return "", errors.New("not found")
+	}
+	return p.defaultBranch, nil
+}
+
+// TestMatchBaseBranches tests re-checking stored PRs against the base branch
+// rule, and leaving them alone when the default branch can't be fetched
+func TestMatchBaseBranches(t *testing.T) {
+	tests := []struct {
+		name          string
+		defaultBranch string
+		want          map[string]bool
+	}{
+		{"default branch fetched", "main", map[string]bool{"main": true, "feature/login": false}},
+		{"default branch unavailable", "", map[string]bool{"main": false, "feature/login": false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, db := newTestCollector(t, 0)
+			c.provider = branchProvider{defaultBranch: tt.defaultBranch}
+			team := teamID(t, db, "Payments")
+			for number, base := range []string{"main", "feature/login"} {
+				query := "INSERT INTO pr_metrics (team_id, pr_number, repository, author, created_at, state, base_ref, base_matched) VALUES (?, ?, 'acme/api', 'alice', ?, 'open', ?, FALSE)"
+				if _, err := db.Exec(query, team, number+1, time.Now(), base); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tracked := database.Repository{Name: "acme/api", BaseBranches: []string{"default"}}
+			rule := c.matchBaseBranches("acme", "api", tracked)
+			if err := c.batch.Commit("acme/api", time.Now()); err != nil {
+				t.Fatalf("Commit() error = %v", err)
+			}
+
+			for base, want := range tt.want {
+				var matched bool
+				if err := db.Get(&matched, "SELECT base_matched FROM pr_metrics WHERE base_ref = ?", base); err != nil {
+					t.Fatal(err)
+				}
+				if matched != want {
+					t.Errorf("stored PR into %s matched = %v, want %v", base, matched, want)
+				}
+			}
+			// This run's PRs count into every base when the default branch is unknown
+			if tt.defaultBranch == "" && !rule.matches("feature/login") {
+				t.Errorf("fallback rule doesn't match feature/login")
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package collector
+
+import (
+	"regexp"
+	"strings"
+)
+
+// coAuthor is an identity parsed from a Co-authored-by trailer
+type coAuthor struct {
+	Name  string
+	Email string
+}
+
+// coAuthorTrailer matches "Co-authored-by: Name <email>" lines (case-insensitive)
+var coAuthorTrailer = regexp.MustCompile(`(?im)^[ \t]*co-authored-by:[ \t]*(.*?)[ \t]*<([^>]+)>[ \t]*$`)
+
+// parseCoAuthors extracts unique co-authors from a commit message
+func parseCoAuthors(message string) []coAuthor {
+	seen := make(map[string]bool)
+	var coAuthors []coAuthor
+	for _, match := range coAuthorTrailer.FindAllStringSubmatch(message, -1) {
+		email := strings.TrimSpace(match[2])
+		key := strings.ToLower(email)
+		if email == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		coAuthors = append(coAuthors, coAuthor{Name: match[1], Email: email})
+	}
+	return coAuthors
+}
+
+// resolveCoAuthors maps the co-authors of a commit message to team member
+// usernames, dropping unknown identities and the commit's own author
+func (c *Collector) resolveCoAuthors(message, author string) []string {
+	seen := map[string]bool{author: true}
+	var usernames []string
+	for _, ca := range parseCoAuthors(message) {
+		username, ok := c.teamMgr.ResolveMember(ca.Name, ca.Email)
+		if !ok || seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+	}
+	return usernames
+}
@@ -0,0 +1,207 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+)
+
+// TestParseCoAuthors tests extracting Co-authored-by trailers from commit messages
+func TestParseCoAuthors(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []coAuthor
+	}{
+		{
+			name:    "no trailers",
+			message: "Fix login redirect",
+			want:    nil,
+		},
+		{
+			name:    "single trailer",
+			message: "Fix login redirect\n\nCo-authored-by: Alice Smith <alice@example.com>",
+			want:    []coAuthor{{Name: "Alice Smith", Email: "alice@example.com"}},
+		},
+		{
+			name: "multiple trailers with mixed case",
+			message: "Pair on cache layer\n\n" +
+				"co-authored-by: bob <12345+bob@users.noreply.github.com>\n" +
+				"CO-AUTHORED-BY: Charlie <charlie@example.com>",
+			want: []coAuthor{
+				{Name: "bob", Email: "12345+bob@users.noreply.github.com"},
+				{Name: "Charlie", Email: "charlie@example.com"},
+			},
+		},
+		{
+			name: "duplicate emails are collapsed",
+			message: "Mob session\n\n" +
+				"Co-authored-by: Alice <alice@example.com>\n" +
+				"Co-authored-by: Alice Smith <ALICE@example.com>",
+			want: []coAuthor{{Name: "Alice", Email: "alice@example.com"}},
+		},
+		{
+			name:    "trailer text inside a sentence is ignored",
+			message: "Mention Co-authored-by: Alice <alice@example.com> inline",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseCoAuthors(tt.message)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCoAuthors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newTestCollector creates a collector on a migrated SQLite database with two
+// teams: Payments (alice, and bob with a work email) and Platform (carol)
+func newTestCollector(t *testing.T, coAuthorWeight float64) (*Collector, *database.DB) {
+	t.Helper()
+
+	// Migrations are read relative to the repository root
+	originalDir, _ := os.Getwd()
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatalf("failed to change to repository root: %v", err)
+	}
+	defer os.Chdir(originalDir)
+
+	db, err := database.Connect("sqlite3", filepath.Join(t.TempDir(), "collector.db"))
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.RunMigrations(); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	joined := &config.Date{Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c, err := New(&config.Config{
+		TeamSource:     "config",
+		CoAuthorWeight: coAuthorWeight,
+		Teams: []config.TeamConfig{
+			{Name: "Payments", Members: []config.TeamMemberConfig{
+				{Username: "alice", Allocation: 1, JoinedAt: joined},
+				{Username: "bob", Allocation: 1, JoinedAt: joined, Emails: []string{"bob@work.example"}},
+			}},
+			{Name: "Platform", Members: []config.TeamMemberConfig{
+				{Username: "carol", Allocation: 1, JoinedAt: joined},
+			}},
+		},
+	}, db)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	c.batch = c.store.NewBatch()
+	return c, db
+}
+
+// teamID looks up a test team's ID
+func teamID(t *testing.T, db *database.DB, name string) int {
+	t.Helper()
+	var id int
+	if err := db.Get(&id, "SELECT id FROM teams WHERE name = ?", name); err != nil {
+		t.Fatalf("failed to look up team %s: %v", name, err)
+	}
+	return id
+}
+
+// TestResolveCoAuthors tests mapping Co-authored-by trailers to members
+func TestResolveCoAuthors(t *testing.T) {
+	c, _ := newTestCollector(t, 0.5)
+
+	message := `Add billing export
+
+Co-authored-by: Bob <bob@work.example>
+Co-authored-by: Carol <123+carol@users.noreply.github.com>
+Co-authored-by: Alice <alice@home.example>
+Co-authored-by: Bobby <BOB@work.example>
+Co-authored-by: Mallory <mallory@elsewhere.example>`
+
+	got := c.resolveCoAuthors(message, "alice")
+	if want := []string{"bob", "carol"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveCoAuthors() = %v, want %v (without the author, duplicates or unknown identities)", got, want)
+	}
+}
+
+// TestStoreCommitCoAuthors tests crediting a commit's co-authors to the teams they belonged to
+func TestStoreCommitCoAuthors(t *testing.T) {
+	tests := []struct {
+		name           string
+		weight         float64
+		wantCoAuthored int
+	}{
+		{"credited", 0.5, 2},
+		{"co-author attribution off", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, db := newTestCollector(t, tt.weight)
+			createdAt := time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC)
+			stored, coAuthored := c.storeCommit(&database.CommitMetric{
+				Repository:  "acme/api",
+				CommitHash:  "abc123",
+				Author:      "alice",
+				Message:     "Add billing export\n\nCo-authored-by: Bob <bob@work.example>\nCo-authored-by: Carol <carol@users.noreply.github.com>",
+				CreatedAt:   createdAt,
+				CreatedDate: &createdAt,
+			})
+			if err := c.batch.Flush(); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+			if stored != 1 || coAuthored != tt.wantCoAuthored {
+				t.Errorf("storeCommit() = %d, %d, want 1, %d", stored, coAuthored, tt.wantCoAuthored)
+			}
+
+			var credits []struct {
+				TeamID   int     `db:"team_id"`
+				Username string  `db:"github_username"`
+				Weight   float64 `db:"weight"`
+			}
+			if err := db.Select(&credits, "SELECT team_id, github_username, weight FROM commit_coauthors ORDER BY github_username"); err != nil {
+				t.Fatal(err)
+			}
+			if len(credits) != tt.wantCoAuthored {
+				t.Fatalf("commit_coauthors = %+v, want %d credits", credits, tt.wantCoAuthored)
+			}
+			if tt.wantCoAuthored > 0 {
+				if credits[0].Username != "bob" || credits[0].TeamID != teamID(t, db, "Payments") || credits[0].Weight != tt.weight ||
+					credits[1].Username != "carol" || credits[1].TeamID != teamID(t, db, "Platform") {
+					t.Errorf("commit_coauthors = %+v, want bob for Payments and carol for Platform at %v", credits, tt.weight)
+				}
+			}
+		})
+	}
+}
+
+// TestStorePRCoAuthors tests that a PR's co-authors are only credited to the team they belonged to
+func TestStorePRCoAuthors(t *testing.T) {
+	c, db := newTestCollector(t, 0.5)
+	payments := teamID(t, db, "Payments")
+
+	c.storePRCoAuthors(payments, "acme/api", 7, time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC), []string{"bob", "carol"})
+	c.storePRCoAuthors(payments, "acme/api", 8, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), []string{"bob"})
+	if err := c.batch.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	var credits []struct {
+		PRNumber int     `db:"pr_number"`
+		Username string  `db:"github_username"`
+		Weight   float64 `db:"weight"`
+	}
+	if err := db.Select(&credits, "SELECT pr_number, github_username, weight FROM pr_coauthors"); err != nil {
+		t.Fatal(err)
+	}
+	if len(credits) != 1 || credits[0].PRNumber != 7 || credits[0].Username != "bob" || credits[0].Weight != 0.5 {
+		t.Errorf("pr_coauthors = %+v, want bob on PR #7 only (carol is in Platform, bob hadn't joined by PR #8)", credits)
+	}
+}
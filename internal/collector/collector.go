@@ -1,81 +1,284 @@
 package collector
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
 	"time"
 
+	"github.com/dothanhlam/go-github-tracker/internal/bitbucket"
 	"github.com/dothanhlam/go-github-tracker/internal/config"
 	"github.com/dothanhlam/go-github-tracker/internal/database"
 	"github.com/dothanhlam/go-github-tracker/internal/github"
+	"github.com/dothanhlam/go-github-tracker/internal/gitlab"
+	"github.com/dothanhlam/go-github-tracker/internal/issuelink"
+	"github.com/dothanhlam/go-github-tracker/internal/jira"
+	"github.com/dothanhlam/go-github-tracker/internal/lock"
+	"github.com/dothanhlam/go-github-tracker/internal/repository"
+	"github.com/dothanhlam/go-github-tracker/internal/source"
 	"github.com/dothanhlam/go-github-tracker/internal/store"
 	"github.com/dothanhlam/go-github-tracker/internal/team"
-	gh "github.com/google/go-github/v58/github"
+	"github.com/dothanhlam/go-github-tracker/internal/worktype"
 )
 
 // Collector orchestrates PR data collection
 type Collector struct {
-	github  *github.Client
-	teamMgr *team.Manager
-	store   *store.Store
-	config  *config.Config
+	hosts *source.Hosts
+
+	// provider reads the repository being collected from its host
+	provider source.Provider
+
+	// ctx and options are those of the current run
+	ctx     context.Context
+	options RunOptions
+
+	teamMgr    *team.Manager
+	repos      *repository.Registry
+	locks      *lock.Locker
+	store      *store.Store
+	batch      *store.Batch // The rows of the repository being collected
+	workTypes  *worktype.Classifier
+	issueLinks *issuelink.Extractor
+	config     *config.Config
+
+	// jira fetches linked Jira issues (nil unless JIRA_BASE_URL is set);
+	// jiraStarts holds when work started on the issues looked up this run
+	jira       *jira.Client
+	jiraStarts map[string]*time.Time
+
+	// dryRun is set on collectors that plan from the database without writing
+	dryRun bool
 }
 
-// New creates a new collector
+// New creates a new collector, syncing the configured teams and repositories
 func New(cfg *config.Config, db *database.DB) (*Collector, error) {
-	// Create GitHub client
+	return newCollector(cfg, db, false)
+}
+
+// NewDryRun creates a collector for dry runs, which plans from the teams as
+// last synced and previews the repository sync without writing
+func NewDryRun(cfg *config.Config, db *database.DB) (*Collector, error) {
+	return newCollector(cfg, db, true)
+}
+
+// newCollector creates a collector; dry-run ones don't sync teams or repositories
+func newCollector(cfg *config.Config, db *database.DB, dryRun bool) (*Collector, error) {
+	// Create a provider per host; teams are read from github.com
 	ghClient := github.NewClient(cfg.GitHubPAT)
+	hosts := source.NewHosts()
+	hosts.Add(config.DefaultGitHubHost, github.NewProvider(ghClient))
+	for _, host := range cfg.GitHubHosts {
+		client, err := github.NewEnterpriseClient(host.PAT, host.BaseURL, host.UploadURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GitHub client for %s: %w", host.Host, err)
+		}
+		hosts.Add(host.Host, github.NewProvider(client))
+	}
+	for _, host := range cfg.GitLabHosts {
+		hosts.Add(host.Host, gitlab.NewClient(host.BaseURL, host.Token))
+	}
+	for _, host := range cfg.BitbucketHosts {
+		if host.Host == config.BitbucketCloudHost {
+			hosts.Add(host.Host, bitbucket.NewCloudClient(host.BaseURL, host.Username, host.Token))
+		} else {
+			hosts.Add(host.Host, bitbucket.NewDataCenterClient(host.BaseURL, host.Username, host.Token))
+		}
+	}
 
 	// Create team manager
-	teamMgr, err := team.NewManager(db, cfg)
+	newManager := team.NewManagerWithOrg
+	if dryRun {
+		newManager = team.LoadManagerWithOrg
+	}
+	teamMgr, err := newManager(db, cfg, ghClient)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create team manager: %w", err)
 	}
 
+	// Sync tracked repositories after teams, so owning teams resolve
+	repos := repository.NewRegistry(db)
+	if !dryRun {
+		if err := repos.Sync(cfg.RepositorySettings); err != nil {
+			return nil, fmt.Errorf("failed to sync repositories: %w", err)
+		}
+	}
+
 	// Create store
 	st := store.New(db)
 
+	issueLinks, err := issuelink.New(cfg.IssueKeyPattern)
+	if err != nil {
+		return nil, err
+	}
+	var jiraClient *jira.Client
+	if cfg.JiraBaseURL != "" {
+		jiraClient = jira.NewClient(cfg.JiraBaseURL, cfg.JiraEmail, cfg.JiraAPIToken)
+	}
+
 	return &Collector{
-		github:  ghClient,
-		teamMgr: teamMgr,
-		store:   st,
-		config:  cfg,
+		hosts:      hosts,
+		teamMgr:    teamMgr,
+		repos:      repos,
+		locks:      lock.NewLocker(db, lock.DefaultOwner(), time.Duration(cfg.LockTTLSeconds)*time.Second),
+		store:      st,
+		workTypes:  worktype.New(cfg.WorkTypes),
+		issueLinks: issueLinks,
+		config:     cfg,
+		jira:       jiraClient,
+		dryRun:     dryRun,
 	}, nil
 }
 
+// activeRepositories returns the repositories to collect; dry-run collectors
+// preview the configured ones as a sync would leave them
+func (c *Collector) activeRepositories() ([]database.Repository, error) {
+	if c.dryRun {
+		return c.repos.Preview(c.config.RepositorySettings)
+	}
+	return c.repos.Active()
+}
+
 // Run executes the collection process
 func (c *Collector) Run() error {
-	fmt.Printf("📊 Teams configured: %d\n", len(c.teamMgr.GetAllTeamIDs()))
-	fmt.Printf("📦 Repositories to track: %d\n\n", len(c.config.Repositories))
+	_, err := c.RunContext(context.Background(), RunOptions{})
+	return err
+}
 
-	totalPRs := 0
-	for _, repoFullName := range c.config.Repositories {
-		parts := strings.Split(repoFullName, "/")
-		if len(parts) != 2 {
-			fmt.Printf("⚠️  Invalid repository format: %s (expected owner/repo)\n", repoFullName)
+// RunContext collects the repositories selected by options and reports what
+// it did with each. When ctx is done the run stops between pull requests,
+// leaving the unfinished repositories to be collected from where they were.
+func (c *Collector) RunContext(ctx context.Context, options RunOptions) (*Result, error) {
+	if c.dryRun && !options.DryRun {
+		return nil, fmt.Errorf("a dry-run collector can only run dry runs")
+	}
+	fmt.Printf("📊 Teams configured: %d\n", len(c.teamMgr.GetAllTeamIDs()))
+	active, err := c.activeRepositories()
+	if err != nil {
+		return nil, err
+	}
+	repos, err := selectRepositories(active, options.Repositories)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("📦 Repositories to track: %d\n\n", len(repos))
+	c.ctx, c.options = ctx, options
+	c.jiraStarts = make(map[string]*time.Time)
+
+	result := &Result{Repositories: []RepositoryResult{}, DryRun: options.DryRun}
+	for _, tracked := range repos {
+		if ctx.Err() != nil {
+			result.TimedOut = true
+			result.Repositories = append(result.Repositories, RepositoryResult{Name: tracked.Name, Status: StatusNotStarted})
+			continue
+		}
+		if options.DryRun {
+			result.Repositories = append(result.Repositories, c.planRepository(tracked))
 			continue
 		}
 
-		owner, repo := parts[0], parts[1]
-		count, err := c.collectRepository(owner, repo)
+		// Overlapping runs (a schedule and a manual backfill, say) take turns
+		// per repository, so their writes and watermarks don't interleave
+		repoLock, err := c.locks.Acquire(ctx, "repository:"+tracked.Name)
+		if errors.Is(err, lock.ErrHeld) {
+			fmt.Printf("🔒 Skipping %s: %v\n", tracked.Name, err)
+			result.Repositories = append(result.Repositories, RepositoryResult{Name: tracked.Name, Status: StatusLocked, Error: err.Error()})
+			continue
+		}
 		if err != nil {
-			return fmt.Errorf("failed to collect %s/%s: %w", owner, repo, err)
+			return result, err
 		}
-		totalPRs += count
+
+		repoResult := RepositoryResult{Name: tracked.Name, Status: StatusCollected}
+		startedAt := time.Now()
+		c.ctx, c.batch = repoLock.Context(), c.store.NewBatch()
+		if tracked.GitPath != nil {
+			err = c.collectGitRepository(tracked)
+		} else {
+			repoResult.PRs, err = c.collectHostedRepository(tracked)
+		}
+		c.ctx = ctx
+
+		// Rows still buffered when a run stops partway are dropped with its
+		// collection time, so the next run collects them again
+		lost := repoLock.Context().Err() != nil
+		if releaseErr := repoLock.Release(); releaseErr != nil {
+			fmt.Printf("  ⚠️  %v\n", releaseErr)
+		}
+
+		switch {
+		case errors.Is(err, errSkipped):
+			repoResult.Status = StatusSkipped
+		case err != nil && ctx.Err() != nil:
+			fmt.Printf("  ⏱️  Stopped at the deadline: %v\n", err)
+			repoResult.Status = StatusInterrupted
+			result.TimedOut = true
+		case err != nil && lost:
+			fmt.Printf("  🔒 Stopped after losing the repository lock: %v\n", err)
+			repoResult.Status, repoResult.Error = StatusInterrupted, "lost the repository lock to another run"
+		case err != nil:
+			repoResult.Status, repoResult.Error = StatusFailed, err.Error()
+			c.recordRun(repoResult, startedAt)
+			result.Repositories = append(result.Repositories, repoResult)
+			return result, fmt.Errorf("failed to collect %s: %w", tracked.Name, err)
+		}
+		c.recordRun(repoResult, startedAt)
+		result.PRs += repoResult.PRs
+		result.Repositories = append(result.Repositories, repoResult)
 	}
 
-	fmt.Printf("\n✅ Collection complete! Processed %d PRs\n", totalPRs)
-	return nil
+	if options.DryRun {
+		fmt.Printf("\n✅ Dry run complete! Planned %d repositories\n", len(result.Repositories))
+	} else if result.TimedOut {
+		fmt.Printf("\n⏱️  Collection stopped at the deadline! Processed %d PRs\n", result.PRs)
+	} else {
+		fmt.Printf("\n✅ Collection complete! Processed %d PRs\n", result.PRs)
+	}
+	return result, nil
+}
+
+// errSkipped is returned for repositories that can't be collected
+var errSkipped = errors.New("repository skipped")
+
+// collectHostedRepository collects a repository from its code host's API
+func (c *Collector) collectHostedRepository(tracked database.Repository) (int, error) {
+	host, owner, repo, ok := config.SplitRepository(tracked.Name)
+	if !ok {
+		fmt.Printf("⚠️  Invalid repository format: %s (expected owner/repo or host/owner/repo)\n", tracked.Name)
+		return 0, errSkipped
+	}
+	provider, err := c.hosts.Provider(host)
+	if err != nil {
+		fmt.Printf("⚠️  Skipping %s: %v\n", tracked.Name, err)
+		return 0, errSkipped
+	}
+
+	c.provider = provider
+	return c.collectRepository(owner, repo, tracked)
+}
+
+// planRepository reports what a dry run would collect from a repository
+func (c *Collector) planRepository(tracked database.Repository) RepositoryResult {
+	planned := RepositoryResult{Name: tracked.Name, Status: StatusPlanned}
+	since, collectionType, err := c.getCollectionSince(tracked.Name, lookbackDays(tracked, c.config.LookbackDays))
+	if err != nil {
+		planned.Status, planned.Error = StatusFailed, err.Error()
+		return planned
+	}
+	fmt.Printf("🔎 Would collect %s: %s (since %s)\n", tracked.Name, collectionType, since.Format("2006-01-02 15:04:05"))
+	planned.CollectionType, planned.Since = collectionType, &since
+	return planned
 }
 
-// collectRepository collects PRs from a single repository
-func (c *Collector) collectRepository(owner, repo string) (int, error) {
-	repoFullName := fmt.Sprintf("%s/%s", owner, repo)
+// collectRepository collects PRs from a single tracked repository. Its data is
+// stored under the tracked name, which includes the host for GitHub Enterprise
+// Server and GitLab repositories, so the same owner/repo on two hosts doesn't collide.
+func (c *Collector) collectRepository(owner, repo string, tracked database.Repository) (int, error) {
+	repoFullName := tracked.Name
 	fmt.Printf("🔄 Processing repository: %s\n", repoFullName)
 
 	// Determine collection window: incremental or initial
-	since, collectionType, err := c.getCollectionSince(repoFullName)
+	since, collectionType, err := c.getCollectionSince(repoFullName, lookbackDays(tracked, c.config.LookbackDays))
 	if err != nil {
 		return 0, err
 	}
@@ -83,62 +286,120 @@ func (c *Collector) collectRepository(owner, repo string) (int, error) {
 		collectionType, since.Format("2006-01-02 15:04:05"))
 
 	// Fetch PRs with date filter
-	prs, err := c.github.FetchPRs(owner, repo, since)
+	prs, err := c.provider.ChangeRequests(owner, repo, since, c.until())
 	if err != nil {
 		return 0, err
 	}
 
+	// Ownership attribution maps each PR's changed files to teams via CODEOWNERS
+	ownerRules := c.loadCodeOwners(owner, repo)
+
+	// Only PRs into matching base branches count toward delivery metrics.
+	// PRs collected earlier are re-checked in case the rules changed; PRs whose
+	// base changed since (e.g. stacked PRs retargeted after their parent merged)
+	// are fetched again below, since that updates them.
+	baseRule := c.matchBaseBranches(owner, repo, tracked)
+
+	// Likewise, re-classify stored PRs in case the work type mapping changed
+	c.batch.ClassifyWorkTypes(repoFullName, c.workTypes)
+
 	processedCount := 0
 	for i, pr := range prs {
+		if err := c.ctx.Err(); err != nil {
+			return processedCount, err
+		}
 		if (i+1)%10 == 0 {
 			fmt.Printf("  ⏳ Processing PR %d/%d...\n", i+1, len(prs))
 		}
 
 		// Fetch reviews and comments
-		reviews, err := c.github.FetchReviews(owner, repo, pr.GetNumber())
+		reviews, err := c.provider.Reviews(owner, repo, pr.Number)
 		if err != nil {
-			fmt.Printf("  ⚠️  Failed to fetch reviews for PR #%d: %v\n", pr.GetNumber(), err)
+			fmt.Printf("  ⚠️  Failed to fetch reviews for PR #%d: %v\n", pr.Number, err)
 			continue
 		}
 
-		comments, err := c.github.FetchComments(owner, repo, pr.GetNumber())
+		comments, err := c.provider.ReviewComments(owner, repo, pr.Number)
 		if err != nil {
-			fmt.Printf("  ⚠️  Failed to fetch comments for PR #%d: %v\n", pr.GetNumber(), err)
+			fmt.Printf("  ⚠️  Failed to fetch comments for PR #%d: %v\n", pr.Number, err)
 			continue
 		}
 
-		// Check if PR involves team members
-		if !c.shouldIncludePR(pr, reviews) {
+		// Co-authors are derived from the PR's commits
+		var commits []source.Commit
+		if c.config.CoAuthorWeight != 0 {
+			commits = c.fetchPRCommits(owner, repo, pr.Number)
+		}
+		coAuthors := c.collectPRCoAuthors(pr, commits)
+		ownerTeams := c.prOwnerTeams(owner, repo, pr.Number, ownerRules, prEventTime(pr))
+
+		// Check if PR involves team members or owning teams
+		if !c.shouldIncludePR(pr, reviews, coAuthors) && len(ownerTeams) == 0 {
 			continue
 		}
 
-		// Process PR for each relevant team
-		teams := c.getRelevantTeams(pr, reviews)
-		for _, teamID := range teams {
+		if err := c.batch.SetPRLabels(repoFullName, pr.Number, pr.Labels); err != nil {
+			fmt.Printf("  ⚠️  Failed to store labels for PR #%d: %v\n", pr.Number, err)
+		}
+
+		// The PR's commits classify it when nothing else does, and let commit
+		// metrics be filtered by its labels
+		if c.config.CoAuthorWeight == 0 {
+			commits = c.fetchPRCommits(owner, repo, pr.Number)
+		}
+		if err := c.batch.SetPRCommits(repoFullName, pr.Number, prCommitHashes(pr, commits)); err != nil {
+			fmt.Printf("  ⚠️  Failed to store commits for PR #%d: %v\n", pr.Number, err)
+		}
+		classification := c.classifyPR(pr, commits)
+		ticketStartedAt := c.linkIssues(repoFullName, pr)
+
+		// Process PR for each team its people belonged to at the time, and each owning team
+		attributions := c.getRelevantTeams(pr, reviews, coAuthors, ownerTeams)
+		teams := make([]int, 0, len(attributions))
+		for teamID := range attributions {
+			teams = append(teams, teamID)
+		}
+		c.batch.PrunePRMetrics(repoFullName, pr.Number, teams)
+		for teamID, attr := range attributions {
 			metric := c.processPR(pr, reviews, comments, teamID, repoFullName)
-			if err := c.store.UpsertPRMetric(metric); err != nil {
-				fmt.Printf("  ⚠️  Failed to store PR #%d: %v\n", pr.GetNumber(), err)
+			metric.AttributionRole = attr.role()
+			metric.Owned = attr.owned
+			metric.BaseMatched = baseRule.matches(pr.BaseRef)
+			setWorkType(metric, classification)
+			metric.TicketStartedAt = ticketStartedAt
+			metric.TicketLeadTimeHours = ticketLeadTime(ticketStartedAt, metric.MergedAt)
+			if err := c.batch.UpsertPRMetric(metric); err != nil {
+				fmt.Printf("  ⚠️  Failed to store PR #%d: %v\n", pr.Number, err)
 				continue
 			}
+			c.storePRCoAuthors(teamID, repoFullName, pr.Number, prEventTime(pr), coAuthors)
 			processedCount++
 		}
 	}
 
 	// Also process commits
-	if err := c.processRepositoryCommits(owner, repo, since); err != nil {
+	if err := c.processRepositoryCommits(repoFullName, owner, repo, since); err != nil {
 		fmt.Printf("  ⚠️  Failed to process commits: %v\n", err)
 	}
 
 	// Also process comments
-	if err := c.processRepositoryComments(owner, repo, since); err != nil {
+	if err := c.processRepositoryComments(repoFullName, owner, repo, since); err != nil {
 		fmt.Printf("  ⚠️  Failed to process comments: %v\n", err)
 	}
 
-	// Update last collection timestamp after successful run
-	if err := c.store.UpdateLastCollectionTime(repoFullName, time.Now()); err != nil {
-		fmt.Printf("  ⚠️  Failed to update collection timestamp: %v\n", err)
-	} else {
-		fmt.Printf("  ✅ Collection timestamp updated\n")
+	// Also process issue labels
+	if err := c.processRepositoryIssues(repoFullName, owner, repo, since); err != nil {
+		fmt.Printf("  ⚠️  Failed to process issues: %v\n", err)
+	}
+
+	// Also process deployments
+	if err := c.processRepositoryDeployments(tracked, owner, repo, since); err != nil {
+		fmt.Printf("  ⚠️  Failed to process deployments: %v\n", err)
+	}
+
+	// Store the repository's rows along with its collection timestamp
+	if err := c.recordCollection(repoFullName, time.Now()); err != nil {
+		return processedCount, err
 	}
 
 	fmt.Printf("  ✓ Processed %d PRs for team members\n", processedCount)
@@ -146,9 +407,14 @@ func (c *Collector) collectRepository(owner, repo string) (int, error) {
 }
 
 // getCollectionSince determines the start time for PR collection.
-// On first run: uses COLLECTION_LOOKBACK_DAYS (default 7).
+// On backfills: uses the backfill's start.
+// On first run: looks back the given number of days.
 // On subsequent runs: uses the last recorded collection timestamp.
-func (c *Collector) getCollectionSince(repoFullName string) (time.Time, string, error) {
+func (c *Collector) getCollectionSince(repoFullName string, lookback int) (time.Time, string, error) {
+	if c.options.backfill() {
+		return c.backfillSince(repoFullName, lookback)
+	}
+
 	lastCollected, err := c.store.GetLastCollectionTime(repoFullName)
 	if err != nil {
 		return time.Time{}, "", fmt.Errorf("failed to get last collection time: %w", err)
@@ -156,25 +422,79 @@ func (c *Collector) getCollectionSince(repoFullName string) (time.Time, string,
 
 	if lastCollected.IsZero() {
 		// First run: fall back to lookback days
-		since := time.Now().AddDate(0, 0, -c.config.LookbackDays)
-		return since, fmt.Sprintf("initial (%d-day lookback)", c.config.LookbackDays), nil
+		since := time.Now().AddDate(0, 0, -lookback)
+		return since, fmt.Sprintf("initial (%d-day lookback)", lookback), nil
 	}
 
 	// Incremental: collect since last run
 	return lastCollected, "incremental", nil
 }
 
+// backfillSince returns a backfill's start, which defaults to the lookback
+// when only its end is given
+func (c *Collector) backfillSince(repoFullName string, lookback int) (time.Time, string, error) {
+	since := time.Now().AddDate(0, 0, -lookback)
+	if c.options.Since != nil {
+		since = *c.options.Since
+	}
+	collectionType := "backfill"
+	if c.options.Until != nil {
+		collectionType = fmt.Sprintf("backfill (until %s)", c.options.Until.Format("2006-01-02 15:04:05"))
+	}
+	return since, collectionType, nil
+}
+
+// recordCollection writes a repository's batch and moves its last collection
+// time forward in the same transaction, except on backfills, which don't
+// change where the next incremental run starts
+func (c *Collector) recordCollection(repoFullName string, collectedAt time.Time) error {
+	if c.options.backfill() {
+		if err := c.batch.Flush(); err != nil {
+			return fmt.Errorf("failed to store collected rows: %w", err)
+		}
+		fmt.Printf("  ⏭️  Collection timestamp left as is for the backfill\n")
+		return nil
+	}
+	if err := c.batch.Commit(repoFullName, collectedAt); err != nil {
+		return fmt.Errorf("failed to store collected rows and timestamp: %w", err)
+	}
+	fmt.Printf("  ✅ Collection timestamp updated\n")
+	return nil
+}
+
+// recordRun stores how a repository's run went for health checks; failing to
+// is only a warning
+func (c *Collector) recordRun(result RepositoryResult, startedAt time.Time) {
+	if err := c.store.RecordRun(result.Name, result.Status, result.Error, startedAt, time.Now()); err != nil {
+		fmt.Printf("  ⚠️  Failed to record run health for %s: %v\n", result.Name, err)
+	}
+}
+
+// lookbackDays returns a repository's lookback override, or COLLECTION_LOOKBACK_DAYS
+func lookbackDays(repo database.Repository, defaultDays int) int {
+	if repo.LookbackDays != nil {
+		return *repo.LookbackDays
+	}
+	return defaultDays
+}
 
 // shouldIncludePR checks if PR involves any team member
-func (c *Collector) shouldIncludePR(pr *gh.PullRequest, reviews []*gh.PullRequestReview) bool {
+func (c *Collector) shouldIncludePR(pr source.ChangeRequest, reviews []source.Review, coAuthors []string) bool {
 	// Check if author is team member
-	if c.teamMgr.IsMember(pr.GetUser().GetLogin()) {
+	if c.teamMgr.IsMember(pr.Author) {
 		return true
 	}
 
+	// Check if any co-author is team member
+	for _, coAuthor := range coAuthors {
+		if c.teamMgr.IsMember(coAuthor) {
+			return true
+		}
+	}
+
 	// Check if any reviewer is team member
 	for _, review := range reviews {
-		if c.teamMgr.IsMember(review.GetUser().GetLogin()) {
+		if c.teamMgr.IsMember(review.Author) {
 			return true
 		}
 	}
@@ -182,52 +502,170 @@ func (c *Collector) shouldIncludePR(pr *gh.PullRequest, reviews []*gh.PullReques
 	return false
 }
 
-// getRelevantTeams returns team IDs that should track this PR
-func (c *Collector) getRelevantTeams(pr *gh.PullRequest, reviews []*gh.PullRequestReview) []int {
-	teamsMap := make(map[int]bool)
+// getRelevantTeams returns the teams that should track this PR and how each
+// is credited: the teams the author, co-authors and reviewers belonged to when
+// they took part, plus the teams owning the changed files
+func (c *Collector) getRelevantTeams(pr source.ChangeRequest, reviews []source.Review, coAuthors []string, ownerTeams []int) map[int]*attribution {
+	teams := make(map[int]*attribution)
+	credit := func(teamID int) *attribution {
+		if teams[teamID] == nil {
+			teams[teamID] = &attribution{}
+		}
+		return teams[teamID]
+	}
+	prTime := prEventTime(pr)
 
 	// Add teams for author
-	for _, teamID := range c.teamMgr.GetTeamsForUser(pr.GetUser().GetLogin()) {
-		teamsMap[teamID] = true
+	for _, teamID := range c.teamMgr.GetTeamsForUserAt(pr.Author, prTime) {
+		credit(teamID).authored = true
+	}
+
+	// Add teams for co-authors
+	for _, coAuthor := range coAuthors {
+		for _, teamID := range c.teamMgr.GetTeamsForUserAt(coAuthor, prTime) {
+			credit(teamID).authored = true
+		}
 	}
 
 	// Add teams for reviewers
 	for _, review := range reviews {
-		for _, teamID := range c.teamMgr.GetTeamsForUser(review.GetUser().GetLogin()) {
-			teamsMap[teamID] = true
+		for _, teamID := range c.teamMgr.GetTeamsForUserAt(review.Author, review.SubmittedAt) {
+			credit(teamID).reviewed = true
 		}
 	}
 
-	// Convert map to slice
-	var teams []int
-	for teamID := range teamsMap {
-		teams = append(teams, teamID)
+	// Add owning teams
+	for _, teamID := range ownerTeams {
+		credit(teamID).owned = true
 	}
 
 	return teams
 }
 
-// processPR converts GitHub PR to database metric
+// prEventTime is the date a PR is attributed on: when it merged, or when it was opened if it hasn't
+func prEventTime(pr source.ChangeRequest) time.Time {
+	if pr.MergedAt != nil {
+		return *pr.MergedAt
+	}
+	return pr.CreatedAt
+}
+
+// fetchPRCommits fetches a PR's commits, or nil if that fails
+func (c *Collector) fetchPRCommits(owner, repo string, prNumber int) []source.Commit {
+	commits, err := c.provider.ChangeRequestCommits(owner, repo, prNumber)
+	if err != nil {
+		fmt.Printf("  ⚠️  Failed to fetch commits for PR #%d: %v\n", prNumber, err)
+		return nil
+	}
+	return commits
+}
+
+// prCommitHashes returns the hashes of a PR's commits and of the commits it merged as
+func prCommitHashes(pr source.ChangeRequest, commits []source.Commit) []string {
+	hashes := make([]string, 0, len(commits)+len(pr.MergeCommits))
+	for _, commit := range commits {
+		hashes = append(hashes, commit.SHA)
+	}
+	return append(hashes, pr.MergeCommits...)
+}
+
+// collectPRCoAuthors returns the team members who co-authored a PR, either as
+// committers other than the PR author or through Co-authored-by trailers
+func (c *Collector) collectPRCoAuthors(pr source.ChangeRequest, commits []source.Commit) []string {
+	if c.config.CoAuthorWeight == 0 {
+		return nil
+	}
+
+	prAuthor := pr.Author
+	seen := map[string]bool{prAuthor: true}
+	var coAuthors []string
+	for _, commit := range commits {
+		candidates := c.resolveCoAuthors(commit.Message, prAuthor)
+		if commit.Login != "" && c.teamMgr.IsMember(commit.Login) {
+			candidates = append(candidates, commit.Login)
+		}
+
+		for _, username := range candidates {
+			if !seen[username] {
+				seen[username] = true
+				coAuthors = append(coAuthors, username)
+			}
+		}
+	}
+
+	return coAuthors
+}
+
+// classifyPR returns a PR's work type from its labels, title and head branch,
+// falling back to its commit messages
+func (c *Collector) classifyPR(pr source.ChangeRequest, commits []source.Commit) worktype.Classification {
+	input := worktype.PR{Labels: pr.Labels, Title: pr.Title, HeadRef: pr.HeadRef}
+	classification := c.workTypes.Classify(input)
+	if classification.WorkType != worktype.Other {
+		return classification
+	}
+
+	for _, commit := range commits {
+		input.CommitMessages = append(input.CommitMessages, commit.Message)
+	}
+	return c.workTypes.Classify(input)
+}
+
+// setWorkType stores a classification on a PR metric
+func setWorkType(metric *database.PRMetric, classification worktype.Classification) {
+	metric.WorkType = classification.WorkType
+	if classification.Source != "" {
+		metric.WorkTypeSource = &classification.Source
+	}
+	if classification.Scope != "" {
+		metric.WorkScope = &classification.Scope
+	}
+	metric.Conventional = &classification.Conventional
+}
+
+// storePRCoAuthors records co-author credit for the co-authors that belong to a team
+func (c *Collector) storePRCoAuthors(teamID int, repository string, prNumber int, prTime time.Time, coAuthors []string) {
+	for _, username := range coAuthors {
+		if !c.teamMgr.InTeamAt(username, teamID, prTime) {
+			continue
+		}
+
+		coAuthor := &database.PRCoAuthor{
+			TeamID:         teamID,
+			Repository:     repository,
+			PRNumber:       prNumber,
+			GitHubUsername: username,
+			Weight:         c.config.CoAuthorWeight,
+		}
+		if err := c.batch.UpsertPRCoAuthor(coAuthor); err != nil {
+			fmt.Printf("  ⚠️  Failed to store co-author %s for PR #%d: %v\n", username, prNumber, err)
+		}
+	}
+}
+
+// processPR converts a change request to a database metric
 func (c *Collector) processPR(
-	pr *gh.PullRequest,
-	reviews []*gh.PullRequestReview,
-	comments []*gh.PullRequestComment,
+	pr source.ChangeRequest,
+	reviews []source.Review,
+	comments []source.ReviewComment,
 	teamID int,
 	repository string,
 ) *database.PRMetric {
 	metric := &database.PRMetric{
 		TeamID:     teamID,
-		PRNumber:   pr.GetNumber(),
+		PRNumber:   pr.Number,
 		Repository: repository,
-		Author:     pr.GetUser().GetLogin(),
-		Title:      pr.GetTitle(),
-		CreatedAt:  pr.GetCreatedAt().Time,
-		State:      pr.GetState(),
+		Author:     pr.Author,
+		Title:      pr.Title,
+		CreatedAt:  pr.CreatedAt,
+		State:      pr.State,
+		BaseRef:    optionalString(pr.BaseRef),
+		HeadRef:    optionalString(pr.HeadRef),
 	}
 
 	// Set merged/closed timestamps
 	if pr.MergedAt != nil {
-		mergedAt := pr.GetMergedAt().Time
+		mergedAt := *pr.MergedAt
 		metric.MergedAt = &mergedAt
 		metric.State = "merged"
 
@@ -237,7 +675,7 @@ func (c *Collector) processPR(
 	}
 
 	if pr.ClosedAt != nil {
-		closedAt := pr.GetClosedAt().Time
+		closedAt := *pr.ClosedAt
 		metric.ClosedAt = &closedAt
 	}
 
@@ -249,12 +687,12 @@ func (c *Collector) processPR(
 		turnaround := calculateReviewTurnaround(metric.CreatedAt, firstReviewAt)
 		metric.ReviewTurnaroundHours = &turnaround
 
-		metric.ChangesRequestedCount = countReviewsByState(reviews, "CHANGES_REQUESTED")
-		metric.ApprovedCount = countReviewsByState(reviews, "APPROVED")
+		metric.ChangesRequestedCount = countReviewsByState(reviews, source.ReviewChangesRequested)
+		metric.ApprovedCount = countReviewsByState(reviews, source.ReviewApproved)
 
 		reviewers := extractReviewers(reviews)
 		metric.ReviewersCount = len(reviewers)
-		metric.ExternalReviewersCount = c.countExternalReviewers(reviewers, teamID)
+		metric.ExternalReviewersCount = c.countExternalReviewers(reviewers, teamID, prEventTime(pr))
 
 		reviewersJSON, _ := json.Marshal(reviewers)
 		metric.ReviewersList = string(reviewersJSON)
@@ -268,128 +706,133 @@ func (c *Collector) processPR(
 }
 
 // processRepositoryCommits collects and stores commits for a repository
-func (c *Collector) processRepositoryCommits(owner, repo string, since time.Time) error {
-	repoFullName := fmt.Sprintf("%s/%s", owner, repo)
-	commits, err := c.github.FetchCommits(owner, repo, since)
+func (c *Collector) processRepositoryCommits(repoFullName, owner, repo string, since time.Time) error {
+	commits, err := c.provider.Commits(owner, repo, since, c.until())
 	if err != nil {
 		return err
 	}
 
 	processedCount := 0
+	coAuthoredCount := 0
 	for _, commit := range commits {
-		// author can be nil sometimes
-		var author string
-		if commit.Author != nil && commit.Author.Login != nil {
-			author = *commit.Author.Login
-		} else if commit.Commit != nil && commit.Commit.Author != nil && commit.Commit.Author.Name != nil {
-			author = *commit.Commit.Author.Name
-		} else {
+		// The author may have no account on the host
+		author := commit.Login
+		if author == "" {
+			author = commit.AuthorName
+		}
+		if author == "" {
 			continue // skip if we can't identify author
 		}
 
+		// Commits made under another identity (work email, git name) can be mapped by config
 		if !c.teamMgr.IsMember(author) {
-			continue
-		}
-
-		teams := c.teamMgr.GetTeamsForUser(author)
-		for _, teamID := range teams {
-			var createdAt time.Time
-			if commit.Commit != nil && commit.Commit.Author != nil {
-				createdAt = commit.Commit.Author.GetDate().Time
+			if username, ok := c.teamMgr.ResolveMember(commit.AuthorName, commit.AuthorEmail); ok {
+				author = username
 			}
+		}
 
-			metric := &database.CommitMetric{
-				TeamID:      teamID,
-				Repository:  repoFullName,
-				CommitHash:  commit.GetSHA(),
-				Author:      author,
-				Message:     commit.Commit.GetMessage(),
-				CreatedAt:   createdAt,
-				CreatedDate: &createdAt,
-			}
-			if err := c.store.UpsertCommitMetric(metric); err != nil {
-				fmt.Printf("  ⚠️  Failed to store commit %s: %v\n", commit.GetSHA(), err)
-				continue
-			}
-			processedCount++
+		createdAt := commit.AuthoredAt
+		conventional := worktype.IsConventional(commit.Message)
+		isMerge := commit.IsMerge()
+		metric := &database.CommitMetric{
+			Repository:   repoFullName,
+			CommitHash:   commit.SHA,
+			Author:       author,
+			Message:      commit.Message,
+			CreatedAt:    createdAt,
+			CreatedDate:  &createdAt,
+			Conventional: &conventional,
+			IsMerge:      &isMerge,
+		}
+		if commit.AuthorEmail != "" {
+			email := commit.AuthorEmail
+			metric.AuthorEmail = &email
 		}
+		stored, coAuthored := c.storeCommit(metric)
+		processedCount += stored
+		coAuthoredCount += coAuthored
 	}
 
-	fmt.Printf("  ✓ Processed %d commits for team members\n", processedCount)
+	fmt.Printf("  ✓ Processed %d commits for team members (%d co-author credits)\n", processedCount, coAuthoredCount)
 	return nil
 }
 
-// processRepositoryComments collects and stores comments for a repository
-func (c *Collector) processRepositoryComments(owner, repo string, since time.Time) error {
-	repoFullName := fmt.Sprintf("%s/%s", owner, repo)
-
-	// Issue comments
-	issueComments, err := c.github.FetchIssueComments(owner, repo, since)
-	if err != nil {
-		return err
-	}
-
-	processedCount := 0
-	for _, comment := range issueComments {
-		if comment.User == nil || comment.User.Login == nil {
-			continue
-		}
-		author := *comment.User.Login
-		if !c.teamMgr.IsMember(author) {
-			continue
+// storeCommit stores a commit for each team its author belonged to when it
+// was authored and credits the members named in its Co-authored-by trailers.
+// It returns the number of commit rows and co-author credits stored.
+func (c *Collector) storeCommit(metric *database.CommitMetric) (stored, coAuthored int) {
+	if c.teamMgr.IsMember(metric.Author) {
+		teams := c.teamMgr.GetTeamsForUserAt(metric.Author, metric.CreatedAt)
+		c.batch.PruneCommitMetrics(metric.Repository, metric.CommitHash, teams)
+		for _, teamID := range teams {
+			metric.TeamID = teamID
+			if err := c.batch.UpsertCommitMetric(metric); err != nil {
+				fmt.Printf("  ⚠️  Failed to store commit %s: %v\n", metric.CommitHash, err)
+				continue
+			}
+			stored++
 		}
+	}
 
-		teams := c.teamMgr.GetTeamsForUser(author)
-		for _, teamID := range teams {
-			createdAt := comment.GetCreatedAt().Time
-			metric := &database.CommentMetric{
-				TeamID:      teamID,
-				Repository:  repoFullName,
-				CommentID:   comment.GetID(),
-				Author:      author,
-				Body:        comment.GetBody(),
-				CreatedAt:   createdAt,
-				CreatedDate: &createdAt,
-				CommentType: "issue",
+	// Credit co-authors named in Co-authored-by trailers
+	if c.config.CoAuthorWeight == 0 {
+		return stored, 0
+	}
+	for _, username := range c.resolveCoAuthors(metric.Message, metric.Author) {
+		for _, teamID := range c.teamMgr.GetTeamsForUserAt(username, metric.CreatedAt) {
+			coAuthor := &database.CommitCoAuthor{
+				TeamID:         teamID,
+				Repository:     metric.Repository,
+				CommitHash:     metric.CommitHash,
+				GitHubUsername: username,
+				Weight:         c.config.CoAuthorWeight,
+				CreatedAt:      metric.CreatedAt,
+				CreatedDate:    metric.CreatedDate,
 			}
-			if err := c.store.UpsertCommentMetric(metric); err != nil {
-				fmt.Printf("  ⚠️  Failed to store issue comment %d: %v\n", comment.GetID(), err)
+			if err := c.batch.UpsertCommitCoAuthor(coAuthor); err != nil {
+				fmt.Printf("  ⚠️  Failed to store co-author %s for commit %s: %v\n", username, metric.CommitHash, err)
 				continue
 			}
-			processedCount++
+			coAuthored++
 		}
 	}
+	return stored, coAuthored
+}
 
-	// Commit comments
-	commitComments, err := c.github.FetchCommitComments(owner, repo, since)
+// processRepositoryComments collects and stores comments for a repository
+func (c *Collector) processRepositoryComments(repoFullName, owner, repo string, since time.Time) error {
+	comments, err := c.provider.Comments(owner, repo, since, c.until())
 	if err != nil {
 		return err
 	}
 
-	for _, comment := range commitComments {
-		if comment.User == nil || comment.User.Login == nil {
-			continue
-		}
-		author := *comment.User.Login
-		if !c.teamMgr.IsMember(author) {
+	processedCount := 0
+	for _, comment := range comments {
+		if comment.Author == "" || !c.teamMgr.IsMember(comment.Author) {
 			continue
 		}
 
-		teams := c.teamMgr.GetTeamsForUser(author)
+		teams := c.teamMgr.GetTeamsForUserAt(comment.Author, comment.CreatedAt)
+		if comment.Kind == source.CommentChangeRequest {
+			// Stored as "issue" before PR conversation comments were told apart
+			c.batch.PruneCommentMetrics(repoFullName, comment.ID, source.CommentIssue, nil)
+		}
+		c.batch.PruneCommentMetrics(repoFullName, comment.ID, comment.Kind, teams)
+		createdAt := comment.CreatedAt
 		for _, teamID := range teams {
-			createdAt := comment.GetCreatedAt().Time
 			metric := &database.CommentMetric{
 				TeamID:      teamID,
 				Repository:  repoFullName,
-				CommentID:   comment.GetID(),
-				Author:      author,
-				Body:        comment.GetBody(),
+				CommentID:   comment.ID,
+				Author:      comment.Author,
+				Body:        comment.Body,
 				CreatedAt:   createdAt,
 				CreatedDate: &createdAt,
-				CommentType: "commit",
+				CommentType: comment.Kind,
+				IssueNumber: comment.IssueNumber,
 			}
-			if err := c.store.UpsertCommentMetric(metric); err != nil {
-				fmt.Printf("  ⚠️  Failed to store commit comment %d: %v\n", comment.GetID(), err)
+			if err := c.batch.UpsertCommentMetric(metric); err != nil {
+				fmt.Printf("  ⚠️  Failed to store %s comment %d: %v\n", comment.Kind, comment.ID, err)
 				continue
 			}
 			processedCount++
@@ -399,3 +842,11 @@ func (c *Collector) processRepositoryComments(owner, repo string, since time.Tim
 	fmt.Printf("  ✓ Processed %d overall comments for team members\n", processedCount)
 	return nil
 }
+
+// optionalString returns s, or nil if it is empty
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
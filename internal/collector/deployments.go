@@ -0,0 +1,48 @@
+package collector
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+)
+
+// processRepositoryDeployments stores a repository's deployments when its
+// deployment_source is "deployments", to its deployment_environment if one is
+// set. Releases and tags aren't collected as deployments.
+func (c *Collector) processRepositoryDeployments(tracked database.Repository, owner, repo string, since time.Time) error {
+	if tracked.DeploymentSource == nil {
+		return nil
+	}
+	if *tracked.DeploymentSource != "deployments" {
+		fmt.Printf("  ℹ️  Deployments from %s aren't collected, only from the deployments API\n", *tracked.DeploymentSource)
+		return nil
+	}
+
+	environment := ""
+	if tracked.DeploymentEnvironment != nil {
+		environment = *tracked.DeploymentEnvironment
+	}
+	deployments, err := c.provider.Deployments(owner, repo, environment, since, c.until())
+	if err != nil {
+		return err
+	}
+
+	for _, deployment := range deployments {
+		err := c.batch.UpsertDeployment(&database.Deployment{
+			Repository:   tracked.Name,
+			DeploymentID: deployment.ID,
+			Environment:  deployment.Environment,
+			Ref:          deployment.Ref,
+			SHA:          deployment.SHA,
+			Creator:      deployment.Creator,
+			CreatedAt:    deployment.CreatedAt,
+		})
+		if err != nil {
+			fmt.Printf("  ⚠️  Failed to store deployment %d: %v\n", deployment.ID, err)
+		}
+	}
+
+	fmt.Printf("  ✓ Processed %d deployments\n", len(deployments))
+	return nil
+}
@@ -0,0 +1,74 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/dothanhlam/go-github-tracker/internal/source"
+)
+
+// deploymentProvider returns fixed deployments and records the environment asked for
+type deploymentProvider struct {
+	source.Provider
+	deployments  []source.Deployment
+	environments *[]string
+}
+
+func (p deploymentProvider) Deployments(owner, repo, environment string, since, until time.Time) ([]source.Deployment, error) {
+	*p.environments = append(*p.environments, environment)
+	return p.deployments, nil
+}
+
+// TestProcessRepositoryDeployments tests storing deployments for repositories
+// whose deployment source is the deployments API, and only those
+func TestProcessRepositoryDeployments(t *testing.T) {
+	text := func(s string) *string { return &s }
+	createdAt := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		source       *string
+		environment  *string
+		environments []string
+		stored       int
+	}{
+		{"no deployment source", nil, nil, nil, 0},
+		{"releases", text("releases"), nil, nil, 0},
+		{"deployments to every environment", text("deployments"), nil, []string{""}, 2},
+		{"deployments to one environment", text("deployments"), text("production"), []string{"production"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, db := newTestCollector(t, 0)
+			var environments []string
+			c.provider = deploymentProvider{
+				deployments: []source.Deployment{
+					{ID: 41, Environment: "production", Ref: "main", SHA: "abc123", Creator: "alice", CreatedAt: createdAt},
+					{ID: 42, Environment: "staging", Ref: "main", SHA: "def456", CreatedAt: createdAt.Add(time.Hour)},
+				},
+				environments: &environments,
+			}
+
+			tracked := database.Repository{Name: "acme/api", DeploymentSource: tt.source, DeploymentEnvironment: tt.environment}
+			if err := c.processRepositoryDeployments(tracked, "acme", "api", createdAt.AddDate(0, 0, -1)); err != nil {
+				t.Fatalf("processRepositoryDeployments() error = %v", err)
+			}
+			if err := c.batch.Commit("acme/api", time.Now()); err != nil {
+				t.Fatalf("Commit() error = %v", err)
+			}
+
+			if len(environments) != len(tt.environments) || (len(environments) == 1 && environments[0] != tt.environments[0]) {
+				t.Errorf("deployments fetched for environments %q, want %q", environments, tt.environments)
+			}
+			var stored int
+			if err := db.Get(&stored, "SELECT COUNT(*) FROM deployments WHERE repository = 'acme/api'"); err != nil {
+				t.Fatal(err)
+			}
+			if stored != tt.stored {
+				t.Errorf("%d deployments stored, want %d", stored, tt.stored)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package collector
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/dothanhlam/go-github-tracker/internal/gitlog"
+	"github.com/dothanhlam/go-github-tracker/internal/worktype"
+)
+
+// collectGitRepository collects the commits of a repository read from its
+// local clone with git instead of the GitHub API. Without the API there are
+// no PRs, issues or comments, so only commit metrics are stored.
+func (c *Collector) collectGitRepository(tracked database.Repository) error {
+	repoFullName := tracked.Name
+	fmt.Printf("🔄 Processing repository: %s (git clone at %s)\n", repoFullName, *tracked.GitPath)
+
+	// Taken before reading, like HEAD, so what is fetched during the run is read next time
+	collectedAt := time.Now()
+	head, err := gitlog.Head(*tracked.GitPath)
+	if err != nil {
+		return err
+	}
+	commits, err := c.readGitCommits(tracked, head)
+	if err != nil {
+		return err
+	}
+	if c.options.Until != nil {
+		commits = keepUntil(commits, *c.options.Until, func(commit gitlog.Commit) time.Time { return commit.AuthoredAt })
+	}
+	fmt.Printf("  ✓ Read %d commits\n", len(commits))
+
+	processedCount, coAuthoredCount := 0, 0
+	for _, commit := range commits {
+		stored, coAuthored := c.storeCommit(gitCommitMetric(repoFullName, commit, c.resolveCommitAuthor(commit)))
+		processedCount += stored
+		coAuthoredCount += coAuthored
+	}
+
+	c.batch.SetGitHead(head)
+	if err := c.recordCollection(repoFullName, collectedAt); err != nil {
+		return err
+	}
+
+	fmt.Printf("  ✓ Processed %d commits for team members (%d co-author credits)\n", processedCount, coAuthoredCount)
+	return nil
+}
+
+// readGitCommits reads the commits of a git clone to collect, up to head.
+// Incremental runs read the commits reachable from head but not from the head
+// the last run read, whatever their dates, so commits fetched into a mirror
+// after that run but dated before it aren't missed. First runs, backfills and
+// runs whose last head is no longer reachable (history was rewritten) read
+// the commits authored in their window instead.
+func (c *Collector) readGitCommits(tracked database.Repository, head string) ([]gitlog.Commit, error) {
+	if !c.options.backfill() {
+		lastHead, err := c.store.GetLastGitHead(tracked.Name)
+		if err != nil {
+			return nil, err
+		}
+		if lastHead != "" {
+			if gitlog.IsAncestor(*tracked.GitPath, lastHead, head) {
+				fmt.Printf("  📅 Collection type: incremental (since commit %s)\n", lastHead)
+				return gitlog.LogRange(*tracked.GitPath, lastHead, head)
+			}
+			fmt.Printf("  ⚠️  Last collected commit %s is no longer reachable, reading the whole window again\n", lastHead)
+		}
+	}
+
+	lookback := lookbackDays(tracked, c.config.LookbackDays)
+	since, collectionType := time.Now().AddDate(0, 0, -lookback), fmt.Sprintf("full scan (%d-day lookback)", lookback)
+	if c.options.backfill() {
+		var err error
+		if since, collectionType, err = c.backfillSince(tracked.Name, lookback); err != nil {
+			return nil, err
+		}
+	}
+	fmt.Printf("  📅 Collection type: %s (since %s)\n", collectionType, since.Format("2006-01-02 15:04:05"))
+	return gitlog.Log(*tracked.GitPath, head, since)
+}
+
+// resolveCommitAuthor maps a commit's git identity to a member's username
+// (by noreply address, configured email or name), or returns the author name
+func (c *Collector) resolveCommitAuthor(commit gitlog.Commit) string {
+	if username, ok := c.teamMgr.ResolveMember(commit.AuthorName, commit.AuthorEmail); ok {
+		return username
+	}
+	return commit.AuthorName
+}
+
+// gitCommitMetric builds the team-independent part of a commit's metric
+func gitCommitMetric(repoFullName string, commit gitlog.Commit, author string) *database.CommitMetric {
+	conventional := worktype.IsConventional(commit.Message)
+	isMerge := commit.IsMerge()
+	additions, deletions, filesChanged := commit.Additions, commit.Deletions, commit.FilesChanged
+	createdAt := commit.AuthoredAt
+	metric := &database.CommitMetric{
+		Repository:   repoFullName,
+		CommitHash:   commit.Hash,
+		Author:       author,
+		Message:      commit.Message,
+		CreatedAt:    createdAt,
+		CreatedDate:  &createdAt,
+		Conventional: &conventional,
+		IsMerge:      &isMerge,
+		Additions:    &additions,
+		Deletions:    &deletions,
+		FilesChanged: &filesChanged,
+	}
+	if commit.AuthorEmail != "" {
+		email := commit.AuthorEmail
+		metric.AuthorEmail = &email
+	}
+	return metric
+}
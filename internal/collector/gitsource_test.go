@@ -0,0 +1,107 @@
+package collector
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/dothanhlam/go-github-tracker/internal/gitlog"
+)
+
+// TestGitCommitMetric tests building a commit metric from a commit read with git
+func TestGitCommitMetric(t *testing.T) {
+	commit := gitlog.Commit{
+		Hash:         "abc123",
+		Parents:      []string{"p1", "p2"},
+		AuthorName:   "Alice",
+		AuthorEmail:  "alice@acme.example",
+		AuthoredAt:   time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC),
+		Message:      "fix: handle retries",
+		Additions:    12,
+		Deletions:    3,
+		FilesChanged: 2,
+	}
+
+	metric := gitCommitMetric("acme/api", commit, "alice")
+	if metric.Author != "alice" || metric.CommitHash != "abc123" || !metric.CreatedAt.Equal(commit.AuthoredAt) {
+		t.Errorf("gitCommitMetric() = %s by %s at %v", metric.CommitHash, metric.Author, metric.CreatedAt)
+	}
+	if metric.AuthorEmail == nil || *metric.AuthorEmail != "alice@acme.example" {
+		t.Errorf("AuthorEmail = %v, want alice@acme.example", metric.AuthorEmail)
+	}
+	if metric.IsMerge == nil || !*metric.IsMerge {
+		t.Errorf("IsMerge = %v, want true for two parents", metric.IsMerge)
+	}
+	if *metric.Additions != 12 || *metric.Deletions != 3 || *metric.FilesChanged != 2 {
+		t.Errorf("churn = +%d -%d in %d files, want +12 -3 in 2", *metric.Additions, *metric.Deletions, *metric.FilesChanged)
+	}
+	if metric.Conventional == nil || !*metric.Conventional {
+		t.Errorf("Conventional = %v, want true", metric.Conventional)
+	}
+}
+
+// TestCollectGitRepository tests that each run reads the commits fetched into
+// a clone since the last one, including ones dated before that run, and that
+// a rewritten history is read again from the lookback window
+func TestCollectGitRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	c, db := newTestCollector(t, 0)
+	dir := t.TempDir()
+	lookback := 30
+	tracked := database.Repository{Name: "mirror/api", GitPath: &dir, LookbackDays: &lookback}
+
+	git := func(at time.Time, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		stamp := at.Format(time.RFC3339)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=alice", "GIT_AUTHOR_EMAIL=alice@acme.example",
+			"GIT_COMMITTER_NAME=alice", "GIT_COMMITTER_EMAIL=alice@acme.example",
+			"GIT_AUTHOR_DATE="+stamp, "GIT_COMMITTER_DATE="+stamp,
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	commit := func(message string, at time.Time) {
+		t.Helper()
+		git(at, "commit", "-q", "--allow-empty", "-m", message)
+	}
+	collect := func(want ...string) {
+		t.Helper()
+		if err := c.collectGitRepository(tracked); err != nil {
+			t.Fatalf("collectGitRepository() error = %v", err)
+		}
+		var messages []string
+		if err := db.Select(&messages, "SELECT message FROM commit_metrics ORDER BY created_at"); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(messages, ",") != strings.Join(want, ",") {
+			t.Errorf("stored commits = %v, want %v", messages, want)
+		}
+	}
+
+	now := time.Now()
+	git(now, "init", "-q", "-b", "main")
+	commit("first", now.AddDate(0, 0, -3))
+	collect("first")
+
+	// Fetched after the first run, but authored and committed before it
+	commit("backdated", now.AddDate(0, 0, -2))
+	collect("first", "backdated")
+
+	// Nothing new
+	collect("first", "backdated")
+
+	// History rewritten: the last collected commit is gone
+	git(now, "reset", "-q", "--hard", "HEAD~1")
+	commit("rewritten", now.AddDate(0, 0, -1))
+	collect("first", "backdated", "rewritten")
+}
@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/dothanhlam/go-github-tracker/internal/issuelink"
+	"github.com/dothanhlam/go-github-tracker/internal/jira"
+	"github.com/dothanhlam/go-github-tracker/internal/source"
+)
+
+// linkIssues stores the issues a PR references, fetches its Jira issues when
+// Jira is configured, and returns when work started on the earliest of them
+func (c *Collector) linkIssues(repoFullName string, pr source.ChangeRequest) *time.Time {
+	links := c.issueLinks.Extract(issuelink.PR{
+		Repository: repoFullName,
+		Title:      pr.Title,
+		Branch:     pr.HeadRef,
+		Body:       pr.Body,
+	})
+
+	records := make([]database.PRIssueLink, 0, len(links))
+	var earliest *time.Time
+	for _, link := range links {
+		records = append(records, database.PRIssueLink{
+			Repository: repoFullName,
+			PRNumber:   pr.Number,
+			IssueKey:   link.Key,
+			Tracker:    link.Tracker,
+			Source:     link.Source,
+		})
+		if link.Tracker != issuelink.TrackerJira {
+			continue
+		}
+		if startedAt := c.jiraIssueStart(link.Key); startedAt != nil && (earliest == nil || startedAt.Before(*earliest)) {
+			earliest = startedAt
+		}
+	}
+	if err := c.batch.SetPRIssueLinks(repoFullName, pr.Number, records); err != nil {
+		fmt.Printf("  ⚠️  Failed to store issue links for PR #%d: %v\n", pr.Number, err)
+		return nil
+	}
+	return earliest
+}
+
+// jiraIssueStart returns when work started on a Jira issue, or nil if it
+// hasn't. Stored issues are fetched again when Jira is configured, at most
+// once per run and only until they are known to have started (their start
+// can't change after).
+func (c *Collector) jiraIssueStart(key string) *time.Time {
+	if startedAt, ok := c.jiraStarts[key]; ok {
+		return startedAt
+	}
+
+	startedAt, err := c.store.JiraIssueStartedAt(key)
+	if err != nil {
+		fmt.Printf("  ⚠️  %v\n", err)
+		return nil
+	}
+	if startedAt == nil && c.jira != nil {
+		startedAt = c.fetchJiraIssue(key)
+	}
+	c.jiraStarts[key] = startedAt
+	return startedAt
+}
+
+// fetchJiraIssue stores a Jira issue and its status history, and returns
+// when work started on it
+func (c *Collector) fetchJiraIssue(key string) *time.Time {
+	issue, err := c.jira.FetchIssue(key)
+	if errors.Is(err, jira.ErrNotFound) {
+		return nil // Text that looks like a key (SHA-256, UTF-8) but isn't an issue
+	}
+	if err != nil {
+		fmt.Printf("  ⚠️  Failed to fetch Jira issue %s: %v\n", key, err)
+		return nil
+	}
+
+	record := &database.JiraIssue{
+		IssueKey:   issue.Key,
+		Summary:    issue.Summary,
+		Status:     issue.Status,
+		ResolvedAt: issue.ResolvedAt,
+		StartedAt:  issue.StartedAt(c.config.JiraStartStatuses),
+		FetchedAt:  time.Now(),
+	}
+	if !issue.CreatedAt.IsZero() {
+		record.CreatedAt = &issue.CreatedAt
+	}
+	transitions := make([]database.JiraTransition, 0, len(issue.Transitions))
+	for _, transition := range issue.Transitions {
+		transitions = append(transitions, database.JiraTransition{
+			IssueKey:       issue.Key,
+			FromStatus:     transition.From,
+			ToStatus:       transition.To,
+			TransitionedAt: transition.At,
+		})
+	}
+	if err := c.batch.UpsertJiraIssue(record, transitions); err != nil {
+		fmt.Printf("  ⚠️  Failed to store Jira issue %s: %v\n", key, err)
+	}
+	return record.StartedAt
+}
+
+// ticketLeadTime returns the hours from ticket start until the PR merged, or
+// nil if either is unknown or the ticket was started only after the merge
+func ticketLeadTime(startedAt, mergedAt *time.Time) *int {
+	if startedAt == nil || mergedAt == nil || mergedAt.Before(*startedAt) {
+		return nil
+	}
+	hours := calculateCycleTime(*startedAt, *mergedAt)
+	return &hours
+}
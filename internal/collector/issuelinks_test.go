@@ -0,0 +1,39 @@
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTicketLeadTime tests the hours from ticket start until merge
+func TestTicketLeadTime(t *testing.T) {
+	at := func(day, hour int) *time.Time {
+		ts := time.Date(2024, 1, day, hour, 0, 0, 0, time.UTC)
+		return &ts
+	}
+
+	tests := []struct {
+		name      string
+		startedAt *time.Time
+		mergedAt  *time.Time
+		want      *int
+	}{
+		{"no linked ticket", nil, at(2, 10), nil},
+		{"not merged", at(1, 10), nil, nil},
+		{"started before merge", at(1, 10), at(3, 12), intPtr(50)},
+		{"started after merge", at(3, 12), at(1, 10), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ticketLeadTime(tt.startedAt, tt.mergedAt)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("ticketLeadTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,104 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/dothanhlam/go-github-tracker/internal/source"
+	"github.com/dothanhlam/go-github-tracker/internal/worktype"
+)
+
+// processRepositoryIssues stores a repository's issues and their labels,
+// attributed to the teams of their assignees and the teams claiming their labels
+func (c *Collector) processRepositoryIssues(repoFullName, owner, repo string, since time.Time) error {
+	issues, err := c.provider.Issues(owner, repo, since, c.until())
+	if err != nil {
+		return err
+	}
+
+	processedCount := 0
+	for _, issue := range issues {
+		if err := c.batch.SetIssueLabels(repoFullName, issue.Number, issue.Labels); err != nil {
+			fmt.Printf("  ⚠️  Failed to store labels for issue #%d: %v\n", issue.Number, err)
+			continue
+		}
+
+		attributions := c.issueTeams(issue)
+		teamIDs := make([]int, 0, len(attributions))
+		for teamID := range attributions {
+			teamIDs = append(teamIDs, teamID)
+		}
+		c.batch.PruneIssueMetrics(repoFullName, issue.Number, teamIDs)
+		if len(attributions) == 0 {
+			continue
+		}
+
+		metric := newIssueMetric(repoFullName, issue)
+		metric.WorkType = c.workTypes.Classify(worktype.PR{Labels: issue.Labels}).WorkType
+		for teamID, role := range attributions {
+			metric.TeamID = teamID
+			metric.AttributionRole = role
+			if err := c.batch.UpsertIssueMetric(metric); err != nil {
+				fmt.Printf("  ⚠️  Failed to store issue #%d: %v\n", issue.Number, err)
+			}
+		}
+		processedCount++
+	}
+
+	fmt.Printf("  ✓ Processed %d issues for teams\n", processedCount)
+	return nil
+}
+
+// issueTeams returns the teams an issue is attributed to and through what: the
+// teams its assignees belonged to when it was closed (or opened, if still
+// open), and the teams whose issue_labels it carries
+func (c *Collector) issueTeams(issue source.Issue) map[int]string {
+	at := issue.CreatedAt
+	if issue.ClosedAt != nil {
+		at = *issue.ClosedAt
+	}
+
+	teams := make(map[int]string)
+	for _, assignee := range issue.Assignees {
+		for _, teamID := range c.teamMgr.GetTeamsForUserAt(assignee, at) {
+			teams[teamID] = "assignee"
+		}
+	}
+	for _, teamID := range c.teamMgr.TeamsForIssueLabels(issue.Labels) {
+		if teams[teamID] == "assignee" {
+			teams[teamID] = "both"
+		} else {
+			teams[teamID] = "label"
+		}
+	}
+	return teams
+}
+
+// newIssueMetric builds the team-independent part of an issue's metric
+func newIssueMetric(repoFullName string, issue source.Issue) *database.IssueMetric {
+	assignees := issue.Assignees
+	if assignees == nil {
+		assignees = []string{}
+	}
+	assigneesJSON, _ := json.Marshal(assignees)
+
+	metric := &database.IssueMetric{
+		Repository:    repoFullName,
+		IssueNumber:   issue.Number,
+		Title:         issue.Title,
+		Author:        issue.Author,
+		State:         issue.State,
+		CreatedAt:     issue.CreatedAt,
+		AssigneesList: string(assigneesJSON),
+	}
+	if issue.ClosedAt != nil {
+		closedAt := *issue.ClosedAt
+		timeToClose := calculateCycleTime(metric.CreatedAt, closedAt)
+		metric.ClosedAt = &closedAt
+		metric.TimeToCloseHours = &timeToClose
+	}
+	metric.Milestone = optionalString(issue.Milestone)
+	return metric
+}
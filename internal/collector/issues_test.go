@@ -0,0 +1,38 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/source"
+)
+
+// TestNewIssueMetric tests building an issue's metric
+func TestNewIssueMetric(t *testing.T) {
+	created := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	closed := created.Add(30 * time.Hour)
+	issue := source.Issue{
+		Number:    12,
+		Title:     "Checkout fails",
+		State:     "closed",
+		Author:    "carol",
+		Assignees: []string{"alice", "bob"},
+		Milestone: "v2.1",
+		CreatedAt: created,
+		ClosedAt:  &closed,
+	}
+
+	metric := newIssueMetric("acme/api", issue)
+	if metric.IssueNumber != 12 || metric.Author != "carol" || metric.State != "closed" {
+		t.Errorf("newIssueMetric() = #%d by %s (%s), want #12 by carol (closed)", metric.IssueNumber, metric.Author, metric.State)
+	}
+	if metric.TimeToCloseHours == nil || *metric.TimeToCloseHours != 30 {
+		t.Errorf("TimeToCloseHours = %v, want 30", metric.TimeToCloseHours)
+	}
+	if metric.Milestone == nil || *metric.Milestone != "v2.1" {
+		t.Errorf("Milestone = %v, want v2.1", metric.Milestone)
+	}
+	if metric.AssigneesList != `["alice","bob"]` {
+		t.Errorf("AssigneesList = %s, want [\"alice\",\"bob\"]", metric.AssigneesList)
+	}
+}
@@ -3,7 +3,7 @@ package collector
 import (
 	"time"
 
-	gh "github.com/google/go-github/v58/github"
+	"github.com/dothanhlam/go-github-tracker/internal/source"
 )
 
 // calculateCycleTime calculates hours from creation to merge
@@ -19,15 +19,15 @@ func calculateReviewTurnaround(createdAt, firstReviewAt time.Time) int {
 }
 
 // getFirstReviewTime finds the earliest review timestamp
-func getFirstReviewTime(reviews []*gh.PullRequestReview) time.Time {
+func getFirstReviewTime(reviews []source.Review) time.Time {
 	if len(reviews) == 0 {
 		return time.Time{}
 	}
 
-	firstReview := reviews[0].GetSubmittedAt().Time
+	firstReview := reviews[0].SubmittedAt
 	for _, review := range reviews[1:] {
-		if review.GetSubmittedAt().Time.Before(firstReview) {
-			firstReview = review.GetSubmittedAt().Time
+		if review.SubmittedAt.Before(firstReview) {
+			firstReview = review.SubmittedAt
 		}
 	}
 
@@ -35,11 +35,10 @@ func getFirstReviewTime(reviews []*gh.PullRequestReview) time.Time {
 }
 
 // extractReviewers extracts unique reviewer usernames
-func extractReviewers(reviews []*gh.PullRequestReview) []string {
+func extractReviewers(reviews []source.Review) []string {
 	reviewerMap := make(map[string]bool)
 	for _, review := range reviews {
-		username := review.GetUser().GetLogin()
-		reviewerMap[username] = true
+		reviewerMap[review.Author] = true
 	}
 
 	var reviewers []string
@@ -51,10 +50,10 @@ func extractReviewers(reviews []*gh.PullRequestReview) []string {
 }
 
 // countReviewsByState counts reviews with a specific state
-func countReviewsByState(reviews []*gh.PullRequestReview, state string) int {
+func countReviewsByState(reviews []source.Review, state string) int {
 	count := 0
 	for _, review := range reviews {
-		if review.GetState() == state {
+		if review.State == state {
 			count++
 		}
 	}
@@ -62,27 +61,27 @@ func countReviewsByState(reviews []*gh.PullRequestReview, state string) int {
 }
 
 // countConversations counts unique conversation threads
-func countConversations(comments []*gh.PullRequestComment) int {
+func countConversations(comments []source.ReviewComment) int {
 	// Group by in_reply_to_id to count threads
 	threadMap := make(map[int64]bool)
 	for _, comment := range comments {
 		// If it's a top-level comment, use its own ID
-		if comment.GetInReplyTo() == 0 {
-			threadMap[comment.GetID()] = true
+		if comment.InReplyTo == 0 {
+			threadMap[comment.ID] = true
 		} else {
 			// Otherwise use the parent comment ID
-			threadMap[comment.GetInReplyTo()] = true
+			threadMap[comment.InReplyTo] = true
 		}
 	}
 
 	return len(threadMap)
 }
 
-// countExternalReviewers counts reviewers outside the team
-func (c *Collector) countExternalReviewers(reviewers []string, teamID int) int {
+// countExternalReviewers counts reviewers outside the team at the given time
+func (c *Collector) countExternalReviewers(reviewers []string, teamID int, at time.Time) int {
 	count := 0
 	for _, reviewer := range reviewers {
-		if c.teamMgr.IsExternalReviewer(reviewer, teamID) {
+		if c.teamMgr.IsExternalReviewer(reviewer, teamID, at) {
 			count++
 		}
 	}
@@ -4,7 +4,7 @@ import (
 	"testing"
 	"time"
 
-	gh "github.com/google/go-github/v58/github"
+	"github.com/dothanhlam/go-github-tracker/internal/source"
 )
 
 // TestCalculateCycleTime tests the cycle time calculation
@@ -97,36 +97,36 @@ func TestGetFirstReviewTime(t *testing.T) {
 
 	tests := []struct {
 		name    string
-		reviews []*gh.PullRequestReview
+		reviews []source.Review
 		want    time.Time
 	}{
 		{
 			name:    "empty reviews",
-			reviews: []*gh.PullRequestReview{},
+			reviews: []source.Review{},
 			want:    time.Time{},
 		},
 		{
 			name: "single review",
-			reviews: []*gh.PullRequestReview{
-				{SubmittedAt: &gh.Timestamp{Time: time1}},
+			reviews: []source.Review{
+				{SubmittedAt: time1},
 			},
 			want: time1,
 		},
 		{
 			name: "multiple reviews - earliest is first",
-			reviews: []*gh.PullRequestReview{
-				{SubmittedAt: &gh.Timestamp{Time: time3}},
-				{SubmittedAt: &gh.Timestamp{Time: time1}},
-				{SubmittedAt: &gh.Timestamp{Time: time2}},
+			reviews: []source.Review{
+				{SubmittedAt: time3},
+				{SubmittedAt: time1},
+				{SubmittedAt: time2},
 			},
 			want: time3,
 		},
 		{
 			name: "multiple reviews - earliest is last",
-			reviews: []*gh.PullRequestReview{
-				{SubmittedAt: &gh.Timestamp{Time: time1}},
-				{SubmittedAt: &gh.Timestamp{Time: time2}},
-				{SubmittedAt: &gh.Timestamp{Time: time3}},
+			reviews: []source.Review{
+				{SubmittedAt: time1},
+				{SubmittedAt: time2},
+				{SubmittedAt: time3},
 			},
 			want: time3,
 		},
@@ -146,47 +146,47 @@ func TestGetFirstReviewTime(t *testing.T) {
 func TestExtractReviewers(t *testing.T) {
 	tests := []struct {
 		name    string
-		reviews []*gh.PullRequestReview
+		reviews []source.Review
 		want    int // number of unique reviewers
 	}{
 		{
 			name:    "no reviews",
-			reviews: []*gh.PullRequestReview{},
+			reviews: []source.Review{},
 			want:    0,
 		},
 		{
 			name: "single reviewer",
-			reviews: []*gh.PullRequestReview{
-				{User: &gh.User{Login: gh.String("reviewer1")}},
+			reviews: []source.Review{
+				{Author: "reviewer1"},
 			},
 			want: 1,
 		},
 		{
 			name: "multiple reviews from same reviewer",
-			reviews: []*gh.PullRequestReview{
-				{User: &gh.User{Login: gh.String("reviewer1")}},
-				{User: &gh.User{Login: gh.String("reviewer1")}},
-				{User: &gh.User{Login: gh.String("reviewer1")}},
+			reviews: []source.Review{
+				{Author: "reviewer1"},
+				{Author: "reviewer1"},
+				{Author: "reviewer1"},
 			},
 			want: 1,
 		},
 		{
 			name: "multiple unique reviewers",
-			reviews: []*gh.PullRequestReview{
-				{User: &gh.User{Login: gh.String("reviewer1")}},
-				{User: &gh.User{Login: gh.String("reviewer2")}},
-				{User: &gh.User{Login: gh.String("reviewer3")}},
+			reviews: []source.Review{
+				{Author: "reviewer1"},
+				{Author: "reviewer2"},
+				{Author: "reviewer3"},
 			},
 			want: 3,
 		},
 		{
 			name: "mixed - some duplicate reviewers",
-			reviews: []*gh.PullRequestReview{
-				{User: &gh.User{Login: gh.String("reviewer1")}},
-				{User: &gh.User{Login: gh.String("reviewer2")}},
-				{User: &gh.User{Login: gh.String("reviewer1")}},
-				{User: &gh.User{Login: gh.String("reviewer3")}},
-				{User: &gh.User{Login: gh.String("reviewer2")}},
+			reviews: []source.Review{
+				{Author: "reviewer1"},
+				{Author: "reviewer2"},
+				{Author: "reviewer1"},
+				{Author: "reviewer3"},
+				{Author: "reviewer2"},
 			},
 			want: 3,
 		},
@@ -206,43 +206,43 @@ func TestExtractReviewers(t *testing.T) {
 func TestCountReviewsByState(t *testing.T) {
 	tests := []struct {
 		name    string
-		reviews []*gh.PullRequestReview
+		reviews []source.Review
 		state   string
 		want    int
 	}{
 		{
 			name:    "no reviews",
-			reviews: []*gh.PullRequestReview{},
+			reviews: []source.Review{},
 			state:   "APPROVED",
 			want:    0,
 		},
 		{
 			name: "all approved",
-			reviews: []*gh.PullRequestReview{
-				{State: gh.String("APPROVED")},
-				{State: gh.String("APPROVED")},
+			reviews: []source.Review{
+				{State: "APPROVED"},
+				{State: "APPROVED"},
 			},
 			state: "APPROVED",
 			want:  2,
 		},
 		{
 			name: "mixed states - count approved",
-			reviews: []*gh.PullRequestReview{
-				{State: gh.String("APPROVED")},
-				{State: gh.String("CHANGES_REQUESTED")},
-				{State: gh.String("APPROVED")},
-				{State: gh.String("COMMENTED")},
+			reviews: []source.Review{
+				{State: "APPROVED"},
+				{State: "CHANGES_REQUESTED"},
+				{State: "APPROVED"},
+				{State: "COMMENTED"},
 			},
 			state: "APPROVED",
 			want:  2,
 		},
 		{
 			name: "mixed states - count changes requested",
-			reviews: []*gh.PullRequestReview{
-				{State: gh.String("APPROVED")},
-				{State: gh.String("CHANGES_REQUESTED")},
-				{State: gh.String("APPROVED")},
-				{State: gh.String("CHANGES_REQUESTED")},
+			reviews: []source.Review{
+				{State: "APPROVED"},
+				{State: "CHANGES_REQUESTED"},
+				{State: "APPROVED"},
+				{State: "CHANGES_REQUESTED"},
 			},
 			state: "CHANGES_REQUESTED",
 			want:  2,
@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/codeowners"
+)
+
+// attribution records how a team came to be credited with a PR
+type attribution struct {
+	authored bool // a member authored or co-authored it
+	reviewed bool // a member reviewed it
+	owned    bool // the team owns a changed file according to CODEOWNERS
+}
+
+// role returns the attribution role stored with the PR metric
+func (a attribution) role() string {
+	switch {
+	case a.authored && a.reviewed:
+		return "both"
+	case a.authored:
+		return "author"
+	case a.reviewed:
+		return "reviewer"
+	default:
+		return "ownership"
+	}
+}
+
+// loadCodeOwners fetches and parses a repository's CODEOWNERS when ownership
+// attribution is enabled. It returns nil if there is nothing to attribute by.
+func (c *Collector) loadCodeOwners(owner, repo string) *codeowners.Ruleset {
+	if !c.config.CodeOwnersAttribution {
+		return nil
+	}
+
+	data, err := c.provider.CodeOwners(owner, repo)
+	if err != nil {
+		fmt.Printf("  ⚠️  Failed to fetch CODEOWNERS: %v\n", err)
+		return nil
+	}
+	if data == nil {
+		fmt.Printf("  ℹ️  No CODEOWNERS file, skipping ownership attribution\n")
+		return nil
+	}
+
+	rules, err := codeowners.Parse(data)
+	if err != nil {
+		// Invalid lines are skipped; the rest of the file still applies
+		fmt.Printf("  ⚠️  %v\n", err)
+	}
+	fmt.Printf("  📜 Loaded %d CODEOWNERS rules\n", len(rules.Rules))
+	return rules
+}
+
+// prOwnerTeams returns the teams owning any of the files a PR changed, as of when it was merged or opened
+func (c *Collector) prOwnerTeams(owner, repo string, prNumber int, rules *codeowners.Ruleset, at time.Time) []int {
+	if rules == nil {
+		return nil
+	}
+
+	files, err := c.provider.ChangeRequestFiles(owner, repo, prNumber)
+	if err != nil {
+		fmt.Printf("  ⚠️  Failed to fetch files for PR #%d: %v\n", prNumber, err)
+		return nil
+	}
+	return c.ownerTeams(rules, files, at)
+}
+
+// ownerTeams maps changed files to the teams that own them
+func (c *Collector) ownerTeams(rules *codeowners.Ruleset, files []string, at time.Time) []int {
+	seen := make(map[int]bool)
+	var teams []int
+	resolved := make(map[string]bool)
+	for _, file := range files {
+		for _, owner := range rules.Owners(file) {
+			if resolved[owner] {
+				continue
+			}
+			resolved[owner] = true
+			for _, teamID := range c.teamMgr.TeamsForCodeOwner(owner, at) {
+				if !seen[teamID] {
+					seen[teamID] = true
+					teams = append(teams, teamID)
+				}
+			}
+		}
+	}
+	return teams
+}
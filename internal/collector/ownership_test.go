@@ -0,0 +1,26 @@
+package collector
+
+import "testing"
+
+// TestAttributionRole tests the role recorded for each way a team is credited with a PR
+func TestAttributionRole(t *testing.T) {
+	tests := []struct {
+		name string
+		attr attribution
+		want string
+	}{
+		{"authored", attribution{authored: true}, "author"},
+		{"reviewed", attribution{reviewed: true}, "reviewer"},
+		{"authored and reviewed", attribution{authored: true, reviewed: true}, "both"},
+		{"authored and owned", attribution{authored: true, owned: true}, "author"},
+		{"owned only", attribution{owned: true}, "ownership"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.attr.role(); got != tt.want {
+				t.Errorf("role() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
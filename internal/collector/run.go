@@ -0,0 +1,149 @@
+package collector
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+)
+
+// RunOptions narrows a collection run. The zero value collects every active
+// repository incrementally.
+type RunOptions struct {
+	// Repositories are the tracked names to collect; every active repository if empty
+	Repositories []string
+
+	// Since starts a backfill: repositories are collected from this time
+	// instead of their last run, and their last collection time isn't moved
+	Since *time.Time
+
+	// Until ends a backfill: pull requests and issues updated after it, and
+	// commits, comments and deployments made after it, are left to a later window
+	Until *time.Time
+
+	// DryRun reports what would be collected, and from when, without
+	// calling code hosts or storing metrics
+	DryRun bool
+}
+
+// backfill reports whether the run collects a fixed window rather than incrementally
+func (o RunOptions) backfill() bool {
+	return o.Since != nil || o.Until != nil
+}
+
+// Repository statuses in a run's result
+const (
+	StatusCollected   = "collected"
+	StatusPlanned     = "planned"     // Dry run
+	StatusSkipped     = "skipped"     // No provider for its host, or an invalid name
+	StatusFailed      = "failed"      // Collection stopped the run
+	StatusInterrupted = "interrupted" // The deadline was reached while collecting it
+	StatusNotStarted  = "not_started" // The deadline was reached before it
+	StatusLocked      = "locked"      // Another run is collecting it
+)
+
+// Result summarizes a collection run
+type Result struct {
+	Repositories []RepositoryResult `json:"repositories"`
+	PRs          int                `json:"prs"`
+	DryRun       bool               `json:"dry_run"`
+
+	// TimedOut is set when the run stopped at its context's deadline; the
+	// repositories it didn't finish are collected again from where they were
+	TimedOut bool `json:"timed_out"`
+}
+
+// RepositoryResult is what a run did with one repository
+type RepositoryResult struct {
+	Name           string     `json:"name"`
+	Status         string     `json:"status"`
+	CollectionType string     `json:"collection_type,omitempty"`
+	Since          *time.Time `json:"since,omitempty"`
+	PRs            int        `json:"prs"`
+	Error          string     `json:"error,omitempty"`
+}
+
+// Repositories returns the names of the active repositories a run would
+// collect for the given names: all of them if names is empty
+func (c *Collector) Repositories(names []string) ([]string, error) {
+	active, err := c.activeRepositories()
+	if err != nil {
+		return nil, err
+	}
+	repos, err := selectRepositories(active, names)
+	if err != nil {
+		return nil, err
+	}
+	selected := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		selected = append(selected, repo.Name)
+	}
+	return selected, nil
+}
+
+// ScheduledRepositories returns the names of the active repositories in a
+// schedule of `collector serve`: those matching it before any other, or
+// matching none for config.DefaultScheduleName
+func (c *Collector) ScheduledRepositories(schedule string) ([]string, error) {
+	active, err := c.activeRepositories()
+	if err != nil {
+		return nil, err
+	}
+	selected := []string{}
+	for _, repo := range active {
+		serviceGroup := ""
+		if repo.ServiceGroup != nil {
+			serviceGroup = *repo.ServiceGroup
+		}
+		if config.ScheduleFor(c.config.Schedules, repo.Name, serviceGroup, repo.Tags) == schedule {
+			selected = append(selected, repo.Name)
+		}
+	}
+	return selected, nil
+}
+
+// selectRepositories returns the active repositories named in names, in the
+// order they are tracked, or all of them if names is empty
+func selectRepositories(active []database.Repository, names []string) ([]database.Repository, error) {
+	if len(names) == 0 {
+		return active, nil
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var selected []database.Repository
+	for _, repo := range active {
+		if wanted[repo.Name] {
+			selected = append(selected, repo)
+			delete(wanted, repo.Name)
+		}
+	}
+	for _, name := range names {
+		if wanted[name] {
+			return nil, fmt.Errorf("repository %s is not tracked or not active", name)
+		}
+	}
+	return selected, nil
+}
+
+// until returns the end of a backfill window, or zero to collect up to now
+func (c *Collector) until() time.Time {
+	if c.options.Until == nil {
+		return time.Time{}
+	}
+	return *c.options.Until
+}
+
+// keepUntil filters items to those created at or before until
+func keepUntil[T any](items []T, until time.Time, createdAt func(T) time.Time) []T {
+	kept := items[:0]
+	for _, item := range items {
+		if !createdAt(item).After(until) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,103 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/dothanhlam/go-github-tracker/internal/source"
+)
+
+// TestSelectRepositories tests narrowing a run to named repositories
+func TestSelectRepositories(t *testing.T) {
+	active := []database.Repository{{Name: "acme/api"}, {Name: "acme/web"}, {Name: "gitlab.corp.example/payments/core/api"}}
+
+	tests := []struct {
+		name    string
+		names   []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "all when none named", want: []string{"acme/api", "acme/web", "gitlab.corp.example/payments/core/api"}},
+		{name: "tracking order", names: []string{"gitlab.corp.example/payments/core/api", "acme/api"}, want: []string{"acme/api", "gitlab.corp.example/payments/core/api"}},
+		{name: "untracked repository", names: []string{"acme/api", "acme/mobile"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := selectRepositories(active, tt.names)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectRepositories() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got []string
+			for _, repo := range selected {
+				got = append(got, repo.Name)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("selectRepositories() = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("selectRepositories() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+// windowProvider records the window ends commits and deployments are asked for
+type windowProvider struct {
+	source.Provider
+	untils *[]time.Time
+}
+
+func (p windowProvider) Commits(owner, repo string, since, until time.Time) ([]source.Commit, error) {
+	*p.untils = append(*p.untils, until)
+	return nil, nil
+}
+
+func (p windowProvider) Deployments(owner, repo, environment string, since, until time.Time) ([]source.Deployment, error) {
+	*p.untils = append(*p.untils, until)
+	return nil, nil
+}
+
+// TestWindowEnd tests passing a backfill's end to the provider, so windows
+// split the history between them, and no end outside a backfill
+func TestWindowEnd(t *testing.T) {
+	until := time.Date(2026, 3, 31, 23, 59, 59, 0, time.UTC)
+	since := until.AddDate(0, -1, 0)
+	deployments := "deployments"
+	tracked := database.Repository{Name: "acme/api", DeploymentSource: &deployments}
+
+	tests := []struct {
+		name  string
+		until *time.Time
+		want  time.Time
+	}{
+		{"backfill window", &until, until},
+		{"incremental", nil, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestCollector(t, 0)
+			var untils []time.Time
+			c.provider = windowProvider{untils: &untils}
+			c.options = RunOptions{Since: &since, Until: tt.until}
+
+			if err := c.processRepositoryCommits("acme/api", "acme", "api", since); err != nil {
+				t.Fatalf("processRepositoryCommits() error = %v", err)
+			}
+			if err := c.processRepositoryDeployments(tracked, "acme", "api", since); err != nil {
+				t.Fatalf("processRepositoryDeployments() error = %v", err)
+			}
+			if len(untils) != 2 || !untils[0].Equal(tt.want) || !untils[1].Equal(tt.want) {
+				t.Errorf("window ends passed = %v, want %v for commits and deployments", untils, tt.want)
+			}
+		})
+	}
+}
@@ -3,27 +3,100 @@ package config
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
 // TeamMemberConfig represents a team member configuration
 type TeamMemberConfig struct {
-	Username   string  `json:"username"`
-	Allocation float64 `json:"allocation"`
+	Username   string  `json:"username" yaml:"username"`
+	Allocation float64 `json:"allocation" yaml:"allocation"`
+
+	// Emails are additional commit identities for this member, used to resolve
+	// Co-authored-by trailers that don't carry a GitHub noreply address
+	Emails []string `json:"emails,omitempty" yaml:"emails,omitempty"`
+
+	// JoinedAt and LeftAt bound the membership explicitly. Without JoinedAt a
+	// new member joins on the day they first appear in config, except in the
+	// initial import, whose members are credited for all collected history.
+	JoinedAt *Date `json:"joined_at,omitempty" yaml:"joined_at,omitempty"`
+	LeftAt   *Date `json:"left_at,omitempty" yaml:"left_at,omitempty"`
+}
+
+// Date is a calendar date in config, written as "2006-01-02" (RFC 3339 timestamps are also accepted)
+type Date struct {
+	time.Time
+}
+
+// UnmarshalJSON parses a date string
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("date must be a string: %w", err)
+	}
+	t, err := parseDate(value)
+	if err != nil {
+		return err
+	}
+	d.Time = t
+	return nil
+}
+
+// UnmarshalYAML parses a date scalar
+func (d *Date) UnmarshalYAML(value *yaml.Node) error {
+	t, err := parseDate(value.Value)
+	if err != nil {
+		return err
+	}
+	d.Time = t
+	return nil
+}
+
+// MarshalJSON formats the date as "2006-01-02"
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Format("2006-01-02"))
+}
+
+// parseDate accepts "2006-01-02" or an RFC 3339 timestamp
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", value)
+	}
+	return t, nil
 }
 
 // TeamConfig represents a team configuration
 // Team IDs are auto-generated by the database based on the unique team name
 type TeamConfig struct {
-	Name    string             `json:"name"`
-	Members []TeamMemberConfig `json:"members"`
+	Name    string             `json:"name" yaml:"name"`
+	Parent  string             `json:"parent,omitempty" yaml:"parent,omitempty"` // Name of the parent team (group, tribe, ...)
+	Members []TeamMemberConfig `json:"members" yaml:"members"`
+
+	// Repositories the team works in (owner/repo); they are added to the tracked repositories
+	Repositories []string `json:"repositories,omitempty" yaml:"repositories,omitempty"`
+
+	// CodeOwners are the CODEOWNERS handles (@org/team-slug) that stand for this team
+	CodeOwners []string `json:"codeowners,omitempty" yaml:"codeowners,omitempty"`
+
+	// IssueLabels attribute issues with any of these labels (matched
+	// case-insensitively) to the team, besides issues assigned to its members
+	IssueLabels []string `json:"issue_labels,omitempty" yaml:"issue_labels,omitempty"`
 }
 
 // Config holds all application configuration
@@ -35,16 +108,85 @@ type Config struct {
 	// GitHub configuration
 	GitHubPAT string
 
+	// GitHubHosts are the GitHub Enterprise Server instances collected from
+	// besides github.com, each with its own API URLs and token
+	GitHubHosts []GitHubHostConfig
+
+	// GitLabHosts are the GitLab instances collected from, each with its own API URL and token
+	GitLabHosts []GitLabHostConfig
+
+	// BitbucketHosts are Bitbucket Cloud and the Bitbucket Data Center
+	// instances collected from, each with its own API URL and credentials
+	BitbucketHosts []BitbucketHostConfig
+
 	// Collection configuration
 	LookbackDays int // Number of days to look back for PR collection
 
+	// Collection job queue: how long a worker's lease on a job lasts without a
+	// heartbeat, and how many times a job is tried before it is dead-lettered
+	JobLeaseSeconds int
+	JobMaxAttempts  int
+
+	// LockTTLSeconds is how long a run's lock on a repository lasts without a
+	// refresh; the lock of a run that died is taken over after it
+	LockTTLSeconds int
+
+	// CoAuthorWeight is the credit given to each Co-authored-by trailer (0 disables co-author attribution)
+	CoAuthorWeight float64
+
 	// Team configuration
 	Teams []TeamConfig
 
+	// Aliases map extra commit identities (git author names or emails) to usernames
+	Aliases map[string]string
+
+	// TeamSource selects where teams come from: "config" (TEAM_CONFIG_JSON),
+	// "github" (organization teams) or "merged" (both, config wins on conflicts)
+	TeamSource    string
+	GitHubOrg     string
+	GitHubTeamMap map[string]string // GitHub team slug -> team name (limits which org teams are synced)
+
+	// CodeOwnersAttribution also credits PRs to the teams that own the changed
+	// files according to each repository's CODEOWNERS
+	CodeOwnersAttribution bool
+
 	// Repositories to track
 	Repositories []string
+
+	// RepositorySettings holds one entry per tracked repository, with the
+	// settings from the team config file and the owning team
+	RepositorySettings []RepositoryConfig
+
+	// Schedules group repositories collected on their own cron schedule by
+	// `collector serve`; the others are collected on DefaultSchedule
+	Schedules       []ScheduleConfig
+	DefaultSchedule string
+
+	// ControlAddr is the address `collector serve` listens on for status,
+	// health and run triggers; MaxConcurrentRuns caps the schedules run at once
+	ControlAddr       string
+	MaxConcurrentRuns int
+
+	// WorkTypes classify PRs by their labels (DefaultWorkTypes unless the team config file sets them)
+	WorkTypes []WorkTypeConfig
+
+	// IssueKeyPattern matches issue tracker keys in PR titles, bodies and
+	// branch names ("" only links the GitHub issues PRs close)
+	IssueKeyPattern string
+
+	// Jira configuration. Without JiraBaseURL linked issues aren't fetched.
+	// Without JiraEmail the token is sent as a bearer token (Server/Data Center).
+	JiraBaseURL  string
+	JiraEmail    string
+	JiraAPIToken string
+
+	// JiraStartStatuses are the statuses that mark work on an issue as started
+	JiraStartStatuses []string
 }
 
+// DefaultIssueKeyPattern matches Jira-style issue keys such as PROJ-123
+const DefaultIssueKeyPattern = `[A-Z][A-Z0-9]+-\d+`
+
 // dbSecret is the JSON structure stored in Secrets Manager for DB credentials
 type dbSecret struct {
 	Username string `json:"username"`
@@ -55,6 +197,9 @@ type dbSecret struct {
 // When running in AWS Lambda:
 //   - DB credentials are fetched from Secrets Manager using DB_SECRET_ARN
 //   - GitHub PAT is fetched from Secrets Manager using GITHUB_PAT_SECRET_ARN
+//     (and GITHUB_PAT_SECRET_ARN_<HOST> for GitHub Enterprise hosts)
+//   - GitLab tokens are fetched using GITLAB_TOKEN_SECRET_ARN_<HOST>
+//   - Bitbucket tokens are fetched using BITBUCKET_TOKEN_SECRET_ARN_<HOST>
 //   - The Postgres DSN is constructed from DB_HOST, DB_NAME, and the fetched credentials
 //
 // When running locally:
@@ -81,18 +226,66 @@ func Load() (*Config, error) {
 		fmt.Printf("Loaded configuration from %s\n", envFile)
 	}
 
+	// Co-author attribution fetches each PR's commits, so it is off unless asked for
+	coAuthorWeight, err := getEnvFloat("COAUTHOR_WEIGHT", 0)
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{
-		DBDriver:     getEnv("DB_DRIVER", "sqlite3"),
-		DBURL:        getEnv("DB_URL", ""),
-		GitHubPAT:    getEnv("GITHUB_PAT", ""),
-		LookbackDays: getEnvInt("COLLECTION_LOOKBACK_DAYS", 7),
+		DBDriver:       getEnv("DB_DRIVER", "sqlite3"),
+		DBURL:          getEnv("DB_URL", ""),
+		GitHubPAT:      getEnv("GITHUB_PAT", ""),
+		GitHubHosts:    parseGitHubHosts(getEnv("GITHUB_HOSTS", "")),
+		GitLabHosts:    parseGitLabHosts(getEnv("GITLAB_HOSTS", "")),
+		BitbucketHosts: parseBitbucketHosts(getEnv("BITBUCKET_HOSTS", "")),
+		LookbackDays:   getEnvInt("COLLECTION_LOOKBACK_DAYS", 7),
+
+		JobLeaseSeconds: getEnvInt("JOB_LEASE_SECONDS", 300),
+		JobMaxAttempts:  getEnvInt("JOB_MAX_ATTEMPTS", 5),
+		LockTTLSeconds:  getEnvInt("LOCK_TTL_SECONDS", 300),
+
+		DefaultSchedule:   getEnv("COLLECTOR_SCHEDULE", "@hourly"),
+		ControlAddr:       getEnv("COLLECTOR_CONTROL_ADDR", "127.0.0.1:8090"),
+		MaxConcurrentRuns: getEnvInt("COLLECTOR_MAX_CONCURRENT_RUNS", 2),
+
+		CoAuthorWeight:        coAuthorWeight,
+		CodeOwnersAttribution: getEnvBool("CODEOWNERS_ATTRIBUTION", false),
+
+		TeamSource: getEnv("TEAM_SOURCE", "config"),
+		GitHubOrg:  getEnv("GITHUB_ORG", ""),
+
+		IssueKeyPattern:   getEnv("ISSUE_KEY_PATTERN", DefaultIssueKeyPattern),
+		JiraBaseURL:       strings.TrimSuffix(getEnv("JIRA_BASE_URL", ""), "/"),
+		JiraEmail:         getEnv("JIRA_EMAIL", ""),
+		JiraAPIToken:      getEnv("JIRA_API_TOKEN", ""),
+		JiraStartStatuses: parseList(getEnv("JIRA_START_STATUSES", "In Progress")),
 	}
 
 	// --- Resolve credentials from AWS Secrets Manager (Lambda path) ---
 	dbSecretARN := getEnv("DB_SECRET_ARN", "")
 	githubPatSecretARN := getEnv("GITHUB_PAT_SECRET_ARN", "")
+	jiraTokenSecretARN := getEnv("JIRA_API_TOKEN_SECRET_ARN", "")
+	hostSecretARNs := make(map[int]string)
+	for i, host := range cfg.GitHubHosts {
+		if arn := getEnv("GITHUB_PAT_SECRET_ARN_"+hostEnvSuffix(host.Host), ""); arn != "" && host.PAT == "" {
+			hostSecretARNs[i] = arn
+		}
+	}
+	gitlabSecretARNs := make(map[int]string)
+	for i, host := range cfg.GitLabHosts {
+		if arn := getEnv("GITLAB_TOKEN_SECRET_ARN_"+hostEnvSuffix(host.Host), ""); arn != "" && host.Token == "" {
+			gitlabSecretARNs[i] = arn
+		}
+	}
+	bitbucketSecretARNs := make(map[int]string)
+	for i, host := range cfg.BitbucketHosts {
+		if arn := getEnv("BITBUCKET_TOKEN_SECRET_ARN_"+hostEnvSuffix(host.Host), ""); arn != "" && host.Token == "" {
+			bitbucketSecretARNs[i] = arn
+		}
+	}
 
-	if dbSecretARN != "" || githubPatSecretARN != "" {
+	if dbSecretARN != "" || githubPatSecretARN != "" || jiraTokenSecretARN != "" || len(hostSecretARNs) > 0 || len(gitlabSecretARNs) > 0 || len(bitbucketSecretARNs) > 0 {
 		awsCfg, err := config.LoadDefaultConfig(context.Background())
 		if err != nil {
 			return nil, fmt.Errorf("failed to load AWS config: %w", err)
@@ -140,6 +333,46 @@ func Load() (*Config, error) {
 			cfg.GitHubPAT = strings.TrimSpace(pat)
 			fmt.Printf("✓ GitHub PAT fetched from Secrets Manager\n")
 		}
+
+		// Fetch the PATs of GitHub Enterprise hosts from Secrets Manager
+		for i, arn := range hostSecretARNs {
+			pat, err := fetchSecret(smClient, arn)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch GitHub PAT secret for %s: %w", cfg.GitHubHosts[i].Host, err)
+			}
+			cfg.GitHubHosts[i].PAT = strings.TrimSpace(pat)
+			fmt.Printf("✓ GitHub PAT for %s fetched from Secrets Manager\n", cfg.GitHubHosts[i].Host)
+		}
+
+		// Fetch the tokens of GitLab hosts from Secrets Manager
+		for i, arn := range gitlabSecretARNs {
+			token, err := fetchSecret(smClient, arn)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch GitLab token secret for %s: %w", cfg.GitLabHosts[i].Host, err)
+			}
+			cfg.GitLabHosts[i].Token = strings.TrimSpace(token)
+			fmt.Printf("✓ GitLab token for %s fetched from Secrets Manager\n", cfg.GitLabHosts[i].Host)
+		}
+
+		// Fetch the tokens of Bitbucket hosts from Secrets Manager
+		for i, arn := range bitbucketSecretARNs {
+			token, err := fetchSecret(smClient, arn)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch Bitbucket token secret for %s: %w", cfg.BitbucketHosts[i].Host, err)
+			}
+			cfg.BitbucketHosts[i].Token = strings.TrimSpace(token)
+			fmt.Printf("✓ Bitbucket token for %s fetched from Secrets Manager\n", cfg.BitbucketHosts[i].Host)
+		}
+
+		// Fetch Jira API token from Secrets Manager
+		if jiraTokenSecretARN != "" && cfg.JiraAPIToken == "" {
+			token, err := fetchSecret(smClient, jiraTokenSecretARN)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch Jira API token secret: %w", err)
+			}
+			cfg.JiraAPIToken = strings.TrimSpace(token)
+			fmt.Printf("✓ Jira API token fetched from Secrets Manager\n")
+		}
 	}
 
 	// Fallback to SQLite default only if still no DB_URL and driver is sqlite3
@@ -147,20 +380,47 @@ func Load() (*Config, error) {
 		cfg.DBURL = "./data/dora_metrics.db"
 	}
 
-	// Parse team configuration
-	teamConfigJSON := getEnv("TEAM_CONFIG_JSON", "[]")
-	if err := json.Unmarshal([]byte(teamConfigJSON), &cfg.Teams); err != nil {
-		return nil, fmt.Errorf("failed to parse TEAM_CONFIG_JSON: %w", err)
+	// Parse team configuration, preferring the team config file over the env var
+	if teamFile := getEnv("TEAM_CONFIG_FILE", ""); teamFile != "" {
+		file, err := LoadTeamFile(teamFile, !cfg.UsesGitHubTeams())
+		if err != nil {
+			return nil, err
+		}
+		cfg.Teams = file.Teams
+		cfg.Aliases = file.Aliases
+		cfg.RepositorySettings = file.Repositories
+		cfg.WorkTypes = file.WorkTypes
+		cfg.Schedules = file.Schedules
+		fmt.Printf("Loaded team configuration from %s\n", teamFile)
+	} else {
+		teamConfigJSON := getEnv("TEAM_CONFIG_JSON", "[]")
+		if err := json.Unmarshal([]byte(teamConfigJSON), &cfg.Teams); err != nil {
+			return nil, fmt.Errorf("failed to parse TEAM_CONFIG_JSON: %w", err)
+		}
+	}
+
+	// Parse GitHub team slug mapping (slug=Team Name,slug2=Other Team)
+	teamMap, err := parseTeamMap(getEnv("GITHUB_TEAM_MAP", ""))
+	if err != nil {
+		return nil, err
 	}
+	cfg.GitHubTeamMap = teamMap
 
 	// Parse repositories
 	reposStr := getEnv("REPOSITORIES", "")
 	if reposStr != "" {
-		cfg.Repositories = strings.Split(reposStr, ",")
-		for i := range cfg.Repositories {
-			cfg.Repositories[i] = strings.TrimSpace(cfg.Repositories[i])
+		for _, repo := range strings.Split(reposStr, ",") {
+			cfg.Repositories = append(cfg.Repositories, strings.TrimSpace(repo))
 		}
 	}
+	cfg.RepositorySettings = mergeRepositories(cfg.Repositories, cfg.RepositorySettings, cfg.Teams)
+	cfg.Repositories = make([]string, 0, len(cfg.RepositorySettings))
+	for _, repo := range cfg.RepositorySettings {
+		cfg.Repositories = append(cfg.Repositories, repo.Name)
+	}
+	if len(cfg.WorkTypes) == 0 {
+		cfg.WorkTypes = DefaultWorkTypes
+	}
 
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
@@ -184,14 +444,78 @@ func fetchSecret(client *secretsmanager.Client, arn string) (string, error) {
 	return *out.SecretString, nil
 }
 
-// Validate validates the configuration
+// Validate validates the configuration, reporting every problem found
 func (c *Config) Validate() error {
+	var errs []error
+
 	if c.DBDriver != "sqlite3" && c.DBDriver != "postgres" {
-		return fmt.Errorf("DB_DRIVER must be 'sqlite3' or 'postgres', got: %s", c.DBDriver)
+		errs = append(errs, fmt.Errorf("DB_DRIVER must be 'sqlite3' or 'postgres', got: %s", c.DBDriver))
 	}
 
 	if c.DBURL == "" {
-		return fmt.Errorf("DB_URL is required (or set DB_SECRET_ARN + DB_HOST + DB_NAME for AWS Lambda)")
+		errs = append(errs, fmt.Errorf("DB_URL is required (or set DB_SECRET_ARN + DB_HOST + DB_NAME for AWS Lambda)"))
+	}
+
+	if c.LockTTLSeconds < 0 {
+		errs = append(errs, fmt.Errorf("LOCK_TTL_SECONDS must be positive, got: %d", c.LockTTLSeconds))
+	}
+
+	if c.JobLeaseSeconds < 0 || c.JobMaxAttempts < 0 {
+		errs = append(errs, fmt.Errorf("JOB_LEASE_SECONDS and JOB_MAX_ATTEMPTS must be positive, got: %d and %d", c.JobLeaseSeconds, c.JobMaxAttempts))
+	}
+
+	if c.CoAuthorWeight < 0 || c.CoAuthorWeight > 1 {
+		errs = append(errs, fmt.Errorf("COAUTHOR_WEIGHT must be between 0 and 1, got: %v", c.CoAuthorWeight))
+	}
+
+	switch c.TeamSource {
+	case "", "config":
+	case "github", "merged":
+		if c.GitHubOrg == "" {
+			errs = append(errs, fmt.Errorf("GITHUB_ORG is required when TEAM_SOURCE is '%s'", c.TeamSource))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("TEAM_SOURCE must be 'config', 'github' or 'merged', got: %s", c.TeamSource))
+	}
+
+	// Parents may name GitHub teams, in which case they are checked when teams are synced
+	errs = append(errs, ValidateTeams(c.Teams, c.Aliases, !c.UsesGitHubTeams())...)
+
+	var teamNames map[string]bool
+	if !c.UsesGitHubTeams() {
+		teamNames = make(map[string]bool, len(c.Teams))
+		for _, team := range c.Teams {
+			teamNames[team.Name] = true
+		}
+	}
+	errs = append(errs, validateRepositories(c.RepositorySettings, teamNames)...)
+	errs = append(errs, validateHosts(c.GitHubHosts, c.GitLabHosts, c.BitbucketHosts, c.RepositorySettings)...)
+	errs = append(errs, validateWorkTypes(c.WorkTypes)...)
+	errs = append(errs, validateSchedules(c.Schedules)...)
+	if c.DefaultSchedule != "" {
+		if _, err := ParseSchedule(c.DefaultSchedule); err != nil {
+			errs = append(errs, fmt.Errorf("COLLECTOR_SCHEDULE is not a valid cron expression: %w", err))
+		}
+	}
+	if c.MaxConcurrentRuns < 0 {
+		errs = append(errs, fmt.Errorf("COLLECTOR_MAX_CONCURRENT_RUNS must be positive, got: %d", c.MaxConcurrentRuns))
+	}
+
+	if c.IssueKeyPattern != "" {
+		if _, err := regexp.Compile(c.IssueKeyPattern); err != nil {
+			errs = append(errs, fmt.Errorf("ISSUE_KEY_PATTERN is not a valid regular expression: %w", err))
+		}
+	}
+	if c.JiraBaseURL != "" {
+		if u, err := url.Parse(c.JiraBaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("JIRA_BASE_URL must be an http(s) URL, got: %s", c.JiraBaseURL))
+		}
+		if c.JiraAPIToken == "" {
+			errs = append(errs, fmt.Errorf("JIRA_API_TOKEN is required when JIRA_BASE_URL is set (or set JIRA_API_TOKEN_SECRET_ARN)"))
+		}
+		if len(c.JiraStartStatuses) == 0 {
+			errs = append(errs, fmt.Errorf("JIRA_START_STATUSES must name at least one status when JIRA_BASE_URL is set"))
+		}
 	}
 
 	// GitHub PAT is optional for now (can be added later)
@@ -199,7 +523,40 @@ func (c *Config) Validate() error {
 	// 	return fmt.Errorf("GITHUB_PAT is required")
 	// }
 
-	return nil
+	return errors.Join(errs...)
+}
+
+// UsesGitHubTeams reports whether teams are (also) loaded from GitHub organization teams
+func (c *Config) UsesGitHubTeams() bool {
+	return c.TeamSource == "github" || c.TeamSource == "merged"
+}
+
+// parseTeamMap parses a comma-separated list of slug=name pairs
+func parseTeamMap(value string) (map[string]string, error) {
+	teamMap := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		slug, name, ok := strings.Cut(pair, "=")
+		slug, name = strings.TrimSpace(slug), strings.TrimSpace(name)
+		if !ok || slug == "" || name == "" {
+			return nil, fmt.Errorf("invalid GITHUB_TEAM_MAP entry %q (expected slug=Team Name)", pair)
+		}
+		teamMap[slug] = name
+	}
+	return teamMap, nil
+}
+
+// parseList parses a comma-separated list, dropping empty entries
+func parseList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
 }
 
 // getEnv gets an environment variable or returns a default value
@@ -220,3 +577,27 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvBool gets a boolean environment variable or returns a default value
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvFloat gets a float environment variable or returns a default value.
+// A value that isn't a number is an error rather than the default.
+func getEnvFloat(key string, defaultValue float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	floatValue, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number, got: %q", key, value)
+	}
+	return floatValue, nil
+}
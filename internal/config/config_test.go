@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 )
 
@@ -154,6 +155,15 @@ func TestConfigValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "negative job lease",
+			config: &Config{
+				DBDriver:        "sqlite3",
+				DBURL:           "./data/test.db",
+				JobLeaseSeconds: -1,
+			},
+			wantErr: true,
+		},
 		{
 			name: "missing DB URL",
 			config: &Config{
@@ -162,6 +172,189 @@ func TestConfigValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "team hierarchy",
+			config: &Config{
+				DBDriver: "sqlite3",
+				DBURL:    "./data/test.db",
+				Teams: []TeamConfig{
+					{Name: "Payments Tribe"},
+					{Name: "Checkout", Parent: "Payments Tribe"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown parent team",
+			config: &Config{
+				DBDriver: "sqlite3",
+				DBURL:    "./data/test.db",
+				Teams:    []TeamConfig{{Name: "Checkout", Parent: "Payments Tribe"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "github team source without org",
+			config: &Config{
+				DBDriver:   "sqlite3",
+				DBURL:      "./data/test.db",
+				TeamSource: "github",
+			},
+			wantErr: true,
+		},
+		{
+			name: "merged team source",
+			config: &Config{
+				DBDriver:   "sqlite3",
+				DBURL:      "./data/test.db",
+				TeamSource: "merged",
+				GitHubOrg:  "acme",
+				Teams:      []TeamConfig{{Name: "Checkout", Parent: "Payments"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown team source",
+			config: &Config{
+				DBDriver:   "sqlite3",
+				DBURL:      "./data/test.db",
+				TeamSource: "ldap",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid issue key pattern",
+			config: &Config{
+				DBDriver:        "sqlite3",
+				DBURL:           "./data/test.db",
+				IssueKeyPattern: "[A-Z+-\\d+",
+			},
+			wantErr: true,
+		},
+		{
+			name: "jira",
+			config: &Config{
+				DBDriver:          "sqlite3",
+				DBURL:             "./data/test.db",
+				IssueKeyPattern:   DefaultIssueKeyPattern,
+				JiraBaseURL:       "https://acme.atlassian.net",
+				JiraEmail:         "bot@acme.example",
+				JiraAPIToken:      "token",
+				JiraStartStatuses: []string{"In Progress"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "jira without token",
+			config: &Config{
+				DBDriver:          "sqlite3",
+				DBURL:             "./data/test.db",
+				JiraBaseURL:       "https://acme.atlassian.net",
+				JiraStartStatuses: []string{"In Progress"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "jira URL without scheme",
+			config: &Config{
+				DBDriver:          "sqlite3",
+				DBURL:             "./data/test.db",
+				JiraBaseURL:       "acme.atlassian.net",
+				JiraAPIToken:      "token",
+				JiraStartStatuses: []string{"In Progress"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "github enterprise host",
+			config: &Config{
+				DBDriver:           "sqlite3",
+				DBURL:              "./data/test.db",
+				GitHubHosts:        parseGitHubHosts("ghe.corp.example"),
+				RepositorySettings: []RepositoryConfig{{Name: "acme/api"}, {Name: "ghe.corp.example/acme/api"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "repository on unknown host",
+			config: &Config{
+				DBDriver:           "sqlite3",
+				DBURL:              "./data/test.db",
+				RepositorySettings: []RepositoryConfig{{Name: "ghe.corp.example/acme/api"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "github.com written as a host",
+			config: &Config{
+				DBDriver:           "sqlite3",
+				DBURL:              "./data/test.db",
+				RepositorySettings: []RepositoryConfig{{Name: "github.com/acme/api"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "gitlab host with subgroups",
+			config: &Config{
+				DBDriver:           "sqlite3",
+				DBURL:              "./data/test.db",
+				GitLabHosts:        parseGitLabHosts("gitlab.corp.example"),
+				RepositorySettings: []RepositoryConfig{{Name: "gitlab.corp.example/payments/core/api"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "subgroups on a github host",
+			config: &Config{
+				DBDriver:           "sqlite3",
+				DBURL:              "./data/test.db",
+				GitHubHosts:        parseGitHubHosts("ghe.corp.example"),
+				RepositorySettings: []RepositoryConfig{{Name: "ghe.corp.example/acme/core/api"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "host listed as github and gitlab",
+			config: &Config{
+				DBDriver:    "sqlite3",
+				DBURL:       "./data/test.db",
+				GitHubHosts: parseGitHubHosts("git.corp.example"),
+				GitLabHosts: parseGitLabHosts("git.corp.example"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "bitbucket cloud and data center repositories",
+			config: &Config{
+				DBDriver:           "sqlite3",
+				DBURL:              "./data/test.db",
+				BitbucketHosts:     parseBitbucketHosts("bitbucket.org,bitbucket.corp.example"),
+				RepositorySettings: []RepositoryConfig{{Name: "bitbucket.org/acme/payments"}, {Name: "bitbucket.corp.example/PAY/payments"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "subgroups on a bitbucket host",
+			config: &Config{
+				DBDriver:           "sqlite3",
+				DBURL:              "./data/test.db",
+				BitbucketHosts:     parseBitbucketHosts("bitbucket.org"),
+				RepositorySettings: []RepositoryConfig{{Name: "bitbucket.org/acme/core/payments"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "parent cycle",
+			config: &Config{
+				DBDriver: "sqlite3",
+				DBURL:    "./data/test.db",
+				Teams: []TeamConfig{
+					{Name: "A", Parent: "B"},
+					{Name: "B", Parent: "A"},
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -199,3 +392,201 @@ func TestTeamMemberAllocation(t *testing.T) {
 		})
 	}
 }
+
+// TestTeamMemberDates tests parsing explicit membership dates
+func TestTeamMemberDates(t *testing.T) {
+	tests := []struct {
+		name       string
+		json       string
+		wantErr    bool
+		wantJoined string
+		wantLeft   string
+	}{
+		{
+			name:       "no dates",
+			json:       `{"username": "alice", "allocation": 1.0}`,
+			wantJoined: "",
+			wantLeft:   "",
+		},
+		{
+			name:       "date only",
+			json:       `{"username": "alice", "allocation": 1.0, "joined_at": "2024-03-01", "left_at": "2024-09-30"}`,
+			wantJoined: "2024-03-01",
+			wantLeft:   "2024-09-30",
+		},
+		{
+			name:       "RFC 3339 timestamp",
+			json:       `{"username": "alice", "allocation": 1.0, "joined_at": "2024-03-01T09:00:00Z"}`,
+			wantJoined: "2024-03-01",
+		},
+		{
+			name:    "invalid date",
+			json:    `{"username": "alice", "allocation": 1.0, "joined_at": "March 1st"}`,
+			wantErr: true,
+		},
+	}
+
+	format := func(d *Date) string {
+		if d == nil {
+			return ""
+		}
+		return d.Format("2006-01-02")
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var member TeamMemberConfig
+			err := json.Unmarshal([]byte(tt.json), &member)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("json.Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := format(member.JoinedAt); got != tt.wantJoined {
+				t.Errorf("JoinedAt = %v, want %v", got, tt.wantJoined)
+			}
+			if got := format(member.LeftAt); got != tt.wantLeft {
+				t.Errorf("LeftAt = %v, want %v", got, tt.wantLeft)
+			}
+		})
+	}
+}
+
+// TestParseTeamMap tests parsing GITHUB_TEAM_MAP slug mappings
+func TestParseTeamMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"empty", "", map[string]string{}, false},
+		{"single mapping", "platform=Platform", map[string]string{"platform": "Platform"}, false},
+		{"names with spaces", "web=Web Team, api = API Team", map[string]string{"web": "Web Team", "api": "API Team"}, false},
+		{"missing name", "platform=", nil, true},
+		{"missing separator", "platform", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTeamMap(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTeamMap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTeamMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseGitHubHosts tests parsing GITHUB_HOSTS and each host's settings
+func TestParseGitHubHosts(t *testing.T) {
+	t.Setenv("GITHUB_PAT_GHE_CORP_EXAMPLE", "corp-token")
+	t.Setenv("GITHUB_UPLOAD_URL_GHE_LEGACY_EXAMPLE", "https://uploads.legacy.example/")
+
+	got := parseGitHubHosts("GHE.corp.example, ghe.legacy.example=https://ghe.legacy.example/github/api/v3")
+	want := []GitHubHostConfig{
+		{
+			Host:      "ghe.corp.example",
+			BaseURL:   "https://ghe.corp.example/api/v3/",
+			UploadURL: "https://ghe.corp.example/api/uploads/",
+			PAT:       "corp-token",
+		},
+		{
+			Host:      "ghe.legacy.example",
+			BaseURL:   "https://ghe.legacy.example/github/api/v3/",
+			UploadURL: "https://uploads.legacy.example/",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseGitHubHosts() = %+v, want %+v", got, want)
+	}
+}
+
+// TestParseGitLabHosts tests parsing GITLAB_HOSTS and each host's token
+func TestParseGitLabHosts(t *testing.T) {
+	t.Setenv("GITLAB_TOKEN_GITLAB_CORP_EXAMPLE", "glpat-corp")
+
+	got := parseGitLabHosts("GitLab.corp.example, gitlab.legacy.example=https://gitlab.legacy.example/gitlab/api/v4")
+	want := []GitLabHostConfig{
+		{Host: "gitlab.corp.example", BaseURL: "https://gitlab.corp.example/api/v4/", Token: "glpat-corp"},
+		{Host: "gitlab.legacy.example", BaseURL: "https://gitlab.legacy.example/gitlab/api/v4/"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseGitLabHosts() = %+v, want %+v", got, want)
+	}
+}
+
+// TestParseBitbucketHosts tests parsing BITBUCKET_HOSTS, the default API URLs
+// of Cloud and Data Center, and each host's credentials
+func TestParseBitbucketHosts(t *testing.T) {
+	t.Setenv("BITBUCKET_USERNAME_BITBUCKET_ORG", "alice")
+	t.Setenv("BITBUCKET_TOKEN_BITBUCKET_ORG", "app-password")
+	t.Setenv("BITBUCKET_TOKEN_BITBUCKET_CORP_EXAMPLE", "http-access-token")
+
+	got := parseBitbucketHosts("bitbucket.org, Bitbucket.corp.example, git.legacy.example=https://git.legacy.example/bitbucket/rest/api/1.0")
+	want := []BitbucketHostConfig{
+		{Host: "bitbucket.org", BaseURL: "https://api.bitbucket.org/2.0/", Username: "alice", Token: "app-password"},
+		{Host: "bitbucket.corp.example", BaseURL: "https://bitbucket.corp.example/rest/api/1.0/", Token: "http-access-token"},
+		{Host: "git.legacy.example", BaseURL: "https://git.legacy.example/bitbucket/rest/api/1.0/"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseBitbucketHosts() = %+v, want %+v", got, want)
+	}
+}
+
+// TestSplitRepository tests splitting repository names with and without a host
+func TestSplitRepository(t *testing.T) {
+	tests := []struct {
+		name                          string
+		wantHost, wantOwner, wantRepo string
+		wantOK                        bool
+	}{
+		{"acme/api", DefaultGitHubHost, "acme", "api", true},
+		{"ghe.corp.example/acme/api", "ghe.corp.example", "acme", "api", true},
+		{"acme", "", "", "", false},
+		{"acme/", "", "", "", false},
+		{"gitlab.corp.example/payments/core/api", "gitlab.corp.example", "payments/core", "api", true},
+		{"acme//api", "", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, owner, repo, ok := SplitRepository(tt.name)
+			if ok != tt.wantOK || (ok && (host != tt.wantHost || owner != tt.wantOwner || repo != tt.wantRepo)) {
+				t.Errorf("SplitRepository() = %s, %s, %s, %v, want %s, %s, %s, %v",
+					host, owner, repo, ok, tt.wantHost, tt.wantOwner, tt.wantRepo, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestGetEnvFloat tests reading float settings strictly
+func TestGetEnvFloat(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{"unset uses the default", "", 0.25, false},
+		{"number", "0.5", 0.5, false},
+		{"surrounding spaces", " 1 ", 1, false},
+		{"trailing garbage", "0.5x", 0, true},
+		{"not a number", "half", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_FLOAT", tt.value)
+			got, err := getEnvFloat("TEST_FLOAT", 0.25)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getEnvFloat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getEnvFloat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
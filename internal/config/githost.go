@@ -0,0 +1,201 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DefaultGitHubHost is the host of repositories named without one (owner/repo)
+const DefaultGitHubHost = "github.com"
+
+// GitHubHostConfig is a GitHub Enterprise Server instance repositories can be
+// collected from, named in repositories as host/owner/repo
+type GitHubHostConfig struct {
+	Host      string // e.g. ghe.corp.example
+	BaseURL   string // REST API URL, e.g. https://ghe.corp.example/api/v3/
+	UploadURL string // Upload API URL, e.g. https://ghe.corp.example/api/uploads/
+	PAT       string
+}
+
+// GitLabHostConfig is a GitLab instance (GitLab.com or self-managed)
+// repositories can be collected from, named in repositories as
+// host/group/project, where the group may include subgroups
+type GitLabHostConfig struct {
+	Host    string // e.g. gitlab.corp.example
+	BaseURL string // REST API URL, e.g. https://gitlab.corp.example/api/v4/
+	Token   string
+}
+
+// BitbucketCloudHost is the host of Bitbucket Cloud; other Bitbucket hosts
+// are Data Center (or Server) instances
+const BitbucketCloudHost = "bitbucket.org"
+
+// BitbucketHostConfig is Bitbucket Cloud or a Bitbucket Data Center instance
+// repositories can be collected from, named in repositories as
+// host/workspace/repo (Cloud) or host/project/repo (Data Center)
+type BitbucketHostConfig struct {
+	Host     string // e.g. bitbucket.org or bitbucket.corp.example
+	BaseURL  string // REST API URL, e.g. https://bitbucket.corp.example/rest/api/1.0/
+	Username string // Set for app passwords (Cloud) or passwords; empty for access tokens
+	Token    string
+}
+
+// SplitRepository splits a repository name into its host, owner and name.
+// Repositories on github.com are written owner/repo, on other hosts
+// host/owner/repo. On GitLab hosts the owner is the project's full namespace,
+// so host/group/subgroup/project has owner group/subgroup.
+func SplitRepository(name string) (host, owner, repo string, ok bool) {
+	parts := strings.Split(name, "/")
+	for _, part := range parts {
+		if part == "" {
+			return "", "", "", false
+		}
+	}
+	switch {
+	case len(parts) == 2:
+		return DefaultGitHubHost, parts[0], parts[1], true
+	case len(parts) >= 3:
+		return parts[0], strings.Join(parts[1:len(parts)-1], "/"), parts[len(parts)-1], true
+	default:
+		return "", "", "", false
+	}
+}
+
+// parseGitHubHosts parses GITHUB_HOSTS, a comma-separated list of hosts, each
+// optionally with its API URL (host=https://host/api/v3/). Tokens and upload
+// URLs are read from GITHUB_PAT_<HOST> and GITHUB_UPLOAD_URL_<HOST>.
+func parseGitHubHosts(value string) []GitHubHostConfig {
+	var hosts []GitHubHostConfig
+	for _, entry := range parseList(value) {
+		host, baseURL, _ := strings.Cut(entry, "=")
+		host, baseURL = strings.ToLower(strings.TrimSpace(host)), strings.TrimSpace(baseURL)
+		if baseURL == "" {
+			baseURL = fmt.Sprintf("https://%s/api/v3/", host)
+		}
+		if !strings.HasSuffix(baseURL, "/") {
+			baseURL += "/"
+		}
+		uploadURL := getEnv("GITHUB_UPLOAD_URL_"+hostEnvSuffix(host), strings.TrimSuffix(baseURL, "api/v3/")+"api/uploads/")
+		hosts = append(hosts, GitHubHostConfig{
+			Host:      host,
+			BaseURL:   baseURL,
+			UploadURL: uploadURL,
+			PAT:       getEnv("GITHUB_PAT_"+hostEnvSuffix(host), ""),
+		})
+	}
+	return hosts
+}
+
+// parseGitLabHosts parses GITLAB_HOSTS, a comma-separated list of hosts, each
+// optionally with its API URL (host=https://host/api/v4/). Tokens are read
+// from GITLAB_TOKEN_<HOST>.
+func parseGitLabHosts(value string) []GitLabHostConfig {
+	var hosts []GitLabHostConfig
+	for _, entry := range parseList(value) {
+		host, baseURL, _ := strings.Cut(entry, "=")
+		host, baseURL = strings.ToLower(strings.TrimSpace(host)), strings.TrimSpace(baseURL)
+		if baseURL == "" {
+			baseURL = fmt.Sprintf("https://%s/api/v4/", host)
+		}
+		if !strings.HasSuffix(baseURL, "/") {
+			baseURL += "/"
+		}
+		hosts = append(hosts, GitLabHostConfig{
+			Host:    host,
+			BaseURL: baseURL,
+			Token:   getEnv("GITLAB_TOKEN_"+hostEnvSuffix(host), ""),
+		})
+	}
+	return hosts
+}
+
+// parseBitbucketHosts parses BITBUCKET_HOSTS, a comma-separated list of
+// hosts, each optionally with its API URL (host=https://host/rest/api/1.0/).
+// Credentials are read from BITBUCKET_USERNAME_<HOST> and BITBUCKET_TOKEN_<HOST>.
+func parseBitbucketHosts(value string) []BitbucketHostConfig {
+	var hosts []BitbucketHostConfig
+	for _, entry := range parseList(value) {
+		host, baseURL, _ := strings.Cut(entry, "=")
+		host, baseURL = strings.ToLower(strings.TrimSpace(host)), strings.TrimSpace(baseURL)
+		switch {
+		case baseURL != "":
+		case host == BitbucketCloudHost:
+			baseURL = "https://api.bitbucket.org/2.0/"
+		default:
+			baseURL = fmt.Sprintf("https://%s/rest/api/1.0/", host)
+		}
+		if !strings.HasSuffix(baseURL, "/") {
+			baseURL += "/"
+		}
+		hosts = append(hosts, BitbucketHostConfig{
+			Host:     host,
+			BaseURL:  baseURL,
+			Username: getEnv("BITBUCKET_USERNAME_"+hostEnvSuffix(host), ""),
+			Token:    getEnv("BITBUCKET_TOKEN_"+hostEnvSuffix(host), ""),
+		})
+	}
+	return hosts
+}
+
+// hostEnvSuffix turns a host into the suffix of its environment variables
+// (ghe.corp.example -> GHE_CORP_EXAMPLE)
+func hostEnvSuffix(host string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, host)
+}
+
+// validateHosts checks the GitHub Enterprise, GitLab and Bitbucket hosts and
+// that every repository is on github.com or one of them
+func validateHosts(githubHosts []GitHubHostConfig, gitlabHosts []GitLabHostConfig, bitbucketHosts []BitbucketHostConfig, repos []RepositoryConfig) []error {
+	var errs []error
+	known := map[string]string{DefaultGitHubHost: "GITHUB_HOSTS"}
+	addHost := func(setting, host string, apiURLs ...string) {
+		switch {
+		case host == "" || strings.Contains(host, "/"):
+			errs = append(errs, fmt.Errorf("%s: invalid host '%s'", setting, host))
+			return
+		case known[host] != "":
+			errs = append(errs, fmt.Errorf("%s: '%s' is listed more than once (github.com is always included in GITHUB_HOSTS)", setting, host))
+		}
+		known[host] = setting
+		for _, apiURL := range apiURLs {
+			if u, err := url.Parse(apiURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, fmt.Errorf("%s: '%s' has an invalid API URL: %s", setting, host, apiURL))
+			}
+		}
+	}
+	for _, host := range githubHosts {
+		addHost("GITHUB_HOSTS", host.Host, host.BaseURL, host.UploadURL)
+	}
+	for _, host := range gitlabHosts {
+		addHost("GITLAB_HOSTS", host.Host, host.BaseURL)
+	}
+	for _, host := range bitbucketHosts {
+		addHost("BITBUCKET_HOSTS", host.Host, host.BaseURL)
+	}
+
+	for _, repo := range repos {
+		host, owner, _, ok := SplitRepository(repo.Name)
+		if !ok {
+			continue // Reported with the other repository settings
+		}
+		host = strings.ToLower(host)
+		switch {
+		case host == DefaultGitHubHost && strings.Count(repo.Name, "/") == 2:
+			errs = append(errs, fmt.Errorf("repositories: '%s': write github.com repositories as owner/repo", repo.Name))
+		case known[host] == "":
+			errs = append(errs, fmt.Errorf("repositories: '%s': host '%s' is not listed in GITHUB_HOSTS, GITLAB_HOSTS or BITBUCKET_HOSTS", repo.Name, host))
+		case known[host] != "GITLAB_HOSTS" && strings.Contains(owner, "/"):
+			errs = append(errs, fmt.Errorf("repositories: '%s': subgroups are only supported on GITLAB_HOSTS", repo.Name))
+		}
+	}
+	return errs
+}
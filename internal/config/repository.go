@@ -0,0 +1,209 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Deployment sources a repository's deployments can be read from
+var deploymentSources = map[string]bool{
+	"releases":    true, // GitHub releases
+	"deployments": true, // The code host's deployments API, the only one collected
+	"tags":        true, // git tags
+}
+
+// DefaultBranchPattern is the base branch pattern matching a repository's default branch
+const DefaultBranchPattern = "default"
+
+// RepositoryConfig is a tracked repository and its settings.
+// In the team config file a repository may also be written as just "owner/repo".
+type RepositoryConfig struct {
+	Name          string   `json:"name" yaml:"name"` // owner/repo, or host/owner/repo on GitHub Enterprise Server
+	DefaultBranch string   `json:"default_branch,omitempty" yaml:"default_branch,omitempty"`
+	Team          string   `json:"team,omitempty" yaml:"team,omitempty"` // Name of the owning team
+	Tags          []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	ServiceGroup  string   `json:"service_group,omitempty" yaml:"service_group,omitempty"`
+
+	// BaseBranches limits delivery metrics to PRs merged into matching branches:
+	// glob patterns such as "release/*", or "default" for the default branch.
+	// PRs into any base count when empty.
+	BaseBranches []string `json:"base_branches,omitempty" yaml:"base_branches,omitempty"`
+
+	// Active is false for repositories that are kept but no longer collected (default true)
+	Active *bool `json:"active,omitempty" yaml:"active,omitempty"`
+
+	// LookbackDays overrides COLLECTION_LOOKBACK_DAYS for the first collection
+	LookbackDays int `json:"lookback_days,omitempty" yaml:"lookback_days,omitempty"`
+
+	// DeploymentSource is where deployments are read from: "releases",
+	// "deployments" or "tags"; DeploymentEnvironment limits GitHub deployments
+	// to one environment
+	DeploymentSource      string `json:"deployment_source,omitempty" yaml:"deployment_source,omitempty"`
+	DeploymentEnvironment string `json:"deployment_environment,omitempty" yaml:"deployment_environment,omitempty"`
+
+	// GitPath is a local clone (bare or not) to read commits from with git
+	// instead of the GitHub API; PRs, issues and comments aren't collected then
+	GitPath string `json:"git_path,omitempty" yaml:"git_path,omitempty"`
+}
+
+// repositoryFields has the same fields as RepositoryConfig without its
+// unmarshalers, so the object form can be decoded strictly
+type repositoryFields RepositoryConfig
+
+// UnmarshalJSON accepts "owner/repo" or an object with settings
+func (r *RepositoryConfig) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*r = RepositoryConfig{Name: name}
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var fields repositoryFields
+	if err := decoder.Decode(&fields); err != nil {
+		return fmt.Errorf("repository must be \"owner/repo\" or an object: %w", err)
+	}
+	*r = RepositoryConfig(fields)
+	return nil
+}
+
+// UnmarshalYAML accepts "owner/repo" or a mapping with settings
+func (r *RepositoryConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*r = RepositoryConfig{Name: value.Value}
+		return nil
+	}
+
+	// Node.Decode doesn't inherit KnownFields, so decode the mapping again strictly
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var fields repositoryFields
+	if err := decoder.Decode(&fields); err != nil {
+		return fmt.Errorf("repository '%s': %w", repositoryName(value), err)
+	}
+	*r = RepositoryConfig(fields)
+	return nil
+}
+
+// repositoryName finds the name in a repository mapping, for error messages
+func repositoryName(value *yaml.Node) string {
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		if value.Content[i].Value == "name" {
+			return value.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// IsActive reports whether the repository should be collected
+func (r RepositoryConfig) IsActive() bool {
+	return r.Active == nil || *r.Active
+}
+
+// Validate checks a repository's name and settings and reports every problem found
+func (r RepositoryConfig) Validate() error {
+	return errors.Join(r.problems()...)
+}
+
+// problems returns what is wrong with a repository's name and settings
+func (r RepositoryConfig) problems() []error {
+	if !validRepository(r.Name) {
+		return []error{fmt.Errorf("'%s' is not in owner/repo or host/owner/repo format", r.Name)}
+	}
+
+	var errs []error
+	if r.LookbackDays < 0 {
+		errs = append(errs, fmt.Errorf("lookback_days must not be negative"))
+	}
+	if r.DeploymentSource != "" && !deploymentSources[r.DeploymentSource] {
+		errs = append(errs, fmt.Errorf("deployment_source must be 'releases', 'deployments' or 'tags', got: %s", r.DeploymentSource))
+	}
+	if r.DeploymentEnvironment != "" && r.DeploymentSource != "deployments" {
+		errs = append(errs, fmt.Errorf("deployment_environment only applies to deployment_source 'deployments'"))
+	}
+	for _, tag := range r.Tags {
+		if strings.TrimSpace(tag) == "" {
+			errs = append(errs, fmt.Errorf("empty tag"))
+		}
+	}
+	for _, pattern := range r.BaseBranches {
+		if strings.TrimSpace(pattern) == "" {
+			errs = append(errs, fmt.Errorf("empty base branch pattern"))
+		} else if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("invalid base branch pattern '%s'", pattern))
+		}
+	}
+	return errs
+}
+
+// validateRepositories checks repository settings and returns every problem found.
+// teamNames holds the configured teams; it is nil when owning teams may be loaded from GitHub.
+func validateRepositories(repos []RepositoryConfig, teamNames map[string]bool) []error {
+	var errs []error
+	seen := make(map[string]bool)
+	for _, repo := range repos {
+		if !validRepository(repo.Name) {
+			errs = append(errs, fmt.Errorf("repositories: '%s' is not in owner/repo or host/owner/repo format", repo.Name))
+			continue
+		}
+
+		label := fmt.Sprintf("repositories: '%s'", repo.Name)
+		if seen[strings.ToLower(repo.Name)] {
+			errs = append(errs, fmt.Errorf("%s is listed more than once", label))
+		}
+		seen[strings.ToLower(repo.Name)] = true
+
+		if repo.Team != "" && teamNames != nil && !teamNames[repo.Team] {
+			errs = append(errs, fmt.Errorf("%s: team '%s' is not configured", label, repo.Team))
+		}
+		for _, problem := range repo.problems() {
+			errs = append(errs, fmt.Errorf("%s: %w", label, problem))
+		}
+	}
+	return errs
+}
+
+// mergeRepositories combines repositories listed by name, with settings and
+// per team into one entry per repository. Settings listed explicitly win;
+// a repository listed under a team is owned by that team unless it says otherwise.
+func mergeRepositories(names []string, repos []RepositoryConfig, teams []TeamConfig) []RepositoryConfig {
+	var merged []RepositoryConfig
+	index := make(map[string]int)
+	add := func(repo RepositoryConfig) {
+		if repo.Name == "" {
+			return
+		}
+		if i, ok := index[repo.Name]; ok {
+			if merged[i].Team == "" {
+				merged[i].Team = repo.Team
+			}
+			return
+		}
+		index[repo.Name] = len(merged)
+		merged = append(merged, repo)
+	}
+
+	for _, repo := range repos {
+		add(repo)
+	}
+	for _, team := range teams {
+		for _, name := range team.Repositories {
+			add(RepositoryConfig{Name: name, Team: team.Name})
+		}
+	}
+	for _, name := range names {
+		add(RepositoryConfig{Name: name})
+	}
+	return merged
+}
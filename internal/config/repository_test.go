@@ -0,0 +1,34 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestMergeRepositories tests combining repositories from REPOSITORIES, the team config file and teams
+func TestMergeRepositories(t *testing.T) {
+	inactive := false
+	settings := []RepositoryConfig{
+		{Name: "acme/api", LookbackDays: 30},
+		{Name: "acme/legacy", Team: "Platform", Active: &inactive},
+	}
+	teams := []TeamConfig{
+		{Name: "Platform", Repositories: []string{"acme/api", "acme/infra"}},
+		{Name: "Web", Repositories: []string{"acme/legacy", "acme/web"}},
+	}
+
+	got := mergeRepositories([]string{"acme/web", "acme/tools", ""}, settings, teams)
+	want := []RepositoryConfig{
+		{Name: "acme/api", Team: "Platform", LookbackDays: 30},
+		{Name: "acme/legacy", Team: "Platform", Active: &inactive},
+		{Name: "acme/infra", Team: "Platform"},
+		{Name: "acme/web", Team: "Web"},
+		{Name: "acme/tools"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeRepositories() = %+v, want %+v", got, want)
+	}
+	if got[1].IsActive() || !got[0].IsActive() {
+		t.Errorf("IsActive() should follow the active setting, defaulting to true")
+	}
+}
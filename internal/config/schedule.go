@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/robfig/cron/v3"
+)
+
+// DefaultScheduleName is the group `collector serve` puts repositories
+// matching no schedule in, collected on COLLECTOR_SCHEDULE
+const DefaultScheduleName = "default"
+
+// ScheduleConfig is a group of repositories `collector serve` collects on a
+// cron schedule. A repository belongs to the first schedule it matches by
+// name, service group or tag.
+type ScheduleConfig struct {
+	Name string `json:"name" yaml:"name"`
+
+	// Cron is a five-field cron expression ("*/30 * * * *") or a descriptor
+	// such as "@hourly", in local time unless prefixed with CRON_TZ=<zone>
+	Cron string `json:"cron" yaml:"cron"`
+
+	Repositories  []string `json:"repositories,omitempty" yaml:"repositories,omitempty"` // Names or patterns like "acme/*"
+	ServiceGroups []string `json:"service_groups,omitempty" yaml:"service_groups,omitempty"`
+	Tags          []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// Matches reports whether a repository belongs to the schedule
+func (s ScheduleConfig) Matches(name, serviceGroup string, tags []string) bool {
+	for _, pattern := range s.Repositories {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	for _, group := range s.ServiceGroups {
+		if serviceGroup == group {
+			return true
+		}
+	}
+	for _, tag := range s.Tags {
+		for _, repoTag := range tags {
+			if repoTag == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ScheduleFor returns the name of the first schedule a repository matches,
+// or DefaultScheduleName
+func ScheduleFor(schedules []ScheduleConfig, name, serviceGroup string, tags []string) string {
+	for _, schedule := range schedules {
+		if schedule.Matches(name, serviceGroup, tags) {
+			return schedule.Name
+		}
+	}
+	return DefaultScheduleName
+}
+
+// ParseSchedule parses a cron expression as schedules do
+func ParseSchedule(expression string) (cron.Schedule, error) {
+	return cron.ParseStandard(expression)
+}
+
+// validateSchedules checks the schedules and returns every problem found
+func validateSchedules(schedules []ScheduleConfig) []error {
+	var errs []error
+	names := map[string]bool{DefaultScheduleName: true}
+	for i, schedule := range schedules {
+		label := fmt.Sprintf("schedule '%s'", schedule.Name)
+		if schedule.Name == "" {
+			label = fmt.Sprintf("schedule #%d", i+1)
+			errs = append(errs, fmt.Errorf("%s: name is required", label))
+		} else if names[schedule.Name] {
+			errs = append(errs, fmt.Errorf("%s: defined more than once (or named like the default schedule)", label))
+		}
+		names[schedule.Name] = true
+
+		if _, err := ParseSchedule(schedule.Cron); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid cron expression %q: %w", label, schedule.Cron, err))
+		}
+		if len(schedule.Repositories) == 0 && len(schedule.ServiceGroups) == 0 && len(schedule.Tags) == 0 {
+			errs = append(errs, fmt.Errorf("%s: repositories, service_groups or tags is required", label))
+		}
+		for _, pattern := range schedule.Repositories {
+			if _, err := path.Match(pattern, ""); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid repository pattern %q", label, pattern))
+			}
+		}
+	}
+	return errs
+}
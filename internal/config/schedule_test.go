@@ -0,0 +1,33 @@
+package config
+
+import "testing"
+
+// TestScheduleFor tests assigning repositories to the first schedule they match
+func TestScheduleFor(t *testing.T) {
+	schedules := []ScheduleConfig{
+		{Name: "payments", Cron: "*/15 * * * *", ServiceGroups: []string{"payments"}},
+		{Name: "acme", Cron: "@hourly", Repositories: []string{"acme/*", "gitlab.corp.example/*/*/*"}},
+		{Name: "frontend", Cron: "@daily", Tags: []string{"web"}},
+	}
+
+	tests := []struct {
+		name         string
+		repo         string
+		serviceGroup string
+		tags         []string
+		want         string
+	}{
+		{"by service group", "acme/ledger", "payments", nil, "payments"},
+		{"by name pattern", "acme/api", "", []string{"web"}, "acme"},
+		{"by pattern with a host", "gitlab.corp.example/payments/core/api", "", nil, "acme"},
+		{"by tag", "other/site", "", []string{"go", "web"}, "frontend"},
+		{"no match", "other/api", "core", []string{"go"}, DefaultScheduleName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScheduleFor(schedules, tt.repo, tt.serviceGroup, tt.tags); got != tt.want {
+				t.Errorf("ScheduleFor(%s) = %s, want %s", tt.repo, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TeamFileVersion is the team config file format version this build understands
+const TeamFileVersion = 1
+
+// TeamFile is the versioned team configuration file referenced by TEAM_CONFIG_FILE
+type TeamFile struct {
+	Version      int                `json:"version" yaml:"version"`
+	Teams        []TeamConfig       `json:"teams" yaml:"teams"`
+	Repositories []RepositoryConfig `json:"repositories,omitempty" yaml:"repositories,omitempty"`
+	Aliases      map[string]string  `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	WorkTypes    []WorkTypeConfig   `json:"work_types,omitempty" yaml:"work_types,omitempty"`
+	Schedules    []ScheduleConfig   `json:"schedules,omitempty" yaml:"schedules,omitempty"`
+}
+
+// LoadTeamFile reads a YAML or JSON team config file (by extension) and
+// validates it. Unknown fields are rejected, so typos don't pass silently.
+// checkParents is false when parents may name teams loaded from GitHub.
+func LoadTeamFile(path string, checkParents bool) (*TeamFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read team config file: %w", err)
+	}
+
+	file, err := ParseTeamFile(data, filepath.Ext(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if err := file.Validate(checkParents); err != nil {
+		return nil, fmt.Errorf("invalid team config file %s:\n%w", path, err)
+	}
+
+	return file, nil
+}
+
+// ParseTeamFile strictly decodes a team config file; ext selects the format (".json", ".yaml" or ".yml")
+func ParseTeamFile(data []byte, ext string) (*TeamFile, error) {
+	var file TeamFile
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&file); err != nil {
+			return nil, err
+		}
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&file); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported team config file type %q (use .yaml, .yml or .json)", ext)
+	}
+	return &file, nil
+}
+
+// Validate checks the whole file and reports every problem found.
+// checkParents is false when parents may name teams loaded from GitHub.
+func (f *TeamFile) Validate(checkParents bool) error {
+	var errs []error
+	if f.Version != TeamFileVersion {
+		errs = append(errs, fmt.Errorf("version must be %d, got: %d", TeamFileVersion, f.Version))
+	}
+	errs = append(errs, ValidateTeams(f.Teams, f.Aliases, checkParents)...)
+
+	// Owning teams may be GitHub teams, which are only known at sync time
+	var teamNames map[string]bool
+	if checkParents {
+		teamNames = make(map[string]bool, len(f.Teams))
+		for _, team := range f.Teams {
+			teamNames[team.Name] = true
+		}
+	}
+	errs = append(errs, validateRepositories(f.Repositories, teamNames)...)
+	errs = append(errs, validateWorkTypes(f.WorkTypes)...)
+	errs = append(errs, validateSchedules(f.Schedules)...)
+	return errors.Join(errs...)
+}
+
+// ValidateTeams checks team definitions and returns every problem found:
+// missing or duplicate names, duplicate members, allocations outside 0-1 or
+// adding up to more than 1 across teams, inverted membership dates, unknown
+// or cyclic parents, malformed repositories and aliases to unknown members.
+func ValidateTeams(teams []TeamConfig, aliases map[string]string, checkParents bool) []error {
+	var errs []error
+	teamNames := make(map[string]bool)
+	members := make(map[string]bool)
+	allocations := make(map[string]float64) // username -> total current allocation
+	codeOwners := make(map[string]string)   // lowercased handle -> team name
+
+	for i, team := range teams {
+		label := fmt.Sprintf("team '%s'", team.Name)
+		if team.Name == "" {
+			label = fmt.Sprintf("team #%d", i+1)
+			errs = append(errs, fmt.Errorf("%s: name is required", label))
+		} else if teamNames[team.Name] {
+			errs = append(errs, fmt.Errorf("%s: defined more than once", label))
+		}
+		teamNames[team.Name] = true
+
+		seen := make(map[string]bool)
+		for _, member := range team.Members {
+			if member.Username == "" {
+				errs = append(errs, fmt.Errorf("%s: member without a username", label))
+				continue
+			}
+			if seen[strings.ToLower(member.Username)] {
+				errs = append(errs, fmt.Errorf("%s: member %s is listed more than once", label, member.Username))
+			}
+			seen[strings.ToLower(member.Username)] = true
+			members[strings.ToLower(member.Username)] = true
+
+			if member.Allocation < 0 || member.Allocation > 1 {
+				errs = append(errs, fmt.Errorf("%s: member %s has allocation %v (must be between 0 and 1)", label, member.Username, member.Allocation))
+			}
+			if member.JoinedAt != nil && member.LeftAt != nil && !member.LeftAt.After(member.JoinedAt.Time) {
+				errs = append(errs, fmt.Errorf("%s: member %s has left_at on or before joined_at", label, member.Username))
+			}
+			if member.LeftAt == nil {
+				allocations[member.Username] += member.Allocation
+			}
+		}
+
+		for _, repo := range team.Repositories {
+			if !validRepository(repo) {
+				errs = append(errs, fmt.Errorf("%s: repository '%s' is not in owner/repo or host/owner/repo format", label, repo))
+			}
+		}
+
+		for _, handle := range team.CodeOwners {
+			key := strings.ToLower(handle)
+			if !strings.HasPrefix(handle, "@") || !strings.Contains(handle, "/") {
+				errs = append(errs, fmt.Errorf("%s: CODEOWNERS handle '%s' is not in @org/team-slug format", label, handle))
+			} else if other, ok := codeOwners[key]; ok && other != team.Name {
+				errs = append(errs, fmt.Errorf("%s: CODEOWNERS handle '%s' is already used by team '%s'", label, handle, other))
+			}
+			codeOwners[key] = team.Name
+		}
+
+		for _, issueLabel := range team.IssueLabels {
+			if strings.TrimSpace(issueLabel) == "" {
+				errs = append(errs, fmt.Errorf("%s: empty issue label", label))
+			}
+		}
+	}
+
+	for _, username := range sortedKeys(allocations) {
+		// Allow for rounding in splits such as 0.33 + 0.33 + 0.34
+		if allocations[username] > 1.0001 {
+			errs = append(errs, fmt.Errorf("member %s: allocations add up to %.2f across teams (must not exceed 1.0)", username, allocations[username]))
+		}
+	}
+
+	if checkParents {
+		errs = append(errs, validateTeamHierarchy(teams)...)
+	}
+
+	for _, alias := range sortedKeys(aliases) {
+		if strings.TrimSpace(alias) == "" {
+			errs = append(errs, fmt.Errorf("aliases: empty alias for %s", aliases[alias]))
+		} else if !members[strings.ToLower(aliases[alias])] {
+			errs = append(errs, fmt.Errorf("aliases: '%s' points to unknown member '%s'", alias, aliases[alias]))
+		}
+	}
+
+	return errs
+}
+
+// validateTeamHierarchy checks that every parent team is configured and that parents don't form a cycle
+func validateTeamHierarchy(teams []TeamConfig) []error {
+	var errs []error
+	parents := make(map[string]string, len(teams))
+	for _, team := range teams {
+		parents[team.Name] = team.Parent
+	}
+
+	for _, team := range teams {
+		if team.Parent == "" {
+			continue
+		}
+		if _, ok := parents[team.Parent]; !ok {
+			errs = append(errs, fmt.Errorf("team '%s': parent team '%s' is not configured", team.Name, team.Parent))
+			continue
+		}
+
+		seen := map[string]bool{team.Name: true}
+		for parent := team.Parent; parent != ""; parent = parents[parent] {
+			if seen[parent] {
+				errs = append(errs, fmt.Errorf("team '%s': parent chain forms a cycle through '%s'", team.Name, parent))
+				break
+			}
+			seen[parent] = true
+		}
+	}
+	return errs
+}
+
+// validRepository reports whether repo looks like owner/repo or host/owner/repo
+// (host/group/subgroup/project on GitLab)
+func validRepository(repo string) bool {
+	_, _, _, ok := SplitRepository(repo)
+	return ok
+}
+
+// sortedKeys returns map keys in sorted order, for stable error output
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
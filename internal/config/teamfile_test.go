@@ -0,0 +1,223 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseTeamFile tests strict decoding of YAML and JSON team config files
+func TestParseTeamFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		ext       string
+		data      string
+		wantErr   bool
+		wantTeams int
+	}{
+		{
+			name: "yaml file",
+			ext:  ".yaml",
+			data: `
+version: 1
+teams:
+  - name: Platform
+    repositories: [acme/api]
+    members:
+      - username: alice
+        allocation: 1.0
+        joined_at: 2024-03-01
+aliases:
+  Alice Smith: alice
+`,
+			wantTeams: 1,
+		},
+		{
+			name:      "json file",
+			ext:       ".json",
+			data:      `{"version": 1, "teams": [{"name": "Platform", "members": [{"username": "alice", "allocation": 1.0}]}]}`,
+			wantTeams: 1,
+		},
+		{
+			name:    "unknown yaml field",
+			ext:     ".yml",
+			data:    "version: 1\nteams:\n  - name: Platform\n    memebers: []\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown json field",
+			ext:     ".json",
+			data:    `{"version": 1, "teams": [{"name": "Platform", "team_id": 1}]}`,
+			wantErr: true,
+		},
+		{
+			name: "repository and work type settings",
+			ext:  ".yaml",
+			data: `
+version: 1
+repositories:
+  - acme/shared-libs
+  - name: acme/api
+    team: Platform
+    tags: [backend]
+    lookback_days: 30
+    base_branches: [default, "release/*"]
+teams:
+  - name: Platform
+work_types:
+  - name: bug
+    labels: ["type:bug", incident]
+    commit_types: [fix]
+    branch_prefixes: [fix, hotfix]
+schedules:
+  - name: core
+    cron: "*/15 * * * *"
+    repositories: ["acme/*"]
+    service_groups: [payments]
+`,
+			wantTeams: 1,
+		},
+		{
+			name:    "unknown repository field",
+			ext:     ".yaml",
+			data:    "version: 1\nrepositories:\n  - name: acme/api\n    branch: main\nteams: []\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown json repository field",
+			ext:     ".json",
+			data:    `{"version": 1, "repositories": [{"name": "acme/api", "branch": "main"}], "teams": []}`,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported extension",
+			ext:     ".toml",
+			data:    "version = 1",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ParseTeamFile([]byte(tt.data), tt.ext)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTeamFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(file.Teams) != tt.wantTeams {
+				t.Errorf("parsed %d teams, want %d", len(file.Teams), tt.wantTeams)
+			}
+		})
+	}
+}
+
+// TestTeamFileValidate tests that validation reports every problem at once
+func TestTeamFileValidate(t *testing.T) {
+	file := &TeamFile{
+		Version:      2,
+		Repositories: []RepositoryConfig{{Name: "not-a-repo"}, {Name: "acme/api", Team: "Billing", DeploymentSource: "argo", BaseBranches: []string{"release/[0-9"}}},
+		Teams: []TeamConfig{
+			{
+				Name: "Platform",
+				Members: []TeamMemberConfig{
+					{Username: "alice", Allocation: 0.8},
+					{Username: "Alice", Allocation: 0.1},
+					{Username: "bob", Allocation: 1.5},
+				},
+				CodeOwners: []string{"@acme/platform"},
+			},
+			{
+				Name:   "Web",
+				Parent: "Frontend",
+				Members: []TeamMemberConfig{
+					{Username: "alice", Allocation: 0.5},
+					{Username: "carol", Allocation: 1.0, JoinedAt: &Date{}, LeftAt: &Date{}},
+				},
+				Repositories: []string{"acme//web"},
+				CodeOwners:   []string{"@ACME/platform", "web-team"},
+				IssueLabels:  []string{"area:web", " "},
+			},
+			{Name: "Platform"},
+		},
+		Aliases: map[string]string{"Dave": "dave"},
+		WorkTypes: []WorkTypeConfig{
+			{Name: "bug", Labels: []string{"type:bug"}, CommitTypes: []string{"fix"}},
+			{Name: "incident", Labels: []string{"Type:Bug", "sev1"}},
+			{Name: "feature", CommitTypes: []string{"feat", "fix"}},
+			{Name: "chore"},
+		},
+		Schedules: []ScheduleConfig{
+			{Name: "core", Cron: "every hour", Repositories: []string{"acme/["}},
+			{Name: "default", Cron: "@hourly", Tags: []string{"backend"}},
+		},
+	}
+
+	err := file.Validate(true)
+	if err == nil {
+		t.Fatal("Validate() returned nil, want errors")
+	}
+
+	wantProblems := []string{
+		"version must be 1",
+		"'not-a-repo' is not in owner/repo or host/owner/repo format",
+		"member Alice is listed more than once",
+		"member bob has allocation 1.5",
+		"member carol has left_at on or before joined_at",
+		"repository 'acme//web'",
+		"team 'Platform': defined more than once",
+		"member alice: allocations add up to 1.30",
+		"parent team 'Frontend' is not configured",
+		"'Dave' points to unknown member 'dave'",
+		"handle '@ACME/platform' is already used by team 'Platform'",
+		"handle 'web-team' is not in @org/team-slug format",
+		"team 'Web': empty issue label",
+		"'acme/api': team 'Billing' is not configured",
+		"deployment_source must be 'releases', 'deployments' or 'tags', got: argo",
+		"invalid base branch pattern 'release/[0-9'",
+		"work type 'incident': label 'Type:Bug' is already mapped to 'bug'",
+		"work type 'feature': commit type 'fix' is already mapped to 'bug'",
+		"work type 'chore' has no labels, commit types or branch prefixes",
+		"schedule 'core': invalid cron expression \"every hour\"",
+		"schedule 'core': invalid repository pattern \"acme/[\"",
+		"schedule 'default': defined more than once (or named like the default schedule)",
+	}
+	for _, problem := range wantProblems {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Validate() error is missing %q:\n%v", problem, err)
+		}
+	}
+
+	// GitHub-sourced parents are checked at sync time instead
+	if err := file.Validate(false); strings.Contains(err.Error(), "Frontend") {
+		t.Errorf("Validate(false) should not check parents:\n%v", err)
+	}
+}
+
+// TestLoadTeamFile tests loading a valid team config file from disk
+func TestLoadTeamFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "teams.yaml")
+	data := `
+version: 1
+teams:
+  - name: Platform
+    members:
+      - username: alice
+        allocation: 0.5
+  - name: Checkout
+    parent: Platform
+    members:
+      - username: alice
+        allocation: 0.5
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("failed to write team file: %v", err)
+	}
+
+	file, err := LoadTeamFile(path, true)
+	if err != nil {
+		t.Fatalf("LoadTeamFile() error = %v", err)
+	}
+	if len(file.Teams) != 2 || file.Teams[1].Parent != "Platform" {
+		t.Errorf("LoadTeamFile() teams = %+v", file.Teams)
+	}
+}
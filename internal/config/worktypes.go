@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WorkTypeConfig maps PR labels, Conventional Commit types and branch prefixes
+// to a work type. When a PR matches several work types, the one listed first
+// in config wins.
+type WorkTypeConfig struct {
+	Name   string   `json:"name" yaml:"name"`
+	Labels []string `json:"labels,omitempty" yaml:"labels,omitempty"` // Matched case-insensitively
+
+	// CommitTypes are Conventional Commit types ("feat" in "feat(api): ...")
+	// matched against PR titles and, failing that, the PR's commit messages
+	CommitTypes []string `json:"commit_types,omitempty" yaml:"commit_types,omitempty"`
+
+	// BranchPrefixes match the head branch's leading segments ("fix" in "fix/login" or "alice/fix/login")
+	BranchPrefixes []string `json:"branch_prefixes,omitempty" yaml:"branch_prefixes,omitempty"`
+}
+
+// DefaultWorkTypes classify the labels GitHub creates for new repositories,
+// the common "type:" prefixed ones, Conventional Commit types and branch
+// prefixes when the team config has no work_types
+var DefaultWorkTypes = []WorkTypeConfig{
+	{
+		Name:           "bug",
+		Labels:         []string{"bug", "type:bug", "type: bug", "kind/bug"},
+		CommitTypes:    []string{"fix"},
+		BranchPrefixes: []string{"fix", "bugfix", "hotfix", "bug"},
+	},
+	{
+		Name:           "feature",
+		Labels:         []string{"feature", "enhancement", "type:feature", "type: feature", "kind/feature"},
+		CommitTypes:    []string{"feat"},
+		BranchPrefixes: []string{"feat", "feature"},
+	},
+	{
+		Name:           "maintenance",
+		Labels:         []string{"chore", "dependencies", "documentation", "type:chore", "type: chore", "type:docs", "kind/cleanup"},
+		CommitTypes:    []string{"chore", "docs", "refactor", "perf", "test", "build", "ci", "style", "revert"},
+		BranchPrefixes: []string{"chore", "docs", "refactor", "perf", "test", "build", "ci", "deps", "dependabot", "renovate"},
+	},
+}
+
+// validateWorkTypes checks the work type mapping and returns every problem found
+func validateWorkTypes(workTypes []WorkTypeConfig) []error {
+	var errs []error
+	names := make(map[string]bool)
+	mapped := map[string]map[string]string{ // field -> lowercased value -> work type
+		"label":         {},
+		"commit type":   {},
+		"branch prefix": {},
+	}
+
+	for i, workType := range workTypes {
+		label := fmt.Sprintf("work type '%s'", workType.Name)
+		if workType.Name == "" {
+			label = fmt.Sprintf("work type #%d", i+1)
+			errs = append(errs, fmt.Errorf("work_types: %s: name is required", label))
+		} else if names[workType.Name] {
+			errs = append(errs, fmt.Errorf("work_types: %s is defined more than once", label))
+		}
+		names[workType.Name] = true
+
+		if len(workType.Labels)+len(workType.CommitTypes)+len(workType.BranchPrefixes) == 0 {
+			errs = append(errs, fmt.Errorf("work_types: %s has no labels, commit types or branch prefixes", label))
+		}
+		fields := []struct {
+			name   string
+			values []string
+		}{
+			{"label", workType.Labels},
+			{"commit type", workType.CommitTypes},
+			{"branch prefix", workType.BranchPrefixes},
+		}
+		for _, field := range fields {
+			for _, value := range field.values {
+				key := strings.ToLower(strings.TrimSpace(value))
+				if key == "" {
+					errs = append(errs, fmt.Errorf("work_types: %s has an empty %s", label, field.name))
+					continue
+				}
+				if other, ok := mapped[field.name][key]; ok && other != workType.Name {
+					errs = append(errs, fmt.Errorf("work_types: %s: %s '%s' is already mapped to '%s'", label, field.name, value, other))
+				}
+				mapped[field.name][key] = workType.Name
+			}
+		}
+	}
+	return errs
+}
@@ -9,10 +9,44 @@ type Team struct {
 	ID          int        `db:"id"`
 	Name        string     `db:"name"`
 	Description *string    `db:"description"` // Nullable
+	ParentID    *int       `db:"parent_id"`   // Nullable, set for sub-teams
+	Source      string     `db:"source"`      // "config" or "api"
 	CreatedAt   time.Time  `db:"created_at"`
 	UpdatedAt   time.Time  `db:"updated_at"`
 }
 
+// TeamAuditEntry records a team or membership change made through the admin API
+type TeamAuditEntry struct {
+	ID             int       `db:"id"`
+	Actor          string    `db:"actor"`
+	Action         string    `db:"action"`
+	TeamID         *int      `db:"team_id"`
+	GitHubUsername *string   `db:"github_username"`
+	Repository     *string   `db:"repository"`
+	Details        *string   `db:"details"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+// Repository is a tracked repository and its settings
+type Repository struct {
+	ID                    int       `db:"id"`
+	Name                  string    `db:"name"` // owner/repo
+	DefaultBranch         *string   `db:"default_branch"`
+	TeamID                *int      `db:"team_id"` // Owning team
+	ServiceGroup          *string   `db:"service_group"`
+	Active                bool      `db:"active"`
+	LookbackDays          *int      `db:"lookback_days"`
+	DeploymentSource      *string   `db:"deployment_source"`
+	DeploymentEnvironment *string   `db:"deployment_environment"`
+	Source                string    `db:"source"` // "config" or "api"
+	GitPath               *string   `db:"git_path"` // Local clone read with git instead of the GitHub API
+	CreatedAt             time.Time `db:"created_at"`
+	UpdatedAt             time.Time `db:"updated_at"`
+
+	Tags         []string `db:"-"` // From repository_tags
+	BaseBranches []string `db:"-"` // From repository_base_branches; empty counts every base
+}
+
 // TeamMembership represents a user's membership in a team
 type TeamMembership struct {
 	ID               int        `db:"id"`
@@ -49,6 +83,31 @@ type PRMetric struct {
 	ReviewersCount         int        `db:"reviewers_count"`
 	ExternalReviewersCount int        `db:"external_reviewers_count"`
 	ReviewersList          string     `db:"reviewers_list"` // JSON array
+
+	// Attribution: "author", "reviewer", "both" or "ownership" (CODEOWNERS only),
+	// and whether the team owns any file the PR changed
+	AttributionRole string `db:"attribution_role"`
+	Owned           bool   `db:"owned"`
+
+	// The branches the PR merges into and was opened from, and whether the
+	// base matches the repository's base branch rules (delivery metrics only
+	// count PRs that do)
+	BaseRef     *string `db:"base_ref"`
+	HeadRef     *string `db:"head_ref"`
+	BaseMatched bool    `db:"base_matched"`
+
+	// WorkType classifies the PR by its labels, Conventional Commit title,
+	// branch name or commits ("other" if none match), WorkTypeSource says
+	// which, and Conventional whether the title follows Conventional Commits
+	WorkType       string  `db:"work_type"`
+	WorkTypeSource *string `db:"work_type_source"`
+	WorkScope      *string `db:"work_scope"`
+	Conventional   *bool   `db:"conventional"`
+
+	// When work started on the earliest linked Jira issue, and the hours from
+	// then until the PR merged
+	TicketStartedAt     *time.Time `db:"ticket_started_at"`
+	TicketLeadTimeHours *int       `db:"ticket_lead_time_hours"`
 }
 
 // TeamVelocity represents the view_team_velocity view
@@ -114,6 +173,17 @@ type CommitMetric struct {
 	Message     string     `db:"message"`
 	CreatedAt   time.Time  `db:"created_at"`
 	CreatedDate *time.Time `db:"created_date"`
+
+	// Conventional is whether the message follows Conventional Commits
+	Conventional *bool `db:"conventional"`
+
+	// AuthorEmail is the git author email; IsMerge is whether the commit has
+	// several parents. Churn is only known for commits read from a local clone.
+	AuthorEmail  *string `db:"author_email"`
+	IsMerge      *bool   `db:"is_merge"`
+	Additions    *int    `db:"additions"`
+	Deletions    *int    `db:"deletions"`
+	FilesChanged *int    `db:"files_changed"`
 }
 
 // CommentMetric represents Comment metrics
@@ -126,7 +196,93 @@ type CommentMetric struct {
 	Body        string     `db:"body"`
 	CreatedAt   time.Time  `db:"created_at"`
 	CreatedDate *time.Time `db:"created_date"`
-	CommentType string     `db:"comment_type"`
+	CommentType string     `db:"comment_type"` // "issue", "pull_request" (PR conversation) or "commit"
+
+	// IssueNumber is the issue or PR a conversation comment belongs to
+	IssueNumber *int `db:"issue_number"`
+}
+
+// IssueMetric represents an issue attributed to a team
+type IssueMetric struct {
+	ID               int        `db:"id"`
+	TeamID           int        `db:"team_id"`
+	Repository       string     `db:"repository"`
+	IssueNumber      int        `db:"issue_number"`
+	Title            string     `db:"title"`
+	Author           string     `db:"author"`
+	State            string     `db:"state"`
+	CreatedAt        time.Time  `db:"created_at"`
+	ClosedAt         *time.Time `db:"closed_at"`
+	TimeToCloseHours *int       `db:"time_to_close_hours"`
+	Milestone        *string    `db:"milestone"`
+	AssigneesList    string     `db:"assignees_list"` // JSON array
+
+	// WorkType classifies the issue by its labels ("other" if none match)
+	WorkType string `db:"work_type"`
+
+	// AttributionRole is "assignee", "label" (the team's issue_labels) or "both"
+	AttributionRole string `db:"attribution_role"`
+}
+
+// CommitCoAuthor represents co-author credit for a commit
+type CommitCoAuthor struct {
+	ID             int        `db:"id"`
+	TeamID         int        `db:"team_id"`
+	Repository     string     `db:"repository"`
+	CommitHash     string     `db:"commit_hash"`
+	GitHubUsername string     `db:"github_username"`
+	Weight         float64    `db:"weight"`
+	CreatedAt      time.Time  `db:"created_at"`
+	CreatedDate    *time.Time `db:"created_date"`
+}
+
+// PRCoAuthor represents co-author credit for a pull request, derived from its commits
+type PRCoAuthor struct {
+	ID             int     `db:"id"`
+	TeamID         int     `db:"team_id"`
+	Repository     string  `db:"repository"`
+	PRNumber       int     `db:"pr_number"`
+	GitHubUsername string  `db:"github_username"`
+	Weight         float64 `db:"weight"`
+}
+
+// PRIssueLink is an issue a PR references
+type PRIssueLink struct {
+	Repository string `db:"repository"`
+	PRNumber   int    `db:"pr_number"`
+	IssueKey   string `db:"issue_key"` // "PROJ-123", or "owner/repo#12" for GitHub issues
+	Tracker    string `db:"tracker"`   // "jira" or "github"
+	Source     string `db:"source"`    // "title", "branch" or "body"
+}
+
+// Deployment is a deployment of a repository to an environment
+type Deployment struct {
+	Repository   string    `db:"repository"`
+	DeploymentID int64     `db:"deployment_id"`
+	Environment  string    `db:"environment"`
+	Ref          string    `db:"ref"`
+	SHA          string    `db:"sha"`
+	Creator      string    `db:"creator"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// JiraIssue is a linked Jira issue
+type JiraIssue struct {
+	IssueKey   string     `db:"issue_key"`
+	Summary    string     `db:"summary"`
+	Status     string     `db:"status"`
+	CreatedAt  *time.Time `db:"created_at"`
+	ResolvedAt *time.Time `db:"resolved_at"`
+	StartedAt  *time.Time `db:"started_at"` // First move into a start status
+	FetchedAt  time.Time  `db:"fetched_at"`
+}
+
+// JiraTransition is a status change of a Jira issue
+type JiraTransition struct {
+	IssueKey       string    `db:"issue_key"`
+	FromStatus     string    `db:"from_status"`
+	ToStatus       string    `db:"to_status"`
+	TransitionedAt time.Time `db:"transitioned_at"`
 }
 
 // TeamCommitVelocity represents the view_team_commit_velocity view
@@ -160,3 +316,23 @@ type MemberCommentActivity struct {
 	CommentsCount  int    `db:"comments_count"`
 	CommentType    string `db:"comment_type"`
 }
+
+// CollectionJob is a queued collection of one repository, over a backfill
+// window or incrementally
+type CollectionJob struct {
+	ID             int        `db:"id"`
+	JobKey         string     `db:"job_key"`
+	Repository     string     `db:"repository"`
+	WindowStart    *time.Time `db:"window_start"` // Nil for incremental collection
+	WindowEnd      *time.Time `db:"window_end"`
+	Status         string     `db:"status"` // "queued", "running", "succeeded" or "dead"
+	Attempts       int        `db:"attempts"`
+	MaxAttempts    int        `db:"max_attempts"`
+	AvailableAt    time.Time  `db:"available_at"`
+	LeasedBy       *string    `db:"leased_by"`
+	LeaseExpiresAt *time.Time `db:"lease_expires_at"`
+	LastError      *string    `db:"last_error"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+	FinishedAt     *time.Time `db:"finished_at"`
+}
@@ -3,6 +3,7 @@ package github
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/google/go-github/v58/github"
@@ -13,6 +14,7 @@ import (
 type Client struct {
 	client *github.Client
 	ctx    context.Context
+	rate   *rateTracker
 }
 
 // NewClient creates a new GitHub API client with authentication
@@ -22,15 +24,37 @@ func NewClient(pat string) *Client {
 		&oauth2.Token{AccessToken: pat},
 	)
 	tc := oauth2.NewClient(ctx, ts)
+	rate := &rateTracker{base: tc.Transport}
+	tc.Transport = rate
 
 	return &Client{
 		client: github.NewClient(tc),
 		ctx:    ctx,
+		rate:   rate,
 	}
 }
 
-// FetchPRs fetches pull requests from a repository since a given date
-func (c *Client) FetchPRs(owner, repo string, since time.Time) ([]*github.PullRequest, error) {
+// NewClientWithBaseURL creates a GitHub API client for a GitHub Enterprise Server
+// (or test server) at baseURL, e.g. https://github.example.com/api/v3/
+func NewClientWithBaseURL(pat, baseURL string) (*Client, error) {
+	return NewEnterpriseClient(pat, baseURL, baseURL)
+}
+
+// NewEnterpriseClient creates a GitHub API client for a GitHub Enterprise
+// Server with its REST and upload API URLs
+func NewEnterpriseClient(pat, baseURL, uploadURL string) (*Client, error) {
+	c := NewClient(pat)
+	client, err := c.client.WithEnterpriseURLs(baseURL, uploadURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub API URL %q: %w", baseURL, err)
+	}
+	c.client = client
+	return c, nil
+}
+
+// FetchPRs fetches pull requests from a repository updated between since and
+// until, or since a given date if until is zero
+func (c *Client) FetchPRs(owner, repo string, since, until time.Time) ([]*github.PullRequest, error) {
 	fmt.Printf("  📥 Fetching PRs from %s/%s (since %s)...\n", owner, repo, since.Format("2006-01-02"))
 
 	var allPRs []*github.PullRequest
@@ -58,6 +82,10 @@ func (c *Client) FetchPRs(owner, repo string, since time.Time) ([]*github.PullRe
 				fmt.Printf("  ✓ Fetched %d PRs within lookback window\n", len(allPRs))
 				return allPRs, nil // Early exit
 			}
+			// The API has no upper bound; newer PRs belong to a later window
+			if pr.UpdatedAt != nil && isAfter(pr.UpdatedAt.Time, until) {
+				continue
+			}
 			allPRs = append(allPRs, pr)
 		}
 
@@ -126,29 +154,169 @@ func (c *Client) FetchComments(owner, repo string, prNumber int) ([]*github.Pull
 	return allComments, nil
 }
 
-// checkRateLimit checks GitHub API rate limit and waits if necessary
-func (c *Client) checkRateLimit() error {
-	rate, _, err := c.client.RateLimits(c.ctx)
+// FetchPRCommits fetches all commits on a pull request
+func (c *Client) FetchPRCommits(owner, repo string, prNumber int) ([]*github.RepositoryCommit, error) {
+	opts := &github.ListOptions{
+		PerPage: 100,
+	}
+
+	var allCommits []*github.RepositoryCommit
+	for {
+		commits, resp, err := c.client.PullRequests.ListCommits(c.ctx, owner, repo, prNumber, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch PR commits: %w", err)
+		}
+
+		allCommits = append(allCommits, commits...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return allCommits, nil
+}
+
+// FetchPRFiles fetches the paths of the files changed by a pull request.
+// Renamed files are listed under both their old and new paths.
+func (c *Client) FetchPRFiles(owner, repo string, prNumber int) ([]string, error) {
+	opts := &github.ListOptions{
+		PerPage: 100,
+	}
+
+	var paths []string
+	for {
+		files, resp, err := c.client.PullRequests.ListFiles(c.ctx, owner, repo, prNumber, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch PR files: %w", err)
+		}
+
+		for _, file := range files {
+			paths = append(paths, file.GetFilename())
+			if previous := file.GetPreviousFilename(); previous != "" {
+				paths = append(paths, previous)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return paths, nil
+}
+
+// codeOwnersPaths are the locations GitHub reads CODEOWNERS from, in order
+var codeOwnersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// FetchCodeOwners fetches a repository's CODEOWNERS file from its default branch.
+// It returns nil if the repository has none.
+func (c *Client) FetchCodeOwners(owner, repo string) ([]byte, error) {
+	for _, path := range codeOwnersPaths {
+		file, _, resp, err := c.client.Repositories.GetContents(c.ctx, owner, repo, path, nil)
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", path, err)
+		}
+		if file == nil {
+			continue // a directory
+		}
+
+		content, err := file.GetContent()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		return []byte(content), nil
+	}
+	return nil, nil
+}
+
+// FetchDefaultBranch fetches the name of a repository's default branch
+func (c *Client) FetchDefaultBranch(owner, repo string) (string, error) {
+	repository, _, err := c.client.Repositories.Get(c.ctx, owner, repo)
 	if err != nil {
-		return fmt.Errorf("failed to check rate limit: %w", err)
+		return "", fmt.Errorf("failed to fetch repository: %w", err)
+	}
+	return repository.GetDefaultBranch(), nil
+}
+
+// FetchOrgTeams fetches all teams in an organization
+func (c *Client) FetchOrgTeams(org string) ([]*github.Team, error) {
+	opts := &github.ListOptions{
+		PerPage: 100,
 	}
 
-	if rate.Core.Remaining < 100 {
-		waitTime := time.Until(rate.Core.Reset.Time)
-		fmt.Printf("  ⏳ Rate limit low (%d remaining), waiting %v...\n", rate.Core.Remaining, waitTime)
+	var allTeams []*github.Team
+	for {
+		teams, resp, err := c.client.Teams.ListTeams(c.ctx, org, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch teams for org %s: %w", org, err)
+		}
+
+		allTeams = append(allTeams, teams...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return allTeams, nil
+}
+
+// FetchTeamMembers fetches the members of an organization team.
+// GitHub includes members of the team's child teams in the result.
+func (c *Client) FetchTeamMembers(org, slug string) ([]*github.User, error) {
+	opts := &github.TeamListTeamMembersOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	var allMembers []*github.User
+	for {
+		members, resp, err := c.client.Teams.ListTeamMembersBySlug(c.ctx, org, slug, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch members of team %s/%s: %w", org, slug, err)
+		}
+
+		allMembers = append(allMembers, members...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return allMembers, nil
+}
+
+// checkRateLimit waits for the rate limit to reset if this client's last
+// response reported little of it left
+func (c *Client) checkRateLimit() error {
+	remaining, reset, known := c.rate.current()
+	if known && remaining < 100 {
+		waitTime := time.Until(reset)
+		fmt.Printf("  ⏳ Rate limit low (%d remaining), waiting %v...\n", remaining, waitTime)
 		time.Sleep(waitTime)
 	}
 
 	return nil
 }
 
-// FetchCommits fetches commits from a repository since a given date
-func (c *Client) FetchCommits(owner, repo string, since time.Time) ([]*github.RepositoryCommit, error) {
+// FetchCommits fetches commits from a repository between since and until,
+// or since a given date if until is zero
+func (c *Client) FetchCommits(owner, repo string, since, until time.Time) ([]*github.RepositoryCommit, error) {
 	fmt.Printf("  📥 Fetching Commits from %s/%s (since %s)...\n", owner, repo, since.Format("2006-01-02"))
 
 	var allCommits []*github.RepositoryCommit
 	opts := &github.CommitsListOptions{
 		Since: since,
+		Until: until,
 		ListOptions: github.ListOptions{
 			PerPage: 100,
 		},
@@ -176,8 +344,9 @@ func (c *Client) FetchCommits(owner, repo string, since time.Time) ([]*github.Re
 	return allCommits, nil
 }
 
-// FetchIssueComments fetches issue comments from a repository since a given date
-func (c *Client) FetchIssueComments(owner, repo string, since time.Time) ([]*github.IssueComment, error) {
+// FetchIssueComments fetches issue comments from a repository updated between
+// since and until, or since a given date if until is zero
+func (c *Client) FetchIssueComments(owner, repo string, since, until time.Time) ([]*github.IssueComment, error) {
 	fmt.Printf("  📥 Fetching Issue Comments from %s/%s (since %s)...\n", owner, repo, since.Format("2006-01-02"))
 
 	var allComments []*github.IssueComment
@@ -203,6 +372,9 @@ func (c *Client) FetchIssueComments(owner, repo string, since time.Time) ([]*git
 				fmt.Printf("  ✓ Fetched %d issue comments within lookback window\n", len(allComments))
 				return allComments, nil
 			}
+			if comment.UpdatedAt != nil && isAfter(comment.UpdatedAt.Time, until) {
+				continue
+			}
 			allComments = append(allComments, comment)
 		}
 
@@ -220,8 +392,52 @@ func (c *Client) FetchIssueComments(owner, repo string, since time.Time) ([]*git
 	return allComments, nil
 }
 
-// FetchCommitComments fetches commit comments from a repository
-func (c *Client) FetchCommitComments(owner, repo string, since time.Time) ([]*github.RepositoryComment, error) {
+// FetchIssues fetches issues (not pull requests) updated in a repository
+// between since and until, or since a given date if until is zero
+func (c *Client) FetchIssues(owner, repo string, since, until time.Time) ([]*github.Issue, error) {
+	fmt.Printf("  📥 Fetching Issues from %s/%s (since %s)...\n", owner, repo, since.Format("2006-01-02"))
+
+	var allIssues []*github.Issue
+	opts := &github.IssueListByRepoOptions{
+		State:     "all",
+		Since:     since,
+		Sort:      "updated",
+		Direction: "desc",
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	for {
+		issues, resp, err := c.client.Issues.ListByRepo(c.ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch issues: %w", err)
+		}
+
+		// The issues API also lists pull requests
+		for _, issue := range issues {
+			if !issue.IsPullRequest() && (issue.UpdatedAt == nil || !isAfter(issue.UpdatedAt.Time, until)) {
+				allIssues = append(allIssues, issue)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+
+		if err := c.checkRateLimit(); err != nil {
+			return nil, err
+		}
+	}
+
+	fmt.Printf("  ✓ Fetched %d issues\n", len(allIssues))
+	return allIssues, nil
+}
+
+// FetchCommitComments fetches commit comments from a repository updated
+// between since and until, or since a given date if until is zero
+func (c *Client) FetchCommitComments(owner, repo string, since, until time.Time) ([]*github.RepositoryComment, error) {
 	fmt.Printf("  📥 Fetching Commit Comments from %s/%s (since %s)...\n", owner, repo, since.Format("2006-01-02"))
 
 	var allComments []*github.RepositoryComment
@@ -236,7 +452,7 @@ func (c *Client) FetchCommitComments(owner, repo string, since time.Time) ([]*gi
 		}
 
 		for _, comment := range comments {
-			if comment.UpdatedAt != nil && !comment.UpdatedAt.Before(since) {
+			if comment.UpdatedAt != nil && !comment.UpdatedAt.Before(since) && !isAfter(comment.UpdatedAt.Time, until) {
 				allComments = append(allComments, comment)
 			}
 		}
@@ -254,3 +470,54 @@ func (c *Client) FetchCommitComments(owner, repo string, since time.Time) ([]*gi
 	fmt.Printf("  ✓ Fetched %d commit comments\n", len(allComments))
 	return allComments, nil
 }
+
+// FetchDeployments fetches deployments created in a repository between since and
+// until (or since a given date if until is zero), to one environment or to all
+// of them if environment is empty
+func (c *Client) FetchDeployments(owner, repo, environment string, since, until time.Time) ([]*github.Deployment, error) {
+	fmt.Printf("  📥 Fetching Deployments from %s/%s (since %s)...\n", owner, repo, since.Format("2006-01-02"))
+
+	var allDeployments []*github.Deployment
+	opts := &github.DeploymentsListOptions{
+		Environment: environment,
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	for {
+		// Deployments are listed newest first
+		deployments, resp, err := c.client.Repositories.ListDeployments(c.ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch deployments: %w", err)
+		}
+
+		for _, deployment := range deployments {
+			if deployment.CreatedAt != nil && deployment.CreatedAt.Before(since) {
+				fmt.Printf("  ✓ Fetched %d deployments within lookback window\n", len(allDeployments))
+				return allDeployments, nil
+			}
+			if deployment.CreatedAt != nil && isAfter(deployment.CreatedAt.Time, until) {
+				continue
+			}
+			allDeployments = append(allDeployments, deployment)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+
+		if err := c.checkRateLimit(); err != nil {
+			return nil, err
+		}
+	}
+
+	fmt.Printf("  ✓ Fetched %d deployments\n", len(allDeployments))
+	return allDeployments, nil
+}
+
+// isAfter reports whether t is after until, a window end that is unset if zero
+func isAfter(t, until time.Time) bool {
+	return !until.IsZero() && t.After(until)
+}
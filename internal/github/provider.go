@@ -0,0 +1,271 @@
+package github
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/source"
+	"github.com/google/go-github/v58/github"
+)
+
+// Provider exposes a GitHub client as a source.Provider
+type Provider struct {
+	client *Client
+}
+
+var _ source.Provider = (*Provider)(nil)
+
+// NewProvider creates a source provider backed by a GitHub client
+func NewProvider(client *Client) *Provider {
+	return &Provider{client: client}
+}
+
+// ChangeRequests returns the pull requests updated between since and until
+func (p *Provider) ChangeRequests(owner, repo string, since, until time.Time) ([]source.ChangeRequest, error) {
+	prs, err := p.client.FetchPRs(owner, repo, since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]source.ChangeRequest, 0, len(prs))
+	for _, pr := range prs {
+		changes = append(changes, source.ChangeRequest{
+			Number:    pr.GetNumber(),
+			Title:     pr.GetTitle(),
+			Body:      pr.GetBody(),
+			Author:    pr.GetUser().GetLogin(),
+			State:     pr.GetState(),
+			CreatedAt: pr.GetCreatedAt().Time,
+			UpdatedAt: pr.GetUpdatedAt().Time,
+			MergedAt:  timestamp(pr.MergedAt),
+			ClosedAt:  timestamp(pr.ClosedAt),
+			BaseRef:   pr.GetBase().GetRef(),
+			HeadRef:   pr.GetHead().GetRef(),
+			Labels:    labelNames(pr.Labels),
+		})
+		if pr.MergedAt != nil && pr.GetMergeCommitSHA() != "" {
+			changes[len(changes)-1].MergeCommits = []string{pr.GetMergeCommitSHA()}
+		}
+	}
+	return changes, nil
+}
+
+// Reviews returns a pull request's reviews
+func (p *Provider) Reviews(owner, repo string, number int) ([]source.Review, error) {
+	reviews, err := p.client.FetchReviews(owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]source.Review, 0, len(reviews))
+	for _, review := range reviews {
+		result = append(result, source.Review{
+			Author:      review.GetUser().GetLogin(),
+			State:       review.GetState(),
+			SubmittedAt: review.GetSubmittedAt().Time,
+		})
+	}
+	return result, nil
+}
+
+// ReviewComments returns the comments on a pull request's diff
+func (p *Provider) ReviewComments(owner, repo string, number int) ([]source.ReviewComment, error) {
+	comments, err := p.client.FetchComments(owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]source.ReviewComment, 0, len(comments))
+	for _, comment := range comments {
+		result = append(result, source.ReviewComment{
+			ID:        comment.GetID(),
+			InReplyTo: comment.GetInReplyTo(),
+			Author:    comment.GetUser().GetLogin(),
+			CreatedAt: comment.GetCreatedAt().Time,
+		})
+	}
+	return result, nil
+}
+
+// ChangeRequestCommits returns a pull request's commits
+func (p *Provider) ChangeRequestCommits(owner, repo string, number int) ([]source.Commit, error) {
+	commits, err := p.client.FetchPRCommits(owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	return convertCommits(commits), nil
+}
+
+// ChangeRequestFiles returns the paths a pull request changes
+func (p *Provider) ChangeRequestFiles(owner, repo string, number int) ([]string, error) {
+	return p.client.FetchPRFiles(owner, repo, number)
+}
+
+// Commits returns the commits on the default branch between since and until
+func (p *Provider) Commits(owner, repo string, since, until time.Time) ([]source.Commit, error) {
+	commits, err := p.client.FetchCommits(owner, repo, since, until)
+	if err != nil {
+		return nil, err
+	}
+	return convertCommits(commits), nil
+}
+
+// Issues returns the issues updated between since and until
+func (p *Provider) Issues(owner, repo string, since, until time.Time) ([]source.Issue, error) {
+	issues, err := p.client.FetchIssues(owner, repo, since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]source.Issue, 0, len(issues))
+	for _, issue := range issues {
+		result = append(result, convertIssue(issue))
+	}
+	return result, nil
+}
+
+// Comments returns the issue, pull request and commit comments made between since and until
+func (p *Provider) Comments(owner, repo string, since, until time.Time) ([]source.Comment, error) {
+	issueComments, err := p.client.FetchIssueComments(owner, repo, since, until)
+	if err != nil {
+		return nil, err
+	}
+	commitComments, err := p.client.FetchCommitComments(owner, repo, since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]source.Comment, 0, len(issueComments)+len(commitComments))
+	for _, comment := range issueComments {
+		kind := source.CommentIssue
+		if isPRComment(comment) {
+			kind = source.CommentChangeRequest
+		}
+		result = append(result, source.Comment{
+			ID:          comment.GetID(),
+			Kind:        kind,
+			Author:      comment.GetUser().GetLogin(),
+			Body:        comment.GetBody(),
+			CreatedAt:   comment.GetCreatedAt().Time,
+			IssueNumber: commentIssueNumber(comment),
+		})
+	}
+	for _, comment := range commitComments {
+		result = append(result, source.Comment{
+			ID:        comment.GetID(),
+			Kind:      source.CommentCommit,
+			Author:    comment.GetUser().GetLogin(),
+			Body:      comment.GetBody(),
+			CreatedAt: comment.GetCreatedAt().Time,
+		})
+	}
+	return result, nil
+}
+
+// Deployments returns the deployments created between since and until
+func (p *Provider) Deployments(owner, repo, environment string, since, until time.Time) ([]source.Deployment, error) {
+	deployments, err := p.client.FetchDeployments(owner, repo, environment, since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]source.Deployment, 0, len(deployments))
+	for _, deployment := range deployments {
+		result = append(result, source.Deployment{
+			ID:          deployment.GetID(),
+			Environment: deployment.GetEnvironment(),
+			Ref:         deployment.GetRef(),
+			SHA:         deployment.GetSHA(),
+			Creator:     deployment.GetCreator().GetLogin(),
+			CreatedAt:   deployment.GetCreatedAt().Time,
+		})
+	}
+	return result, nil
+}
+
+// CodeOwners returns the repository's CODEOWNERS file, or nil if it has none
+func (p *Provider) CodeOwners(owner, repo string) ([]byte, error) {
+	return p.client.FetchCodeOwners(owner, repo)
+}
+
+// DefaultBranch returns the repository's default branch
+func (p *Provider) DefaultBranch(owner, repo string) (string, error) {
+	return p.client.FetchDefaultBranch(owner, repo)
+}
+
+// convertCommits converts commits, taking the author's login from their GitHub
+// account and their name, email and date from git
+func convertCommits(commits []*github.RepositoryCommit) []source.Commit {
+	result := make([]source.Commit, 0, len(commits))
+	for _, commit := range commits {
+		gitAuthor := commit.GetCommit().GetAuthor()
+		converted := source.Commit{
+			SHA:         commit.GetSHA(),
+			Login:       commit.GetAuthor().GetLogin(),
+			AuthorName:  gitAuthor.GetName(),
+			AuthorEmail: gitAuthor.GetEmail(),
+			AuthoredAt:  gitAuthor.GetDate().Time,
+			Message:     commit.GetCommit().GetMessage(),
+		}
+		for _, parent := range commit.Parents {
+			converted.Parents = append(converted.Parents, parent.GetSHA())
+		}
+		result = append(result, converted)
+	}
+	return result
+}
+
+// convertIssue converts an issue
+func convertIssue(issue *github.Issue) source.Issue {
+	converted := source.Issue{
+		Number:    issue.GetNumber(),
+		Title:     issue.GetTitle(),
+		Author:    issue.GetUser().GetLogin(),
+		State:     issue.GetState(),
+		CreatedAt: issue.GetCreatedAt().Time,
+		ClosedAt:  timestamp(issue.ClosedAt),
+		Labels:    labelNames(issue.Labels),
+		Milestone: issue.GetMilestone().GetTitle(),
+	}
+	for _, assignee := range issue.Assignees {
+		converted.Assignees = append(converted.Assignees, assignee.GetLogin())
+	}
+	return converted
+}
+
+// isPRComment reports whether a conversation comment was made on a PR rather than an issue
+func isPRComment(comment *github.IssueComment) bool {
+	return strings.Contains(comment.GetHTMLURL(), "/pull/")
+}
+
+// commentIssueNumber returns the number of the issue or PR a conversation
+// comment belongs to, parsed from its issue URL
+func commentIssueNumber(comment *github.IssueComment) *int {
+	url := comment.GetIssueURL()
+	number, err := strconv.Atoi(url[strings.LastIndex(url, "/")+1:])
+	if err != nil {
+		return nil
+	}
+	return &number
+}
+
+// labelNames returns the names of a PR's or issue's labels
+func labelNames(labels []*github.Label) []string {
+	names := make([]string, 0, len(labels))
+	for _, label := range labels {
+		if label.GetName() != "" {
+			names = append(names, label.GetName())
+		}
+	}
+	return names
+}
+
+// timestamp returns a GitHub timestamp as a time, or nil if it is unset
+func timestamp(ts *github.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.Time
+	return &t
+}
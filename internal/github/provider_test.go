@@ -0,0 +1,67 @@
+package github
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v58/github"
+)
+
+// TestCommentIssueNumber tests telling PR conversation comments from issue comments
+func TestCommentIssueNumber(t *testing.T) {
+	twelve, seven := 12, 7
+	tests := []struct {
+		name       string
+		comment    *github.IssueComment
+		wantPR     bool
+		wantNumber *int
+	}{
+		{"issue comment", &github.IssueComment{
+			HTMLURL:  github.String("https://github.com/acme/api/issues/12#issuecomment-1"),
+			IssueURL: github.String("https://api.github.com/repos/acme/api/issues/12"),
+		}, false, &twelve},
+		{"PR comment", &github.IssueComment{
+			HTMLURL:  github.String("https://github.com/acme/api/pull/7#issuecomment-2"),
+			IssueURL: github.String("https://api.github.com/repos/acme/api/issues/7"),
+		}, true, &seven},
+		{"no URLs", &github.IssueComment{}, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPRComment(tt.comment); got != tt.wantPR {
+				t.Errorf("isPRComment() = %v, want %v", got, tt.wantPR)
+			}
+			got := commentIssueNumber(tt.comment)
+			if (got == nil) != (tt.wantNumber == nil) || (got != nil && *got != *tt.wantNumber) {
+				t.Errorf("commentIssueNumber() = %v, want %v", got, tt.wantNumber)
+			}
+		})
+	}
+}
+
+// TestConvertIssue tests converting a GitHub issue to a source issue
+func TestConvertIssue(t *testing.T) {
+	created := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	closed := created.Add(30 * time.Hour)
+	issue := convertIssue(&github.Issue{
+		Number:    github.Int(12),
+		State:     github.String("closed"),
+		User:      &github.User{Login: github.String("carol")},
+		Labels:    []*github.Label{{Name: github.String("bug")}, {}},
+		Assignees: []*github.User{{Login: github.String("alice")}, {Login: github.String("bob")}},
+		Milestone: &github.Milestone{Title: github.String("v2.1")},
+		CreatedAt: &github.Timestamp{Time: created},
+		ClosedAt:  &github.Timestamp{Time: closed},
+	})
+
+	if issue.Number != 12 || issue.Author != "carol" || issue.Milestone != "v2.1" {
+		t.Errorf("convertIssue() = #%d by %s (%s), want #12 by carol (v2.1)", issue.Number, issue.Author, issue.Milestone)
+	}
+	if issue.ClosedAt == nil || !issue.ClosedAt.Equal(closed) {
+		t.Errorf("ClosedAt = %v, want %v", issue.ClosedAt, closed)
+	}
+	if len(issue.Labels) != 1 || len(issue.Assignees) != 2 {
+		t.Errorf("Labels = %v, Assignees = %v, want [bug] and [alice bob]", issue.Labels, issue.Assignees)
+	}
+}
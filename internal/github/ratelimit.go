@@ -0,0 +1,55 @@
+package github
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateTracker records the core rate limit reported on each API response, so
+// every client (one per host) waits on its own quota without extra requests.
+// GitHub Enterprise Server instances with rate limiting disabled report none.
+type rateTracker struct {
+	base http.RoundTripper
+
+	mu        sync.Mutex
+	known     bool
+	remaining int
+	reset     time.Time
+}
+
+// RoundTrip performs the request and records the rate limit of the response
+func (t *rateTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		t.record(resp.Header)
+	}
+	return resp, err
+}
+
+// record stores the core rate limit from response headers, if present
+func (t *rateTracker) record(header http.Header) {
+	if resource := header.Get("X-RateLimit-Resource"); resource != "" && resource != "core" {
+		return // Search and GraphQL have quotas of their own
+	}
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.known, t.remaining, t.reset = true, remaining, time.Unix(reset, 0)
+}
+
+// current returns the last recorded remaining requests and reset time
+func (t *rateTracker) current() (remaining int, reset time.Time, known bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.remaining, t.reset, t.known
+}
@@ -0,0 +1,34 @@
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestClientsTrackRateLimitsSeparately tests that each host's client tracks its own rate limit
+func TestClientsTrackRateLimitsSeparately(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Resource", "core")
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.Header().Set("X-RateLimit-Reset", "1767225600")
+		w.Write([]byte(`{"default_branch": "main"}`))
+	}))
+	defer server.Close()
+
+	enterprise, err := NewEnterpriseClient("token", server.URL+"/api/v3/", server.URL+"/api/uploads/")
+	if err != nil {
+		t.Fatalf("NewEnterpriseClient() error = %v", err)
+	}
+	github := NewClient("token")
+
+	if branch, err := NewProvider(enterprise).DefaultBranch("acme", "api"); err != nil || branch != "main" {
+		t.Fatalf("DefaultBranch() = %q, %v, want main", branch, err)
+	}
+	if remaining, _, known := enterprise.rate.current(); !known || remaining != 42 {
+		t.Errorf("enterprise rate = %d (known %v), want 42", remaining, known)
+	}
+	if _, _, known := github.rate.current(); known {
+		t.Errorf("github.com rate is known before any github.com request")
+	}
+}
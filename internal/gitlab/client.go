@@ -0,0 +1,587 @@
+// Package gitlab reads merge requests, discussions, commits, issues and
+// deployments from the GitLab REST API (v4), for GitLab.com and self-managed
+// instances, as a source.Provider
+package gitlab
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/source"
+)
+
+// errNotFound is returned for paths GitLab doesn't know, or the token can't see
+var errNotFound = errors.New("not found")
+
+// maxAttempts is how many times a rate-limited request is sent
+const maxAttempts = 3
+
+// codeOwnersPaths are where GitLab looks for CODEOWNERS, in order
+var codeOwnersPaths = []string{"CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"}
+
+// Client is a GitLab API client. Projects are identified by their namespace
+// (group and subgroups, or user) as owner and their path as repo.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+var _ source.Provider = (*Client)(nil)
+
+// NewClient creates a GitLab client for the API at baseURL
+// (e.g. https://gitlab.example.com/api/v4/) authenticating with a personal,
+// group or project access token
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// user is a GitLab user as embedded in other objects
+type user struct {
+	Username string `json:"username"`
+}
+
+// mergeRequest is a merge request as listed by the API
+type mergeRequest struct {
+	IID          int        `json:"iid"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description"`
+	State        string     `json:"state"` // opened, closed, locked or merged
+	Author       user       `json:"author"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	MergedAt     *time.Time `json:"merged_at"`
+	ClosedAt     *time.Time `json:"closed_at"`
+	TargetBranch string     `json:"target_branch"`
+	SourceBranch string     `json:"source_branch"`
+	Labels       []string   `json:"labels"`
+	MergeCommit  *string    `json:"merge_commit_sha"`
+	SquashCommit *string    `json:"squash_commit_sha"`
+}
+
+// note is a comment, or a system note recording an event such as an approval
+type note struct {
+	ID           int64     `json:"id"`
+	Type         *string   `json:"type"` // DiffNote, DiscussionNote or null
+	Body         string    `json:"body"`
+	Author       user      `json:"author"`
+	CreatedAt    time.Time `json:"created_at"`
+	System       bool      `json:"system"`
+	Resolvable   bool      `json:"resolvable"`
+	NoteableType string    `json:"noteable_type"` // Issue, MergeRequest or Commit
+	NoteableIID  *int      `json:"noteable_iid"`
+}
+
+// discussion is a thread of notes
+type discussion struct {
+	ID    string `json:"id"`
+	Notes []note `json:"notes"`
+}
+
+// commit is a commit as listed by the API
+type commit struct {
+	ID          string    `json:"id"`
+	ParentIDs   []string  `json:"parent_ids"`
+	AuthorName  string    `json:"author_name"`
+	AuthorEmail string    `json:"author_email"`
+	AuthoredAt  time.Time `json:"authored_date"`
+	Message     string    `json:"message"`
+}
+
+// ChangeRequests returns the merge requests updated between since and until
+func (c *Client) ChangeRequests(owner, repo string, since, until time.Time) ([]source.ChangeRequest, error) {
+	fmt.Printf("  📥 Fetching MRs from %s/%s (since %s)...\n", owner, repo, since.Format("2006-01-02"))
+
+	query := url.Values{
+		"state":         {"all"},
+		"order_by":      {"updated_at"},
+		"sort":          {"desc"},
+		"updated_after": {since.UTC().Format(time.RFC3339)},
+	}
+	if !until.IsZero() {
+		query.Set("updated_before", until.UTC().Format(time.RFC3339))
+	}
+	var mrs []mergeRequest
+	if err := c.list(projectPath(owner, repo)+"/merge_requests", query, &mrs); err != nil {
+		return nil, fmt.Errorf("failed to fetch merge requests: %w", err)
+	}
+
+	changes := make([]source.ChangeRequest, 0, len(mrs))
+	for _, mr := range mrs {
+		change := source.ChangeRequest{
+			Number:    mr.IID,
+			Title:     mr.Title,
+			Body:      mr.Description,
+			Author:    mr.Author.Username,
+			State:     "open",
+			CreatedAt: mr.CreatedAt,
+			UpdatedAt: mr.UpdatedAt,
+			ClosedAt:  mr.ClosedAt,
+			BaseRef:   mr.TargetBranch,
+			HeadRef:   mr.SourceBranch,
+			Labels:    mr.Labels,
+		}
+		switch mr.State {
+		case "merged":
+			change.State = "closed"
+			change.MergedAt = mr.MergedAt
+			if change.MergedAt == nil {
+				// Merged before GitLab recorded merged_at
+				mergedAt := mr.UpdatedAt
+				change.MergedAt = &mergedAt
+			}
+			for _, sha := range []*string{mr.MergeCommit, mr.SquashCommit} {
+				if sha != nil && *sha != "" {
+					change.MergeCommits = append(change.MergeCommits, *sha)
+				}
+			}
+		case "closed":
+			change.State = "closed"
+		}
+		changes = append(changes, change)
+	}
+
+	fmt.Printf("  ✓ Fetched %d MRs\n", len(changes))
+	return changes, nil
+}
+
+// Reviews returns a merge request's reviews: approvals and requested changes
+// from its system notes, and a comment review for each note by someone other
+// than its author
+func (c *Client) Reviews(owner, repo string, number int) ([]source.Review, error) {
+	var mr mergeRequest
+	if err := c.get(fmt.Sprintf("%s/merge_requests/%d", projectPath(owner, repo), number), url.Values{}, &mr); err != nil {
+		return nil, fmt.Errorf("failed to fetch merge request: %w", err)
+	}
+	discussions, err := c.discussions(owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	var reviews []source.Review
+	for _, d := range discussions {
+		for _, n := range d.Notes {
+			review := source.Review{Author: n.Author.Username, SubmittedAt: n.CreatedAt}
+			switch {
+			case n.System && n.Body == "approved this merge request":
+				review.State = source.ReviewApproved
+			case n.System && n.Body == "requested changes":
+				review.State = source.ReviewChangesRequested
+			case !n.System && n.Author.Username != mr.Author.Username:
+				review.State = source.ReviewCommented
+			default:
+				continue
+			}
+			reviews = append(reviews, review)
+		}
+	}
+	return reviews, nil
+}
+
+// ReviewComments returns the notes in a merge request's resolvable threads:
+// those on its diff and those started as threads rather than plain comments
+func (c *Client) ReviewComments(owner, repo string, number int) ([]source.ReviewComment, error) {
+	discussions, err := c.discussions(owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []source.ReviewComment
+	for _, d := range discussions {
+		if len(d.Notes) == 0 || !d.Notes[0].Resolvable {
+			continue
+		}
+		first := d.Notes[0].ID
+		for i, n := range d.Notes {
+			comment := source.ReviewComment{ID: n.ID, Author: n.Author.Username, CreatedAt: n.CreatedAt}
+			if i > 0 {
+				comment.InReplyTo = first
+			}
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+// discussions fetches a merge request's discussions, including system notes
+func (c *Client) discussions(owner, repo string, number int) ([]discussion, error) {
+	var discussions []discussion
+	path := fmt.Sprintf("%s/merge_requests/%d/discussions", projectPath(owner, repo), number)
+	if err := c.list(path, url.Values{}, &discussions); err != nil {
+		return nil, fmt.Errorf("failed to fetch discussions: %w", err)
+	}
+	return discussions, nil
+}
+
+// ChangeRequestCommits returns a merge request's commits
+func (c *Client) ChangeRequestCommits(owner, repo string, number int) ([]source.Commit, error) {
+	var commits []commit
+	path := fmt.Sprintf("%s/merge_requests/%d/commits", projectPath(owner, repo), number)
+	if err := c.list(path, url.Values{}, &commits); err != nil {
+		return nil, fmt.Errorf("failed to fetch merge request commits: %w", err)
+	}
+	return convertCommits(commits), nil
+}
+
+// ChangeRequestFiles returns the paths a merge request changes, including the
+// old paths of renamed files
+func (c *Client) ChangeRequestFiles(owner, repo string, number int) ([]string, error) {
+	var diffs []struct {
+		OldPath string `json:"old_path"`
+		NewPath string `json:"new_path"`
+	}
+	path := fmt.Sprintf("%s/merge_requests/%d/diffs", projectPath(owner, repo), number)
+	if err := c.list(path, url.Values{}, &diffs); err != nil {
+		return nil, fmt.Errorf("failed to fetch merge request files: %w", err)
+	}
+
+	var files []string
+	for _, diff := range diffs {
+		files = append(files, diff.NewPath)
+		if diff.OldPath != "" && diff.OldPath != diff.NewPath {
+			files = append(files, diff.OldPath)
+		}
+	}
+	return files, nil
+}
+
+// Commits returns the commits on the default branch between since and until
+func (c *Client) Commits(owner, repo string, since, until time.Time) ([]source.Commit, error) {
+	fmt.Printf("  📥 Fetching Commits from %s/%s (since %s)...\n", owner, repo, since.Format("2006-01-02"))
+
+	var commits []commit
+	query := url.Values{"since": {since.UTC().Format(time.RFC3339)}}
+	if !until.IsZero() {
+		query.Set("until", until.UTC().Format(time.RFC3339))
+	}
+	if err := c.list(projectPath(owner, repo)+"/repository/commits", query, &commits); err != nil {
+		return nil, fmt.Errorf("failed to fetch commits: %w", err)
+	}
+
+	fmt.Printf("  ✓ Fetched %d commits\n", len(commits))
+	return convertCommits(commits), nil
+}
+
+// Issues returns the issues updated between since and until
+func (c *Client) Issues(owner, repo string, since, until time.Time) ([]source.Issue, error) {
+	fmt.Printf("  📥 Fetching Issues from %s/%s (since %s)...\n", owner, repo, since.Format("2006-01-02"))
+
+	var raw []struct {
+		IID       int        `json:"iid"`
+		Title     string     `json:"title"`
+		State     string     `json:"state"` // opened or closed
+		Author    user       `json:"author"`
+		CreatedAt time.Time  `json:"created_at"`
+		ClosedAt  *time.Time `json:"closed_at"`
+		Labels    []string   `json:"labels"`
+		Assignees []user     `json:"assignees"`
+		Milestone *struct {
+			Title string `json:"title"`
+		} `json:"milestone"`
+	}
+	query := url.Values{
+		"scope":         {"all"},
+		"order_by":      {"updated_at"},
+		"sort":          {"desc"},
+		"updated_after": {since.UTC().Format(time.RFC3339)},
+	}
+	if !until.IsZero() {
+		query.Set("updated_before", until.UTC().Format(time.RFC3339))
+	}
+	if err := c.list(projectPath(owner, repo)+"/issues", query, &raw); err != nil {
+		return nil, fmt.Errorf("failed to fetch issues: %w", err)
+	}
+
+	issues := make([]source.Issue, 0, len(raw))
+	for _, r := range raw {
+		issue := source.Issue{
+			Number:    r.IID,
+			Title:     r.Title,
+			Author:    r.Author.Username,
+			State:     "open",
+			CreatedAt: r.CreatedAt,
+			ClosedAt:  r.ClosedAt,
+			Labels:    r.Labels,
+		}
+		if r.State == "closed" {
+			issue.State = "closed"
+		}
+		for _, assignee := range r.Assignees {
+			issue.Assignees = append(issue.Assignees, assignee.Username)
+		}
+		if r.Milestone != nil {
+			issue.Milestone = r.Milestone.Title
+		}
+		issues = append(issues, issue)
+	}
+
+	fmt.Printf("  ✓ Fetched %d issues\n", len(issues))
+	return issues, nil
+}
+
+// Comments returns the comments on issues, merge requests and commits made
+// between since and until, read from the project's comment events. Notes on a
+// merge request's diff are review comments and left out.
+func (c *Client) Comments(owner, repo string, since, until time.Time) ([]source.Comment, error) {
+	fmt.Printf("  📥 Fetching Comments from %s/%s (since %s)...\n", owner, repo, since.Format("2006-01-02"))
+
+	var events []struct {
+		Note *note `json:"note"`
+	}
+	query := url.Values{
+		"action": {"commented"},
+		"after":  {since.AddDate(0, 0, -1).Format("2006-01-02")}, // Exclusive, by day
+		"sort":   {"desc"},
+	}
+	if !until.IsZero() {
+		query.Set("before", until.AddDate(0, 0, 1).Format("2006-01-02")) // Exclusive, by day
+	}
+	if err := c.list(projectPath(owner, repo)+"/events", query, &events); err != nil {
+		return nil, fmt.Errorf("failed to fetch comments: %w", err)
+	}
+
+	var comments []source.Comment
+	for _, event := range events {
+		n := event.Note
+		if n == nil || n.System || n.CreatedAt.Before(since) || (!until.IsZero() && n.CreatedAt.After(until)) {
+			continue
+		}
+		comment := source.Comment{
+			ID:          n.ID,
+			Author:      n.Author.Username,
+			Body:        n.Body,
+			CreatedAt:   n.CreatedAt,
+			IssueNumber: n.NoteableIID,
+		}
+		switch n.NoteableType {
+		case "Issue":
+			comment.Kind = source.CommentIssue
+		case "MergeRequest":
+			if n.Type != nil && *n.Type == "DiffNote" {
+				continue
+			}
+			comment.Kind = source.CommentChangeRequest
+		case "Commit":
+			comment.Kind = source.CommentCommit
+			comment.IssueNumber = nil
+		default:
+			continue
+		}
+		comments = append(comments, comment)
+	}
+
+	fmt.Printf("  ✓ Fetched %d comments\n", len(comments))
+	return comments, nil
+}
+
+// Deployments returns the deployments created between since and until
+func (c *Client) Deployments(owner, repo, environment string, since, until time.Time) ([]source.Deployment, error) {
+	fmt.Printf("  📥 Fetching Deployments from %s/%s (since %s)...\n", owner, repo, since.Format("2006-01-02"))
+
+	var raw []struct {
+		ID          int64     `json:"id"`
+		Ref         string    `json:"ref"`
+		SHA         string    `json:"sha"`
+		CreatedAt   time.Time `json:"created_at"`
+		User        user      `json:"user"`
+		Environment struct {
+			Name string `json:"name"`
+		} `json:"environment"`
+	}
+	// GitLab only filters by update time when ordering by it
+	query := url.Values{
+		"order_by":      {"updated_at"},
+		"sort":          {"desc"},
+		"updated_after": {since.UTC().Format(time.RFC3339)},
+	}
+	if environment != "" {
+		query.Set("environment", environment)
+	}
+	if err := c.list(projectPath(owner, repo)+"/deployments", query, &raw); err != nil {
+		return nil, fmt.Errorf("failed to fetch deployments: %w", err)
+	}
+
+	var deployments []source.Deployment
+	for _, r := range raw {
+		if r.CreatedAt.Before(since) {
+			continue // Updated, e.g. finished, since but created before
+		}
+		if !until.IsZero() && r.CreatedAt.After(until) {
+			continue // Not bounded by update time: one created by until may finish after it
+		}
+		deployments = append(deployments, source.Deployment{
+			ID:          r.ID,
+			Environment: r.Environment.Name,
+			Ref:         r.Ref,
+			SHA:         r.SHA,
+			Creator:     r.User.Username,
+			CreatedAt:   r.CreatedAt,
+		})
+	}
+
+	fmt.Printf("  ✓ Fetched %d deployments\n", len(deployments))
+	return deployments, nil
+}
+
+// CodeOwners returns the project's CODEOWNERS file from the first location
+// GitLab reads it from, or nil if there is none
+func (c *Client) CodeOwners(owner, repo string) ([]byte, error) {
+	for _, file := range codeOwnersPaths {
+		path := fmt.Sprintf("%s/repository/files/%s/raw", projectPath(owner, repo), url.PathEscape(file))
+		data, err := c.raw(path, url.Values{"ref": {"HEAD"}})
+		if errors.Is(err, errNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch CODEOWNERS: %w", err)
+		}
+		return data, nil
+	}
+	return nil, nil
+}
+
+// DefaultBranch returns the project's default branch
+func (c *Client) DefaultBranch(owner, repo string) (string, error) {
+	var project struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := c.get(projectPath(owner, repo), url.Values{}, &project); err != nil {
+		return "", fmt.Errorf("failed to fetch project: %w", err)
+	}
+	return project.DefaultBranch, nil
+}
+
+// convertCommits converts commits. GitLab doesn't link commits to accounts,
+// so their authors are matched to members by git name and email.
+func convertCommits(commits []commit) []source.Commit {
+	result := make([]source.Commit, 0, len(commits))
+	for _, c := range commits {
+		result = append(result, source.Commit{
+			SHA:         c.ID,
+			AuthorName:  c.AuthorName,
+			AuthorEmail: c.AuthorEmail,
+			AuthoredAt:  c.AuthoredAt,
+			Message:     c.Message,
+			Parents:     c.ParentIDs,
+		})
+	}
+	return result
+}
+
+// projectPath is the API path of a project, addressed by its full path
+func projectPath(owner, repo string) string {
+	return "/projects/" + url.PathEscape(owner+"/"+repo)
+}
+
+// list fetches every page of a list endpoint and decodes the items into out,
+// a pointer to a slice
+func (c *Client) list(path string, query url.Values, out interface{}) error {
+	query.Set("per_page", "100")
+	var items []json.RawMessage
+	for page := "1"; page != ""; {
+		query.Set("page", page)
+		resp, err := c.do(path, query)
+		if err != nil {
+			return err
+		}
+		var pageItems []json.RawMessage
+		err = json.NewDecoder(resp.Body).Decode(&pageItems)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to decode GitLab response: %w", err)
+		}
+		items = append(items, pageItems...)
+		page = resp.Header.Get("X-Next-Page")
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// get fetches a GitLab API path and decodes the JSON response into out
+func (c *Client) get(path string, query url.Values, out interface{}) error {
+	resp, err := c.do(path, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode GitLab response: %w", err)
+	}
+	return nil
+}
+
+// raw fetches a GitLab API path's response body as is
+func (c *Client) raw(path string, query url.Values) ([]byte, error) {
+	resp, err := c.do(path, query)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitLab response: %w", err)
+	}
+	return data, nil
+}
+
+// do sends a GET request for an API path, returning the response if it
+// succeeded. Rate-limited requests are retried after the wait GitLab asks for.
+func (c *Client) do(path string, query url.Values) (*http.Response, error) {
+	// url.Parse keeps the %2F separators of project paths as sent
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitLab request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call GitLab: %w", err)
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			return resp, nil
+		case resp.StatusCode == http.StatusTooManyRequests && attempt < maxAttempts:
+			resp.Body.Close()
+			wait := retryAfter(resp.Header)
+			fmt.Printf("  ⏳ GitLab rate limit reached. Waiting %v...\n", wait)
+			time.Sleep(wait)
+		case resp.StatusCode == http.StatusNotFound:
+			resp.Body.Close()
+			return nil, errNotFound
+		default:
+			resp.Body.Close()
+			return nil, fmt.Errorf("gitlab returned %s for %s", resp.Status, path)
+		}
+	}
+}
+
+// retryAfter returns how long GitLab asks rate-limited clients to wait
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds < 1 {
+		return time.Minute
+	}
+	return time.Duration(seconds) * time.Second
+}
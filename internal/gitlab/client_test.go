@@ -0,0 +1,296 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/source"
+)
+
+// project is the escaped API path of the project the fake GitLab serves
+const project = "/api/v4/projects/acme%2Fpayments%2Fapi"
+
+// newFakeGitLab serves a v4 API stand-in for acme/payments/api, with the
+// responses keyed by escaped path (and page, for paginated ones)
+func newFakeGitLab(t *testing.T, responses map[string]interface{}) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "glpat-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// Like GitLab, reject filtering by update time without ordering by it
+		if query := r.URL.Query(); query.Get("updated_after") != "" && query.Get("order_by") != "updated_at" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		key := r.URL.EscapedPath()
+		if page := r.URL.Query().Get("page"); page != "" && page != "1" {
+			key += "?page=" + page
+		}
+		if _, ok := responses[key+"?page=2"]; ok {
+			w.Header().Set("X-Next-Page", "2")
+		}
+		response, ok := responses[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if text, ok := response.(string); ok {
+			w.Write([]byte(text))
+			return
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	return NewClient(server.URL+"/api/v4/", "glpat-secret")
+}
+
+// at parses an RFC 3339 timestamp
+func at(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+// TestChangeRequests tests listing merge requests across pages and mapping their states
+func TestChangeRequests(t *testing.T) {
+	client := newFakeGitLab(t, map[string]interface{}{
+		project + "/merge_requests": []map[string]interface{}{
+			{"iid": 7, "title": "feat: retries", "state": "merged", "author": map[string]string{"username": "alice"},
+				"created_at": "2026-03-02T09:00:00Z", "updated_at": "2026-03-03T12:00:00Z", "merged_at": "2026-03-03T11:00:00Z",
+				"target_branch": "main", "source_branch": "feat/retries", "labels": []string{"backend"}},
+			{"iid": 8, "title": "Old merge", "state": "merged", "author": map[string]string{"username": "bob"},
+				"created_at": "2026-03-01T09:00:00Z", "updated_at": "2026-03-01T10:00:00Z", "merged_at": nil},
+		},
+		project + "/merge_requests?page=2": []map[string]interface{}{
+			{"iid": 9, "title": "Draft", "state": "opened", "author": map[string]string{"username": "carol"},
+				"created_at": "2026-03-04T09:00:00Z", "updated_at": "2026-03-04T09:00:00Z"},
+			{"iid": 10, "title": "Abandoned", "state": "closed", "author": map[string]string{"username": "carol"},
+				"created_at": "2026-03-04T09:00:00Z", "updated_at": "2026-03-05T09:00:00Z", "closed_at": "2026-03-05T09:00:00Z"},
+		},
+	})
+
+	changes, err := client.ChangeRequests("acme/payments", "api", at(t, "2026-03-01T00:00:00Z"), time.Time{})
+	if err != nil {
+		t.Fatalf("ChangeRequests() error = %v", err)
+	}
+	if len(changes) != 4 {
+		t.Fatalf("ChangeRequests() returned %d merge requests, want 4", len(changes))
+	}
+
+	mr := changes[0]
+	if mr.Number != 7 || mr.Author != "alice" || mr.State != "closed" || mr.BaseRef != "main" || mr.HeadRef != "feat/retries" {
+		t.Errorf("ChangeRequests()[0] = %+v, want !7 by alice, closed, feat/retries into main", mr)
+	}
+	if mr.MergedAt == nil || !mr.MergedAt.Equal(at(t, "2026-03-03T11:00:00Z")) {
+		t.Errorf("MergedAt = %v, want 2026-03-03T11:00:00Z", mr.MergedAt)
+	}
+	if changes[1].MergedAt == nil || !changes[1].MergedAt.Equal(changes[1].UpdatedAt) {
+		t.Errorf("MergedAt without merged_at = %v, want the update time", changes[1].MergedAt)
+	}
+	if changes[2].State != "open" || changes[2].MergedAt != nil {
+		t.Errorf("opened merge request = %s (merged %v), want open", changes[2].State, changes[2].MergedAt)
+	}
+	if changes[3].State != "closed" || changes[3].MergedAt != nil || changes[3].ClosedAt == nil {
+		t.Errorf("closed merge request = %s (merged %v, closed %v), want closed unmerged", changes[3].State, changes[3].MergedAt, changes[3].ClosedAt)
+	}
+}
+
+// TestReviewsAndThreads tests reading approvals, requested changes, comments
+// and resolvable threads from a merge request's discussions
+func TestReviewsAndThreads(t *testing.T) {
+	note := func(id int64, author, body string, system, resolvable bool, created string) map[string]interface{} {
+		return map[string]interface{}{"id": id, "author": map[string]string{"username": author}, "body": body,
+			"system": system, "resolvable": resolvable, "created_at": created}
+	}
+	client := newFakeGitLab(t, map[string]interface{}{
+		project + "/merge_requests/7": map[string]interface{}{"iid": 7, "author": map[string]string{"username": "alice"}},
+		project + "/merge_requests/7/discussions": []map[string]interface{}{
+			{"id": "a", "notes": []interface{}{note(1, "alice", "added 2 commits", true, false, "2026-03-02T10:00:00Z")}},
+			{"id": "b", "notes": []interface{}{
+				note(2, "bob", "Retry forever?", false, true, "2026-03-02T11:00:00Z"),
+				note(3, "alice", "Capped at 5 now", false, true, "2026-03-02T12:00:00Z"),
+			}},
+			{"id": "c", "notes": []interface{}{note(4, "bob", "requested changes", true, false, "2026-03-02T11:05:00Z")}},
+			{"id": "d", "notes": []interface{}{note(5, "carol", "LGTM", false, false, "2026-03-03T09:00:00Z")}},
+			{"id": "e", "notes": []interface{}{note(6, "carol", "approved this merge request", true, false, "2026-03-03T09:01:00Z")}},
+			{"id": "f", "notes": []interface{}{note(7, "dave", "Nit: naming", false, true, "2026-03-03T10:00:00Z")}},
+		},
+	})
+
+	reviews, err := client.Reviews("acme/payments", "api", 7)
+	if err != nil {
+		t.Fatalf("Reviews() error = %v", err)
+	}
+	var states []string
+	for _, review := range reviews {
+		states = append(states, review.Author+":"+review.State)
+	}
+	want := "bob:COMMENTED bob:CHANGES_REQUESTED carol:COMMENTED carol:APPROVED dave:COMMENTED"
+	if got := strings.Join(states, " "); got != want {
+		t.Errorf("Reviews() = %s, want %s", got, want)
+	}
+
+	comments, err := client.ReviewComments("acme/payments", "api", 7)
+	if err != nil {
+		t.Fatalf("ReviewComments() error = %v", err)
+	}
+	wantComments := []source.ReviewComment{
+		{ID: 2, Author: "bob"},
+		{ID: 3, InReplyTo: 2, Author: "alice"},
+		{ID: 7, Author: "dave"},
+	}
+	if len(comments) != len(wantComments) {
+		t.Fatalf("ReviewComments() = %+v, want %d comments", comments, len(wantComments))
+	}
+	for i, comment := range comments {
+		if comment.ID != wantComments[i].ID || comment.InReplyTo != wantComments[i].InReplyTo || comment.Author != wantComments[i].Author {
+			t.Errorf("ReviewComments()[%d] = %+v, want %+v", i, comment, wantComments[i])
+		}
+	}
+}
+
+// TestComments tests reading comments from the project's comment events
+func TestComments(t *testing.T) {
+	event := func(id int64, noteType interface{}, noteableType string, iid interface{}, system bool, created string) map[string]interface{} {
+		return map[string]interface{}{"note": map[string]interface{}{
+			"id": id, "type": noteType, "body": "text", "author": map[string]string{"username": "bob"},
+			"created_at": created, "system": system, "noteable_type": noteableType, "noteable_iid": iid,
+		}}
+	}
+	client := newFakeGitLab(t, map[string]interface{}{
+		project + "/events": []interface{}{
+			event(1, nil, "Issue", 12, false, "2026-03-03T09:00:00Z"),
+			event(2, "DiscussionNote", "MergeRequest", 7, false, "2026-03-03T09:00:00Z"),
+			event(3, "DiffNote", "MergeRequest", 7, false, "2026-03-03T09:00:00Z"),
+			event(4, nil, "Commit", nil, false, "2026-03-03T09:00:00Z"),
+			event(5, nil, "Issue", 12, true, "2026-03-03T09:00:00Z"),
+			event(6, nil, "Issue", 12, false, "2026-03-01T09:00:00Z"),
+			map[string]interface{}{"note": nil},
+		},
+	})
+
+	comments, err := client.Comments("acme/payments", "api", at(t, "2026-03-02T00:00:00Z"), time.Time{})
+	if err != nil {
+		t.Fatalf("Comments() error = %v", err)
+	}
+	want := []struct {
+		id    int64
+		kind  string
+		issue int
+	}{{1, source.CommentIssue, 12}, {2, source.CommentChangeRequest, 7}, {4, source.CommentCommit, 0}}
+	if len(comments) != len(want) {
+		t.Fatalf("Comments() = %+v, want %d comments", comments, len(want))
+	}
+	for i, comment := range comments {
+		issue := 0
+		if comment.IssueNumber != nil {
+			issue = *comment.IssueNumber
+		}
+		if comment.ID != want[i].id || comment.Kind != want[i].kind || issue != want[i].issue {
+			t.Errorf("Comments()[%d] = %d %s on #%d, want %d %s on #%d", i, comment.ID, comment.Kind, issue, want[i].id, want[i].kind, want[i].issue)
+		}
+	}
+}
+
+// TestCommitsAndDeployments tests converting commits and filtering deployments by creation time
+func TestCommitsAndDeployments(t *testing.T) {
+	client := newFakeGitLab(t, map[string]interface{}{
+		project + "/repository/commits": []map[string]interface{}{
+			{"id": "abc", "parent_ids": []string{"p1", "p2"}, "author_name": "Alice", "author_email": "alice@acme.example",
+				"authored_date": "2026-03-03T09:00:00+01:00", "message": "Merge branch 'feat/retries'"},
+		},
+		project + "/deployments": []map[string]interface{}{
+			{"id": 41, "ref": "main", "sha": "abc", "created_at": "2026-03-03T10:00:00Z",
+				"user": map[string]string{"username": "alice"}, "environment": map[string]string{"name": "production"}},
+			{"id": 40, "ref": "main", "sha": "def", "created_at": "2026-02-27T10:00:00Z",
+				"user": map[string]string{"username": "alice"}, "environment": map[string]string{"name": "production"}},
+		},
+	})
+	since := at(t, "2026-03-01T00:00:00Z")
+
+	commits, err := client.Commits("acme/payments", "api", since, time.Time{})
+	if err != nil {
+		t.Fatalf("Commits() error = %v", err)
+	}
+	if len(commits) != 1 || !commits[0].IsMerge() || commits[0].AuthorEmail != "alice@acme.example" || commits[0].Login != "" {
+		t.Errorf("Commits() = %+v, want one merge by alice@acme.example without a login", commits)
+	}
+
+	deployments, err := client.Deployments("acme/payments", "api", "production", since, time.Time{})
+	if err != nil {
+		t.Fatalf("Deployments() error = %v", err)
+	}
+	if len(deployments) != 1 || deployments[0].ID != 41 || deployments[0].Environment != "production" || deployments[0].Creator != "alice" {
+		t.Errorf("Deployments() = %+v, want deployment 41 to production by alice", deployments)
+	}
+}
+
+// TestWindowEnd tests passing a backfill window's end to GitLab's filters, so
+// only what falls in the window is paged through
+func TestWindowEnd(t *testing.T) {
+	queries := map[string]url.Values{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries[strings.TrimPrefix(r.URL.EscapedPath(), project)] = r.URL.Query()
+		w.Write([]byte("[]"))
+	}))
+	t.Cleanup(server.Close)
+	client := NewClient(server.URL+"/api/v4/", "glpat-secret")
+	since, until := at(t, "2026-03-01T00:00:00Z"), at(t, "2026-03-31T23:59:59Z")
+
+	if _, err := client.ChangeRequests("acme/payments", "api", since, until); err != nil {
+		t.Fatalf("ChangeRequests() error = %v", err)
+	}
+	if _, err := client.Commits("acme/payments", "api", since, until); err != nil {
+		t.Fatalf("Commits() error = %v", err)
+	}
+	if _, err := client.Issues("acme/payments", "api", since, until); err != nil {
+		t.Fatalf("Issues() error = %v", err)
+	}
+	if _, err := client.Comments("acme/payments", "api", since, until); err != nil {
+		t.Fatalf("Comments() error = %v", err)
+	}
+
+	for _, tt := range []struct {
+		path, param, want string
+	}{
+		{"/merge_requests", "updated_before", "2026-03-31T23:59:59Z"},
+		{"/repository/commits", "until", "2026-03-31T23:59:59Z"},
+		{"/issues", "updated_before", "2026-03-31T23:59:59Z"},
+		{"/events", "before", "2026-04-01"},
+	} {
+		if got := queries[tt.path].Get(tt.param); got != tt.want {
+			t.Errorf("%s %s = %q, want %q", tt.path, tt.param, got, tt.want)
+		}
+	}
+}
+
+// TestCodeOwners tests finding CODEOWNERS in the locations GitLab reads it from
+func TestCodeOwners(t *testing.T) {
+	client := newFakeGitLab(t, map[string]interface{}{
+		project + "/repository/files/.gitlab%2FCODEOWNERS/raw": "* @acme/payments\n",
+	})
+	data, err := client.CodeOwners("acme/payments", "api")
+	if err != nil || string(data) != "* @acme/payments\n" {
+		t.Errorf("CodeOwners() = %q, %v, want the .gitlab/CODEOWNERS file", data, err)
+	}
+
+	empty := newFakeGitLab(t, map[string]interface{}{})
+	if data, err := empty.CodeOwners("acme/payments", "api"); err != nil || data != nil {
+		t.Errorf("CodeOwners() without a file = %q, %v, want nil", data, err)
+	}
+	if _, err := empty.DefaultBranch("acme/payments", "api"); err == nil {
+		t.Errorf("DefaultBranch() of an unknown project returned no error")
+	}
+}
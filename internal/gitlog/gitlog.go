@@ -0,0 +1,141 @@
+// Package gitlog reads commits from a local git clone by parsing `git log`,
+// for repositories collected without the GitHub API (air-gapped mirrors,
+// historical backfills)
+package gitlog
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Separators in the log format: each commit starts with a record separator
+// and its header fields end with NUL, neither of which occur in commit messages
+const (
+	recordSeparator = "\x1e"
+	fieldSeparator  = "\x00"
+)
+
+// logFormat prints a commit's hash, parents, author name, email and date, and message
+const logFormat = recordSeparator + "%H%x00%P%x00%an%x00%ae%x00%aI%x00%B%x00"
+
+// Commit is a commit read from git log
+type Commit struct {
+	Hash        string
+	Parents     []string
+	AuthorName  string
+	AuthorEmail string
+	AuthoredAt  time.Time
+	Message     string
+
+	// Churn from --numstat. Binary files count as changed without lines;
+	// merge commits have no churn of their own.
+	Additions    int
+	Deletions    int
+	FilesChanged int
+}
+
+// IsMerge reports whether the commit has more than one parent
+func (c Commit) IsMerge() bool {
+	return len(c.Parents) > 1
+}
+
+// Head returns the commit HEAD of the clone at repoPath points to
+func Head(repoPath string) (string, error) {
+	out, err := git(repoPath, "rev-parse", "--verify", "HEAD^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// IsAncestor reports whether commit exists in the clone at repoPath and is
+// reachable from rev. It isn't once history was rewritten or pruned.
+func IsAncestor(repoPath, commit, rev string) bool {
+	_, err := git(repoPath, "merge-base", "--is-ancestor", commit, rev)
+	return err == nil
+}
+
+// Log returns the commits reachable from rev in the clone at repoPath (bare
+// or not) authored since the given time, newest first
+func Log(repoPath, rev string, since time.Time) ([]Commit, error) {
+	args := []string{"log", "--numstat", "--format=" + logFormat}
+	if !since.IsZero() {
+		args = append(args, "--since="+since.Format(time.RFC3339))
+	}
+	out, err := git(repoPath, append(args, rev, "--")...)
+	if err != nil {
+		return nil, err
+	}
+	return parse(out)
+}
+
+// LogRange returns the commits reachable from rev but not from from, whatever
+// their dates, newest first. Unlike Log with a time, it doesn't miss commits
+// fetched into the clone after from was read but dated before it.
+func LogRange(repoPath, from, rev string) ([]Commit, error) {
+	out, err := git(repoPath, "log", "--numstat", "--format="+logFormat, from+".."+rev, "--")
+	if err != nil {
+		return nil, err
+	}
+	return parse(out)
+}
+
+// git runs a git command in the clone at repoPath and returns its output
+func git(repoPath string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", repoPath}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run git %s in %s: %w: %s", args[0], repoPath, err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// parse parses git log output in logFormat with --numstat
+func parse(out string) ([]Commit, error) {
+	var commits []Commit
+	for _, record := range strings.Split(out, recordSeparator) {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+		fields := strings.SplitN(record, fieldSeparator, 7)
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("unexpected git log record %q", record)
+		}
+
+		authoredAt, err := time.Parse(time.RFC3339, fields[4])
+		if err != nil {
+			return nil, fmt.Errorf("invalid author date %q of commit %s: %w", fields[4], fields[0], err)
+		}
+		commit := Commit{
+			Hash:        fields[0],
+			Parents:     strings.Fields(fields[1]),
+			AuthorName:  fields[2],
+			AuthorEmail: fields[3],
+			AuthoredAt:  authoredAt,
+			Message:     strings.TrimRight(fields[5], "\n"),
+		}
+
+		// What follows the message are numstat lines: added, deleted, path
+		for _, line := range strings.Split(fields[6], "\n") {
+			parts := strings.SplitN(line, "\t", 3)
+			if len(parts) != 3 {
+				continue
+			}
+			commit.FilesChanged++
+			if added, err := strconv.Atoi(parts[0]); err == nil {
+				commit.Additions += added
+			}
+			if deleted, err := strconv.Atoi(parts[1]); err == nil {
+				commit.Deletions += deleted
+			}
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
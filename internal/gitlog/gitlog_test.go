@@ -0,0 +1,188 @@
+package gitlog
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixtureRepo builds a repository in a temp dir: two commits on main, one on
+// a branch merged back with a merge commit, one with a binary file
+func fixtureRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	date := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	run := func(args ...string) {
+		t.Helper()
+		runGit(t, dir, date, args...)
+		if args[0] == "commit" || args[0] == "merge" {
+			date = date.Add(24 * time.Hour) // One commit a day
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "-q", "-b", "main")
+	write("README.md", "one\ntwo\nthree\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "Initial commit")
+
+	run("checkout", "-q", "-b", "feature")
+	write("README.md", "one\n2\nthree\nfour\n")
+	write("main.go", "package main\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "feat: add main\n\nCo-authored-by: Bob <bob@acme.example>")
+
+	run("checkout", "-q", "main")
+	write("logo.png", "\x89PNG\x00\x01")
+	run("add", ".")
+	run("commit", "-q", "-m", "Add logo")
+	run("merge", "-q", "--no-ff", "-m", "Merge branch 'feature'", "feature")
+	return dir
+}
+
+// runGit runs a git command in dir as Alice, dated at date
+func runGit(t *testing.T, dir string, date time.Time, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	stamp := date.Format(time.RFC3339)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Alice", "GIT_AUTHOR_EMAIL=alice@acme.example",
+		"GIT_COMMITTER_NAME=Alice", "GIT_COMMITTER_EMAIL=alice@acme.example",
+		"GIT_AUTHOR_DATE="+stamp, "GIT_COMMITTER_DATE="+stamp,
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+// TestLog tests reading commits, churn and merges from a fixture repository
+func TestLog(t *testing.T) {
+	dir := fixtureRepo(t)
+
+	commits, err := Log(dir, "HEAD", time.Time{})
+	if err != nil {
+		t.Fatalf("Log() error = %v", err)
+	}
+	if len(commits) != 4 {
+		t.Fatalf("Log() returned %d commits, want 4", len(commits))
+	}
+
+	byMessage := make(map[string]Commit)
+	for _, commit := range commits {
+		byMessage[strings.SplitN(commit.Message, "\n", 2)[0]] = commit
+	}
+
+	merge := byMessage["Merge branch 'feature'"]
+	if !merge.IsMerge() || merge.FilesChanged != 0 {
+		t.Errorf("merge commit: IsMerge() = %v, files = %d, want true, 0", merge.IsMerge(), merge.FilesChanged)
+	}
+
+	feature := byMessage["feat: add main"]
+	if feature.IsMerge() || feature.Additions != 3 || feature.Deletions != 1 || feature.FilesChanged != 2 {
+		t.Errorf("feature commit churn = +%d -%d in %d files, want +3 -1 in 2", feature.Additions, feature.Deletions, feature.FilesChanged)
+	}
+	if !strings.HasSuffix(feature.Message, "Co-authored-by: Bob <bob@acme.example>") {
+		t.Errorf("feature commit message = %q, want the co-author trailer kept", feature.Message)
+	}
+	if feature.AuthorName != "Alice" || feature.AuthorEmail != "alice@acme.example" {
+		t.Errorf("author = %s <%s>, want Alice <alice@acme.example>", feature.AuthorName, feature.AuthorEmail)
+	}
+	if want := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC); !feature.AuthoredAt.Equal(want) {
+		t.Errorf("AuthoredAt = %v, want %v", feature.AuthoredAt, want)
+	}
+
+	logo := byMessage["Add logo"]
+	if logo.FilesChanged != 1 || logo.Additions != 0 {
+		t.Errorf("binary commit churn = +%d in %d files, want +0 in 1", logo.Additions, logo.FilesChanged)
+	}
+}
+
+// TestLogSince tests limiting the log to recent commits
+func TestLogSince(t *testing.T) {
+	dir := fixtureRepo(t)
+
+	commits, err := Log(dir, "HEAD", time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Log() error = %v", err)
+	}
+	if len(commits) != 2 {
+		t.Errorf("Log() returned %d commits since March 3, want 2 (logo and merge)", len(commits))
+	}
+}
+
+// TestLogRange tests reading the commits since a previously read head,
+// including one dated before that head was read
+func TestLogRange(t *testing.T) {
+	dir := fixtureRepo(t)
+	head, err := Head(dir)
+	if err != nil {
+		t.Fatalf("Head() error = %v", err)
+	}
+
+	// A commit fetched later, but authored and committed before the merge
+	if err := os.WriteFile(filepath.Join(dir, "late.txt"), []byte("late\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC), "add", ".")
+	runGit(t, dir, time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC), "commit", "-q", "-m", "Backdated")
+
+	newHead, err := Head(dir)
+	if err != nil {
+		t.Fatalf("Head() error = %v", err)
+	}
+	commits, err := LogRange(dir, head, newHead)
+	if err != nil {
+		t.Fatalf("LogRange() error = %v", err)
+	}
+	if len(commits) != 1 || commits[0].Message != "Backdated" {
+		t.Errorf("LogRange() = %+v, want the backdated commit", commits)
+	}
+	if commits, err := LogRange(dir, newHead, newHead); err != nil || len(commits) != 0 {
+		t.Errorf("LogRange() of an unchanged head = %d commits, %v, want none", len(commits), err)
+	}
+}
+
+// TestIsAncestor tests recognising a head that is no longer reachable
+func TestIsAncestor(t *testing.T) {
+	dir := fixtureRepo(t)
+	head, err := Head(dir)
+	if err != nil {
+		t.Fatalf("Head() error = %v", err)
+	}
+	if !IsAncestor(dir, head, "HEAD") {
+		t.Errorf("IsAncestor(HEAD) = false, want true")
+	}
+
+	// Rewriting history drops the merge
+	runGit(t, dir, time.Now(), "reset", "-q", "--hard", "HEAD~1")
+	if IsAncestor(dir, head, "HEAD") {
+		t.Errorf("IsAncestor() of a rewritten commit = true, want false")
+	}
+	if IsAncestor(dir, strings.Repeat("0", 40), "HEAD") {
+		t.Errorf("IsAncestor() of an unknown commit = true, want false")
+	}
+}
+
+// TestLogNotARepository tests the error for a path that isn't a git repository
+func TestLogNotARepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	if _, err := Log(t.TempDir(), "HEAD", time.Time{}); err == nil {
+		t.Error("Log() error = nil, want an error outside a repository")
+	}
+}
@@ -0,0 +1,117 @@
+// Package issuelink finds the issues a PR references: tracker keys (such as
+// Jira's PROJ-123) in its title, branch name and body, and the GitHub issues it
+// closes with a keyword ("Closes #12").
+package issuelink
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Issue trackers a link can point to
+const (
+	TrackerJira   = "jira"
+	TrackerGitHub = "github"
+)
+
+// Where in the PR a link was found
+const (
+	SourceTitle  = "title"
+	SourceBranch = "branch"
+	SourceBody   = "body"
+)
+
+// closingPattern matches GitHub's (and GitLab's) closing keywords followed by
+// an issue reference, optionally in another repository ("fixes acme/api#12",
+// "fixes payments/core/api#12" on GitLab)
+var closingPattern = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+((?:[\w.-]+/)+[\w.-]+)?#(\d+)\b`)
+
+// PR holds what links are extracted from
+type PR struct {
+	Repository string // owner/repo (host/owner/repo on GitHub Enterprise and GitLab), for issues referenced without a host
+	Title      string
+	Branch     string
+	Body       string
+}
+
+// Link is a reference from a PR to an issue
+type Link struct {
+	Key     string // "PROJ-123", or "owner/repo#12" ("host/owner/repo#12") for GitHub issues
+	Tracker string
+	Source  string
+}
+
+// Extractor extracts issue links from PRs
+type Extractor struct {
+	keys *regexp.Regexp // nil to only link GitHub issues
+}
+
+// New creates an extractor for issue keys matching keyPattern ("" to only link GitHub issues)
+func New(keyPattern string) (*Extractor, error) {
+	if keyPattern == "" {
+		return &Extractor{}, nil
+	}
+	keys, err := regexp.Compile(keyPattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid issue key pattern %q: %w", keyPattern, err)
+	}
+	return &Extractor{keys: keys}, nil
+}
+
+// Extract returns the issues a PR links to, each once, from its title, branch
+// name and body in that order. Keys must stand apart from surrounding letters
+// and digits, so "ABC-12" is not found in "XABC-123".
+func (e *Extractor) Extract(pr PR) []Link {
+	var links []Link
+	seen := make(map[string]bool)
+	add := func(key, tracker, source string) {
+		if !seen[key] {
+			seen[key] = true
+			links = append(links, Link{Key: key, Tracker: tracker, Source: source})
+		}
+	}
+
+	for _, part := range []struct{ source, text string }{
+		{SourceTitle, pr.Title},
+		{SourceBranch, pr.Branch},
+		{SourceBody, pr.Body},
+	} {
+		for _, key := range e.issueKeys(part.text) {
+			add(key, TrackerJira, part.source)
+		}
+		if part.source == SourceBranch {
+			continue
+		}
+		for _, match := range closingPattern.FindAllStringSubmatch(part.text, -1) {
+			repository := match[1]
+			if repository == "" {
+				repository = pr.Repository
+			} else if host, _, ok := strings.Cut(pr.Repository, "/"); ok && strings.Count(pr.Repository, "/") >= 2 {
+				repository = host + "/" + repository // owner/repo on the PR's GitHub Enterprise or GitLab host
+			}
+			add(fmt.Sprintf("%s#%s", strings.ToLower(repository), match[2]), TrackerGitHub, part.source)
+		}
+	}
+	return links
+}
+
+// issueKeys returns the keys in text that aren't part of a longer word
+func (e *Extractor) issueKeys(text string) []string {
+	if e.keys == nil {
+		return nil
+	}
+	var keys []string
+	for _, loc := range e.keys.FindAllStringIndex(text, -1) {
+		if loc[0] == loc[1] || (loc[0] > 0 && isAlphanumeric(text[loc[0]-1])) || (loc[1] < len(text) && isAlphanumeric(text[loc[1]])) {
+			continue
+		}
+		keys = append(keys, text[loc[0]:loc[1]])
+	}
+	return keys
+}
+
+// isAlphanumeric reports whether b is an ASCII letter or digit
+func isAlphanumeric(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
@@ -0,0 +1,68 @@
+package issuelink
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+)
+
+// TestExtract tests finding issue keys and closed GitHub issues in PRs
+func TestExtract(t *testing.T) {
+	extractor, err := New(config.DefaultIssueKeyPattern)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		pr   PR
+		want []Link
+	}{
+		{"nothing", PR{Title: "Update README", Branch: "docs"}, nil},
+		{"key in title", PR{Title: "PAY-12: Retry failed charges"}, []Link{{"PAY-12", TrackerJira, SourceTitle}}},
+		{"key in branch", PR{Branch: "feature/PAY-7_refunds"}, []Link{{"PAY-7", TrackerJira, SourceBranch}}},
+		{"first source wins", PR{Title: "[PAY-12] Retry", Branch: "PAY-12-retry", Body: "Also see OPS-3."}, []Link{
+			{"PAY-12", TrackerJira, SourceTitle},
+			{"OPS-3", TrackerJira, SourceBody},
+		}},
+		{"part of a longer word", PR{Title: "Bump SHA-256X, see notePAY-12"}, nil},
+		{"closing keywords", PR{Repository: "Acme/API", Body: "Fixes #12, closes acme/web#3 and refs #4"}, []Link{
+			{"acme/api#12", TrackerGitHub, SourceBody},
+			{"acme/web#3", TrackerGitHub, SourceBody},
+		}},
+		{"closing keywords on an enterprise host", PR{Repository: "ghe.corp.example/acme/api", Body: "Fixes #12, closes acme/web#3"}, []Link{
+			{"ghe.corp.example/acme/api#12", TrackerGitHub, SourceBody},
+			{"ghe.corp.example/acme/web#3", TrackerGitHub, SourceBody},
+		}},
+		{"closing keywords on a gitlab host", PR{Repository: "gitlab.corp.example/payments/core/api", Body: "Closes #12 and payments/web#3"}, []Link{
+			{"gitlab.corp.example/payments/core/api#12", TrackerGitHub, SourceBody},
+		}},
+		{"closing keywords across gitlab subgroups", PR{Repository: "gitlab.corp.example/payments/core/api", Body: "Closes payments/core/web#3"}, []Link{
+			{"gitlab.corp.example/payments/core/web#3", TrackerGitHub, SourceBody},
+		}},
+		{"closing keyword in branch", PR{Repository: "acme/api", Branch: "fixes#12"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractor.Extract(tt.pr); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extract() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExtractWithoutKeyPattern tests that only GitHub issues are linked without a key pattern
+func TestExtractWithoutKeyPattern(t *testing.T) {
+	extractor, err := New("")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	got := extractor.Extract(PR{Repository: "acme/api", Title: "PAY-12: Retry", Body: "Resolves #5"})
+	want := []Link{{"acme/api#5", TrackerGitHub, SourceBody}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Extract() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,202 @@
+// Package jira fetches issues and their status history from the Jira REST API
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned for issue keys Jira doesn't know, or the user can't see
+var ErrNotFound = errors.New("issue not found")
+
+// timeLayout is how the Jira API formats timestamps
+const timeLayout = "2006-01-02T15:04:05.000-0700"
+
+// Issue is a Jira issue and its status transitions, oldest first
+type Issue struct {
+	Key         string
+	Summary     string
+	Status      string
+	CreatedAt   time.Time
+	ResolvedAt  *time.Time
+	Transitions []Transition
+}
+
+// Transition is a change of an issue's status
+type Transition struct {
+	From string
+	To   string
+	At   time.Time
+}
+
+// StartedAt returns when the issue first moved into one of the start statuses
+// (matched case-insensitively), or nil if it never has
+func (i *Issue) StartedAt(startStatuses []string) *time.Time {
+	for _, transition := range i.Transitions {
+		for _, status := range startStatuses {
+			if strings.EqualFold(transition.To, status) {
+				at := transition.At
+				return &at
+			}
+		}
+	}
+	return nil
+}
+
+// Client is a Jira REST API client. It uses API version 2, which Jira Cloud,
+// Server and Data Center all serve.
+type Client struct {
+	baseURL string
+	email   string
+	token   string
+	http    *http.Client
+}
+
+// NewClient creates a Jira client for the site at baseURL (e.g. https://acme.atlassian.net).
+// With an email the token is an API token (Jira Cloud); without one it is
+// sent as a personal access token (Server and Data Center).
+func NewClient(baseURL, email, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		email:   email,
+		token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// jiraTime parses Jira's timestamp format
+type jiraTime struct {
+	time.Time
+}
+
+// UnmarshalJSON parses a Jira timestamp, leaving null as the zero time
+func (t *jiraTime) UnmarshalJSON(data []byte) error {
+	var value *string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if value == nil {
+		return nil
+	}
+	parsed, err := time.Parse(timeLayout, *value)
+	if err != nil {
+		if parsed, err = time.Parse(time.RFC3339, *value); err != nil {
+			return fmt.Errorf("invalid Jira timestamp %q", *value)
+		}
+	}
+	t.Time = parsed
+	return nil
+}
+
+// history is a changelog entry
+type history struct {
+	Created jiraTime `json:"created"`
+	Items   []struct {
+		Field      string `json:"field"`
+		FromString string `json:"fromString"`
+		ToString   string `json:"toString"`
+	} `json:"items"`
+}
+
+// changelog is a page of an issue's changelog
+type changelog struct {
+	StartAt    int       `json:"startAt"`
+	MaxResults int       `json:"maxResults"`
+	Total      int       `json:"total"`
+	Histories  []history `json:"histories"` // When expanded on the issue
+	Values     []history `json:"values"`    // From the changelog endpoint
+}
+
+// FetchIssue fetches an issue with its full status history
+func (c *Client) FetchIssue(key string) (*Issue, error) {
+	var raw struct {
+		Key    string `json:"key"`
+		Fields struct {
+			Summary        string   `json:"summary"`
+			Created        jiraTime `json:"created"`
+			ResolutionDate jiraTime `json:"resolutiondate"`
+			Status         struct {
+				Name string `json:"name"`
+			} `json:"status"`
+		} `json:"fields"`
+		Changelog changelog `json:"changelog"`
+	}
+	query := url.Values{"fields": {"summary,status,created,resolutiondate"}, "expand": {"changelog"}}
+	if err := c.get("/rest/api/2/issue/"+url.PathEscape(key), query, &raw); err != nil {
+		return nil, err
+	}
+
+	issue := &Issue{
+		Key:       raw.Key,
+		Summary:   raw.Fields.Summary,
+		Status:    raw.Fields.Status.Name,
+		CreatedAt: raw.Fields.Created.Time,
+	}
+	if !raw.Fields.ResolutionDate.IsZero() {
+		resolvedAt := raw.Fields.ResolutionDate.Time
+		issue.ResolvedAt = &resolvedAt
+	}
+
+	// Jira Cloud only expands the first page of the changelog; fetch the rest
+	histories := raw.Changelog.Histories
+	for len(histories) < raw.Changelog.Total {
+		var page changelog
+		query := url.Values{"startAt": {fmt.Sprint(len(histories))}, "maxResults": {"100"}}
+		if err := c.get("/rest/api/2/issue/"+url.PathEscape(key)+"/changelog", query, &page); err != nil {
+			return nil, err
+		}
+		if len(page.Values) == 0 {
+			break
+		}
+		histories = append(histories, page.Values...)
+	}
+
+	for _, entry := range histories {
+		for _, item := range entry.Items {
+			if item.Field == "status" {
+				issue.Transitions = append(issue.Transitions, Transition{From: item.FromString, To: item.ToString, At: entry.Created.Time})
+			}
+		}
+	}
+	sort.SliceStable(issue.Transitions, func(i, j int) bool {
+		return issue.Transitions[i].At.Before(issue.Transitions[j].At)
+	})
+	return issue, nil
+}
+
+// get fetches a Jira API path and decodes the JSON response into out
+func (c *Client) get(path string, query url.Values, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Jira request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.email != "" {
+		req.SetBasicAuth(c.email, c.token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Jira: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("jira returned %s for %s", resp.Status, path)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode Jira response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// statusChange builds a changelog entry moving an issue between statuses
+func statusChange(created, from, to string) map[string]interface{} {
+	return map[string]interface{}{
+		"created": created,
+		"items": []map[string]string{
+			{"field": "assignee", "fromString": "", "toString": "Alice"},
+			{"field": "status", "fromString": from, "toString": to},
+		},
+	}
+}
+
+// newFakeJira serves PAY-12, whose changelog spans two pages, and nothing else
+func newFakeJira(t *testing.T) *Client {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/2/issue/PAY-12", func(w http.ResponseWriter, r *http.Request) {
+		if user, token, ok := r.BasicAuth(); !ok || user != "bot@acme.example" || token != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"key": "PAY-12",
+			"fields": map[string]interface{}{
+				"summary":        "Retry failed charges",
+				"created":        "2026-03-01T09:00:00.000+0000",
+				"resolutiondate": "2026-03-09T17:30:00.000+0100",
+				"status":         map[string]string{"name": "Done"},
+			},
+			"changelog": map[string]interface{}{
+				"startAt":    0,
+				"maxResults": 2,
+				"total":      3,
+				"histories": []interface{}{
+					statusChange("2026-03-02T10:00:00.000+0000", "To Do", "In Progress"),
+					statusChange("2026-03-03T10:00:00.000+0000", "In Progress", "Blocked"),
+				},
+			},
+		})
+	})
+	mux.HandleFunc("/rest/api/2/issue/PAY-12/changelog", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("startAt") != "2" {
+			t.Errorf("changelog requested from %s, want 2", r.URL.Query().Get("startAt"))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"startAt": 2,
+			"total":   3,
+			"values":  []interface{}{statusChange("2026-03-09T16:30:00.000+0000", "Blocked", "Done")},
+		})
+	})
+	mux.HandleFunc("/rest/api/2/issue/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL+"/", "bot@acme.example", "secret")
+}
+
+// TestFetchIssue tests fetching an issue with a paged changelog
+func TestFetchIssue(t *testing.T) {
+	client := newFakeJira(t)
+
+	issue, err := client.FetchIssue("PAY-12")
+	if err != nil {
+		t.Fatalf("FetchIssue() error = %v", err)
+	}
+	if issue.Summary != "Retry failed charges" || issue.Status != "Done" {
+		t.Errorf("FetchIssue() = %q (%s), want %q (Done)", issue.Summary, issue.Status, "Retry failed charges")
+	}
+	if want := time.Date(2026, 3, 9, 16, 30, 0, 0, time.UTC); issue.ResolvedAt == nil || !issue.ResolvedAt.Equal(want) {
+		t.Errorf("ResolvedAt = %v, want %v", issue.ResolvedAt, want)
+	}
+
+	var statuses []string
+	for _, transition := range issue.Transitions {
+		statuses = append(statuses, transition.To)
+	}
+	if len(statuses) != 3 || statuses[0] != "In Progress" || statuses[2] != "Done" {
+		t.Errorf("Transitions = %v, want [In Progress Blocked Done]", statuses)
+	}
+
+	startedAt := issue.StartedAt([]string{"in progress"})
+	if want := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC); startedAt == nil || !startedAt.Equal(want) {
+		t.Errorf("StartedAt() = %v, want %v", startedAt, want)
+	}
+	if startedAt := issue.StartedAt([]string{"In Review"}); startedAt != nil {
+		t.Errorf("StartedAt() = %v, want nil for a status never entered", startedAt)
+	}
+}
+
+// TestFetchIssueNotFound tests that unknown keys return ErrNotFound
+func TestFetchIssueNotFound(t *testing.T) {
+	client := newFakeJira(t)
+
+	if _, err := client.FetchIssue("SHA-256"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("FetchIssue() error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+)
+
+// Window is a backfill window of a job
+type Window struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Windows splits the time from since to until into windows of the given
+// number of days, the last one ending at until. Zero days gives one window.
+func Windows(since, until time.Time, days int) []Window {
+	if !since.Before(until) {
+		return nil
+	}
+	if days <= 0 {
+		return []Window{{Start: since, End: until}}
+	}
+
+	var windows []Window
+	for start := since; start.Before(until); {
+		end := start.AddDate(0, 0, days)
+		if end.After(until) {
+			end = until
+		}
+		windows = append(windows, Window{Start: start, End: end})
+		start = end
+	}
+	return windows
+}
+
+// Plan enqueues a job per repository: an incremental one without a since,
+// or one per window of windowDays days from since to until (now if nil).
+// It returns how many jobs were added; jobs already pending are skipped.
+func (q *Queue) Plan(repositories []string, since, until *time.Time, windowDays int) (int, error) {
+	if since == nil && until != nil {
+		return 0, fmt.Errorf("a backfill window needs a start")
+	}
+
+	added := 0
+	for _, repository := range repositories {
+		if since == nil {
+			ok, err := q.Enqueue(repository, nil, nil)
+			if err != nil {
+				return added, err
+			}
+			if ok {
+				added++
+			}
+			continue
+		}
+
+		end := q.now()
+		if until != nil {
+			end = *until
+		}
+		for _, window := range Windows(*since, end, windowDays) {
+			start, end := window.Start, window.End
+			ok, err := q.Enqueue(repository, &start, &end)
+			if err != nil {
+				return added, err
+			}
+			if ok {
+				added++
+			}
+		}
+	}
+	return added, nil
+}
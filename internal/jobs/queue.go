@@ -0,0 +1,241 @@
+// Package jobs queues repository collections in the database so that several
+// collector workers can share them. A planner enqueues a job per repository
+// (or per repository and backfill window); workers lease jobs, heartbeat while
+// collecting, and complete them or fail them for a retry with backoff. Jobs
+// out of attempts are moved to the dead-letter status 'dead'.
+package jobs
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+)
+
+// Job statuses
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead"
+)
+
+// Defaults for a queue created without a lease length or attempt limit
+const (
+	DefaultLease       = 5 * time.Minute
+	DefaultMaxAttempts = 5
+)
+
+// Retry backoff: the wait before the first retry, doubled for each further one
+const (
+	retryBackoff    = time.Minute
+	maxRetryBackoff = time.Hour
+)
+
+// ErrLeaseLost is returned when a worker's lease on a job expired and the job
+// was leased again, or finished, without it
+var ErrLeaseLost = errors.New("job lease lost")
+
+// Queue is the collection_jobs table
+type Queue struct {
+	db          *database.DB
+	lease       time.Duration // How long a lease lasts without a heartbeat
+	maxAttempts int
+	now         func() time.Time
+}
+
+// NewQueue creates a job queue whose leases last the given time between
+// heartbeats, and whose jobs are tried at most maxAttempts times (the
+// defaults if zero)
+func NewQueue(db *database.DB, lease time.Duration, maxAttempts int) *Queue {
+	if lease <= 0 {
+		lease = DefaultLease
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	return &Queue{
+		db:          db,
+		lease:       lease,
+		maxAttempts: maxAttempts,
+		now:         func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// LeaseDuration returns how long a lease lasts between heartbeats
+func (q *Queue) LeaseDuration() time.Duration {
+	return q.lease
+}
+
+// Enqueue adds a job collecting a repository over a window, or incrementally
+// without one. It reports false if the same job is already queued or running.
+func (q *Queue) Enqueue(repository string, windowStart, windowEnd *time.Time) (bool, error) {
+	now := q.now()
+	result, err := q.db.Exec(q.db.Rebind(`
+		INSERT INTO collection_jobs (job_key, repository, window_start, window_end, max_attempts, available_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (job_key) WHERE status IN ('queued', 'running') DO NOTHING
+	`), jobKey(repository, windowStart, windowEnd), repository, windowStart, windowEnd, q.maxAttempts, now, now, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to enqueue job for %s: %w", repository, err)
+	}
+	added, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to enqueue job for %s: %w", repository, err)
+	}
+	return added > 0, nil
+}
+
+// jobKey identifies a job by its repository and window
+func jobKey(repository string, windowStart, windowEnd *time.Time) string {
+	if windowStart == nil && windowEnd == nil {
+		return repository
+	}
+	key := repository + "@"
+	if windowStart != nil {
+		key += windowStart.UTC().Format(time.RFC3339)
+	}
+	key += ".."
+	if windowEnd != nil {
+		key += windowEnd.UTC().Format(time.RFC3339)
+	}
+	return key
+}
+
+// Lease leases the next ready job to a worker: the longest-waiting queued
+// job, or a running one whose lease expired. It returns nil if none is ready.
+// Expired jobs out of attempts are moved to 'dead' instead.
+func (q *Queue) Lease(worker string) (*database.CollectionJob, error) {
+	now := q.now()
+	if _, err := q.db.Exec(q.db.Rebind(`
+		UPDATE collection_jobs
+		SET status = 'dead', leased_by = NULL, lease_expires_at = NULL,
+			last_error = 'lease expired on the last attempt', updated_at = ?, finished_at = ?
+		WHERE status = 'running' AND lease_expires_at <= ? AND attempts >= max_attempts
+	`), now, now, now); err != nil {
+		return nil, fmt.Errorf("failed to bury expired jobs: %w", err)
+	}
+
+	// Workers on Postgres skip rows another worker is leasing; SQLite
+	// serializes writes, so the statement alone is enough there
+	lock := ""
+	if q.db.Driver() == "postgres" {
+		lock = "FOR UPDATE SKIP LOCKED"
+	}
+	var job database.CollectionJob
+	err := q.db.Get(&job, q.db.Rebind(`
+		UPDATE collection_jobs
+		SET status = 'running', attempts = attempts + 1, leased_by = ?, lease_expires_at = ?, updated_at = ?
+		WHERE id = (
+			SELECT id FROM collection_jobs
+			WHERE (status = 'queued' AND available_at <= ?) OR (status = 'running' AND lease_expires_at <= ?)
+			ORDER BY available_at, id
+			LIMIT 1
+			`+lock+`
+		)
+		RETURNING *
+	`), worker, now.Add(q.lease), now, now, now)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lease job: %w", err)
+	}
+	return &job, nil
+}
+
+// Heartbeat extends a worker's lease on a job
+func (q *Queue) Heartbeat(job *database.CollectionJob, worker string) error {
+	now := q.now()
+	return q.updateLeased(job, worker, "heartbeat", `
+		UPDATE collection_jobs SET lease_expires_at = ?, updated_at = ?
+		WHERE id = ? AND leased_by = ? AND status = 'running'
+	`, now.Add(q.lease), now, job.ID, worker)
+}
+
+// Complete marks a worker's job as succeeded
+func (q *Queue) Complete(job *database.CollectionJob, worker string) error {
+	now := q.now()
+	return q.updateLeased(job, worker, "complete", `
+		UPDATE collection_jobs
+		SET status = 'succeeded', leased_by = NULL, lease_expires_at = NULL, updated_at = ?, finished_at = ?
+		WHERE id = ? AND leased_by = ? AND status = 'running'
+	`, now, now, job.ID, worker)
+}
+
+// Fail records a worker's failed attempt at a job. The job is queued again
+// after a backoff, or moved to 'dead' if it is out of attempts. It returns
+// the job's new status.
+func (q *Queue) Fail(job *database.CollectionJob, worker string, cause error) (string, error) {
+	now := q.now()
+	if job.Attempts >= job.MaxAttempts {
+		return StatusDead, q.updateLeased(job, worker, "fail", `
+			UPDATE collection_jobs
+			SET status = 'dead', leased_by = NULL, lease_expires_at = NULL, last_error = ?, updated_at = ?, finished_at = ?
+			WHERE id = ? AND leased_by = ? AND status = 'running'
+		`, cause.Error(), now, now, job.ID, worker)
+	}
+	return StatusQueued, q.updateLeased(job, worker, "fail", `
+		UPDATE collection_jobs
+		SET status = 'queued', leased_by = NULL, lease_expires_at = NULL, last_error = ?, available_at = ?, updated_at = ?
+		WHERE id = ? AND leased_by = ? AND status = 'running'
+	`, cause.Error(), now.Add(backoff(job.Attempts)), now, job.ID, worker)
+}
+
+// Release gives a job back without counting the attempt, for a worker that
+// had to stop before finishing it
+func (q *Queue) Release(job *database.CollectionJob, worker string) error {
+	now := q.now()
+	return q.updateLeased(job, worker, "release", `
+		UPDATE collection_jobs
+		SET status = 'queued', attempts = attempts - 1, leased_by = NULL, lease_expires_at = NULL, available_at = ?, updated_at = ?
+		WHERE id = ? AND leased_by = ? AND status = 'running'
+	`, now, now, job.ID, worker)
+}
+
+// updateLeased runs an update of a job the worker holds the lease on,
+// returning ErrLeaseLost if it no longer does
+func (q *Queue) updateLeased(job *database.CollectionJob, worker, action, query string, args ...interface{}) error {
+	result, err := q.db.Exec(q.db.Rebind(query), args...)
+	if err != nil {
+		return fmt.Errorf("failed to %s job %d: %w", action, job.ID, err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to %s job %d: %w", action, job.ID, err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("failed to %s job %d: %w", action, job.ID, ErrLeaseLost)
+	}
+	return nil
+}
+
+// backoff returns the wait before retrying a job that failed its nth attempt
+func backoff(attempts int) time.Duration {
+	wait := retryBackoff
+	for i := 1; i < attempts && wait < maxRetryBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return wait
+}
+
+// Counts returns the number of jobs in each status
+func (q *Queue) Counts() (map[string]int, error) {
+	var rows []struct {
+		Status string `db:"status"`
+		Count  int    `db:"count"`
+	}
+	if err := q.db.Select(&rows, `SELECT status, COUNT(*) AS count FROM collection_jobs GROUP BY status`); err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+	counts := map[string]int{StatusQueued: 0, StatusRunning: 0, StatusSucceeded: 0, StatusDead: 0}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,362 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+)
+
+// newTestQueue creates a queue on a migrated SQLite database in a temp
+// directory, with a clock the test moves by hand
+func newTestQueue(t *testing.T, maxAttempts int) (*Queue, *time.Time) {
+	t.Helper()
+
+	db, err := database.Connect("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// Migrations are read relative to the repository root
+	originalDir, _ := os.Getwd()
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatalf("failed to change to repository root: %v", err)
+	}
+	defer os.Chdir(originalDir)
+
+	if err := db.RunMigrations(); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	now := time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC)
+	q := NewQueue(db, 5*time.Minute, maxAttempts)
+	q.now = func() time.Time { return now }
+	return q, &now
+}
+
+// mustLease leases a job, failing the test if none is ready
+func mustLease(t *testing.T, q *Queue, worker string) *database.CollectionJob {
+	t.Helper()
+	job, err := q.Lease(worker)
+	if err != nil {
+		t.Fatalf("Lease() error = %v", err)
+	}
+	if job == nil {
+		t.Fatal("Lease() = nil, want a job")
+	}
+	return job
+}
+
+// assertNoJob fails the test if a job is ready
+func assertNoJob(t *testing.T, q *Queue, worker string) {
+	t.Helper()
+	job, err := q.Lease(worker)
+	if err != nil {
+		t.Fatalf("Lease() error = %v", err)
+	}
+	if job != nil {
+		t.Fatalf("Lease() = job %d (%s), want none ready", job.ID, job.Repository)
+	}
+}
+
+// assertCounts checks the number of jobs in each status
+func assertCounts(t *testing.T, q *Queue, want map[string]int) {
+	t.Helper()
+	counts, err := q.Counts()
+	if err != nil {
+		t.Fatalf("Counts() error = %v", err)
+	}
+	for status, n := range want {
+		if counts[status] != n {
+			t.Errorf("Counts() = %v, want %v", counts, want)
+			return
+		}
+	}
+}
+
+// TestEnqueueDeduplicates tests that a job already pending isn't queued twice
+func TestEnqueueDeduplicates(t *testing.T) {
+	q, _ := newTestQueue(t, 5)
+
+	for i, want := range []bool{true, false} {
+		added, err := q.Enqueue("acme/api", nil, nil)
+		if err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+		if added != want {
+			t.Errorf("Enqueue() #%d = %v, want %v", i+1, added, want)
+		}
+	}
+
+	// A finished job can be queued again
+	job := mustLease(t, q, "a")
+	if err := q.Complete(job, "a"); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	added, err := q.Enqueue("acme/api", nil, nil)
+	if err != nil || !added {
+		t.Errorf("Enqueue() after completion = %v, %v, want true", added, err)
+	}
+	assertCounts(t, q, map[string]int{StatusQueued: 1, StatusSucceeded: 1})
+}
+
+// TestLeaseOrder tests that jobs are leased oldest first, each to one worker
+func TestLeaseOrder(t *testing.T) {
+	q, now := newTestQueue(t, 5)
+
+	for _, repo := range []string{"acme/api", "acme/web"} {
+		if _, err := q.Enqueue(repo, nil, nil); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+		*now = now.Add(time.Second)
+	}
+
+	first := mustLease(t, q, "a")
+	second := mustLease(t, q, "b")
+	if first.Repository != "acme/api" || second.Repository != "acme/web" {
+		t.Errorf("leased %s then %s, want acme/api then acme/web", first.Repository, second.Repository)
+	}
+	if first.Status != StatusRunning || first.Attempts != 1 || first.LeasedBy == nil || *first.LeasedBy != "a" {
+		t.Errorf("leased job = %+v, want running attempt 1 leased by a", first)
+	}
+	assertNoJob(t, q, "c")
+}
+
+// TestExpiredLease tests that a job whose lease expired goes to another worker
+func TestExpiredLease(t *testing.T) {
+	q, now := newTestQueue(t, 5)
+	if _, err := q.Enqueue("acme/api", nil, nil); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	job := mustLease(t, q, "a")
+
+	// Heartbeats keep the lease
+	*now = now.Add(4 * time.Minute)
+	if err := q.Heartbeat(job, "a"); err != nil {
+		t.Fatalf("Heartbeat() error = %v", err)
+	}
+	*now = now.Add(4 * time.Minute)
+	assertNoJob(t, q, "b")
+
+	// Without them it expires
+	*now = now.Add(2 * time.Minute)
+	stolen := mustLease(t, q, "b")
+	if stolen.ID != job.ID || stolen.Attempts != 2 {
+		t.Errorf("re-leased job = %+v, want job %d on attempt 2", stolen, job.ID)
+	}
+	if err := q.Heartbeat(job, "a"); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("Heartbeat() by the old worker error = %v, want ErrLeaseLost", err)
+	}
+	if err := q.Complete(job, "a"); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("Complete() by the old worker error = %v, want ErrLeaseLost", err)
+	}
+	if err := q.Complete(stolen, "b"); err != nil {
+		t.Errorf("Complete() error = %v", err)
+	}
+}
+
+// TestFailRetriesWithBackoff tests failed jobs waiting longer before each retry, then dying
+func TestFailRetriesWithBackoff(t *testing.T) {
+	q, now := newTestQueue(t, 3)
+	if _, err := q.Enqueue("acme/api", nil, nil); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	for attempt, wait := range []time.Duration{time.Minute, 2 * time.Minute} {
+		job := mustLease(t, q, "a")
+		status, err := q.Fail(job, "a", errors.New("rate limited"))
+		if err != nil || status != StatusQueued {
+			t.Fatalf("Fail() on attempt %d = %s, %v, want queued", attempt+1, status, err)
+		}
+		*now = now.Add(wait - time.Second)
+		assertNoJob(t, q, "a")
+		*now = now.Add(time.Second)
+	}
+
+	job := mustLease(t, q, "a")
+	status, err := q.Fail(job, "a", errors.New("rate limited"))
+	if err != nil || status != StatusDead {
+		t.Fatalf("Fail() on the last attempt = %s, %v, want dead", status, err)
+	}
+	*now = now.Add(24 * time.Hour)
+	assertNoJob(t, q, "a")
+	assertCounts(t, q, map[string]int{StatusQueued: 0, StatusRunning: 0, StatusDead: 1})
+
+	var lastError string
+	if err := q.db.Get(&lastError, "SELECT last_error FROM collection_jobs WHERE id = ?", job.ID); err != nil || lastError != "rate limited" {
+		t.Errorf("last_error = %q, %v, want the failure", lastError, err)
+	}
+
+	// A dead job doesn't block queuing the repository again
+	if added, err := q.Enqueue("acme/api", nil, nil); err != nil || !added {
+		t.Errorf("Enqueue() after death = %v, %v, want true", added, err)
+	}
+}
+
+// TestExpiredLastAttempt tests that a job whose last attempt's lease expired is moved to dead
+func TestExpiredLastAttempt(t *testing.T) {
+	q, now := newTestQueue(t, 2)
+	if _, err := q.Enqueue("acme/api", nil, nil); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	mustLease(t, q, "a")
+	*now = now.Add(10 * time.Minute)
+	mustLease(t, q, "b")
+	*now = now.Add(10 * time.Minute)
+	assertNoJob(t, q, "c")
+	assertCounts(t, q, map[string]int{StatusRunning: 0, StatusDead: 1})
+}
+
+// TestRelease tests that a released job is ready at once without using up an attempt
+func TestRelease(t *testing.T) {
+	q, _ := newTestQueue(t, 1)
+	if _, err := q.Enqueue("acme/api", nil, nil); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	job := mustLease(t, q, "a")
+	if err := q.Release(job, "a"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	job = mustLease(t, q, "b")
+	if job.Attempts != 1 {
+		t.Errorf("Attempts = %d, want 1", job.Attempts)
+	}
+}
+
+// TestBackoff tests the retry backoff doubling up to its cap
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{7, time.Hour},
+		{50, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// TestWindows tests splitting a backfill into windows
+func TestWindows(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name  string
+		since time.Time
+		until time.Time
+		days  int
+		want  []Window
+	}{
+		{"one window", day(1), day(20), 0, []Window{{day(1), day(20)}}},
+		{"even split", day(1), day(15), 7, []Window{{day(1), day(8)}, {day(8), day(15)}}},
+		{"short last window", day(1), day(10), 7, []Window{{day(1), day(8)}, {day(8), day(10)}}},
+		{"empty range", day(10), day(1), 7, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Windows(tt.since, tt.until, tt.days); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Windows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPlan tests planning incremental and windowed backfill jobs, skipping pending ones
+func TestPlan(t *testing.T) {
+	q, _ := newTestQueue(t, 5)
+	repos := []string{"acme/api", "acme/web"}
+
+	added, err := q.Plan(repos, nil, nil, 7)
+	if err != nil || added != 2 {
+		t.Fatalf("Plan() incremental = %d, %v, want 2", added, err)
+	}
+
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	added, err = q.Plan(repos, &since, &until, 7)
+	if err != nil || added != 4 {
+		t.Fatalf("Plan() backfill = %d, %v, want 4", added, err)
+	}
+	added, err = q.Plan(repos, &since, &until, 7)
+	if err != nil || added != 0 {
+		t.Errorf("Plan() again = %d, %v, want 0", added, err)
+	}
+
+	job := mustLease(t, q, "a")
+	if job.WindowStart != nil {
+		t.Errorf("first job = %+v, want the incremental one", job)
+	}
+	if _, err := q.Plan(repos, nil, &until, 7); err == nil {
+		t.Error("Plan() with until and no since error = nil, want an error")
+	}
+}
+
+// TestWorker tests a worker completing, retrying and releasing jobs until none is ready
+func TestWorker(t *testing.T) {
+	q, _ := newTestQueue(t, 5)
+	for _, repo := range []string{"acme/api", "acme/web", "acme/docs"} {
+		if _, err := q.Enqueue(repo, nil, nil); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	var ran []string
+	w := NewWorker(q, "a", func(ctx context.Context, job *database.CollectionJob) error {
+		ran = append(ran, job.Repository)
+		switch job.Repository {
+		case "acme/web":
+			return errors.New("not found")
+		case "acme/docs":
+			// Released jobs keep their attempt count, so count runs here
+			if len(ran) == 3 {
+				return ErrInterrupted
+			}
+		}
+		return nil
+	})
+
+	result, err := w.Work(context.Background())
+	if err != nil {
+		t.Fatalf("Work() error = %v", err)
+	}
+	// The failed job waits out its backoff; the released one is run again
+	want := WorkResult{Succeeded: 2, Retried: 1, Released: 1}
+	if result != want {
+		t.Errorf("Work() = %+v, want %+v", result, want)
+	}
+	if len(ran) != 4 {
+		t.Errorf("ran %v, want 4 runs", ran)
+	}
+	assertCounts(t, q, map[string]int{StatusQueued: 1, StatusSucceeded: 2})
+}
+
+// TestWorkerStops tests that a worker doesn't lease jobs once its context is done
+func TestWorkerStops(t *testing.T) {
+	q, _ := newTestQueue(t, 5)
+	if _, err := q.Enqueue("acme/api", nil, nil); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := NewWorker(q, "a", func(context.Context, *database.CollectionJob) error {
+		t.Error("ran a job after the context was done")
+		return nil
+	}).Work(ctx)
+	if err != nil || result != (WorkResult{}) {
+		t.Errorf("Work() = %+v, %v, want nothing done", result, err)
+	}
+}
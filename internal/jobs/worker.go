@@ -0,0 +1,127 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+)
+
+// ErrInterrupted is returned by a RunFunc stopped by its context before it
+// finished; the job is released for another worker instead of failed
+var ErrInterrupted = errors.New("job interrupted")
+
+// RunFunc collects a job's repository
+type RunFunc func(ctx context.Context, job *database.CollectionJob) error
+
+// Worker leases jobs from a queue and runs them
+type Worker struct {
+	queue *Queue
+	id    string
+	run   RunFunc
+}
+
+// WorkResult counts what a worker did with the jobs it leased
+type WorkResult struct {
+	Succeeded int `json:"succeeded"`
+	Retried   int `json:"retried"`  // Failed, queued again after a backoff
+	Dead      int `json:"dead"`     // Failed on their last attempt
+	Released  int `json:"released"` // Interrupted, queued again at once
+	Lost      int `json:"lost"`     // Leased again by another worker
+}
+
+// NewWorker creates a worker identified by id (unique among running workers)
+func NewWorker(queue *Queue, id string, run RunFunc) *Worker {
+	return &Worker{queue: queue, id: id, run: run}
+}
+
+// Work runs jobs until none is ready or ctx is done
+func (w *Worker) Work(ctx context.Context) (WorkResult, error) {
+	var result WorkResult
+	for ctx.Err() == nil {
+		job, err := w.queue.Lease(w.id)
+		if err != nil {
+			return result, err
+		}
+		if job == nil {
+			break
+		}
+
+		fmt.Printf("🧰 Job %d: %s (attempt %d/%d)\n", job.ID, job.Repository, job.Attempts, job.MaxAttempts)
+		if err := w.runJob(ctx, job, &result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// runJob runs a leased job, heartbeating while it runs, and records how it went
+func (w *Worker) runJob(ctx context.Context, job *database.CollectionJob, result *WorkResult) error {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lost := make(chan struct{})
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(w.queue.LeaseDuration() / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := w.queue.Heartbeat(job, w.id); errors.Is(err, ErrLeaseLost) {
+					close(lost)
+					cancel()
+					return
+				} else if err != nil {
+					fmt.Printf("  ⚠️  Failed to heartbeat job %d: %v\n", job.ID, err)
+				}
+			}
+		}
+	}()
+	runErr := w.run(jobCtx, job)
+	close(done)
+	<-stopped
+
+	select {
+	case <-lost:
+		fmt.Printf("  ⚠️  Lost the lease on job %d; another worker has it\n", job.ID)
+		result.Lost++
+		return nil
+	default:
+	}
+
+	var err error
+	switch {
+	case runErr == nil:
+		if err = w.queue.Complete(job, w.id); err == nil {
+			result.Succeeded++
+		}
+	case errors.Is(runErr, ErrInterrupted):
+		if err = w.queue.Release(job, w.id); err == nil {
+			fmt.Printf("  ⏱️  Job %d released\n", job.ID)
+			result.Released++
+		}
+	default:
+		var status string
+		if status, err = w.queue.Fail(job, w.id, runErr); err == nil {
+			fmt.Printf("  ❌ Job %d failed (%s): %v\n", job.ID, status, runErr)
+			if status == StatusDead {
+				result.Dead++
+			} else {
+				result.Retried++
+			}
+		}
+	}
+	if errors.Is(err, ErrLeaseLost) {
+		fmt.Printf("  ⚠️  Lost the lease on job %d; another worker has it\n", job.ID)
+		result.Lost++
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,260 @@
+// Package lock provides named locks that collector processes share through
+// the database, so overlapping runs (a schedule and a manual backfill, say)
+// never collect the same repository at once. Each lock records its owner and
+// expires unless its holder refreshes it.
+//
+// On SQLite the collection_locks row is the lock: it is taken with an upsert
+// that only succeeds while the existing row is expired, so the lock of a
+// holder that died is reclaimed once its TTL passes. On PostgreSQL the lock is
+// a session advisory lock (pg_try_advisory_lock) on a connection kept for as
+// long as it is held, which the server frees if the holder's connection ends;
+// the row then only records the owner.
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+)
+
+// DefaultTTL is how long a lock lasts without a refresh unless NewLocker is given one
+const DefaultTTL = 5 * time.Minute
+
+var (
+	// ErrHeld is returned when another owner holds a lock
+	ErrHeld = errors.New("lock is held")
+
+	// ErrLost is returned when refreshing a lock that expired and was taken over
+	ErrLost = errors.New("lock lost")
+)
+
+// DefaultOwner identifies this process as a lock owner: its host and process ID
+func DefaultOwner() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// Locker acquires locks for an owner
+type Locker struct {
+	db    *database.DB
+	owner string
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// NewLocker creates a locker whose locks expire ttl after they were last
+// refreshed (DefaultTTL if zero); held locks are refreshed every third of it
+func NewLocker(db *database.DB, owner string, ttl time.Duration) *Locker {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Locker{
+		db:    db,
+		owner: owner,
+		ttl:   ttl,
+		now:   func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Lock is a held lock
+type Lock struct {
+	locker *Locker
+	name   string
+	token  string
+	conn   *sql.Conn // Holds the advisory lock on PostgreSQL
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stop    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+	err     error
+}
+
+// Acquire takes a lock without waiting. It returns an error wrapping ErrHeld,
+// naming the owner, if another one holds it.
+func (l *Locker) Acquire(ctx context.Context, name string) (*Lock, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	lock := &Lock{locker: l, name: name, token: token}
+
+	now := l.now()
+	upsert := `
+		INSERT INTO collection_locks (name, owner, token, acquired_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET
+			owner = excluded.owner,
+			token = excluded.token,
+			acquired_at = excluded.acquired_at,
+			expires_at = excluded.expires_at
+	`
+	args := []interface{}{name, l.owner, token, now, now.Add(l.ttl)}
+
+	if l.db.Driver() == "postgres" {
+		conn, err := l.db.Conn(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+		}
+		var acquired bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", advisoryKey(name)).Scan(&acquired); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+		}
+		if !acquired {
+			conn.Close()
+			return nil, l.heldError(name)
+		}
+		lock.conn = conn
+
+		// Holding the advisory lock, any row left behind is stale
+		if _, err := conn.ExecContext(ctx, l.db.Rebind(upsert), args...); err != nil {
+			lock.release()
+			return nil, fmt.Errorf("failed to record lock %s: %w", name, err)
+		}
+	} else {
+		result, err := l.db.Exec(upsert+" WHERE collection_locks.expires_at <= ?", append(args, now)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+		}
+		acquired, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+		}
+		if acquired == 0 {
+			return nil, l.heldError(name)
+		}
+	}
+
+	lock.ctx, lock.cancel = context.WithCancel(ctx)
+	lock.stop, lock.stopped = make(chan struct{}), make(chan struct{})
+	go lock.keepAlive()
+	return lock, nil
+}
+
+// heldError describes who holds a lock
+func (l *Locker) heldError(name string) error {
+	var holder struct {
+		Owner     string    `db:"owner"`
+		ExpiresAt time.Time `db:"expires_at"`
+	}
+	if err := l.db.Get(&holder, l.db.Rebind("SELECT owner, expires_at FROM collection_locks WHERE name = ?"), name); err != nil {
+		return fmt.Errorf("%w: %s", ErrHeld, name)
+	}
+	return fmt.Errorf("%w: %s by %s (until %s)", ErrHeld, name, holder.Owner, holder.ExpiresAt.UTC().Format(time.RFC3339))
+}
+
+// Context returns a context that is done when the lock is lost or released
+func (lk *Lock) Context() context.Context {
+	return lk.ctx
+}
+
+// keepAlive refreshes the lock until it is released, cancelling its context
+// if it is lost
+func (lk *Lock) keepAlive() {
+	defer close(lk.stopped)
+	ticker := time.NewTicker(lk.locker.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lk.stop:
+			return
+		case <-ticker.C:
+			err := lk.refresh()
+			if errors.Is(err, ErrLost) {
+				fmt.Printf("  ⚠️  Lost lock %s: another run took it over\n", lk.name)
+				lk.cancel()
+				return
+			}
+			if err != nil {
+				// Retried on the next tick; the lock only goes once it expires
+				fmt.Printf("  ⚠️  Failed to refresh lock %s: %v\n", lk.name, err)
+			}
+		}
+	}
+}
+
+// refresh extends the lock's expiry, returning ErrLost if it was taken over
+func (lk *Lock) refresh() error {
+	l := lk.locker
+	query := l.db.Rebind("UPDATE collection_locks SET expires_at = ? WHERE name = ? AND token = ?")
+	args := []interface{}{l.now().Add(l.ttl), lk.name, lk.token}
+
+	var result sql.Result
+	var err error
+	if lk.conn != nil {
+		// The advisory lock goes with its connection, so losing it loses the lock
+		if result, err = lk.conn.ExecContext(context.Background(), query, args...); err != nil {
+			return fmt.Errorf("%w: %v", ErrLost, err)
+		}
+	} else if result, err = l.db.Exec(query, args...); err != nil {
+		return err
+	}
+	refreshed, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if refreshed == 0 {
+		return ErrLost
+	}
+	return nil
+}
+
+// Release gives the lock up. A lock that was lost is left to its new holder.
+func (lk *Lock) Release() error {
+	lk.once.Do(func() {
+		close(lk.stop)
+		<-lk.stopped
+		lk.cancel()
+		lk.err = lk.release()
+	})
+	return lk.err
+}
+
+// release deletes the lock's row and frees the advisory lock
+func (lk *Lock) release() error {
+	l := lk.locker
+	query := l.db.Rebind("DELETE FROM collection_locks WHERE name = ? AND token = ?")
+	if lk.conn == nil {
+		if _, err := l.db.Exec(query, lk.name, lk.token); err != nil {
+			return fmt.Errorf("failed to release lock %s: %w", lk.name, err)
+		}
+		return nil
+	}
+
+	defer lk.conn.Close()
+	ctx := context.Background()
+	if _, err := lk.conn.ExecContext(ctx, query, lk.name, lk.token); err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", lk.name, err)
+	}
+	if _, err := lk.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", advisoryKey(lk.name)); err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", lk.name, err)
+	}
+	return nil
+}
+
+// advisoryKey maps a lock name to a PostgreSQL advisory lock key
+func advisoryKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("collection_locks:" + name))
+	return int64(h.Sum64())
+}
+
+// newToken returns a random token identifying one acquisition of a lock
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,136 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+)
+
+// newTestDB creates a migrated SQLite database in a temp directory
+func newTestDB(t *testing.T) *database.DB {
+	t.Helper()
+
+	db, err := database.Connect("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// Migrations are read relative to the repository root
+	originalDir, _ := os.Getwd()
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatalf("failed to change to repository root: %v", err)
+	}
+	defer os.Chdir(originalDir)
+
+	if err := db.RunMigrations(); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+	return db
+}
+
+// newTestLocker creates a locker for owner with a clock shared by the test
+func newTestLocker(db *database.DB, owner string, now *time.Time) *Locker {
+	l := NewLocker(db, owner, time.Minute)
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+// mustAcquire acquires a lock, failing the test if it can't
+func mustAcquire(t *testing.T, l *Locker, name string) *Lock {
+	t.Helper()
+	lock, err := l.Acquire(context.Background(), name)
+	if err != nil {
+		t.Fatalf("Acquire(%s) error = %v", name, err)
+	}
+	t.Cleanup(func() { lock.Release() })
+	return lock
+}
+
+// TestAcquire tests that a lock has one holder at a time, and locks of different names don't conflict
+func TestAcquire(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC)
+	a := newTestLocker(db, "host-a-1", &now)
+	b := newTestLocker(db, "host-b-2", &now)
+
+	lock := mustAcquire(t, a, "repository:acme/api")
+	_, err := b.Acquire(context.Background(), "repository:acme/api")
+	if !errors.Is(err, ErrHeld) {
+		t.Fatalf("Acquire() of a held lock error = %v, want ErrHeld", err)
+	}
+	if !strings.Contains(err.Error(), "by host-a-1 (until 2026-03-02T06:01:00Z)") {
+		t.Errorf("Acquire() error = %v, want the owner and expiry", err)
+	}
+	mustAcquire(t, b, "repository:acme/web")
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if lock.Context().Err() == nil {
+		t.Error("Context() isn't done after Release()")
+	}
+	mustAcquire(t, b, "repository:acme/api")
+}
+
+// TestStaleLock tests reclaiming an expired lock, and its old holder losing it
+func TestStaleLock(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC)
+	a := newTestLocker(db, "host-a-1", &now)
+	b := newTestLocker(db, "host-b-2", &now)
+
+	stale := mustAcquire(t, a, "repository:acme/api")
+
+	// Refreshed, the lock holds past its first expiry
+	now = now.Add(50 * time.Second)
+	if err := stale.refresh(); err != nil {
+		t.Fatalf("refresh() error = %v", err)
+	}
+	now = now.Add(50 * time.Second)
+	if _, err := b.Acquire(context.Background(), "repository:acme/api"); !errors.Is(err, ErrHeld) {
+		t.Fatalf("Acquire() of a refreshed lock error = %v, want ErrHeld", err)
+	}
+
+	// Its holder stops refreshing it, and it expires
+	now = now.Add(time.Minute)
+	reclaimed := mustAcquire(t, b, "repository:acme/api")
+	if err := stale.refresh(); !errors.Is(err, ErrLost) {
+		t.Errorf("refresh() by the old holder error = %v, want ErrLost", err)
+	}
+
+	// The old holder releasing doesn't free the new holder's lock
+	if err := stale.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := a.Acquire(context.Background(), "repository:acme/api"); !errors.Is(err, ErrHeld) {
+		t.Errorf("Acquire() after the old holder released error = %v, want ErrHeld", err)
+	}
+	if reclaimed.Context().Err() != nil {
+		t.Error("the new holder's Context() is done")
+	}
+}
+
+// TestLostLockCancelsContext tests that a holder's context is done once its lock is taken over
+func TestLostLockCancelsContext(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC)
+	a := NewLocker(db, "host-a-1", 30*time.Millisecond)
+	a.now = func() time.Time { return now }
+
+	lock := mustAcquire(t, a, "repository:acme/api")
+	if _, err := db.Exec("UPDATE collection_locks SET token = 'taken over'"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-lock.Context().Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Context() isn't done after the lock was lost")
+	}
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// Errors returned by the admin operations
+var (
+	ErrNotFound      = errors.New("repository not found")
+	ErrExists        = errors.New("repository already exists")
+	ErrConfigManaged = errors.New("repository is managed by the team config")
+	ErrInvalid       = errors.New("invalid request")
+)
+
+// Audit log actions
+const (
+	AuditRepositoryCreate = "repository.create"
+	AuditRepositoryUpdate = "repository.update"
+)
+
+// Changes lists the repository settings to set; nil fields are left unchanged.
+// A TeamID or LookbackDays of 0, or an empty string, clears the setting.
+type Changes struct {
+	DefaultBranch         *string
+	TeamID                *int
+	Tags                  *[]string
+	BaseBranches          *[]string // Empty counts PRs into every base
+	ServiceGroup          *string
+	Active                *bool
+	LookbackDays          *int
+	DeploymentSource      *string
+	DeploymentEnvironment *string
+}
+
+// Get returns a repository by ID
+func (r *Registry) Get(id int) (*database.Repository, error) {
+	repo, err := getRepository(r.db, id)
+	if err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+// Create adds a repository managed through the admin API. It is active unless the changes say otherwise.
+func (r *Registry) Create(actor, name string, changes Changes) (*database.Repository, error) {
+	name = strings.TrimSpace(name)
+	repo := database.Repository{Name: name, Active: true, Source: "api", Tags: []string{}, BaseBranches: []string{}}
+	if err := apply(&repo, changes); err != nil {
+		return nil, err
+	}
+
+	var created database.Repository
+	err := r.inTx(func(tx *sqlx.Tx) error {
+		var exists int
+		if err := tx.Get(&exists, "SELECT COUNT(*) FROM repositories WHERE name = ?", name); err != nil {
+			return fmt.Errorf("failed to check repository name: %w", err)
+		}
+		if exists > 0 {
+			return ErrExists
+		}
+		if err := checkTeam(tx, repo.TeamID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		query := `
+			INSERT INTO repositories (
+				name, default_branch, team_id, service_group, active, lookback_days,
+				deployment_source, deployment_environment, source, created_at, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, 'api', ?, ?)
+			RETURNING id
+		`
+		var id int
+		err := tx.QueryRow(query,
+			repo.Name, repo.DefaultBranch, repo.TeamID, repo.ServiceGroup, repo.Active,
+			repo.LookbackDays, repo.DeploymentSource, repo.DeploymentEnvironment, now, now,
+		).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to create repository '%s': %w", name, err)
+		}
+		if err := setLists(tx, id, repo.Tags, repo.BaseBranches); err != nil {
+			return err
+		}
+		if err := writeAudit(tx, actor, AuditRepositoryCreate, repo, changeDetails(changes), now); err != nil {
+			return err
+		}
+
+		created, err = getRepository(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Update changes the settings of a repository managed through the admin API.
+// Repositories from the team config can't be changed, since the next sync would undo it.
+func (r *Registry) Update(actor string, id int, changes Changes) (*database.Repository, error) {
+	var updated database.Repository
+	err := r.inTx(func(tx *sqlx.Tx) error {
+		repo, err := getRepository(tx, id)
+		if err != nil {
+			return err
+		}
+		if repo.Source != "api" {
+			return fmt.Errorf("%w: change '%s' in the team config instead", ErrConfigManaged, repo.Name)
+		}
+		if err := apply(&repo, changes); err != nil {
+			return err
+		}
+		if err := checkTeam(tx, repo.TeamID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		query := `
+			UPDATE repositories SET
+				default_branch = ?, team_id = ?, service_group = ?, active = ?, lookback_days = ?,
+				deployment_source = ?, deployment_environment = ?, updated_at = ?
+			WHERE id = ?
+		`
+		_, err = tx.Exec(query,
+			repo.DefaultBranch, repo.TeamID, repo.ServiceGroup, repo.Active, repo.LookbackDays,
+			repo.DeploymentSource, repo.DeploymentEnvironment, now, id,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update repository '%s': %w", repo.Name, err)
+		}
+		if changes.Tags != nil {
+			if err := setLists(tx, id, repo.Tags, repo.BaseBranches); err != nil {
+				return err
+			}
+		}
+		if err := writeAudit(tx, actor, AuditRepositoryUpdate, repo, changeDetails(changes), now); err != nil {
+			return err
+		}
+
+		updated, err = getRepository(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// apply sets the changed fields on repo and validates the result
+func apply(repo *database.Repository, changes Changes) error {
+	if changes.DefaultBranch != nil {
+		repo.DefaultBranch = nullString(strings.TrimSpace(*changes.DefaultBranch))
+	}
+	if changes.TeamID != nil {
+		repo.TeamID = nullInt(*changes.TeamID)
+	}
+	if changes.Tags != nil {
+		repo.Tags = *changes.Tags
+	}
+	if changes.BaseBranches != nil {
+		repo.BaseBranches = *changes.BaseBranches
+	}
+	if changes.ServiceGroup != nil {
+		repo.ServiceGroup = nullString(strings.TrimSpace(*changes.ServiceGroup))
+	}
+	if changes.Active != nil {
+		repo.Active = *changes.Active
+	}
+	if changes.LookbackDays != nil {
+		repo.LookbackDays = nullInt(*changes.LookbackDays)
+	}
+	if changes.DeploymentSource != nil {
+		repo.DeploymentSource = nullString(*changes.DeploymentSource)
+	}
+	if changes.DeploymentEnvironment != nil {
+		repo.DeploymentEnvironment = nullString(*changes.DeploymentEnvironment)
+	}
+
+	// The same rules as repositories in the team config file
+	settings := config.RepositoryConfig{
+		Name:                  repo.Name,
+		Tags:                  repo.Tags,
+		BaseBranches:          repo.BaseBranches,
+		LookbackDays:          value(repo.LookbackDays),
+		DeploymentSource:      value(repo.DeploymentSource),
+		DeploymentEnvironment: value(repo.DeploymentEnvironment),
+	}
+	if err := settings.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return nil
+}
+
+// checkTeam ensures the owning team exists
+func checkTeam(db sqlx.Queryer, teamID *int) error {
+	if teamID == nil {
+		return nil
+	}
+	var exists int
+	if err := sqlx.Get(db, &exists, "SELECT COUNT(*) FROM teams WHERE id = ?", *teamID); err != nil {
+		return fmt.Errorf("failed to check team: %w", err)
+	}
+	if exists == 0 {
+		return fmt.Errorf("%w: team %d not found", ErrInvalid, *teamID)
+	}
+	return nil
+}
+
+// getRepository loads a repository and its tags by ID
+func getRepository(db sqlx.Queryer, id int) (database.Repository, error) {
+	var repo database.Repository
+	err := sqlx.Get(db, &repo, "SELECT * FROM repositories WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repo, ErrNotFound
+	}
+	if err != nil {
+		return repo, fmt.Errorf("failed to load repository %d: %w", id, err)
+	}
+	err = loadLists(db, &repo)
+	return repo, err
+}
+
+// writeAudit records an admin change to a repository in the audit log
+func writeAudit(db sqlx.Execer, actor, action string, repo database.Repository, details map[string]interface{}, now time.Time) error {
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit details: %w", err)
+	}
+	detailsJSON := string(encoded)
+
+	query := `
+		INSERT INTO team_audit_log (actor, action, team_id, repository, details, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	if _, err := db.Exec(query, actor, action, repo.TeamID, repo.Name, detailsJSON, now); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// changeDetails describes the requested changes for the audit log
+func changeDetails(changes Changes) map[string]interface{} {
+	details := make(map[string]interface{})
+	if changes.DefaultBranch != nil {
+		details["default_branch"] = *changes.DefaultBranch
+	}
+	if changes.TeamID != nil {
+		details["team_id"] = *changes.TeamID
+	}
+	if changes.Tags != nil {
+		details["tags"] = *changes.Tags
+	}
+	if changes.BaseBranches != nil {
+		details["base_branches"] = *changes.BaseBranches
+	}
+	if changes.ServiceGroup != nil {
+		details["service_group"] = *changes.ServiceGroup
+	}
+	if changes.Active != nil {
+		details["active"] = *changes.Active
+	}
+	if changes.LookbackDays != nil {
+		details["lookback_days"] = *changes.LookbackDays
+	}
+	if changes.DeploymentSource != nil {
+		details["deployment_source"] = *changes.DeploymentSource
+	}
+	if changes.DeploymentEnvironment != nil {
+		details["deployment_environment"] = *changes.DeploymentEnvironment
+	}
+	return details
+}
+
+// value dereferences an optional setting, giving the zero value if unset
+func value[T any](ptr *T) T {
+	var zero T
+	if ptr == nil {
+		return zero
+	}
+	return *ptr
+}
@@ -0,0 +1,286 @@
+// Package repository keeps the tracked repositories and their settings in sync
+// with the team config and the admin API.
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// Registry manages the repositories table
+type Registry struct {
+	db *database.DB
+}
+
+// NewRegistry creates a repository registry
+func NewRegistry(db *database.DB) *Registry {
+	return &Registry{db: db}
+}
+
+// Sync upserts the configured repositories and their settings. Repositories
+// dropped from config are kept, with their history, but no longer collected;
+// repositories added through the admin API are left alone.
+// An empty config is treated as "not configured" rather than "deactivate everything".
+func (r *Registry) Sync(repos []config.RepositoryConfig) error {
+	now := time.Now()
+	configured := make(map[string]bool, len(repos))
+
+	return r.inTx(func(tx *sqlx.Tx) error {
+		for _, repo := range repos {
+			teamID, err := teamIDByName(tx, repo.Team)
+			if err != nil {
+				return err
+			}
+			if repo.Team != "" && teamID == nil {
+				fmt.Printf("  ⚠️  Repository %s: owning team '%s' not found\n", repo.Name, repo.Team)
+			}
+
+			query := `
+				INSERT INTO repositories (
+					name, default_branch, team_id, service_group, active, lookback_days,
+					deployment_source, deployment_environment, git_path, source, created_at, updated_at
+				) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 'config', ?, ?)
+				ON CONFLICT(name) DO UPDATE SET
+					default_branch = excluded.default_branch,
+					team_id = excluded.team_id,
+					service_group = excluded.service_group,
+					active = excluded.active,
+					lookback_days = excluded.lookback_days,
+					deployment_source = excluded.deployment_source,
+					deployment_environment = excluded.deployment_environment,
+					git_path = excluded.git_path,
+					source = 'config',
+					updated_at = excluded.updated_at
+				RETURNING id
+			`
+			var id int
+			err = tx.QueryRow(query,
+				repo.Name, nullString(repo.DefaultBranch), teamID, nullString(repo.ServiceGroup), repo.IsActive(),
+				nullInt(repo.LookbackDays), nullString(repo.DeploymentSource), nullString(repo.DeploymentEnvironment),
+				nullString(repo.GitPath), now, now,
+			).Scan(&id)
+			if err != nil {
+				return fmt.Errorf("failed to upsert repository '%s': %w", repo.Name, err)
+			}
+			if err := setLists(tx, id, repo.Tags, repo.BaseBranches); err != nil {
+				return err
+			}
+			configured[repo.Name] = true
+		}
+
+		if len(repos) == 0 {
+			return nil
+		}
+		var names []string
+		if err := tx.Select(&names, "SELECT name FROM repositories WHERE source = 'config' AND active = ?", true); err != nil {
+			return fmt.Errorf("failed to list repositories: %w", err)
+		}
+		for _, name := range names {
+			if configured[name] {
+				continue
+			}
+			query := "UPDATE repositories SET active = ?, updated_at = ? WHERE name = ?"
+			if _, err := tx.Exec(query, false, now, name); err != nil {
+				return fmt.Errorf("failed to deactivate repository '%s': %w", name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Preview returns the repositories Active would return after syncing the
+// configured ones, computed from reads only so a dry run never takes a write
+// lock. Repositories not in the database yet have no ID.
+func (r *Registry) Preview(repos []config.RepositoryConfig) ([]database.Repository, error) {
+	active, err := r.Active()
+	if err != nil || len(repos) == 0 {
+		return active, err
+	}
+
+	existing := make(map[string]database.Repository, len(active))
+	for _, repo := range active {
+		existing[repo.Name] = repo
+	}
+	configured := make(map[string]bool, len(repos))
+	var preview []database.Repository
+	for _, repo := range repos {
+		configured[repo.Name] = true
+		if !repo.IsActive() {
+			continue
+		}
+		teamID, err := teamIDByName(r.db, repo.Team)
+		if err != nil {
+			return nil, err
+		}
+		previewed := existing[repo.Name]
+		previewed.Name = repo.Name
+		previewed.DefaultBranch = nullString(repo.DefaultBranch)
+		previewed.TeamID = teamID
+		previewed.ServiceGroup = nullString(repo.ServiceGroup)
+		previewed.Active = true
+		previewed.LookbackDays = nullInt(repo.LookbackDays)
+		previewed.DeploymentSource = nullString(repo.DeploymentSource)
+		previewed.DeploymentEnvironment = nullString(repo.DeploymentEnvironment)
+		previewed.GitPath = nullString(repo.GitPath)
+		previewed.Source = "config"
+		previewed.Tags = sortedValues(repo.Tags)
+		previewed.BaseBranches = sortedValues(repo.BaseBranches)
+		preview = append(preview, previewed)
+	}
+	// Sync deactivates config repositories dropped from it and leaves API ones alone
+	for _, repo := range active {
+		if !configured[repo.Name] && repo.Source != "config" {
+			preview = append(preview, repo)
+		}
+	}
+
+	sort.Slice(preview, func(i, j int) bool { return preview[i].Name < preview[j].Name })
+	return preview, nil
+}
+
+// Active returns the repositories to collect, by name
+func (r *Registry) Active() ([]database.Repository, error) {
+	var repos []database.Repository
+	if err := r.db.Select(&repos, "SELECT * FROM repositories WHERE active = ? ORDER BY name", true); err != nil {
+		return nil, fmt.Errorf("failed to load repositories: %w", err)
+	}
+	for i := range repos {
+		if err := loadLists(r.db, &repos[i]); err != nil {
+			return nil, err
+		}
+	}
+	return repos, nil
+}
+
+// inTx runs fn in a transaction
+func (r *Registry) inTx(fn func(tx *sqlx.Tx) error) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// teamIDByName looks up a team by name; an empty name or unknown team gives nil
+func teamIDByName(db sqlx.Queryer, name string) (*int, error) {
+	if name == "" {
+		return nil, nil
+	}
+	var id int
+	err := sqlx.Get(db, &id, "SELECT id FROM teams WHERE name = ?", name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up team '%s': %w", name, err)
+	}
+	return &id, nil
+}
+
+// Per-repository settings that are lists are stored one value per row
+var (
+	tagList        = valueList{table: "repository_tags", column: "tag"}
+	baseBranchList = valueList{table: "repository_base_branches", column: "pattern"}
+)
+
+// valueList is a table of repository_id and value rows
+type valueList struct {
+	table  string
+	column string
+}
+
+// set replaces a repository's values, skipping blanks and duplicates
+func (l valueList) set(db sqlx.Execer, repositoryID int, values []string) error {
+	if _, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE repository_id = ?", l.table), repositoryID); err != nil {
+		return fmt.Errorf("failed to clear %s of repository %d: %w", l.table, repositoryID, err)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (repository_id, %s) VALUES (?, ?)", l.table, l.column)
+	for _, value := range uniqueValues(values) {
+		if _, err := db.Exec(query, repositoryID, value); err != nil {
+			return fmt.Errorf("failed to add %s '%s' to repository %d: %w", l.column, value, repositoryID, err)
+		}
+	}
+	return nil
+}
+
+// get loads a repository's values in order
+func (l valueList) get(db sqlx.Queryer, repositoryID int) ([]string, error) {
+	values := []string{}
+	query := fmt.Sprintf("SELECT %[2]s FROM %[1]s WHERE repository_id = ? ORDER BY %[2]s", l.table, l.column)
+	if err := sqlx.Select(db, &values, query, repositoryID); err != nil {
+		return nil, fmt.Errorf("failed to load %s of repository %d: %w", l.table, repositoryID, err)
+	}
+	return values, nil
+}
+
+// uniqueValues trims values, skipping blanks and duplicates
+func uniqueValues(values []string) []string {
+	unique := []string{}
+	seen := make(map[string]bool)
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		unique = append(unique, value)
+	}
+	return unique
+}
+
+// sortedValues returns values as get loads them once stored
+func sortedValues(values []string) []string {
+	unique := uniqueValues(values)
+	sort.Strings(unique)
+	return unique
+}
+
+// setLists stores a repository's tags and base branch patterns
+func setLists(db sqlx.Execer, repositoryID int, tags, baseBranches []string) error {
+	if err := tagList.set(db, repositoryID, tags); err != nil {
+		return err
+	}
+	return baseBranchList.set(db, repositoryID, baseBranches)
+}
+
+// loadLists fills in a repository's tags and base branch patterns
+func loadLists(db sqlx.Queryer, repo *database.Repository) error {
+	var err error
+	if repo.Tags, err = tagList.get(db, repo.ID); err != nil {
+		return err
+	}
+	repo.BaseBranches, err = baseBranchList.get(db, repo.ID)
+	return err
+}
+
+// nullString stores an empty setting as NULL
+func nullString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// nullInt stores a zero setting as NULL
+func nullInt(value int) *int {
+	if value == 0 {
+		return nil
+	}
+	return &value
+}
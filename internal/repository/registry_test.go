@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+)
+
+// newTestDB creates a migrated SQLite database in a temp directory
+func newTestDB(t *testing.T) *database.DB {
+	t.Helper()
+
+	db, err := database.Connect("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// Migrations are read relative to the repository root
+	originalDir, _ := os.Getwd()
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatalf("failed to change to repository root: %v", err)
+	}
+	defer os.Chdir(originalDir)
+
+	if err := db.RunMigrations(); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO teams (name) VALUES ('Platform')"); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+	return db
+}
+
+// activeNames returns the names of the active repositories
+func activeNames(t *testing.T, r *Registry) []string {
+	t.Helper()
+	repos, err := r.Active()
+	if err != nil {
+		t.Fatalf("Active() error = %v", err)
+	}
+	names := []string{}
+	for _, repo := range repos {
+		names = append(names, repo.Name)
+	}
+	return names
+}
+
+// TestSync tests syncing repository settings from config across runs
+func TestSync(t *testing.T) {
+	db := newTestDB(t)
+	r := NewRegistry(db)
+
+	inactive := false
+	err := r.Sync([]config.RepositoryConfig{
+		{Name: "acme/api", Team: "Platform", Tags: []string{"backend", "go", "backend"}, LookbackDays: 30, DeploymentSource: "releases", BaseBranches: []string{"release/*", "default"}},
+		{Name: "acme/web"},
+		{Name: "acme/legacy", Active: &inactive},
+	})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if got, want := activeNames(t, r), []string{"acme/api", "acme/web"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("active repositories = %v, want %v", got, want)
+	}
+
+	repos, _ := r.Active()
+	api := repos[0]
+	if api.TeamID == nil || api.LookbackDays == nil || *api.LookbackDays != 30 || api.Source != "config" {
+		t.Errorf("acme/api settings = %+v", api)
+	}
+	if !reflect.DeepEqual(api.Tags, []string{"backend", "go"}) {
+		t.Errorf("acme/api tags = %v, want [backend go]", api.Tags)
+	}
+	if !reflect.DeepEqual(api.BaseBranches, []string{"default", "release/*"}) {
+		t.Errorf("acme/api base branches = %v, want [default release/*]", api.BaseBranches)
+	}
+
+	// An API-managed repository survives config changes
+	if _, err := r.Create("alice", "acme/tools", Changes{}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Dropping a repository from config stops collecting it; clearing a setting clears it
+	if err := r.Sync([]config.RepositoryConfig{{Name: "acme/api"}}); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if got, want := activeNames(t, r), []string{"acme/api", "acme/tools"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("active repositories after resync = %v, want %v", got, want)
+	}
+	repos, _ = r.Active()
+	if repos[0].TeamID != nil || repos[0].LookbackDays != nil || len(repos[0].Tags) != 0 || len(repos[0].BaseBranches) != 0 {
+		t.Errorf("acme/api settings after resync = %+v", repos[0])
+	}
+
+	// An empty config leaves everything as it was
+	if err := r.Sync(nil); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if got := activeNames(t, r); len(got) != 2 {
+		t.Errorf("active repositories after empty sync = %v, want 2", got)
+	}
+}
+
+// TestPreview tests that a preview lists what Active returns after a sync,
+// without writing anything
+func TestPreview(t *testing.T) {
+	db := newTestDB(t)
+	r := NewRegistry(db)
+	if err := r.Sync([]config.RepositoryConfig{{Name: "acme/api", Tags: []string{"go"}}, {Name: "acme/web"}}); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if _, err := r.Create("alice", "acme/tools", Changes{}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := r.Create("alice", "acme/docs", Changes{}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Settings changed, a repository added, one dropped and an API one taken over
+	repos := []config.RepositoryConfig{
+		{Name: "acme/api", Team: "Platform", Tags: []string{" backend", "go", "backend"}, BaseBranches: []string{"main"}},
+		{Name: "acme/mobile", LookbackDays: 14, DeploymentSource: "releases"},
+		{Name: "acme/tools", ServiceGroup: "internal"},
+	}
+	// summary leaves out the IDs and times a sync assigns
+	summary := func(repos []database.Repository) []database.Repository {
+		for i := range repos {
+			repos[i].ID, repos[i].CreatedAt, repos[i].UpdatedAt = 0, time.Time{}, time.Time{}
+		}
+		return repos
+	}
+
+	preview, err := r.Preview(repos)
+	if err != nil {
+		t.Fatalf("Preview() error = %v", err)
+	}
+	if got, want := activeNames(t, r), []string{"acme/api", "acme/docs", "acme/tools", "acme/web"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("active repositories after preview = %v, want %v", got, want)
+	}
+
+	if err := r.Sync(repos); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	synced, err := r.Active()
+	if err != nil {
+		t.Fatalf("Active() error = %v", err)
+	}
+	if got, want := summary(preview), summary(synced); !reflect.DeepEqual(got, want) {
+		t.Errorf("Preview() = %+v, want %+v", got, want)
+	}
+}
+
+// TestAdmin tests creating and updating repositories through the admin operations
+func TestAdmin(t *testing.T) {
+	db := newTestDB(t)
+	r := NewRegistry(db)
+	if err := r.Sync([]config.RepositoryConfig{{Name: "acme/api"}}); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	text := func(s string) *string { return &s }
+	number := func(i int) *int { return &i }
+	tags := []string{"frontend"}
+
+	tools, err := r.Create("alice", "acme/tools", Changes{DefaultBranch: text("main"), TeamID: number(1), Tags: &tags})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if tools.Source != "api" || !tools.Active || tools.TeamID == nil || !reflect.DeepEqual(tools.Tags, tags) {
+		t.Errorf("created repository = %+v", tools)
+	}
+
+	var apiID int
+	if err := db.Get(&apiID, "SELECT id FROM repositories WHERE name = 'acme/api'"); err != nil {
+		t.Fatalf("failed to load acme/api: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		run     func() error
+		wantErr error
+	}{
+		{"duplicate name", func() error { _, err := r.Create("alice", "acme/api", Changes{}); return err }, ErrExists},
+		{"invalid name", func() error { _, err := r.Create("alice", "tools", Changes{}); return err }, ErrInvalid},
+		{"unknown team", func() error { _, err := r.Create("alice", "acme/ops", Changes{TeamID: number(99)}); return err }, ErrInvalid},
+		{"invalid base branch pattern", func() error {
+			_, err := r.Update("alice", tools.ID, Changes{BaseBranches: &[]string{"release/[0-9"}})
+			return err
+		}, ErrInvalid},
+		{"invalid deployment source", func() error {
+			_, err := r.Update("alice", tools.ID, Changes{DeploymentSource: text("argo")})
+			return err
+		}, ErrInvalid},
+		{"config repository", func() error { _, err := r.Update("alice", apiID, Changes{Active: new(bool)}); return err }, ErrConfigManaged},
+		{"unknown repository", func() error { _, err := r.Update("alice", 9999, Changes{}); return err }, ErrNotFound},
+		{"deactivate and clear team", func() error {
+			_, err := r.Update("alice", tools.ID, Changes{Active: new(bool), TeamID: number(0)})
+			return err
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	updated, err := r.Get(tools.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if updated.Active || updated.TeamID != nil || *updated.DefaultBranch != "main" || !reflect.DeepEqual(updated.Tags, tags) {
+		t.Errorf("updated repository = %+v", updated)
+	}
+
+	var audited int
+	if err := db.Get(&audited, "SELECT COUNT(*) FROM team_audit_log WHERE repository = 'acme/tools'"); err != nil {
+		t.Fatalf("failed to count audit entries: %v", err)
+	}
+	if audited != 2 {
+		t.Errorf("audit entries for acme/tools = %d, want 2", audited)
+	}
+}
@@ -0,0 +1,239 @@
+// Package scheduler runs named schedules of repositories on cron expressions
+// for `collector serve`. A schedule never overlaps itself: it is skipped while
+// its previous run is still going. At most a fixed number of schedules run at
+// once; the others wait for a slot. Schedules can also be run on demand.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule states
+const (
+	StateIdle    = "idle"
+	StateWaiting = "waiting" // For a free slot under the concurrency cap
+	StateRunning = "running"
+)
+
+// Run triggers
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+var (
+	// ErrUnknownSchedule is returned when triggering a schedule that doesn't exist
+	ErrUnknownSchedule = errors.New("unknown schedule")
+
+	// ErrBusy is returned when triggering a schedule that is waiting or running
+	ErrBusy = errors.New("schedule is already waiting or running")
+
+	// ErrStopped is returned when triggering a schedule of a scheduler that isn't running
+	ErrStopped = errors.New("scheduler is not running")
+)
+
+// RunFunc runs a schedule's collection; ctx is done when the scheduler stops
+type RunFunc func(ctx context.Context, schedule string) error
+
+// Schedule is a named cron expression
+type Schedule struct {
+	Name     string
+	Cron     string
+	schedule cron.Schedule
+}
+
+// Run is one run of a schedule
+type Run struct {
+	Trigger    string     `json:"trigger"` // "schedule" or "manual"
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// Status is the state of a schedule
+type Status struct {
+	Name      string    `json:"name"`
+	Cron      string    `json:"cron"`
+	State     string    `json:"state"`
+	NextRunAt time.Time `json:"next_run_at"`
+	LastRun   *Run      `json:"last_run,omitempty"`
+	Runs      int       `json:"runs"`
+	Failures  int       `json:"failures"`
+	Skipped   int       `json:"skipped"` // Scheduled runs skipped while the last one was still going
+}
+
+// Scheduler runs schedules
+type Scheduler struct {
+	run   RunFunc
+	slots chan struct{}
+	now   func() time.Time
+
+	mu        sync.Mutex
+	ctx       context.Context // Set while Run is running
+	schedules []*Schedule
+	status    map[string]*Status
+	wg        sync.WaitGroup
+}
+
+// New creates a scheduler running at most maxConcurrent schedules at once
+// (1 if zero). It fails if a cron expression is invalid.
+func New(schedules []Schedule, maxConcurrent int, run RunFunc) (*Scheduler, error) {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+	s := &Scheduler{
+		run:    run,
+		slots:  make(chan struct{}, maxConcurrent),
+		now:    time.Now,
+		status: make(map[string]*Status, len(schedules)),
+	}
+	for _, schedule := range schedules {
+		if _, ok := s.status[schedule.Name]; ok {
+			return nil, fmt.Errorf("schedule %s is defined more than once", schedule.Name)
+		}
+		parsed, err := cron.ParseStandard(schedule.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q for schedule %s: %w", schedule.Cron, schedule.Name, err)
+		}
+		schedule.schedule = parsed
+		s.schedules = append(s.schedules, &schedule)
+		s.status[schedule.Name] = &Status{Name: schedule.Name, Cron: schedule.Cron, State: StateIdle}
+	}
+	return s, nil
+}
+
+// Run starts schedules when they are due until ctx is done, then waits for
+// the runs in progress to return
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx = ctx
+	now := s.now()
+	for _, schedule := range s.schedules {
+		s.status[schedule.Name].NextRunAt = schedule.schedule.Next(now)
+	}
+	s.mu.Unlock()
+
+	for {
+		wake := s.tick()
+		timer := time.NewTimer(time.Until(wake))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.mu.Lock()
+			s.ctx = nil
+			s.mu.Unlock()
+			s.wg.Wait()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// tick starts the schedules that are due and returns when the next one is
+func (s *Scheduler) tick() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var wake time.Time
+	for _, schedule := range s.schedules {
+		status := s.status[schedule.Name]
+		if !status.NextRunAt.After(now) {
+			if err := s.start(schedule.Name, TriggerSchedule); errors.Is(err, ErrBusy) {
+				fmt.Printf("⏭️  Schedule %s skipped: its last run is still %s\n", schedule.Name, status.State)
+				status.Skipped++
+			}
+			status.NextRunAt = schedule.schedule.Next(now)
+		}
+		if wake.IsZero() || status.NextRunAt.Before(wake) {
+			wake = status.NextRunAt
+		}
+	}
+	if wake.IsZero() {
+		wake = now.Add(time.Hour) // No schedules; wake up only to notice ctx is done
+	}
+	return wake
+}
+
+// Trigger runs a schedule now, unless it is already waiting or running
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.start(name, TriggerManual)
+}
+
+// start starts a run of a schedule that waits for a slot; s.mu must be held
+func (s *Scheduler) start(name, trigger string) error {
+	status, ok := s.status[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownSchedule, name)
+	}
+	if s.ctx == nil || s.ctx.Err() != nil {
+		return ErrStopped
+	}
+	if status.State != StateIdle {
+		return ErrBusy
+	}
+
+	ctx := s.ctx
+	status.State = StateWaiting
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		select {
+		case s.slots <- struct{}{}:
+		case <-ctx.Done():
+			s.mu.Lock()
+			status.State = StateIdle
+			s.mu.Unlock()
+			return
+		}
+		defer func() { <-s.slots }()
+
+		s.mu.Lock()
+		run := &Run{Trigger: trigger, StartedAt: s.now()}
+		status.State, status.LastRun = StateRunning, run
+		s.mu.Unlock()
+
+		fmt.Printf("⏰ Schedule %s started (%s)\n", name, trigger)
+		err := s.run(ctx, name)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		finishedAt := s.now()
+		run.FinishedAt = &finishedAt
+		status.State = StateIdle
+		status.Runs++
+		if err != nil {
+			run.Error = err.Error()
+			status.Failures++
+			fmt.Printf("❌ Schedule %s failed: %v\n", name, err)
+		} else {
+			fmt.Printf("✅ Schedule %s finished in %s\n", name, finishedAt.Sub(run.StartedAt).Round(time.Second))
+		}
+	}()
+	return nil
+}
+
+// Status returns the state of every schedule, in the order they were given
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]Status, 0, len(s.schedules))
+	for _, schedule := range s.schedules {
+		status := *s.status[schedule.Name]
+		if status.LastRun != nil {
+			run := *status.LastRun
+			status.LastRun = &run
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
@@ -0,0 +1,210 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingRuns is a RunFunc whose runs last until released, recording which schedules ran
+type blockingRuns struct {
+	mu      sync.Mutex
+	started []string
+	release chan error
+}
+
+func newBlockingRuns() *blockingRuns {
+	return &blockingRuns{release: make(chan error)}
+}
+
+func (b *blockingRuns) run(ctx context.Context, schedule string) error {
+	b.mu.Lock()
+	b.started = append(b.started, schedule)
+	b.mu.Unlock()
+	select {
+	case err := <-b.release:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// newTestScheduler creates a started scheduler with a clock the test moves by hand
+func newTestScheduler(t *testing.T, schedules []Schedule, maxConcurrent int, run RunFunc) (*Scheduler, *time.Time) {
+	t.Helper()
+	s, err := New(schedules, maxConcurrent, run)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	now := time.Date(2026, 3, 2, 5, 59, 0, 0, time.Local)
+	s.now = func() time.Time { return now }
+	ctx, cancel := context.WithCancel(context.Background())
+	s.ctx = ctx
+	for _, schedule := range s.schedules {
+		s.status[schedule.Name].NextRunAt = schedule.schedule.Next(now)
+	}
+	t.Cleanup(func() {
+		cancel()
+		s.wg.Wait()
+	})
+	return s, &now
+}
+
+// waitForState waits until a schedule reaches a state
+func waitForState(t *testing.T, s *Scheduler, name, state string) Status {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		for _, status := range s.Status() {
+			if status.Name == name && status.State == state {
+				return status
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("schedule %s never became %s: %+v", name, state, s.Status())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestNew tests rejecting invalid and duplicate schedules
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		schedules []Schedule
+		wantErr   bool
+	}{
+		{"cron expressions and descriptors", []Schedule{{Name: "core", Cron: "*/15 * * * *"}, {Name: "default", Cron: "@hourly"}}, false},
+		{"invalid cron expression", []Schedule{{Name: "core", Cron: "every hour"}}, true},
+		{"duplicate name", []Schedule{{Name: "core", Cron: "@hourly"}, {Name: "core", Cron: "@daily"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.schedules, 1, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestTick tests starting due schedules and skipping one whose last run is still going
+func TestTick(t *testing.T) {
+	runs := newBlockingRuns()
+	s, now := newTestScheduler(t, []Schedule{{Name: "core", Cron: "0 * * * *"}, {Name: "default", Cron: "0 6 * * *"}}, 2, runs.run)
+
+	if wake := s.tick(); !wake.Equal(now.Add(time.Minute)) {
+		t.Errorf("tick() before 06:00 = %v, want 06:00", wake)
+	}
+	*now = now.Add(time.Minute)
+	if wake := s.tick(); !wake.Equal(now.Add(time.Hour)) {
+		t.Errorf("tick() at 06:00 = %v, want 07:00", wake)
+	}
+	waitForState(t, s, "core", StateRunning)
+	waitForState(t, s, "default", StateRunning)
+
+	// core is due again while its 06:00 run is still going
+	*now = now.Add(time.Hour)
+	s.tick()
+	runs.release <- nil
+	runs.release <- errors.New("rate limited")
+	core := waitForState(t, s, "core", StateIdle)
+	other := waitForState(t, s, "default", StateIdle)
+
+	if core.Skipped != 1 || core.Runs+other.Runs != 2 || core.Failures+other.Failures != 1 {
+		t.Errorf("Status() = %+v, %+v, want one run each, one failed and core skipped once", core, other)
+	}
+	if core.LastRun == nil || core.LastRun.Trigger != TriggerSchedule || core.LastRun.FinishedAt == nil {
+		t.Errorf("core.LastRun = %+v, want a finished scheduled run", core.LastRun)
+	}
+	if !core.NextRunAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("core.NextRunAt = %v, want 08:00", core.NextRunAt)
+	}
+}
+
+// TestConcurrencyCap tests that schedules beyond the cap wait for a slot
+func TestConcurrencyCap(t *testing.T) {
+	runs := newBlockingRuns()
+	s, _ := newTestScheduler(t, []Schedule{{Name: "core", Cron: "@daily"}, {Name: "default", Cron: "@daily"}}, 1, runs.run)
+
+	if err := s.Trigger("core"); err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+	waitForState(t, s, "core", StateRunning)
+	if err := s.Trigger("default"); err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+	waitForState(t, s, "default", StateWaiting)
+
+	runs.release <- nil
+	waitForState(t, s, "default", StateRunning)
+	runs.release <- nil
+	status := waitForState(t, s, "default", StateIdle)
+	if status.LastRun == nil || status.LastRun.Trigger != TriggerManual {
+		t.Errorf("LastRun = %+v, want a manual run", status.LastRun)
+	}
+}
+
+// TestTrigger tests triggering unknown, busy and stopped schedules
+func TestTrigger(t *testing.T) {
+	runs := newBlockingRuns()
+	s, _ := newTestScheduler(t, []Schedule{{Name: "core", Cron: "@daily"}}, 1, runs.run)
+
+	if err := s.Trigger("nightly"); !errors.Is(err, ErrUnknownSchedule) {
+		t.Errorf("Trigger(unknown) error = %v, want ErrUnknownSchedule", err)
+	}
+	if err := s.Trigger("core"); err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+	if err := s.Trigger("core"); !errors.Is(err, ErrBusy) {
+		t.Errorf("Trigger() while running error = %v, want ErrBusy", err)
+	}
+	runs.release <- nil
+	waitForState(t, s, "core", StateIdle)
+
+	stopped, err := New([]Schedule{{Name: "core", Cron: "@daily"}}, 1, runs.run)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := stopped.Trigger("core"); !errors.Is(err, ErrStopped) {
+		t.Errorf("Trigger() before Run error = %v, want ErrStopped", err)
+	}
+}
+
+// TestRunStops tests that Run returns once its context is done and the runs in progress stopped
+func TestRunStops(t *testing.T) {
+	runs := newBlockingRuns()
+	s, err := New([]Schedule{{Name: "core", Cron: "@daily"}}, 1, runs.run)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Trigger("core") != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("scheduler never started")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	waitForState(t, s, "core", StateRunning)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run() didn't return after its context was done")
+	}
+	status := s.Status()[0]
+	if status.State != StateIdle || status.LastRun == nil || status.LastRun.Error == "" {
+		t.Errorf("Status() = %+v, want idle with the interrupted run", status)
+	}
+}
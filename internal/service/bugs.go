@@ -0,0 +1,304 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// defaultBugWorkType is the work type bug metrics cover unless another is requested
+const defaultBugWorkType = "bug"
+
+// BugFlowMetric represents the issues opened and closed in a week
+type BugFlowMetric struct {
+	Period    string `json:"period"` // Date the week starts on (Monday)
+	Opened    int    `json:"opened"`
+	Closed    int    `json:"closed"`
+	Net       int    `json:"net"`         // Opened minus closed
+	OpenAtEnd int    `json:"open_at_end"` // Backlog still open when the week ended
+}
+
+// BugFlowResponse represents the API response for bug inflow and outflow
+type BugFlowResponse struct {
+	TeamID      int             `json:"team_id"`
+	TeamName    string          `json:"team_name"`
+	WorkType    string          `json:"work_type"`
+	Period      Period          `json:"period"`
+	OpenAtStart int             `json:"open_at_start"`
+	Metrics     []BugFlowMetric `json:"metrics"`
+}
+
+// BugAgeBucket counts the open issues within an age range
+type BugAgeBucket struct {
+	Label   string `json:"label"`
+	MinDays int    `json:"min_days"`
+	MaxDays *int   `json:"max_days"` // Exclusive; null for the open-ended last bucket
+	Issues  int    `json:"issues"`
+}
+
+// BugAgeResponse represents the API response for the age of open issues
+type BugAgeResponse struct {
+	TeamID        int            `json:"team_id"`
+	TeamName      string         `json:"team_name"`
+	WorkType      string         `json:"work_type"`
+	AsOf          string         `json:"as_of"`
+	OpenIssues    int            `json:"open_issues"`
+	MedianAgeDays *float64       `json:"median_age_days"`
+	P95AgeDays    *float64       `json:"p95_age_days"`
+	Buckets       []BugAgeBucket `json:"buckets"`
+}
+
+// BugTimeToCloseMetric represents how long the issues closed in a month were open
+type BugTimeToCloseMetric struct {
+	Period      string  `json:"period"`
+	Closed      int     `json:"closed"`
+	MedianHours float64 `json:"median_hours"`
+	P95Hours    float64 `json:"p95_hours"`
+}
+
+// BugTimeToCloseResponse represents the API response for time to close
+type BugTimeToCloseResponse struct {
+	TeamID   int                    `json:"team_id"`
+	TeamName string                 `json:"team_name"`
+	WorkType string                 `json:"work_type"`
+	Period   Period                 `json:"period"`
+	Metrics  []BugTimeToCloseMetric `json:"metrics"`
+}
+
+// bugAgeBuckets are the age ranges open issues are counted in, in days
+var bugAgeBuckets = []struct {
+	label   string
+	minDays int
+	maxDays int // 0 for no upper bound
+}{
+	{"under 1 week", 0, 7},
+	{"1-4 weeks", 7, 28},
+	{"1-3 months", 28, 90},
+	{"3-6 months", 90, 180},
+	{"over 6 months", 180, 0},
+}
+
+// scopeIssue is an issue credited to a team scope
+type scopeIssue struct {
+	Repository       string     `db:"repository"`
+	IssueNumber      int        `db:"issue_number"`
+	CreatedAt        time.Time  `db:"created_at"`
+	ClosedAt         *time.Time `db:"closed_at"`
+	TimeToCloseHours *int       `db:"time_to_close_hours"`
+}
+
+// openAt reports whether the issue was open at a point in time
+func (i scopeIssue) openAt(at time.Time) bool {
+	return i.CreatedAt.Before(at) && (i.ClosedAt == nil || !i.ClosedAt.Before(at))
+}
+
+// loadIssues returns the issues of a work type credited to the scope that were
+// open at some point between from and to, limited by their labels, each once
+// however many of its teams they are attributed to
+func (s *MetricsService) loadIssues(sc *teamScope, workType string, labels LabelFilter, from, to time.Time) ([]scopeIssue, error) {
+	filter, args := sc.filter("team_id")
+	condition, labelArgs := labels.issueCondition("issue_metrics")
+	query := fmt.Sprintf(`
+		SELECT DISTINCT repository, issue_number, created_at, closed_at, time_to_close_hours
+		FROM issue_metrics
+		WHERE %s%s
+			AND work_type = ?
+			AND created_at < ?
+			AND (closed_at IS NULL OR closed_at >= ?)
+	`, filter, condition)
+	args = append(args, labelArgs...)
+
+	var issues []scopeIssue
+	if err := s.db.Select(&issues, query, append(args, workType, to, from)...); err != nil {
+		return nil, fmt.Errorf("failed to query issues: %w", err)
+	}
+	return issues, nil
+}
+
+// GetBugFlow returns the weekly inflow and outflow of a team's issues of a
+// work type (bugs by default) and the backlog left open after each week.
+// Issues are limited by their labels.
+func (s *MetricsService) GetBugFlow(teamID int, startDate, endDate time.Time, workType string, labels LabelFilter) (*BugFlowResponse, error) {
+	scope, err := s.resolveScope(teamID)
+	if err != nil {
+		return nil, err
+	}
+	if workType == "" {
+		workType = defaultBugWorkType
+	}
+	from, to := dateRange(startDate, endDate)
+
+	issues, err := s.loadIssues(scope, workType, labels, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BugFlowResponse{
+		TeamID:   teamID,
+		TeamName: scope.TeamName,
+		WorkType: workType,
+		Period: Period{
+			Start: startDate.Format(time.RFC3339),
+			End:   endDate.Format(time.RFC3339),
+		},
+		Metrics: []BugFlowMetric{},
+	}
+	for _, issue := range issues {
+		if issue.openAt(from) {
+			result.OpenAtStart++
+		}
+	}
+
+	open := result.OpenAtStart
+	for week := mondayOf(from); week.Before(to); week = week.AddDate(0, 0, 7) {
+		weekFrom, weekTo := week, week.AddDate(0, 0, 7)
+		if weekFrom.Before(from) {
+			weekFrom = from
+		}
+		if weekTo.After(to) {
+			weekTo = to
+		}
+
+		metric := BugFlowMetric{Period: week.Format("2006-01-02")}
+		for _, issue := range issues {
+			if !issue.CreatedAt.Before(weekFrom) && issue.CreatedAt.Before(weekTo) {
+				metric.Opened++
+			}
+			if issue.ClosedAt != nil && !issue.ClosedAt.Before(weekFrom) && issue.ClosedAt.Before(weekTo) {
+				metric.Closed++
+			}
+		}
+		metric.Net = metric.Opened - metric.Closed
+		open += metric.Net
+		metric.OpenAtEnd = open
+		result.Metrics = append(result.Metrics, metric)
+	}
+
+	return result, nil
+}
+
+// GetBugAge returns how long a team's issues of a work type (bugs by default)
+// still open at the end date (or now, if earlier) have been open, limited by their labels
+func (s *MetricsService) GetBugAge(teamID int, endDate time.Time, workType string, labels LabelFilter) (*BugAgeResponse, error) {
+	scope, err := s.resolveScope(teamID)
+	if err != nil {
+		return nil, err
+	}
+	if workType == "" {
+		workType = defaultBugWorkType
+	}
+	_, asOf := dateRange(endDate, endDate)
+	if now := time.Now().UTC(); now.Before(asOf) {
+		asOf = now
+	}
+
+	issues, err := s.loadIssues(scope, workType, labels, asOf, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BugAgeResponse{
+		TeamID:   teamID,
+		TeamName: scope.TeamName,
+		WorkType: workType,
+		AsOf:     asOf.Format(time.RFC3339),
+		Buckets:  make([]BugAgeBucket, len(bugAgeBuckets)),
+	}
+	for i, bucket := range bugAgeBuckets {
+		result.Buckets[i] = BugAgeBucket{Label: bucket.label, MinDays: bucket.minDays}
+		if bucket.maxDays > 0 {
+			maxDays := bucket.maxDays
+			result.Buckets[i].MaxDays = &maxDays
+		}
+	}
+
+	var ages []float64
+	for _, issue := range issues {
+		if !issue.openAt(asOf) {
+			continue
+		}
+		age := asOf.Sub(issue.CreatedAt).Hours() / 24
+		ages = append(ages, age)
+		for i, bucket := range bugAgeBuckets {
+			if age >= float64(bucket.minDays) && (bucket.maxDays == 0 || age < float64(bucket.maxDays)) {
+				result.Buckets[i].Issues++
+				break
+			}
+		}
+	}
+
+	result.OpenIssues = len(ages)
+	if len(ages) > 0 {
+		sort.Float64s(ages)
+		median, p95 := percentile(ages, 50), percentile(ages, 95)
+		result.MedianAgeDays, result.P95AgeDays = &median, &p95
+	}
+	return result, nil
+}
+
+// GetBugTimeToClose returns the monthly median and 95th percentile of how long
+// a team's issues of a work type (bugs by default) were open before closing,
+// limited by their labels
+func (s *MetricsService) GetBugTimeToClose(teamID int, startDate, endDate time.Time, workType string, labels LabelFilter) (*BugTimeToCloseResponse, error) {
+	scope, err := s.resolveScope(teamID)
+	if err != nil {
+		return nil, err
+	}
+	if workType == "" {
+		workType = defaultBugWorkType
+	}
+	from, to := dateRange(startDate, endDate)
+
+	issues, err := s.loadIssues(scope, workType, labels, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	hoursByMonth := make(map[string][]float64)
+	for _, issue := range issues {
+		if issue.ClosedAt == nil || issue.TimeToCloseHours == nil || issue.ClosedAt.Before(from) || !issue.ClosedAt.Before(to) {
+			continue
+		}
+		month := issue.ClosedAt.UTC().Format("2006-01")
+		hoursByMonth[month] = append(hoursByMonth[month], float64(*issue.TimeToCloseHours))
+	}
+
+	metrics := make([]BugTimeToCloseMetric, 0, len(hoursByMonth))
+	for month, hours := range hoursByMonth {
+		sort.Float64s(hours)
+		metrics = append(metrics, BugTimeToCloseMetric{
+			Period:      month,
+			Closed:      len(hours),
+			MedianHours: percentile(hours, 50),
+			P95Hours:    percentile(hours, 95),
+		})
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Period < metrics[j].Period })
+
+	return &BugTimeToCloseResponse{
+		TeamID:   teamID,
+		TeamName: scope.TeamName,
+		WorkType: workType,
+		Period: Period{
+			Start: startDate.Format(time.RFC3339),
+			End:   endDate.Format(time.RFC3339),
+		},
+		Metrics: metrics,
+	}, nil
+}
+
+// mondayOf returns the start of the week (Monday) a time falls in
+func mondayOf(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// percentile returns the p-th percentile (nearest rank) of sorted, non-empty values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
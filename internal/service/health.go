@@ -0,0 +1,45 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CollectionHealth summarizes how the last collection runs of the active repositories went
+type CollectionHealth struct {
+	Repositories int        `json:"repositories"` // Active repositories
+	Failing      []string   `json:"failing"`      // Active repositories whose last run failed
+	LastRunAt    *time.Time `json:"last_run_at"`  // When the latest run finished
+}
+
+// CollectionHealth returns the run health of the active repositories
+func (s *MetricsService) CollectionHealth() (*CollectionHealth, error) {
+	var rows []struct {
+		Name          string         `db:"name"`
+		LastRunAt     sql.NullTime   `db:"last_run_finished_at"`
+		LastRunStatus sql.NullString `db:"last_run_status"`
+	}
+	query := `
+		SELECT r.name, cm.last_run_finished_at, cm.last_run_status
+		FROM repositories r
+		LEFT JOIN collection_metadata cm ON cm.repository = r.name
+		WHERE r.active = ?
+		ORDER BY r.name
+	`
+	if err := s.db.Select(&rows, query, true); err != nil {
+		return nil, fmt.Errorf("failed to query collection health: %w", err)
+	}
+
+	health := &CollectionHealth{Repositories: len(rows), Failing: []string{}}
+	for _, row := range rows {
+		if row.LastRunStatus.String == "failed" {
+			health.Failing = append(health.Failing, row.Name)
+		}
+		if row.LastRunAt.Valid && (health.LastRunAt == nil || row.LastRunAt.Time.After(*health.LastRunAt)) {
+			lastRunAt := row.LastRunAt.Time
+			health.LastRunAt = &lastRunAt
+		}
+	}
+	return health, nil
+}
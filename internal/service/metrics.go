@@ -3,6 +3,7 @@ package service
 import (
 	"database/sql"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/dothanhlam/go-github-tracker/internal/database"
@@ -22,7 +23,9 @@ func NewMetricsService(db *database.DB) *MetricsService {
 type Team struct {
 	ID          int    `json:"id"`
 	Name        string `json:"name"`
+	ParentID    *int   `json:"parent_id"`
 	MemberCount int    `json:"member_count"`
+	Children    []Team `json:"children,omitempty"`
 }
 
 // VelocityMetric represents team velocity for a period
@@ -30,6 +33,12 @@ type VelocityMetric struct {
 	Period           string  `json:"period"`
 	PRsMerged        int     `json:"prs_merged"`
 	AvgCycleTimeHrs  float64 `json:"avg_cycle_time_hours"`
+
+	// WeightedPRsMerged scales each merged PR by the credited author's or co-author's allocation (weighted mode only)
+	WeightedPRsMerged *float64 `json:"weighted_prs_merged,omitempty"`
+
+	// ByWorkType counts the merged PRs per work type (work type breakdown only)
+	ByWorkType map[string]int `json:"by_work_type,omitempty"`
 }
 
 // VelocityResponse represents the API response for velocity
@@ -37,6 +46,7 @@ type VelocityResponse struct {
 	TeamID   int              `json:"team_id"`
 	TeamName string           `json:"team_name"`
 	Period   Period           `json:"period"`
+	FTE      *float64         `json:"fte,omitempty"`
 	Metrics  []VelocityMetric `json:"metrics"`
 }
 
@@ -45,6 +55,12 @@ type LeadTimeMetric struct {
 	Period              string  `json:"period"`
 	MedianLeadTimeHrs   float64 `json:"median_lead_time_hours"`
 	P95LeadTimeHrs      float64 `json:"p95_lead_time_hours"`
+
+	// Lead time from when work started on the PR's linked Jira issue, over
+	// the PRs with one (omitted when none have)
+	MedianTicketLeadTimeHrs *float64 `json:"median_ticket_lead_time_hours,omitempty"`
+	P95TicketLeadTimeHrs    *float64 `json:"p95_ticket_lead_time_hours,omitempty"`
+	TicketLinkedPRs         int      `json:"ticket_linked_prs"`
 }
 
 // LeadTimeResponse represents the API response for lead time
@@ -107,16 +123,17 @@ type Period struct {
 	End   string `json:"end"`
 }
 
-// ListTeams returns all teams
+// ListTeams returns all teams as a tree, each team nested under its parent
 func (s *MetricsService) ListTeams() ([]Team, error) {
 	query := `
 		SELECT 
 			t.id,
 			t.name,
+			t.parent_id,
 			COUNT(DISTINCT tm.github_username) as member_count
 		FROM teams t
-		LEFT JOIN team_memberships tm ON t.id = tm.team_id
-		GROUP BY t.id, t.name
+		LEFT JOIN team_memberships tm ON t.id = tm.team_id AND tm.left_at IS NULL
+		GROUP BY t.id, t.name, t.parent_id
 		ORDER BY t.name
 	`
 
@@ -129,120 +146,125 @@ func (s *MetricsService) ListTeams() ([]Team, error) {
 	var teams []Team
 	for rows.Next() {
 		var team Team
-		if err := rows.Scan(&team.ID, &team.Name, &team.MemberCount); err != nil {
+		var parentID sql.NullInt64
+		if err := rows.Scan(&team.ID, &team.Name, &parentID, &team.MemberCount); err != nil {
 			return nil, fmt.Errorf("failed to scan team: %w", err)
 		}
+		if parentID.Valid {
+			id := int(parentID.Int64)
+			team.ParentID = &id
+		}
 		teams = append(teams, team)
 	}
 
-	return teams, nil
+	return buildTeamTree(teams), nil
 }
 
-// GetTeamVelocity returns velocity metrics for a team
-func (s *MetricsService) GetTeamVelocity(teamID int, startDate, endDate time.Time, granularity string) (*VelocityResponse, error) {
-	// Get team name
-	var teamName string
-	err := s.db.QueryRow("SELECT name FROM teams WHERE id = ?", teamID).Scan(&teamName)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("team not found")
+// buildTeamTree nests teams under their parents, keeping name order at each level.
+// Teams whose parent is missing are returned at the top level.
+func buildTeamTree(teams []Team) []Team {
+	ids := make(map[int]bool, len(teams))
+	children := make(map[int][]Team)
+	for _, team := range teams {
+		ids[team.ID] = true
+	}
+
+	var roots []Team
+	for _, team := range teams {
+		if team.ParentID != nil && ids[*team.ParentID] {
+			children[*team.ParentID] = append(children[*team.ParentID], team)
+		} else {
+			roots = append(roots, team)
+		}
 	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to get team: %w", err)
+
+	var attach func(team Team, visited map[int]bool) Team
+	attach = func(team Team, visited map[int]bool) Team {
+		visited[team.ID] = true
+		for _, child := range children[team.ID] {
+			if !visited[child.ID] {
+				team.Children = append(team.Children, attach(child, visited))
+			}
+		}
+		return team
 	}
 
-	// Query velocity metrics
-	query := `
-		SELECT 
-			week,
-			prs_merged,
-			avg_cycle_time_hours
-		FROM view_team_velocity
-		WHERE team_id = ?
-			AND week >= ?
-			AND week <= ?
-		ORDER BY week
-	`
+	visited := make(map[int]bool)
+	for i := range roots {
+		roots[i] = attach(roots[i], visited)
+	}
+	return roots
+}
 
-	rows, err := s.db.Query(query, teamID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+// GetTeamVelocity returns velocity metrics for a team and its sub-teams.
+// In weighted mode each PR is also scaled by its author's (or co-author's)
+// allocation to the team, and the team's FTE total is returned alongside the metrics.
+// PRs are limited by the filter, by default to those the team authored.
+// byWorkType also breaks each period's count down by work type.
+func (s *MetricsService) GetTeamVelocity(teamID int, startDate, endDate time.Time, granularity string, weighted bool, filter PRFilter, byWorkType bool) (*VelocityResponse, error) {
+	scope, err := s.resolveScope(teamID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query velocity: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
+	filter = filter.withDefaultRole(defaultDeliveryRole)
 
-	var metrics []VelocityMetric
-	for rows.Next() {
-		var metric VelocityMetric
-		var cycleTime sql.NullFloat64
-		if err := rows.Scan(&metric.Period, &metric.PRsMerged, &cycleTime); err != nil {
-			return nil, fmt.Errorf("failed to scan velocity: %w", err)
-		}
-		if cycleTime.Valid {
-			metric.AvgCycleTimeHrs = cycleTime.Float64
+	prs, prArgs := scope.deliveryPRs(filter)
+	args := prArgs
+	weightColumn, weightJoin := "NULL", ""
+	if weighted {
+		var weightArgs []interface{}
+		condition, conditionArgs := filter.condition("e")
+		weightJoin, weightArgs = scope.weightJoin("pr_metrics", "prs", []string{"repository", "pr_number"}, "merged_at", condition+baseCondition("e."), conditionArgs...)
+		weightColumn = "SUM(COALESCE(w.weight, 0))"
+		args = append(append([]interface{}{}, args...), weightArgs...)
+	}
+
+	metrics, err := s.velocityMetrics(prs, args, weightColumn, weightJoin, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	if byWorkType {
+		if err := s.addWorkTypes(metrics, prs, prArgs, startDate, endDate); err != nil {
+			return nil, err
 		}
-		metrics = append(metrics, metric)
 	}
 
-	return &VelocityResponse{
+	resp := &VelocityResponse{
 		TeamID:   teamID,
-		TeamName: teamName,
+		TeamName: scope.TeamName,
 		Period: Period{
 			Start: startDate.Format(time.RFC3339),
 			End:   endDate.Format(time.RFC3339),
 		},
 		Metrics: metrics,
-	}, nil
-}
-
-// GetTeamLeadTime returns DORA lead time metrics for a team
-func (s *MetricsService) GetTeamLeadTime(teamID int, startDate, endDate time.Time, granularity string) (*LeadTimeResponse, error) {
-	// Get team name
-	var teamName string
-	err := s.db.QueryRow("SELECT name FROM teams WHERE id = ?", teamID).Scan(&teamName)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("team not found")
 	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to get team: %w", err)
+
+	if weighted {
+		if resp.FTE, err = s.fte(scope); err != nil {
+			return nil, err
+		}
 	}
 
-	// Query lead time metrics (SQLite uses avg/max as approximation for median/p95)
-	query := `
-		SELECT 
-			month,
-			avg_lead_time_hours,
-			max_lead_time_hours
-		FROM view_dora_lead_time
-		WHERE team_id = ?
-			AND month >= ?
-			AND month <= ?
-		ORDER BY month
-	`
+	return resp, nil
+}
 
-	rows, err := s.db.Query(query, teamID, startDate.Format("2006-01"), endDate.Format("2006-01"))
+// GetTeamLeadTime returns DORA lead time metrics for a team and its sub-teams
+func (s *MetricsService) GetTeamLeadTime(teamID int, startDate, endDate time.Time, granularity string, filter PRFilter) (*LeadTimeResponse, error) {
+	scope, err := s.resolveScope(teamID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query lead time: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
+	filter = filter.withDefaultRole(defaultDeliveryRole)
 
-	var metrics []LeadTimeMetric
-	for rows.Next() {
-		var metric LeadTimeMetric
-		var median, p95 sql.NullFloat64
-		if err := rows.Scan(&metric.Period, &median, &p95); err != nil {
-			return nil, fmt.Errorf("failed to scan lead time: %w", err)
-		}
-		if median.Valid {
-			metric.MedianLeadTimeHrs = median.Float64
-		}
-		if p95.Valid {
-			metric.P95LeadTimeHrs = p95.Float64
-		}
-		metrics = append(metrics, metric)
+	prs, args := scope.deliveryPRs(filter)
+	metrics, err := s.leadTimeMetrics(prs, args, startDate, endDate)
+	if err != nil {
+		return nil, err
 	}
 
 	return &LeadTimeResponse{
 		TeamID:   teamID,
-		TeamName: teamName,
+		TeamName: scope.TeamName,
 		Period: Period{
 			Start: startDate.Format(time.RFC3339),
 			End:   endDate.Format(time.RFC3339),
@@ -251,56 +273,23 @@ func (s *MetricsService) GetTeamLeadTime(teamID int, startDate, endDate time.Tim
 	}, nil
 }
 
-// GetReviewTurnaround returns review turnaround metrics for a team
-func (s *MetricsService) GetReviewTurnaround(teamID int, startDate, endDate time.Time) (*ReviewTurnaroundResponse, error) {
-	// Get team name
-	var teamName string
-	err := s.db.QueryRow("SELECT name FROM teams WHERE id = ?", teamID).Scan(&teamName)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("team not found")
-	}
+// GetReviewTurnaround returns review turnaround metrics for a team and its sub-teams
+func (s *MetricsService) GetReviewTurnaround(teamID int, startDate, endDate time.Time, filter PRFilter) (*ReviewTurnaroundResponse, error) {
+	scope, err := s.resolveScope(teamID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get team: %w", err)
+		return nil, err
 	}
+	filter = filter.withDefaultRole(defaultReviewRole)
 
-	// Query review turnaround metrics (SQLite uses avg/min as approximation for avg/median)
-	query := `
-		SELECT 
-			month,
-			avg_turnaround_hours,
-			min_turnaround_hours
-		FROM view_review_turnaround
-		WHERE team_id = ?
-			AND month >= ?
-			AND month <= ?
-		ORDER BY month
-	`
-
-	rows, err := s.db.Query(query, teamID, startDate.Format("2006-01"), endDate.Format("2006-01"))
+	prs, args := scope.prs(filter)
+	metrics, err := s.reviewTurnaroundMetrics(prs, args, startDate, endDate)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query review turnaround: %w", err)
-	}
-	defer rows.Close()
-
-	var metrics []ReviewTurnaroundMetric
-	for rows.Next() {
-		var metric ReviewTurnaroundMetric
-		var avg, median sql.NullFloat64
-		if err := rows.Scan(&metric.Period, &avg, &median); err != nil {
-			return nil, fmt.Errorf("failed to scan review turnaround: %w", err)
-		}
-		if avg.Valid {
-			metric.AvgTurnaroundHrs = avg.Float64
-		}
-		if median.Valid {
-			metric.MedianTurnaroundHrs = median.Float64
-		}
-		metrics = append(metrics, metric)
+		return nil, err
 	}
 
 	return &ReviewTurnaroundResponse{
 		TeamID:   teamID,
-		TeamName: teamName,
+		TeamName: scope.TeamName,
 		Period: Period{
 			Start: startDate.Format(time.RFC3339),
 			End:   endDate.Format(time.RFC3339),
@@ -309,33 +298,31 @@ func (s *MetricsService) GetReviewTurnaround(teamID int, startDate, endDate time
 	}, nil
 }
 
-// GetReviewEngagement returns review engagement metrics for a team
-func (s *MetricsService) GetReviewEngagement(teamID int, startDate, endDate time.Time) (*ReviewEngagementResponse, error) {
-	// Get team name
-	var teamName string
-	err := s.db.QueryRow("SELECT name FROM teams WHERE id = ?", teamID).Scan(&teamName)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("team not found")
-	}
+// GetReviewEngagement returns review engagement metrics for a team and its sub-teams
+func (s *MetricsService) GetReviewEngagement(teamID int, startDate, endDate time.Time, filter PRFilter) (*ReviewEngagementResponse, error) {
+	scope, err := s.resolveScope(teamID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get team: %w", err)
+		return nil, err
 	}
+	filter = filter.withDefaultRole(defaultReviewRole)
 
 	// Query review engagement metrics
-	query := `
+	prs, args := scope.prs(filter)
+	query := fmt.Sprintf(`
 		SELECT 
-			month,
-			pr_count,
-			pr_count,
-			avg_reviewers_per_pr
-		FROM view_review_engagement
-		WHERE team_id = ?
-			AND month >= ?
-			AND month <= ?
+			%s as month,
+			COUNT(*) as pr_count,
+			COUNT(*) as pr_count,
+			AVG(reviewers_count) as avg_reviewers_per_pr
+		FROM %s
+		WHERE created_at >= ?
+			AND created_at < ?
+		GROUP BY month
 		ORDER BY month
-	`
+	`, s.yearMonth("created_at"), prs)
 
-	rows, err := s.db.Query(query, teamID, startDate.Format("2006-01"), endDate.Format("2006-01"))
+	from, to := dateRange(startDate, endDate)
+	rows, err := s.db.Query(query, append(args, from, to)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query review engagement: %w", err)
 	}
@@ -356,7 +343,7 @@ func (s *MetricsService) GetReviewEngagement(teamID int, startDate, endDate time
 
 	return &ReviewEngagementResponse{
 		TeamID:   teamID,
-		TeamName: teamName,
+		TeamName: scope.TeamName,
 		Period: Period{
 			Start: startDate.Format(time.RFC3339),
 			End:   endDate.Format(time.RFC3339),
@@ -365,32 +352,32 @@ func (s *MetricsService) GetReviewEngagement(teamID int, startDate, endDate time
 	}, nil
 }
 
-// GetKnowledgeSharing returns knowledge sharing metrics for a team
-func (s *MetricsService) GetKnowledgeSharing(teamID int, startDate, endDate time.Time) (*KnowledgeSharingResponse, error) {
-	// Get team name
-	var teamName string
-	err := s.db.QueryRow("SELECT name FROM teams WHERE id = ?", teamID).Scan(&teamName)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("team not found")
-	}
+// GetKnowledgeSharing returns knowledge sharing metrics for a team and its sub-teams.
+// Reviewers count as external only if they are outside every team in the subtree.
+func (s *MetricsService) GetKnowledgeSharing(teamID int, startDate, endDate time.Time, filter PRFilter) (*KnowledgeSharingResponse, error) {
+	scope, err := s.resolveScope(teamID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get team: %w", err)
+		return nil, err
 	}
+	filter = filter.withDefaultRole(defaultReviewRole)
 
 	// Query knowledge sharing metrics
-	query := `
+	prs, args := scope.prs(filter)
+	query := fmt.Sprintf(`
 		SELECT 
-			month,
-			total_external_reviews,
-			external_reviewer_rate
-		FROM view_knowledge_sharing
-		WHERE team_id = ?
-			AND month >= ?
-			AND month <= ?
+			%s as month,
+			SUM(external_reviewers_count) as total_external_reviews,
+			AVG(external_reviewers_count * 100.0 / NULLIF(reviewers_count, 0)) as external_reviewer_rate
+		FROM %s
+		WHERE reviewers_count > 0
+			AND created_at >= ?
+			AND created_at < ?
+		GROUP BY month
 		ORDER BY month
-	`
+	`, s.yearMonth("created_at"), prs)
 
-	rows, err := s.db.Query(query, teamID, startDate.Format("2006-01"), endDate.Format("2006-01"))
+	from, to := dateRange(startDate, endDate)
+	rows, err := s.db.Query(query, append(args, from, to)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query knowledge sharing: %w", err)
 	}
@@ -411,7 +398,7 @@ func (s *MetricsService) GetKnowledgeSharing(teamID int, startDate, endDate time
 
 	return &KnowledgeSharingResponse{
 		TeamID:   teamID,
-		TeamName: teamName,
+		TeamName: scope.TeamName,
 		Period: Period{
 			Start: startDate.Format(time.RFC3339),
 			End:   endDate.Format(time.RFC3339),
@@ -424,6 +411,10 @@ func (s *MetricsService) GetKnowledgeSharing(teamID int, startDate, endDate time
 type CommitActivityMetric struct {
 	Period       string `json:"period"`
 	CommitsCount int    `json:"commits_count"`
+
+	// Co-authorship credit, only populated when co-authored commits are included
+	CoauthoredCount int     `json:"coauthored_count,omitempty"`
+	WeightedCommits float64 `json:"weighted_commits,omitempty"`
 }
 
 // CommitActivityResponse represents the API response for commit activity
@@ -432,6 +423,7 @@ type CommitActivityResponse struct {
 	TeamName string                 `json:"team_name"`
 	Username string                 `json:"username,omitempty"`
 	Period   Period                 `json:"period"`
+	FTE      *float64               `json:"fte,omitempty"`
 	Metrics  []CommitActivityMetric `json:"metrics"`
 }
 
@@ -440,6 +432,9 @@ type CommentActivityMetric struct {
 	Period        string `json:"period"`
 	CommentsCount int    `json:"comments_count"`
 	CommentType   string `json:"comment_type"`
+
+	// WeightedComments scales each comment by the author's allocation (weighted mode only)
+	WeightedComments *float64 `json:"weighted_comments,omitempty"`
 }
 
 // CommentActivityResponse represents the API response for comment activity
@@ -448,6 +443,7 @@ type CommentActivityResponse struct {
 	TeamName string                  `json:"team_name"`
 	Username string                  `json:"username,omitempty"`
 	Period   Period                  `json:"period"`
+	FTE      *float64                `json:"fte,omitempty"`
 	Metrics  []CommentActivityMetric `json:"metrics"`
 }
 
@@ -464,30 +460,39 @@ func (s *MetricsService) getTeamName(teamID int) (string, error) {
 	return teamName, nil
 }
 
-func getWeekStr(t time.Time) string {
-	year, week := t.ISOWeek()
-	return fmt.Sprintf("%d-%02d", year, week)
-}
-
-// GetTeamCommits returns commit metrics for a team
-func (s *MetricsService) GetTeamCommits(teamID int, startDate, endDate time.Time) (*CommitActivityResponse, error) {
-	teamName, err := s.getTeamName(teamID)
+// GetTeamCommits returns commit metrics for a team and its sub-teams, limited
+// by the labels of the PRs they belong to.
+// In weighted mode each commit is also scaled by its author's allocation.
+func (s *MetricsService) GetTeamCommits(teamID int, startDate, endDate time.Time, weighted bool, labels LabelFilter) (*CommitActivityResponse, error) {
+	scope, err := s.resolveScope(teamID)
 	if err != nil {
 		return nil, err
 	}
 
-	query := `
+	commits, args := scope.commits("", labels)
+	weightColumn, weightJoin := "0", ""
+	if weighted {
+		var weightArgs []interface{}
+		weightJoin, weightArgs = scope.weightJoin("commit_metrics", "commits", []string{"repository", "commit_hash"}, "created_at", "")
+		weightColumn = "SUM(COALESCE(w.weight, 0))"
+		args = append(args, weightArgs...)
+	}
+
+	query := fmt.Sprintf(`
 		SELECT 
-			week,
-			commits_count
-		FROM view_team_commit_velocity
-		WHERE team_id = ?
-			AND week >= ?
-			AND week <= ?
+			%s as week,
+			COUNT(*) as commits_count,
+			%s as weighted_commits
+		FROM %s
+		%s
+		WHERE commits.created_at >= ?
+			AND commits.created_at < ?
+		GROUP BY week
 		ORDER BY week
-	`
+	`, s.yearWeek("commits.created_at"), weightColumn, commits, weightJoin)
 
-	rows, err := s.db.Query(query, teamID, getWeekStr(startDate), getWeekStr(endDate))
+	from, to := dateRange(startDate, endDate)
+	rows, err := s.db.Query(query, append(args, from, to)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query commits: %w", err)
 	}
@@ -496,40 +501,62 @@ func (s *MetricsService) GetTeamCommits(teamID int, startDate, endDate time.Time
 	var metrics []CommitActivityMetric
 	for rows.Next() {
 		var metric CommitActivityMetric
-		if err := rows.Scan(&metric.Period, &metric.CommitsCount); err != nil {
+		if err := rows.Scan(&metric.Period, &metric.CommitsCount, &metric.WeightedCommits); err != nil {
 			return nil, fmt.Errorf("failed to scan commits: %w", err)
 		}
 		metrics = append(metrics, metric)
 	}
 
-	return &CommitActivityResponse{
+	resp := &CommitActivityResponse{
 		TeamID:   teamID,
-		TeamName: teamName,
+		TeamName: scope.TeamName,
 		Period: Period{Start: startDate.Format(time.RFC3339), End: endDate.Format(time.RFC3339)},
 		Metrics:  metrics,
-	}, nil
+	}
+
+	if weighted {
+		if resp.FTE, err = s.fte(scope); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
 }
 
-// GetTeamComments returns comment metrics for a team
-func (s *MetricsService) GetTeamComments(teamID int, startDate, endDate time.Time) (*CommentActivityResponse, error) {
-	teamName, err := s.getTeamName(teamID)
+// GetTeamComments returns comment metrics for a team and its sub-teams, limited
+// by the labels of the PRs or issues they belong to.
+// In weighted mode each comment is also scaled by its author's allocation.
+func (s *MetricsService) GetTeamComments(teamID int, startDate, endDate time.Time, weighted bool, labels LabelFilter) (*CommentActivityResponse, error) {
+	scope, err := s.resolveScope(teamID)
 	if err != nil {
 		return nil, err
 	}
 
-	query := `
-		SELECT 
-			week,
-			comments_count,
-			comment_type
-		FROM view_team_comment_activity
-		WHERE team_id = ?
-			AND week >= ?
-			AND week <= ?
-		ORDER BY week, comment_type
-	`
+	comments, args := scope.comments("", labels)
+	weightColumn, weightJoin := "NULL", ""
+	if weighted {
+		var weightArgs []interface{}
+		weightJoin, weightArgs = scope.weightJoin("comment_metrics", "comments", []string{"repository", "comment_id", "comment_type"}, "created_at", "")
+		weightColumn = "SUM(COALESCE(w.weight, 0))"
+		args = append(args, weightArgs...)
+	}
 
-	rows, err := s.db.Query(query, teamID, getWeekStr(startDate), getWeekStr(endDate))
+	query := fmt.Sprintf(`
+		SELECT 
+			%s as week,
+			COUNT(*) as comments_count,
+			comments.comment_type,
+			%s as weighted_comments
+		FROM %s
+		%s
+		WHERE comments.created_at >= ?
+			AND comments.created_at < ?
+		GROUP BY week, comments.comment_type
+		ORDER BY week, comments.comment_type
+	`, s.yearWeek("comments.created_at"), weightColumn, comments, weightJoin)
+
+	from, to := dateRange(startDate, endDate)
+	rows, err := s.db.Query(query, append(args, from, to)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query comments: %w", err)
 	}
@@ -538,40 +565,56 @@ func (s *MetricsService) GetTeamComments(teamID int, startDate, endDate time.Tim
 	var metrics []CommentActivityMetric
 	for rows.Next() {
 		var metric CommentActivityMetric
-		if err := rows.Scan(&metric.Period, &metric.CommentsCount, &metric.CommentType); err != nil {
+		var weightedComments sql.NullFloat64
+		if err := rows.Scan(&metric.Period, &metric.CommentsCount, &metric.CommentType, &weightedComments); err != nil {
 			return nil, fmt.Errorf("failed to scan comments: %w", err)
 		}
+		if weightedComments.Valid {
+			metric.WeightedComments = &weightedComments.Float64
+		}
 		metrics = append(metrics, metric)
 	}
 
-	return &CommentActivityResponse{
+	resp := &CommentActivityResponse{
 		TeamID:   teamID,
-		TeamName: teamName,
+		TeamName: scope.TeamName,
 		Period: Period{Start: startDate.Format(time.RFC3339), End: endDate.Format(time.RFC3339)},
 		Metrics:  metrics,
-	}, nil
+	}
+
+	if weighted {
+		if resp.FTE, err = s.fte(scope); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
 }
 
-// GetMemberCommits returns commit metrics for a member
-func (s *MetricsService) GetMemberCommits(teamID int, username string, startDate, endDate time.Time) (*CommitActivityResponse, error) {
-	teamName, err := s.getTeamName(teamID)
+// GetMemberCommits returns commit metrics for a member within a team and its
+// sub-teams, limited by the labels of the PRs they belong to.
+// When includeCoauthored is set, commits the member co-authored through
+// Co-authored-by trailers are added to the counts and weighted by their credit.
+func (s *MetricsService) GetMemberCommits(teamID int, username string, startDate, endDate time.Time, includeCoauthored bool, labels LabelFilter) (*CommitActivityResponse, error) {
+	scope, err := s.resolveScope(teamID)
 	if err != nil {
 		return nil, err
 	}
 
-	query := `
+	commits, args := scope.commits(username, labels)
+	query := fmt.Sprintf(`
 		SELECT 
-			week,
-			commits_count
-		FROM view_member_commit_velocity
-		WHERE team_id = ?
-			AND github_username = ?
-			AND week >= ?
-			AND week <= ?
+			%s as week,
+			COUNT(*) as commits_count
+		FROM %s
+		WHERE created_at >= ?
+			AND created_at < ?
+		GROUP BY week
 		ORDER BY week
-	`
+	`, s.yearWeek("created_at"), commits)
 
-	rows, err := s.db.Query(query, teamID, username, getWeekStr(startDate), getWeekStr(endDate))
+	from, to := dateRange(startDate, endDate)
+	rows, err := s.db.Query(query, append(args, from, to)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query member commits: %w", err)
 	}
@@ -586,36 +629,103 @@ func (s *MetricsService) GetMemberCommits(teamID int, username string, startDate
 		metrics = append(metrics, metric)
 	}
 
+	if includeCoauthored {
+		metrics, err = s.addCoauthoredCommits(metrics, scope, username, labels, from, to)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &CommitActivityResponse{
 		TeamID:   teamID,
-		TeamName: teamName,
+		TeamName: scope.TeamName,
 		Username: username,
 		Period: Period{Start: startDate.Format(time.RFC3339), End: endDate.Format(time.RFC3339)},
 		Metrics:  metrics,
 	}, nil
 }
 
-// GetMemberComments returns comment metrics for a member
-func (s *MetricsService) GetMemberComments(teamID int, username string, startDate, endDate time.Time) (*CommentActivityResponse, error) {
-	teamName, err := s.getTeamName(teamID)
+// addCoauthoredCommits merges a member's co-authored commits into their authored commit metrics
+func (s *MetricsService) addCoauthoredCommits(metrics []CommitActivityMetric, scope *teamScope, username string, labels LabelFilter, from, to time.Time) ([]CommitActivityMetric, error) {
+	filter, args := scope.filter("team_id")
+	condition, labelArgs := labels.commitCondition("commit_coauthors")
+	query := fmt.Sprintf(`
+		SELECT 
+			%s as week,
+			COUNT(*) as commits_count,
+			SUM(weight) as weighted_commits
+		FROM (
+			SELECT repository, commit_hash, MIN(created_at) as created_at, MAX(weight) as weight
+			FROM commit_coauthors
+			WHERE %s
+				AND github_username = ?%s
+			GROUP BY repository, commit_hash
+		) coauthored
+		WHERE created_at >= ?
+			AND created_at < ?
+		GROUP BY week
+		ORDER BY week
+	`, s.yearWeek("created_at"), filter, condition)
+
+	args = append(append(args, username), labelArgs...)
+	rows, err := s.db.Query(query, append(args, from, to)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query co-authored commits: %w", err)
+	}
+	defer rows.Close()
+
+	byWeek := make(map[string]int)
+	for i := range metrics {
+		metrics[i].WeightedCommits = float64(metrics[i].CommitsCount)
+		byWeek[metrics[i].Period] = i
+	}
+
+	for rows.Next() {
+		var week string
+		var count int
+		var weighted float64
+		if err := rows.Scan(&week, &count, &weighted); err != nil {
+			return nil, fmt.Errorf("failed to scan co-authored commits: %w", err)
+		}
+
+		i, ok := byWeek[week]
+		if !ok {
+			metrics = append(metrics, CommitActivityMetric{Period: week})
+			i = len(metrics) - 1
+			byWeek[week] = i
+		}
+		metrics[i].CommitsCount += count
+		metrics[i].CoauthoredCount += count
+		metrics[i].WeightedCommits += weighted
+	}
+
+	sort.Slice(metrics, func(a, b int) bool { return metrics[a].Period < metrics[b].Period })
+	return metrics, nil
+}
+
+// GetMemberComments returns comment metrics for a member within a team and its
+// sub-teams, limited by the labels of the PRs or issues they belong to
+func (s *MetricsService) GetMemberComments(teamID int, username string, startDate, endDate time.Time, labels LabelFilter) (*CommentActivityResponse, error) {
+	scope, err := s.resolveScope(teamID)
 	if err != nil {
 		return nil, err
 	}
 
-	query := `
+	comments, args := scope.comments(username, labels)
+	query := fmt.Sprintf(`
 		SELECT 
-			week,
-			comments_count,
+			%s as week,
+			COUNT(*) as comments_count,
 			comment_type
-		FROM view_member_comment_activity
-		WHERE team_id = ?
-			AND github_username = ?
-			AND week >= ?
-			AND week <= ?
+		FROM %s
+		WHERE created_at >= ?
+			AND created_at < ?
+		GROUP BY week, comment_type
 		ORDER BY week, comment_type
-	`
+	`, s.yearWeek("created_at"), comments)
 
-	rows, err := s.db.Query(query, teamID, username, getWeekStr(startDate), getWeekStr(endDate))
+	from, to := dateRange(startDate, endDate)
+	rows, err := s.db.Query(query, append(args, from, to)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query member comments: %w", err)
 	}
@@ -632,10 +742,184 @@ func (s *MetricsService) GetMemberComments(teamID int, username string, startDat
 
 	return &CommentActivityResponse{
 		TeamID:   teamID,
-		TeamName: teamName,
+		TeamName: scope.TeamName,
 		Username: username,
 		Period: Period{Start: startDate.Format(time.RFC3339), End: endDate.Format(time.RFC3339)},
 		Metrics:  metrics,
 	}, nil
 }
 
+// velocityMetrics counts merged PRs per week from a deduplicated PR subquery.
+// weightColumn and weightJoin add weighted counts ("NULL" and "" without weighting).
+func (s *MetricsService) velocityMetrics(prs string, args []interface{}, weightColumn, weightJoin string, startDate, endDate time.Time) ([]VelocityMetric, error) {
+	query := fmt.Sprintf(`
+		SELECT 
+			%s as week,
+			COUNT(*) as prs_merged,
+			AVG(prs.cycle_time_hours) as avg_cycle_time_hours,
+			%s as weighted_prs_merged
+		FROM %s
+		%s
+		WHERE prs.merged_at IS NOT NULL
+			AND prs.merged_at >= ?
+			AND prs.merged_at < ?
+		GROUP BY week
+		ORDER BY week
+	`, s.weekStart("prs.merged_at"), weightColumn, prs, weightJoin)
+
+	from, to := dateRange(startDate, endDate)
+	rows, err := s.db.Query(query, append(args, from, to)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query velocity: %w", err)
+	}
+	defer rows.Close()
+
+	var metrics []VelocityMetric
+	for rows.Next() {
+		var metric VelocityMetric
+		var cycleTime, weightedPRs sql.NullFloat64
+		if err := rows.Scan(&metric.Period, &metric.PRsMerged, &cycleTime, &weightedPRs); err != nil {
+			return nil, fmt.Errorf("failed to scan velocity: %w", err)
+		}
+		if cycleTime.Valid {
+			metric.AvgCycleTimeHrs = cycleTime.Float64
+		}
+		if weightedPRs.Valid {
+			metric.WeightedPRsMerged = &weightedPRs.Float64
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, nil
+}
+
+// addWorkTypes breaks the velocity metrics computed from a deduplicated PR
+// subquery down by work type
+func (s *MetricsService) addWorkTypes(metrics []VelocityMetric, prs string, args []interface{}, startDate, endDate time.Time) error {
+	query := fmt.Sprintf(`
+		SELECT 
+			%s as week,
+			prs.work_type,
+			COUNT(*) as prs_merged
+		FROM %s
+		WHERE prs.merged_at IS NOT NULL
+			AND prs.merged_at >= ?
+			AND prs.merged_at < ?
+		GROUP BY week, prs.work_type
+	`, s.weekStart("prs.merged_at"), prs)
+
+	from, to := dateRange(startDate, endDate)
+	rows, err := s.db.Query(query, append(args, from, to)...)
+	if err != nil {
+		return fmt.Errorf("failed to query velocity by work type: %w", err)
+	}
+	defer rows.Close()
+
+	byPeriod := make(map[string]map[string]int)
+	for rows.Next() {
+		var period, workType string
+		var count int
+		if err := rows.Scan(&period, &workType, &count); err != nil {
+			return fmt.Errorf("failed to scan velocity by work type: %w", err)
+		}
+		if byPeriod[period] == nil {
+			byPeriod[period] = make(map[string]int)
+		}
+		byPeriod[period][workType] = count
+	}
+
+	for i := range metrics {
+		metrics[i].ByWorkType = byPeriod[metrics[i].Period]
+	}
+	return nil
+}
+
+// leadTimeMetrics computes monthly lead time from a deduplicated PR subquery
+// (uses avg/max as approximation for median/p95, as SQLite has no PERCENTILE_CONT)
+func (s *MetricsService) leadTimeMetrics(prs string, args []interface{}, startDate, endDate time.Time) ([]LeadTimeMetric, error) {
+	query := fmt.Sprintf(`
+		SELECT 
+			%s as month,
+			AVG(cycle_time_hours) as avg_lead_time_hours,
+			MAX(cycle_time_hours) as max_lead_time_hours,
+			AVG(ticket_lead_time_hours) as avg_ticket_lead_time_hours,
+			MAX(ticket_lead_time_hours) as max_ticket_lead_time_hours,
+			COUNT(ticket_lead_time_hours) as ticket_linked_prs
+		FROM %s
+		WHERE merged_at IS NOT NULL
+			AND merged_at >= ?
+			AND merged_at < ?
+		GROUP BY month
+		ORDER BY month
+	`, s.yearMonth("merged_at"), prs)
+
+	from, to := dateRange(startDate, endDate)
+	rows, err := s.db.Query(query, append(args, from, to)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lead time: %w", err)
+	}
+	defer rows.Close()
+
+	var metrics []LeadTimeMetric
+	for rows.Next() {
+		var metric LeadTimeMetric
+		var median, p95, ticketMedian, ticketP95 sql.NullFloat64
+		if err := rows.Scan(&metric.Period, &median, &p95, &ticketMedian, &ticketP95, &metric.TicketLinkedPRs); err != nil {
+			return nil, fmt.Errorf("failed to scan lead time: %w", err)
+		}
+		if median.Valid {
+			metric.MedianLeadTimeHrs = median.Float64
+		}
+		if p95.Valid {
+			metric.P95LeadTimeHrs = p95.Float64
+		}
+		if ticketMedian.Valid {
+			metric.MedianTicketLeadTimeHrs = &ticketMedian.Float64
+		}
+		if ticketP95.Valid {
+			metric.P95TicketLeadTimeHrs = &ticketP95.Float64
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, nil
+}
+
+// reviewTurnaroundMetrics computes monthly review turnaround from a deduplicated
+// PR subquery (uses avg/min as approximation for avg/median)
+func (s *MetricsService) reviewTurnaroundMetrics(prs string, args []interface{}, startDate, endDate time.Time) ([]ReviewTurnaroundMetric, error) {
+	query := fmt.Sprintf(`
+		SELECT 
+			%s as month,
+			AVG(review_turnaround_hours) as avg_turnaround_hours,
+			MIN(review_turnaround_hours) as min_turnaround_hours
+		FROM %s
+		WHERE first_review_at IS NOT NULL
+			AND created_at >= ?
+			AND created_at < ?
+		GROUP BY month
+		ORDER BY month
+	`, s.yearMonth("created_at"), prs)
+
+	from, to := dateRange(startDate, endDate)
+	rows, err := s.db.Query(query, append(args, from, to)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query review turnaround: %w", err)
+	}
+	defer rows.Close()
+
+	var metrics []ReviewTurnaroundMetric
+	for rows.Next() {
+		var metric ReviewTurnaroundMetric
+		var avg, median sql.NullFloat64
+		if err := rows.Scan(&metric.Period, &avg, &median); err != nil {
+			return nil, fmt.Errorf("failed to scan review turnaround: %w", err)
+		}
+		if avg.Valid {
+			metric.AvgTurnaroundHrs = avg.Float64
+		}
+		if median.Valid {
+			metric.MedianTurnaroundHrs = median.Float64
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, nil
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+)
+
+// newTestService creates a metrics service on a migrated SQLite database with
+// two teams, Payments (1) and Platform (2)
+func newTestService(t *testing.T) (*MetricsService, *database.DB) {
+	t.Helper()
+
+	db, err := database.Connect("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// Migrations are read relative to the repository root
+	originalDir, _ := os.Getwd()
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatalf("failed to change to repository root: %v", err)
+	}
+	defer os.Chdir(originalDir)
+
+	if err := db.RunMigrations(); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+	exec(t, db, "INSERT INTO teams (id, name) VALUES (1, 'Payments'), (2, 'Platform')")
+	return NewMetricsService(db), db
+}
+
+// exec runs a statement setting up test data
+func exec(t *testing.T, db *database.DB, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("failed to set up test data: %v", err)
+	}
+}
+
+// at returns a UTC time on a day of 2026
+func at(month time.Month, day int) time.Time {
+	return time.Date(2026, month, day, 12, 0, 0, 0, time.UTC)
+}
+
+// addMembership adds a membership period; left is zero for one that hasn't ended
+func addMembership(t *testing.T, db *database.DB, teamID int, username string, allocation float64, joined, left time.Time) {
+	t.Helper()
+	var leftAt *time.Time
+	if !left.IsZero() {
+		leftAt = &left
+	}
+	exec(t, db, "INSERT INTO team_memberships (team_id, github_username, allocation_weight, joined_at, left_at) VALUES (?, ?, ?, ?, ?)",
+		teamID, username, allocation, joined, leftAt)
+}
+
+// addMergedPR stores a merged PR credited to a team
+func addMergedPR(t *testing.T, db *database.DB, teamID, number int, author, role string, mergedAt time.Time) {
+	t.Helper()
+	exec(t, db, `INSERT INTO pr_metrics (team_id, pr_number, repository, author, created_at, merged_at, state, cycle_time_hours, attribution_role)
+		VALUES (?, ?, 'acme/api', ?, ?, ?, 'merged', 24, ?)`, teamID, number, author, mergedAt.Add(-24*time.Hour), mergedAt, role)
+}
+
+// weightedPRs returns the weighted PR count of each period of a team's weighted velocity
+func weightedPRs(t *testing.T, s *MetricsService, teamID int) []float64 {
+	t.Helper()
+	resp, err := s.GetTeamVelocity(teamID, at(1, 1), at(12, 31), "week", true, PRFilter{}, false)
+	if err != nil {
+		t.Fatalf("GetTeamVelocity() error = %v", err)
+	}
+	var weighted []float64
+	for _, metric := range resp.Metrics {
+		if metric.WeightedPRsMerged == nil {
+			t.Fatalf("period %s has no weighted count", metric.Period)
+		}
+		weighted = append(weighted, *metric.WeightedPRsMerged)
+	}
+	return weighted
+}
+
+// TestWeightedVelocityCoAuthor tests that a co-author's team is weighted by
+// the co-author's allocation rather than the PR author's
+func TestWeightedVelocityCoAuthor(t *testing.T) {
+	s, db := newTestService(t)
+	addMembership(t, db, 1, "alice", 0.5, at(1, 1), time.Time{})
+	addMembership(t, db, 2, "bob", 0.8, at(1, 1), time.Time{})
+
+	// alice's PR, co-authored by bob, is credited to both their teams
+	addMergedPR(t, db, 1, 7, "alice", "author", at(3, 4))
+	addMergedPR(t, db, 2, 7, "alice", "author", at(3, 4))
+	exec(t, db, "INSERT INTO pr_coauthors (team_id, repository, pr_number, github_username, weight) VALUES (2, 'acme/api', 7, 'bob', 0.5)")
+
+	for _, tt := range []struct {
+		name   string
+		teamID int
+		want   float64
+	}{
+		{"author's team", 1, 0.5},
+		{"co-author's team", 2, 0.8},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := weightedPRs(t, s, tt.teamID); len(got) != 1 || got[0] != tt.want {
+				t.Errorf("weighted PRs merged = %v, want [%v]", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWeightedVelocityAllocationAtEventTime tests that each PR is weighted by
+// the allocation in effect when it merged
+func TestWeightedVelocityAllocationAtEventTime(t *testing.T) {
+	s, db := newTestService(t)
+	addMembership(t, db, 1, "alice", 1, at(1, 1), at(2, 1))
+	addMembership(t, db, 1, "alice", 0.25, at(2, 1), time.Time{})
+
+	addMergedPR(t, db, 1, 1, "alice", "author", at(1, 14))
+	addMergedPR(t, db, 1, 2, "alice", "author", at(3, 4))
+
+	if got := weightedPRs(t, s, 1); len(got) != 2 || got[0] != 1 || got[1] != 0.25 {
+		t.Errorf("weighted PRs merged = %v, want [1 0.25]", got)
+	}
+}
+
+// TestAttributionRoles tests that delivery metrics count the PRs a team
+// authored by default, review metrics also count the ones it reviewed, and
+// the role filter overrides either default
+func TestAttributionRoles(t *testing.T) {
+	s, db := newTestService(t)
+	addMergedPR(t, db, 1, 1, "alice", "author", at(3, 4))
+	addMergedPR(t, db, 1, 2, "carol", "reviewer", at(3, 4))
+	addMergedPR(t, db, 1, 3, "alice", "both", at(3, 4))
+	addMergedPR(t, db, 1, 4, "carol", "ownership", at(3, 4))
+	exec(t, db, "UPDATE pr_metrics SET owned = TRUE WHERE pr_number = 4")
+
+	velocity := func(role string) int {
+		resp, err := s.GetTeamVelocity(1, at(1, 1), at(12, 31), "week", false, PRFilter{Role: role}, false)
+		if err != nil {
+			t.Fatalf("GetTeamVelocity() error = %v", err)
+		}
+		count := 0
+		for _, metric := range resp.Metrics {
+			count += metric.PRsMerged
+		}
+		return count
+	}
+	engagement := func(role string) int {
+		resp, err := s.GetReviewEngagement(1, at(1, 1), at(12, 31), PRFilter{Role: role})
+		if err != nil {
+			t.Fatalf("GetReviewEngagement() error = %v", err)
+		}
+		count := 0
+		for _, metric := range resp.Metrics {
+			count += metric.TotalReviews
+		}
+		return count
+	}
+
+	tests := []struct {
+		name   string
+		metric func(role string) int
+		role   string
+		want   int
+	}{
+		{"velocity counts authored PRs by default", velocity, "", 2},
+		{"velocity of reviewed PRs", velocity, RoleReviewed, 2},
+		{"velocity of owned PRs", velocity, RoleOwned, 1},
+		{"velocity of authored or owned PRs", velocity, RoleAuthored + "," + RoleOwned, 3},
+		{"velocity of every PR", velocity, RoleAll, 4},
+		{"review engagement counts authored and reviewed PRs by default", engagement, "", 3},
+		{"review engagement of authored PRs", engagement, RoleAuthored, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.metric(tt.role); got != tt.want {
+				t.Errorf("PR count for role %q = %d, want %d", tt.role, got, tt.want)
+			}
+		})
+	}
+
+	// The SQL views follow the same defaults
+	var merged int
+	if err := db.Get(&merged, "SELECT COALESCE(SUM(prs_merged), 0) FROM view_team_velocity WHERE team_id = 1"); err != nil {
+		t.Fatal(err)
+	}
+	if merged != 2 {
+		t.Errorf("view_team_velocity counts %d PRs, want the 2 authored", merged)
+	}
+}
+
+// TestLabelFilters tests that issue metrics are filtered by the issues' labels,
+// and commit and comment metrics by those of the PR or issue they belong to
+func TestLabelFilters(t *testing.T) {
+	s, db := newTestService(t)
+	addMergedPR(t, db, 1, 1, "alice", "author", at(3, 4))
+	exec(t, db, "INSERT INTO pr_labels (repository, pr_number, label) VALUES ('acme/api', 1, 'area:Payments')")
+	exec(t, db, "INSERT INTO pr_commits (repository, pr_number, commit_hash) VALUES ('acme/api', 1, 'c1')")
+	for _, hash := range []string{"c1", "c2"} {
+		exec(t, db, "INSERT INTO commit_metrics (team_id, repository, commit_hash, author, created_at) VALUES (1, 'acme/api', ?, 'alice', ?)", hash, at(3, 3))
+	}
+	for number := 1; number <= 2; number++ {
+		exec(t, db, `INSERT INTO issue_metrics (team_id, repository, issue_number, state, created_at, work_type, attribution_role)
+			VALUES (1, 'acme/api', ?, 'open', ?, 'bug', 'assignee')`, number, at(3, 2))
+	}
+	exec(t, db, "INSERT INTO issue_labels (repository, issue_number, label) VALUES ('acme/api', 2, 'area:payments')")
+	comments := []struct {
+		id          int
+		commentType string
+		number      *int
+	}{{1, "pull_request", intPtr(1)}, {2, "issue", intPtr(2)}, {3, "issue", intPtr(1)}, {4, "commit", nil}}
+	for _, comment := range comments {
+		exec(t, db, "INSERT INTO comment_metrics (team_id, repository, comment_id, author, created_at, comment_type, issue_number) VALUES (1, 'acme/api', ?, 'alice', ?, ?, ?)",
+			comment.id, at(3, 4), comment.commentType, comment.number)
+	}
+
+	openIssues := func(labels LabelFilter) int {
+		resp, err := s.GetBugAge(1, at(3, 10), "bug", labels)
+		if err != nil {
+			t.Fatalf("GetBugAge() error = %v", err)
+		}
+		return resp.OpenIssues
+	}
+	commits := func(labels LabelFilter) int {
+		resp, err := s.GetTeamCommits(1, at(1, 1), at(12, 31), false, labels)
+		if err != nil {
+			t.Fatalf("GetTeamCommits() error = %v", err)
+		}
+		count := 0
+		for _, metric := range resp.Metrics {
+			count += metric.CommitsCount
+		}
+		return count
+	}
+	teamComments := func(labels LabelFilter) int {
+		resp, err := s.GetTeamComments(1, at(1, 1), at(12, 31), false, labels)
+		if err != nil {
+			t.Fatalf("GetTeamComments() error = %v", err)
+		}
+		count := 0
+		for _, metric := range resp.Metrics {
+			count += metric.CommentsCount
+		}
+		return count
+	}
+
+	payments := LabelFilter{Labels: []string{"AREA:PAYMENTS"}}
+	notPayments := LabelFilter{ExcludeLabels: []string{"area:payments"}}
+	tests := []struct {
+		name   string
+		metric func(labels LabelFilter) int
+		labels LabelFilter
+		want   int
+	}{
+		{"issues", openIssues, LabelFilter{}, 2},
+		{"issues with a label", openIssues, payments, 1},
+		{"issues without a label", openIssues, notPayments, 1},
+		{"commits", commits, LabelFilter{}, 2},
+		{"commits of PRs with a label", commits, payments, 1},
+		{"commits outside PRs with a label", commits, notPayments, 1},
+		{"comments", teamComments, LabelFilter{}, 4},
+		{"comments on PRs and issues with a label", teamComments, payments, 2},
+		{"comments outside PRs and issues with a label", teamComments, notPayments, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.metric(tt.labels); got != tt.want {
+				t.Errorf("count with %+v = %d, want %d", tt.labels, got, tt.want)
+			}
+		})
+	}
+}
+
+// intPtr returns a pointer to i
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Repository represents a tracked repository and its settings
+type Repository struct {
+	ID                    int        `json:"id"`
+	Name                  string     `json:"name"`
+	DefaultBranch         *string    `json:"default_branch"`
+	TeamID                *int       `json:"team_id"`
+	TeamName              *string    `json:"team_name"`
+	Tags                  []string   `json:"tags"`
+	BaseBranches          []string   `json:"base_branches"`
+	ServiceGroup          *string    `json:"service_group"`
+	Active                bool       `json:"active"`
+	LookbackDays          *int       `json:"lookback_days"`
+	DeploymentSource      *string    `json:"deployment_source"`
+	DeploymentEnvironment *string    `json:"deployment_environment"`
+	Source                string     `json:"source"`
+	LastCollectedAt       *time.Time `json:"last_collected_at"`
+	LastRunAt             *time.Time `json:"last_run_at"`     // When the last run finished
+	LastRunStatus         *string    `json:"last_run_status"` // collected, skipped, failed or interrupted
+	LastRunError          *string    `json:"last_run_error"`
+}
+
+// RepositoryFilter limits the repositories listed; zero fields match everything
+type RepositoryFilter struct {
+	Tag          string
+	ServiceGroup string
+	TeamID       *int
+	Active       *bool
+}
+
+// RepositoryVelocityResponse represents the API response for repository velocity
+type RepositoryVelocityResponse struct {
+	RepositoryID int              `json:"repository_id"`
+	Repository   string           `json:"repository"`
+	Period       Period           `json:"period"`
+	Metrics      []VelocityMetric `json:"metrics"`
+}
+
+// RepositoryLeadTimeResponse represents the API response for repository lead time
+type RepositoryLeadTimeResponse struct {
+	RepositoryID int              `json:"repository_id"`
+	Repository   string           `json:"repository"`
+	Period       Period           `json:"period"`
+	Metrics      []LeadTimeMetric `json:"metrics"`
+}
+
+// RepositoryReviewTurnaroundResponse represents the API response for repository review turnaround
+type RepositoryReviewTurnaroundResponse struct {
+	RepositoryID int                      `json:"repository_id"`
+	Repository   string                   `json:"repository"`
+	Period       Period                   `json:"period"`
+	Metrics      []ReviewTurnaroundMetric `json:"metrics"`
+}
+
+// repositoryRow is a repositories row joined with its owning team and collection state
+type repositoryRow struct {
+	ID                    int            `db:"id"`
+	Name                  string         `db:"name"`
+	DefaultBranch         sql.NullString `db:"default_branch"`
+	TeamID                sql.NullInt64  `db:"team_id"`
+	TeamName              sql.NullString `db:"team_name"`
+	ServiceGroup          sql.NullString `db:"service_group"`
+	Active                bool           `db:"active"`
+	LookbackDays          sql.NullInt64  `db:"lookback_days"`
+	DeploymentSource      sql.NullString `db:"deployment_source"`
+	DeploymentEnvironment sql.NullString `db:"deployment_environment"`
+	Source                string         `db:"source"`
+	LastCollectedAt       sql.NullTime   `db:"last_collected_at"`
+	LastRunAt             sql.NullTime   `db:"last_run_finished_at"`
+	LastRunStatus         sql.NullString `db:"last_run_status"`
+	LastRunError          sql.NullString `db:"last_run_error"`
+}
+
+// ListRepositories returns the tracked repositories matching a filter, by name
+func (s *MetricsService) ListRepositories(filter RepositoryFilter) ([]Repository, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Tag != "" {
+		conditions = append(conditions, "r.id IN (SELECT repository_id FROM repository_tags WHERE tag = ?)")
+		args = append(args, filter.Tag)
+	}
+	if filter.ServiceGroup != "" {
+		conditions = append(conditions, "r.service_group = ?")
+		args = append(args, filter.ServiceGroup)
+	}
+	if filter.TeamID != nil {
+		conditions = append(conditions, "r.team_id = ?")
+		args = append(args, *filter.TeamID)
+	}
+	if filter.Active != nil {
+		conditions = append(conditions, "r.active = ?")
+		args = append(args, *filter.Active)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	return s.queryRepositories(where, args...)
+}
+
+// GetRepository returns a tracked repository by ID
+func (s *MetricsService) GetRepository(repositoryID int) (*Repository, error) {
+	repos, err := s.queryRepositories("WHERE r.id = ?", repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	if len(repos) == 0 {
+		return nil, fmt.Errorf("repository not found")
+	}
+	return &repos[0], nil
+}
+
+// queryRepositories loads repositories with their owning team, tags and last collection time
+func (s *MetricsService) queryRepositories(where string, args ...interface{}) ([]Repository, error) {
+	query := fmt.Sprintf(`
+		SELECT
+			r.id,
+			r.name,
+			r.default_branch,
+			r.team_id,
+			t.name as team_name,
+			r.service_group,
+			r.active,
+			r.lookback_days,
+			r.deployment_source,
+			r.deployment_environment,
+			r.source,
+			cm.last_collected_at,
+			cm.last_run_finished_at,
+			cm.last_run_status,
+			cm.last_run_error
+		FROM repositories r
+		LEFT JOIN teams t ON t.id = r.team_id
+		LEFT JOIN collection_metadata cm ON cm.repository = r.name
+		%s
+		ORDER BY r.name
+	`, where)
+
+	var rows []repositoryRow
+	if err := s.db.Select(&rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to query repositories: %w", err)
+	}
+
+	tags, err := s.repositoryValues("repository_tags", "tag")
+	if err != nil {
+		return nil, err
+	}
+	baseBranches, err := s.repositoryValues("repository_base_branches", "pattern")
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]Repository, 0, len(rows))
+	for _, row := range rows {
+		repo := Repository{
+			ID:           row.ID,
+			Name:         row.Name,
+			Tags:         tags[row.ID],
+			BaseBranches: baseBranches[row.ID],
+			Active:       row.Active,
+			Source:       row.Source,
+		}
+		if repo.Tags == nil {
+			repo.Tags = []string{}
+		}
+		if repo.BaseBranches == nil {
+			repo.BaseBranches = []string{}
+		}
+		if row.DefaultBranch.Valid {
+			repo.DefaultBranch = &row.DefaultBranch.String
+		}
+		if row.TeamID.Valid {
+			id := int(row.TeamID.Int64)
+			repo.TeamID = &id
+		}
+		if row.TeamName.Valid {
+			repo.TeamName = &row.TeamName.String
+		}
+		if row.ServiceGroup.Valid {
+			repo.ServiceGroup = &row.ServiceGroup.String
+		}
+		if row.LookbackDays.Valid {
+			days := int(row.LookbackDays.Int64)
+			repo.LookbackDays = &days
+		}
+		if row.DeploymentSource.Valid {
+			repo.DeploymentSource = &row.DeploymentSource.String
+		}
+		if row.DeploymentEnvironment.Valid {
+			repo.DeploymentEnvironment = &row.DeploymentEnvironment.String
+		}
+		if row.LastCollectedAt.Valid {
+			repo.LastCollectedAt = &row.LastCollectedAt.Time
+		}
+		if row.LastRunAt.Valid {
+			repo.LastRunAt = &row.LastRunAt.Time
+		}
+		if row.LastRunStatus.Valid {
+			repo.LastRunStatus = &row.LastRunStatus.String
+		}
+		if row.LastRunError.Valid {
+			repo.LastRunError = &row.LastRunError.String
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+// repositoryValues returns a per-repository list setting (such as tags) of
+// every repository, keyed by repository ID
+func (s *MetricsService) repositoryValues(table, column string) (map[int][]string, error) {
+	rows, err := s.db.Query(fmt.Sprintf("SELECT repository_id, %[2]s FROM %[1]s ORDER BY %[2]s", table, column))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	values := make(map[int][]string)
+	for rows.Next() {
+		var repositoryID int
+		var value string
+		if err := rows.Scan(&repositoryID, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		values[repositoryID] = append(values[repositoryID], value)
+	}
+	return values, nil
+}
+
+// getRepositoryName looks up a repository's owner/repo name
+func (s *MetricsService) getRepositoryName(repositoryID int) (string, error) {
+	var name string
+	err := s.db.QueryRow("SELECT name FROM repositories WHERE id = ?", repositoryID).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("repository not found")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get repository: %w", err)
+	}
+	return name, nil
+}
+
+// repositoryPRs returns a subquery with one row per PR collected from a
+// repository, limited by the filter's labels (every role counts).
+// Only PRs credited to at least one team are collected.
+func repositoryPRs(repository string, filter PRFilter) (string, []interface{}) {
+	filter.Role = RoleAll
+	condition, args := filter.condition("pr_metrics")
+	return dedupedPRs("repository = ?" + condition), append([]interface{}{repository}, args...)
+}
+
+// repositoryDeliveryPRs is repositoryPRs limited to PRs into a base branch matching the repository's rules
+func repositoryDeliveryPRs(repository string, filter PRFilter) (string, []interface{}) {
+	filter.Role = RoleAll
+	condition, args := filter.condition("pr_metrics")
+	return dedupedPRs("repository = ?" + condition + baseCondition("")), append([]interface{}{repository}, args...)
+}
+
+// GetRepositoryVelocity returns velocity metrics for a repository.
+// byWorkType also breaks each period's count down by work type.
+func (s *MetricsService) GetRepositoryVelocity(repositoryID int, startDate, endDate time.Time, filter PRFilter, byWorkType bool) (*RepositoryVelocityResponse, error) {
+	name, err := s.getRepositoryName(repositoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	prs, args := repositoryDeliveryPRs(name, filter)
+	metrics, err := s.velocityMetrics(prs, args, "NULL", "", startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	if byWorkType {
+		if err := s.addWorkTypes(metrics, prs, args, startDate, endDate); err != nil {
+			return nil, err
+		}
+	}
+
+	return &RepositoryVelocityResponse{
+		RepositoryID: repositoryID,
+		Repository:   name,
+		Period: Period{
+			Start: startDate.Format(time.RFC3339),
+			End:   endDate.Format(time.RFC3339),
+		},
+		Metrics: metrics,
+	}, nil
+}
+
+// GetRepositoryLeadTime returns DORA lead time metrics for a repository
+func (s *MetricsService) GetRepositoryLeadTime(repositoryID int, startDate, endDate time.Time, filter PRFilter) (*RepositoryLeadTimeResponse, error) {
+	name, err := s.getRepositoryName(repositoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	prs, args := repositoryDeliveryPRs(name, filter)
+	metrics, err := s.leadTimeMetrics(prs, args, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RepositoryLeadTimeResponse{
+		RepositoryID: repositoryID,
+		Repository:   name,
+		Period: Period{
+			Start: startDate.Format(time.RFC3339),
+			End:   endDate.Format(time.RFC3339),
+		},
+		Metrics: metrics,
+	}, nil
+}
+
+// GetRepositoryReviewTurnaround returns review turnaround metrics for a repository
+func (s *MetricsService) GetRepositoryReviewTurnaround(repositoryID int, startDate, endDate time.Time, filter PRFilter) (*RepositoryReviewTurnaroundResponse, error) {
+	name, err := s.getRepositoryName(repositoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	prs, args := repositoryPRs(name, filter)
+	metrics, err := s.reviewTurnaroundMetrics(prs, args, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RepositoryReviewTurnaroundResponse{
+		RepositoryID: repositoryID,
+		Repository:   name,
+		Period: Period{
+			Start: startDate.Format(time.RFC3339),
+			End:   endDate.Format(time.RFC3339),
+		},
+		Metrics: metrics,
+	}, nil
+}
@@ -0,0 +1,413 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// teamScope is a team together with all of its descendant teams.
+// Metrics for a team roll up everything credited to its subtree.
+type teamScope struct {
+	TeamID   int
+	TeamName string
+	TeamIDs  []int
+}
+
+// resolveScope looks up a team and collects the IDs of its descendants
+func (s *MetricsService) resolveScope(teamID int) (*teamScope, error) {
+	teamName, err := s.getTeamName(teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	// UNION (not UNION ALL) stops the recursion even if the hierarchy has a cycle
+	query := `
+		WITH RECURSIVE subtree(id) AS (
+			SELECT id FROM teams WHERE id = ?
+			UNION
+			SELECT t.id FROM teams t JOIN subtree st ON t.parent_id = st.id
+		)
+		SELECT id FROM subtree ORDER BY id
+	`
+
+	var teamIDs []int
+	if err := s.db.Select(&teamIDs, query, teamID); err != nil {
+		return nil, fmt.Errorf("failed to resolve team hierarchy: %w", err)
+	}
+
+	return &teamScope{TeamID: teamID, TeamName: teamName, TeamIDs: teamIDs}, nil
+}
+
+// filter returns a "column IN (...)" condition matching the teams in scope
+func (sc *teamScope) filter(column string) (string, []interface{}) {
+	placeholders := make([]string, len(sc.TeamIDs))
+	args := make([]interface{}, len(sc.TeamIDs))
+	for i, id := range sc.TeamIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	return fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", ")), args
+}
+
+// Attribution roles PR metrics can be limited to. A role parameter may list
+// several of them separated by commas, matching PRs credited by any of them.
+const (
+	RoleAuthored = "authored" // teams whose members authored or co-authored the PR
+	RoleReviewed = "reviewed" // teams whose members reviewed the PR
+	RoleOwned    = "owned"    // teams owning changed files according to CODEOWNERS
+	RoleAll      = "all"      // every PR credited to the team
+)
+
+// Default roles when none is requested: throughput views count what a team
+// shipped, review views also include the PRs its members reviewed
+const (
+	defaultDeliveryRole = RoleAuthored
+	defaultReviewRole   = RoleAuthored + "," + RoleReviewed
+)
+
+// ValidRole reports whether role is empty (the endpoint's default) or a
+// comma-separated list of known attribution roles
+func ValidRole(role string) bool {
+	if role == "" {
+		return true
+	}
+	for _, r := range strings.Split(role, ",") {
+		switch strings.TrimSpace(r) {
+		case RoleAuthored, RoleReviewed, RoleOwned, RoleAll:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// roleOrDefault returns role, or the endpoint's default if none was requested
+func roleOrDefault(role, defaultRole string) string {
+	if role == "" {
+		return defaultRole
+	}
+	return role
+}
+
+// roleCondition returns an " AND ..." condition limiting pr_metrics rows (with
+// the given column prefix) to the listed attribution roles, or "" for every role
+func roleCondition(prefix, role string) string {
+	var conditions []string
+	for _, r := range strings.Split(role, ",") {
+		switch strings.TrimSpace(r) {
+		case RoleAuthored:
+			conditions = append(conditions, fmt.Sprintf("%sattribution_role IN ('author', 'both')", prefix))
+		case RoleReviewed:
+			conditions = append(conditions, fmt.Sprintf("%sattribution_role IN ('reviewer', 'both')", prefix))
+		case RoleOwned:
+			conditions = append(conditions, fmt.Sprintf("%sowned = TRUE", prefix))
+		case RoleAll, "":
+			return ""
+		}
+	}
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " AND (" + strings.Join(conditions, " OR ") + ")"
+}
+
+// PRFilter limits the PRs that PR metrics are computed over
+type PRFilter struct {
+	Role          string   // Attribution roles (see ValidRole); "" for the endpoint's default
+	Labels        []string // Only PRs with any of these labels
+	ExcludeLabels []string // Leaves out PRs with any of these labels
+}
+
+// condition returns an " AND ..." condition limiting rows of the pr_metrics
+// table (or its alias) to the filter's roles and labels. Labels match case-insensitively.
+func (f PRFilter) condition(table string) (string, []interface{}) {
+	labels, args := labelConditions(f.Labels, f.ExcludeLabels, 1, func(placeholders string) string {
+		return fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM pr_labels l
+			WHERE l.repository = %[1]s.repository AND l.pr_number = %[1]s.pr_number
+			AND LOWER(l.label) IN (%[2]s)
+		)`, table, placeholders)
+	})
+	return roleCondition(table+".", f.Role) + labels, args
+}
+
+// LabelFilter limits the issues, commits and comments that metrics are
+// computed over by label: an issue's own labels, and for commits and comments
+// those of the PR or issue they belong to. Ones that belong to none only pass
+// exclude_labels.
+type LabelFilter struct {
+	Labels        []string // Only items with any of these labels
+	ExcludeLabels []string // Leaves out items with any of these labels
+}
+
+// issueCondition returns an " AND ..." condition limiting rows of the
+// issue_metrics table (or its alias) to the filter's labels
+func (f LabelFilter) issueCondition(table string) (string, []interface{}) {
+	return labelConditions(f.Labels, f.ExcludeLabels, 1, func(placeholders string) string {
+		return fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM issue_labels l
+			WHERE l.repository = %[1]s.repository AND l.issue_number = %[1]s.issue_number
+			AND LOWER(l.label) IN (%[2]s)
+		)`, table, placeholders)
+	})
+}
+
+// commitCondition returns an " AND ..." condition limiting rows of a table
+// keyed by repository and commit_hash to commits of PRs with the filter's labels
+func (f LabelFilter) commitCondition(table string) (string, []interface{}) {
+	return labelConditions(f.Labels, f.ExcludeLabels, 1, func(placeholders string) string {
+		return fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM pr_commits pc
+			JOIN pr_labels l ON l.repository = pc.repository AND l.pr_number = pc.pr_number
+			WHERE pc.repository = %[1]s.repository AND pc.commit_hash = %[1]s.commit_hash
+			AND LOWER(l.label) IN (%[2]s)
+		)`, table, placeholders)
+	})
+}
+
+// commentCondition returns an " AND ..." condition limiting rows of the
+// comment_metrics table (or its alias) to conversation comments on PRs or
+// issues with the filter's labels
+func (f LabelFilter) commentCondition(table string) (string, []interface{}) {
+	return labelConditions(f.Labels, f.ExcludeLabels, 2, func(placeholders string) string {
+		return fmt.Sprintf(`(EXISTS (
+			SELECT 1 FROM pr_labels l
+			WHERE %[1]s.comment_type = 'pull_request'
+			AND l.repository = %[1]s.repository AND l.pr_number = %[1]s.issue_number
+			AND LOWER(l.label) IN (%[2]s)
+		) OR EXISTS (
+			SELECT 1 FROM issue_labels l
+			WHERE %[1]s.comment_type = 'issue'
+			AND l.repository = %[1]s.repository AND l.issue_number = %[1]s.issue_number
+			AND LOWER(l.label) IN (%[2]s)
+		))`, table, placeholders)
+	})
+}
+
+// labelConditions returns " AND ..." conditions requiring any of labels and
+// none of excluded, lowercased. exists returns the condition matching any
+// label of a list, given its placeholders, which it uses the given number of times.
+func labelConditions(labels, excluded []string, uses int, exists func(placeholders string) string) (string, []interface{}) {
+	var condition string
+	var args []interface{}
+	match := func(labels []string) string {
+		placeholders := make([]string, len(labels))
+		for i := range labels {
+			placeholders[i] = "?"
+		}
+		for i := 0; i < uses; i++ {
+			for _, label := range labels {
+				args = append(args, strings.ToLower(label))
+			}
+		}
+		return exists(strings.Join(placeholders, ", "))
+	}
+	if len(labels) > 0 {
+		condition += " AND " + match(labels)
+	}
+	if len(excluded) > 0 {
+		condition += " AND NOT " + match(excluded)
+	}
+	return condition, args
+}
+
+// labels returns the filter's labels, for the commits and comments of its PRs
+func (f PRFilter) labels() LabelFilter {
+	return LabelFilter{Labels: f.Labels, ExcludeLabels: f.ExcludeLabels}
+}
+
+// withDefaultRole returns the filter with the endpoint's default role if none was requested
+func (f PRFilter) withDefaultRole(defaultRole string) PRFilter {
+	f.Role = roleOrDefault(f.Role, defaultRole)
+	return f
+}
+
+// prs returns a subquery with one row per PR credited to the scope, limited by
+// the filter. A PR attributed to several teams in the
+// scope is counted once; the per-team columns (such as external reviewers)
+// take the smallest value, since a reviewer outside the whole subtree is
+// outside every team in it.
+func (sc *teamScope) prs(filter PRFilter) (string, []interface{}) {
+	where, args := sc.filter("team_id")
+	condition, conditionArgs := filter.condition("pr_metrics")
+	return dedupedPRs(where + condition), append(args, conditionArgs...)
+}
+
+// deliveryPRs is prs further limited to PRs merged into a base branch that
+// counts for their repository, so work merged into a feature branch or down a
+// stack isn't counted again when that branch merges
+func (sc *teamScope) deliveryPRs(filter PRFilter) (string, []interface{}) {
+	where, args := sc.filter("team_id")
+	condition, conditionArgs := filter.condition("pr_metrics")
+	return dedupedPRs(where + condition + baseCondition("")), append(args, conditionArgs...)
+}
+
+// baseCondition returns an " AND ..." condition limiting pr_metrics rows (with
+// the given column prefix) to PRs into a base branch matching the repository's rules
+func baseCondition(prefix string) string {
+	return fmt.Sprintf(" AND %sbase_matched = TRUE", prefix)
+}
+
+// dedupedPRs returns a subquery aliased "prs" with one row per PR among the
+// pr_metrics rows matching filter, however many teams each is credited to
+func dedupedPRs(filter string) string {
+	return fmt.Sprintf(`(
+		SELECT
+			repository,
+			pr_number,
+			MIN(author) as author,
+			MIN(created_at) as created_at,
+			MIN(merged_at) as merged_at,
+			MIN(cycle_time_hours) as cycle_time_hours,
+			MIN(first_review_at) as first_review_at,
+			MIN(review_turnaround_hours) as review_turnaround_hours,
+			MIN(reviewers_count) as reviewers_count,
+			MIN(external_reviewers_count) as external_reviewers_count,
+			MIN(work_type) as work_type,
+			MIN(ticket_lead_time_hours) as ticket_lead_time_hours,
+			%s as conventional
+		FROM pr_metrics
+		WHERE %s
+		GROUP BY repository, pr_number
+	) prs`, conventionalFlag, filter)
+}
+
+// conventionalFlag aggregates the conventional column as 1, 0 or NULL (not yet
+// checked), which sums and counts the same on SQLite and PostgreSQL booleans
+const conventionalFlag = "MIN(CASE WHEN conventional THEN 1 WHEN NOT conventional THEN 0 END)"
+
+// commits returns a subquery with one row per commit credited to the scope,
+// limited by the labels of the PRs they belong to
+func (sc *teamScope) commits(username string, labels LabelFilter) (string, []interface{}) {
+	filter, args := sc.filter("team_id")
+	if username != "" {
+		filter += " AND author = ?"
+		args = append(args, username)
+	}
+	condition, labelArgs := labels.commitCondition("commit_metrics")
+	filter += condition
+	args = append(args, labelArgs...)
+	query := fmt.Sprintf(`(
+		SELECT repository, commit_hash, MIN(created_at) as created_at, %s as conventional
+		FROM commit_metrics
+		WHERE %s
+		GROUP BY repository, commit_hash
+	) commits`, conventionalFlag, filter)
+	return query, args
+}
+
+// comments returns a subquery with one row per comment credited to the scope,
+// limited by the labels of the PRs or issues they belong to
+func (sc *teamScope) comments(username string, labels LabelFilter) (string, []interface{}) {
+	filter, args := sc.filter("team_id")
+	if username != "" {
+		filter += " AND author = ?"
+		args = append(args, username)
+	}
+	condition, labelArgs := labels.commentCondition("comment_metrics")
+	filter += condition
+	args = append(args, labelArgs...)
+	query := fmt.Sprintf(`(
+		SELECT repository, comment_id, comment_type, MIN(created_at) as created_at
+		FROM comment_metrics
+		WHERE %s
+		GROUP BY repository, comment_id, comment_type
+	) comments`, filter)
+	return query, args
+}
+
+// membershipJoin joins the membership in effect when an event happened, for
+// allocation weighting. The member is the row's author, or for PRs also a
+// co-author credited to the row's team.
+func membershipJoin(alias, eventColumn string, coAuthors bool) string {
+	member := fmt.Sprintf("tm.github_username = %s.author", alias)
+	if coAuthors {
+		member = fmt.Sprintf(`(%[2]s OR EXISTS (
+				SELECT 1 FROM pr_coauthors c
+				WHERE c.team_id = %[1]s.team_id AND c.repository = %[1]s.repository
+				AND c.pr_number = %[1]s.pr_number AND c.github_username = tm.github_username
+			))`, alias, member)
+	}
+	return fmt.Sprintf(`LEFT JOIN team_memberships tm
+			ON tm.team_id = %[1]s.team_id
+			AND %[3]s
+			AND tm.joined_at <= %[1]s.%[2]s
+			AND (tm.left_at IS NULL OR tm.left_at > %[1]s.%[2]s)`, alias, eventColumn, member)
+}
+
+// weekStart buckets a timestamp by the date its week starts on (Monday), as view_team_velocity does
+func (s *MetricsService) weekStart(column string) string {
+	if s.db.Driver() == "postgres" {
+		return fmt.Sprintf("TO_CHAR(DATE_TRUNC('week', %s), 'YYYY-MM-DD')", column)
+	}
+	return fmt.Sprintf("DATE(%s, 'weekday 0', '-6 days')", column)
+}
+
+// yearWeek buckets a timestamp by year and week number, as the commit and comment views do
+func (s *MetricsService) yearWeek(column string) string {
+	if s.db.Driver() == "postgres" {
+		return fmt.Sprintf("TO_CHAR(%s, 'IYYY-IW')", column)
+	}
+	return fmt.Sprintf("strftime('%%Y-%%W', %s)", column)
+}
+
+// yearMonth buckets a timestamp by month ("2006-01")
+func (s *MetricsService) yearMonth(column string) string {
+	if s.db.Driver() == "postgres" {
+		return fmt.Sprintf("TO_CHAR(%s, 'YYYY-MM')", column)
+	}
+	return fmt.Sprintf("strftime('%%Y-%%m', %s)", column)
+}
+
+// dateRange returns the bounds for filtering timestamps between two dates, end date inclusive
+func dateRange(startDate, endDate time.Time) (time.Time, time.Time) {
+	start := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	return start, end
+}
+
+// weightJoin joins a per-row allocation weight onto a deduplicated subquery.
+// Each team row carries the allocation of the member it credits the team for
+// (the highest, if several are), and weights are summed over every team in
+// scope the row is credited to, so a PR by someone split across two child
+// teams counts their full allocation. PR rows also credit co-authors.
+// condition (with its args) further limits the rows of table (aliased "e"), e.g. to a role.
+func (sc *teamScope) weightJoin(table, alias string, keys []string, eventColumn, condition string, conditionArgs ...interface{}) (string, []interface{}) {
+	filter, args := sc.filter("e.team_id")
+	filter += condition
+	args = append(args, conditionArgs...)
+
+	selected := make([]string, len(keys))
+	matches := make([]string, len(keys))
+	for i, key := range keys {
+		selected[i] = "e." + key
+		matches[i] = fmt.Sprintf("w.%[1]s = %[2]s.%[1]s", key, alias)
+	}
+
+	query := fmt.Sprintf(`LEFT JOIN (
+		SELECT %[1]s, SUM(weight) as weight
+		FROM (
+			SELECT %[2]s, e.team_id, MAX(COALESCE(tm.allocation_weight, 0)) as weight
+			FROM %[3]s e
+			%[4]s
+			WHERE %[5]s
+			GROUP BY %[2]s, e.team_id
+		) credited
+		GROUP BY %[1]s
+	) w ON %[6]s`, strings.Join(keys, ", "), strings.Join(selected, ", "), table,
+		membershipJoin("e", eventColumn, table == "pr_metrics"), filter, strings.Join(matches, " AND "))
+	return query, args
+}
+
+// fte returns the sum of current member allocations across the scope
+func (s *MetricsService) fte(sc *teamScope) (*float64, error) {
+	filter, args := sc.filter("team_id")
+
+	var fte float64
+	err := s.db.QueryRow("SELECT COALESCE(SUM(fte), 0) FROM view_team_fte WHERE "+filter, args...).Scan(&fte)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team FTE: %w", err)
+	}
+	return &fte, nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// WorkTypeShare is one work type's part of a period's merged PRs
+type WorkTypeShare struct {
+	WorkType  string  `json:"work_type"`
+	PRsMerged int     `json:"prs_merged"`
+	Share     float64 `json:"share"` // Percentage of the period's merged PRs
+}
+
+// WorkMixMetric represents the mix of work merged in a month
+type WorkMixMetric struct {
+	Period    string          `json:"period"`
+	PRsMerged int             `json:"prs_merged"`
+	WorkTypes []WorkTypeShare `json:"work_types"`
+
+	// Percentage of merged PR titles and of the team's commit messages that
+	// follow Conventional Commits (null when none were checked)
+	ConventionalRate       *float64 `json:"conventional_rate"`
+	CommitConventionalRate *float64 `json:"commit_conventional_rate"`
+}
+
+// WorkMixResponse represents the API response for work mix
+type WorkMixResponse struct {
+	TeamID   int             `json:"team_id"`
+	TeamName string          `json:"team_name"`
+	Period   Period          `json:"period"`
+	Metrics  []WorkMixMetric `json:"metrics"`
+}
+
+// GetWorkMix returns the monthly share of each work type among the PRs a team
+// and its sub-teams merged, along with their Conventional Commits compliance.
+// PRs are limited by the filter, by default to those the team authored, and
+// commits by the labels of the PRs they belong to.
+func (s *MetricsService) GetWorkMix(teamID int, startDate, endDate time.Time, filter PRFilter) (*WorkMixResponse, error) {
+	scope, err := s.resolveScope(teamID)
+	if err != nil {
+		return nil, err
+	}
+	filter = filter.withDefaultRole(defaultDeliveryRole)
+	from, to := dateRange(startDate, endDate)
+
+	prs, args := scope.deliveryPRs(filter)
+	query := fmt.Sprintf(`
+		SELECT
+			%s as month,
+			prs.work_type,
+			COUNT(*) as prs_merged,
+			COUNT(prs.conventional) as checked,
+			COALESCE(SUM(prs.conventional), 0) as conventional
+		FROM %s
+		WHERE prs.merged_at IS NOT NULL
+			AND prs.merged_at >= ?
+			AND prs.merged_at < ?
+		GROUP BY month, prs.work_type
+	`, s.yearMonth("prs.merged_at"), prs)
+
+	var prRows []struct {
+		Month        string `db:"month"`
+		WorkType     string `db:"work_type"`
+		PRsMerged    int    `db:"prs_merged"`
+		Checked      int    `db:"checked"`
+		Conventional int    `db:"conventional"`
+	}
+	if err := s.db.Select(&prRows, query, append(args, from, to)...); err != nil {
+		return nil, fmt.Errorf("failed to query work mix: %w", err)
+	}
+
+	commits, commitArgs := scope.commits("", filter.labels())
+	query = fmt.Sprintf(`
+		SELECT
+			%s as month,
+			COUNT(commits.conventional) as checked,
+			COALESCE(SUM(commits.conventional), 0) as conventional
+		FROM %s
+		WHERE commits.created_at >= ?
+			AND commits.created_at < ?
+		GROUP BY month
+	`, s.yearMonth("commits.created_at"), commits)
+
+	var commitRows []struct {
+		Month        string `db:"month"`
+		Checked      int    `db:"checked"`
+		Conventional int    `db:"conventional"`
+	}
+	if err := s.db.Select(&commitRows, query, append(commitArgs, from, to)...); err != nil {
+		return nil, fmt.Errorf("failed to query commit compliance: %w", err)
+	}
+
+	// Months with commits but no merged PRs still report commit compliance
+	months := make(map[string]*WorkMixMetric)
+	month := func(period string) *WorkMixMetric {
+		if months[period] == nil {
+			months[period] = &WorkMixMetric{Period: period, WorkTypes: []WorkTypeShare{}}
+		}
+		return months[period]
+	}
+	prChecked, prConventional := make(map[string]int), make(map[string]int)
+	for _, row := range prRows {
+		metric := month(row.Month)
+		metric.PRsMerged += row.PRsMerged
+		metric.WorkTypes = append(metric.WorkTypes, WorkTypeShare{WorkType: row.WorkType, PRsMerged: row.PRsMerged})
+		prChecked[row.Month] += row.Checked
+		prConventional[row.Month] += row.Conventional
+	}
+	for _, row := range commitRows {
+		month(row.Month).CommitConventionalRate = percentage(row.Conventional, row.Checked)
+	}
+
+	metrics := make([]WorkMixMetric, 0, len(months))
+	for period, metric := range months {
+		for i := range metric.WorkTypes {
+			metric.WorkTypes[i].Share = float64(metric.WorkTypes[i].PRsMerged) * 100 / float64(metric.PRsMerged)
+		}
+		sort.Slice(metric.WorkTypes, func(i, j int) bool {
+			a, b := metric.WorkTypes[i], metric.WorkTypes[j]
+			if a.PRsMerged != b.PRsMerged {
+				return a.PRsMerged > b.PRsMerged
+			}
+			return a.WorkType < b.WorkType
+		})
+		metric.ConventionalRate = percentage(prConventional[period], prChecked[period])
+		metrics = append(metrics, *metric)
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Period < metrics[j].Period })
+
+	return &WorkMixResponse{
+		TeamID:   teamID,
+		TeamName: scope.TeamName,
+		Period: Period{
+			Start: startDate.Format(time.RFC3339),
+			End:   endDate.Format(time.RFC3339),
+		},
+		Metrics: metrics,
+	}, nil
+}
+
+// percentage returns part as a percentage of total, or nil if total is 0
+func percentage(part, total int) *float64 {
+	if total == 0 {
+		return nil
+	}
+	rate := float64(part) * 100 / float64(total)
+	return &rate
+}
@@ -0,0 +1,32 @@
+package source
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Hosts holds the provider for each code host repositories are collected
+// from: github.com, GitHub Enterprise Server and GitLab instances, each with
+// its own credentials and rate limit
+type Hosts struct {
+	providers map[string]Provider
+}
+
+// NewHosts creates an empty set of providers
+func NewHosts() *Hosts {
+	return &Hosts{providers: make(map[string]Provider)}
+}
+
+// Add registers the provider for a host
+func (h *Hosts) Add(host string, provider Provider) {
+	h.providers[strings.ToLower(host)] = provider
+}
+
+// Provider returns the provider for a host
+func (h *Hosts) Provider(host string) (Provider, error) {
+	provider, ok := h.providers[strings.ToLower(host)]
+	if !ok {
+		return nil, fmt.Errorf("no provider for host %s (add it to GITHUB_HOSTS, GITLAB_HOSTS or BITBUCKET_HOSTS)", host)
+	}
+	return provider, nil
+}
@@ -0,0 +1,27 @@
+package source
+
+import "testing"
+
+// namedProvider is a Provider that only knows its name
+type namedProvider struct {
+	Provider
+	name string
+}
+
+// TestHostsProvider tests looking up providers by host, ignoring case
+func TestHostsProvider(t *testing.T) {
+	hosts := NewHosts()
+	hosts.Add("github.com", namedProvider{name: "github"})
+	hosts.Add("GitLab.corp.example", namedProvider{name: "gitlab"})
+
+	provider, err := hosts.Provider("gitlab.CORP.example")
+	if err != nil {
+		t.Fatalf("Provider() error = %v", err)
+	}
+	if got := provider.(namedProvider).name; got != "gitlab" {
+		t.Errorf("Provider() = %s, want gitlab", got)
+	}
+	if _, err := hosts.Provider("ghe.other.example"); err == nil {
+		t.Errorf("Provider() for an unknown host returned no error")
+	}
+}
@@ -0,0 +1,139 @@
+// Package source defines a provider-neutral view of a code host (GitHub,
+// GitLab, Bitbucket, ...): the change requests, reviews, comments, commits,
+// issues and deployments metrics are computed from. Each code host has an
+// adapter implementing Provider.
+package source
+
+import "time"
+
+// Provider reads a repository's activity from a code host. Repositories are
+// identified by owner (user, organization, group or workspace) and name.
+// Activity is read from since up to until, or up to now if until is zero, so
+// backfill windows split a repository's history between them.
+type Provider interface {
+	// ChangeRequests returns the change requests (pull or merge requests)
+	// last updated between since and until, most recently updated first
+	ChangeRequests(owner, repo string, since, until time.Time) ([]ChangeRequest, error)
+	Reviews(owner, repo string, number int) ([]Review, error)
+	ReviewComments(owner, repo string, number int) ([]ReviewComment, error)
+	ChangeRequestCommits(owner, repo string, number int) ([]Commit, error)
+	ChangeRequestFiles(owner, repo string, number int) ([]string, error)
+
+	// Commits returns the commits on the default branch authored between since and until
+	Commits(owner, repo string, since, until time.Time) ([]Commit, error)
+
+	// Issues returns the issues (not change requests) last updated between since and until
+	Issues(owner, repo string, since, until time.Time) ([]Issue, error)
+
+	// Comments returns the conversation comments on issues and change
+	// requests and the comments on commits made between since and until
+	Comments(owner, repo string, since, until time.Time) ([]Comment, error)
+
+	// Deployments returns the deployments created between since and until, to
+	// one environment or to all of them if environment is ""
+	Deployments(owner, repo, environment string, since, until time.Time) ([]Deployment, error)
+
+	// CodeOwners returns the repository's CODEOWNERS file, or nil if it has none
+	CodeOwners(owner, repo string) ([]byte, error)
+	DefaultBranch(owner, repo string) (string, error)
+}
+
+// ChangeRequest is a pull request (GitHub, Bitbucket) or merge request (GitLab)
+type ChangeRequest struct {
+	Number    int
+	Title     string
+	Body      string
+	Author    string
+	State     string // "open" or "closed"; merged ones are closed with MergedAt set
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	MergedAt  *time.Time
+	ClosedAt  *time.Time
+	BaseRef   string
+	HeadRef   string
+	Labels    []string
+
+	// MergeCommits are the commits a merged change request landed as (its
+	// merge or squash commit), where the host reports them
+	MergeCommits []string
+}
+
+// Review states
+const (
+	ReviewApproved         = "APPROVED"
+	ReviewChangesRequested = "CHANGES_REQUESTED"
+	ReviewCommented        = "COMMENTED"
+)
+
+// Review is a reviewer's verdict on a change request
+type Review struct {
+	Author      string
+	State       string // One of the review states, or a provider-specific one
+	SubmittedAt time.Time
+}
+
+// ReviewComment is a comment on a change request's diff
+type ReviewComment struct {
+	ID        int64
+	InReplyTo int64 // Comment that started the thread, 0 for a thread's first comment
+	Author    string
+	CreatedAt time.Time
+}
+
+// Commit is a commit with its git and code host identities
+type Commit struct {
+	SHA         string
+	Login       string // The author's code host account, "" if unknown
+	AuthorName  string
+	AuthorEmail string
+	AuthoredAt  time.Time
+	Message     string
+	Parents     []string
+}
+
+// IsMerge reports whether the commit has more than one parent
+func (c Commit) IsMerge() bool {
+	return len(c.Parents) > 1
+}
+
+// Issue is an issue in the code host's tracker
+type Issue struct {
+	Number    int
+	Title     string
+	Author    string
+	State     string // "open" or "closed"
+	CreatedAt time.Time
+	ClosedAt  *time.Time
+	Labels    []string
+	Assignees []string
+	Milestone string
+}
+
+// Comment kinds, as stored in comment_metrics.comment_type
+const (
+	CommentIssue         = "issue"        // Conversation comment on an issue
+	CommentChangeRequest = "pull_request" // Conversation comment on a change request
+	CommentCommit        = "commit"       // Comment on a commit
+)
+
+// Comment is a conversation comment on an issue or change request, or a comment on a commit
+type Comment struct {
+	ID        int64
+	Kind      string
+	Author    string
+	Body      string
+	CreatedAt time.Time
+
+	// IssueNumber is the issue or change request a conversation comment belongs to
+	IssueNumber *int
+}
+
+// Deployment is a deployment of a ref to an environment
+type Deployment struct {
+	ID          int64
+	Environment string
+	Ref         string
+	SHA         string
+	Creator     string
+	CreatedAt   time.Time
+}
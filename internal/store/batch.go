@@ -0,0 +1,396 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/dothanhlam/go-github-tracker/internal/worktype"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// batchSize is how many rows a Batch buffers before writing them
+const batchSize = 1000
+
+// maxBindVars caps the parameters of one multi-row insert, SQLite's default limit
+const maxBindVars = 999
+
+// batchTables are the tables a Batch writes, in order
+var batchTables = []upsert{
+	prMetrics, prCoAuthors, prLabels, prCommits, prIssueLinks,
+	commitMetrics, commitCoAuthors, commentMetrics,
+	issueMetrics, issueLabels, jiraIssues, jiraTransitions, deployments,
+}
+
+// batchRows are a table's buffered rows by entity (the PR, commit, comment,
+// issue, Jira issue or deployment they belong to) and by key within it (the team, label, ...)
+type batchRows map[string]map[string][]interface{}
+
+// Batch is a unit of work for a repository's rows: its PR, commit, comment and
+// issue metrics, co-author credit, labels, PR commits, issue links and Jira issues.
+// Rows, prunes and updates of stored rows are buffered and written together
+// in one transaction, with multi-row inserts on SQLite and COPY through a
+// staging table on PostgreSQL. Committing a repository's batch also moves its
+// last collection time, so a run that stops partway never records a
+// collection it didn't finish.
+//
+// The transaction is only open while writing, so the batch doesn't hold up
+// other writers while the run fetches from the code host.
+type Batch struct {
+	store   *Store
+	limit   int
+	size    int
+	prunes  []prune
+	updates []func(tx *sqlx.Tx) error
+	rows    map[string]batchRows // By table
+	gitHead string               // Recorded by Commit, for repositories read from a git clone
+}
+
+// NewBatch starts a unit of work
+func (s *Store) NewBatch() *Batch {
+	return &Batch{store: s, limit: batchSize, rows: make(map[string]batchRows)}
+}
+
+// UpsertPRMetric buffers a PR metric
+func (b *Batch) UpsertPRMetric(metric *database.PRMetric) error {
+	return b.add(prMetrics, prEntity(metric.Repository, metric.PRNumber), teamKey(metric.TeamID), prMetricRow(metric))
+}
+
+// UpsertCommitMetric buffers a commit metric
+func (b *Batch) UpsertCommitMetric(metric *database.CommitMetric) error {
+	return b.add(commitMetrics, metric.Repository+"@"+metric.CommitHash, teamKey(metric.TeamID), commitMetricRow(metric))
+}
+
+// UpsertCommentMetric buffers a comment metric
+func (b *Batch) UpsertCommentMetric(metric *database.CommentMetric) error {
+	return b.add(commentMetrics, commentEntity(metric.Repository, metric.CommentID, metric.CommentType), teamKey(metric.TeamID), commentMetricRow(metric))
+}
+
+// UpsertIssueMetric buffers an issue metric
+func (b *Batch) UpsertIssueMetric(metric *database.IssueMetric) error {
+	return b.add(issueMetrics, issueEntity(metric.Repository, metric.IssueNumber), teamKey(metric.TeamID), issueMetricRow(metric))
+}
+
+// UpsertCommitCoAuthor buffers co-author credit for a commit
+func (b *Batch) UpsertCommitCoAuthor(coAuthor *database.CommitCoAuthor) error {
+	values := []interface{}{
+		coAuthor.TeamID, coAuthor.Repository, coAuthor.CommitHash, coAuthor.GitHubUsername,
+		coAuthor.Weight, coAuthor.CreatedAt, coAuthor.CreatedDate,
+	}
+	key := fmt.Sprintf("%d/%s", coAuthor.TeamID, coAuthor.GitHubUsername)
+	return b.add(commitCoAuthors, coAuthor.Repository+"@"+coAuthor.CommitHash, key, values)
+}
+
+// UpsertPRCoAuthor buffers co-author credit for a pull request
+func (b *Batch) UpsertPRCoAuthor(coAuthor *database.PRCoAuthor) error {
+	values := []interface{}{coAuthor.TeamID, coAuthor.Repository, coAuthor.PRNumber, coAuthor.GitHubUsername, coAuthor.Weight}
+	key := fmt.Sprintf("%d/%s", coAuthor.TeamID, coAuthor.GitHubUsername)
+	return b.add(prCoAuthors, prEntity(coAuthor.Repository, coAuthor.PRNumber), key, values)
+}
+
+// SetPRLabels buffers replacing the labels stored for a PR
+func (b *Batch) SetPRLabels(repository string, prNumber int, labels []string) error {
+	entity := prEntity(repository, prNumber)
+	b.prune(entity, prune{table: prLabels.table, where: "repository = ? AND pr_number = ?", args: []interface{}{repository, prNumber}})
+	for _, label := range labels {
+		if err := b.add(prLabels, entity, label, []interface{}{repository, prNumber, label}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetPRCommits buffers replacing the commits stored for a PR
+func (b *Batch) SetPRCommits(repository string, prNumber int, hashes []string) error {
+	entity := prEntity(repository, prNumber)
+	b.prune(entity, prune{table: prCommits.table, where: "repository = ? AND pr_number = ?", args: []interface{}{repository, prNumber}})
+	for _, hash := range hashes {
+		if err := b.add(prCommits, entity, hash, []interface{}{repository, prNumber, hash}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetIssueLabels buffers replacing the labels stored for an issue
+func (b *Batch) SetIssueLabels(repository string, issueNumber int, labels []string) error {
+	entity := issueEntity(repository, issueNumber)
+	b.prune(entity, prune{table: issueLabels.table, where: "repository = ? AND issue_number = ?", args: []interface{}{repository, issueNumber}})
+	for _, label := range labels {
+		if err := b.add(issueLabels, entity, label, []interface{}{repository, issueNumber, label}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetPRIssueLinks buffers replacing the issue links stored for a PR
+func (b *Batch) SetPRIssueLinks(repository string, prNumber int, links []database.PRIssueLink) error {
+	entity := prEntity(repository, prNumber)
+	b.prune(entity, prune{table: prIssueLinks.table, where: "repository = ? AND pr_number = ?", args: []interface{}{repository, prNumber}})
+	for _, link := range links {
+		values := []interface{}{repository, prNumber, link.IssueKey, link.Tracker, link.Source}
+		if err := b.add(prIssueLinks, entity, link.IssueKey, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpsertDeployment buffers a deployment
+func (b *Batch) UpsertDeployment(deployment *database.Deployment) error {
+	values := []interface{}{
+		deployment.Repository, deployment.DeploymentID, deployment.Environment,
+		nullString(deployment.Ref), nullString(deployment.SHA), nullString(deployment.Creator), deployment.CreatedAt,
+	}
+	return b.add(deployments, deploymentEntity(deployment.Repository, deployment.DeploymentID), "", values)
+}
+
+// UpsertJiraIssue buffers storing a Jira issue, replacing its status transitions
+func (b *Batch) UpsertJiraIssue(issue *database.JiraIssue, transitions []database.JiraTransition) error {
+	values := []interface{}{issue.IssueKey, issue.Summary, issue.Status, issue.CreatedAt, issue.ResolvedAt, issue.StartedAt, issue.FetchedAt}
+	if err := b.add(jiraIssues, issue.IssueKey, "", values); err != nil {
+		return err
+	}
+
+	b.prune(issue.IssueKey, prune{table: jiraTransitions.table, where: "issue_key = ?", args: []interface{}{issue.IssueKey}})
+	for _, transition := range transitions {
+		values := []interface{}{issue.IssueKey, transition.FromStatus, transition.ToStatus, transition.TransitionedAt}
+		key := fmt.Sprintf("%s/%s", transition.TransitionedAt.UTC().Format(time.RFC3339Nano), transition.ToStatus)
+		if err := b.add(jiraTransitions, issue.IssueKey, key, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PrunePRMetrics buffers removing a PR's attributions (and co-author credit)
+// for teams outside keepTeamIDs
+func (b *Batch) PrunePRMetrics(repository string, prNumber int, keepTeamIDs []int) {
+	b.prune(prEntity(repository, prNumber), prPrunes(repository, prNumber, keepTeamIDs)...)
+}
+
+// PruneCommitMetrics buffers removing a commit's attributions for teams outside keepTeamIDs
+func (b *Batch) PruneCommitMetrics(repository, commitHash string, keepTeamIDs []int) {
+	b.prune(repository+"@"+commitHash, commitPrune(repository, commitHash, keepTeamIDs))
+}
+
+// PruneCommentMetrics buffers removing a comment's attributions for teams outside keepTeamIDs
+func (b *Batch) PruneCommentMetrics(repository string, commentID int64, commentType string, keepTeamIDs []int) {
+	b.prune(commentEntity(repository, commentID, commentType), commentPrune(repository, commentID, commentType, keepTeamIDs))
+}
+
+// PruneIssueMetrics buffers removing an issue's attributions for teams outside keepTeamIDs
+func (b *Batch) PruneIssueMetrics(repository string, issueNumber int, keepTeamIDs []int) {
+	b.prune(issueEntity(repository, issueNumber), issuePrune(repository, issueNumber, keepTeamIDs))
+}
+
+// MatchBaseRefs buffers re-evaluating which of a repository's stored PRs
+// count toward delivery metrics, so changed base branch rules also apply to
+// PRs collected earlier
+func (b *Batch) MatchBaseRefs(repository string, matches func(baseRef string) bool) {
+	b.updates = append(b.updates, func(tx *sqlx.Tx) error { return matchBaseRefs(tx, repository, matches) })
+}
+
+// ClassifyWorkTypes buffers re-classifying a repository's stored PRs, as
+// classifyWorkTypes describes
+func (b *Batch) ClassifyWorkTypes(repository string, classifier *worktype.Classifier) {
+	b.updates = append(b.updates, func(tx *sqlx.Tx) error { return classifyWorkTypes(tx, repository, classifier) })
+}
+
+// SetGitHead sets the commit a git clone's HEAD pointed to when it was read,
+// which Commit records with the last collection time
+func (b *Batch) SetGitHead(head string) {
+	b.gitHead = head
+}
+
+// Len returns the number of buffered rows
+func (b *Batch) Len() int {
+	return b.size
+}
+
+// Flush writes the buffered rows, prunes and updates in one transaction
+func (b *Batch) Flush() error {
+	return b.write("", nil)
+}
+
+// Commit writes the buffered rows, prunes and updates and moves a
+// repository's last collection time forward, and records its git head if
+// one was set, in one transaction
+func (b *Batch) Commit(repository string, collectedAt time.Time) error {
+	return b.write(repository, &collectedAt)
+}
+
+// add buffers a row, replacing one buffered earlier with the same key, and
+// writes the batch once it is full. Rows that fail to be written stay
+// buffered, so a later Commit either writes them or leaves the last
+// collection time alone.
+func (b *Batch) add(table upsert, entity, key string, values []interface{}) error {
+	rows := b.rows[table.table]
+	if rows == nil {
+		rows = make(batchRows)
+		b.rows[table.table] = rows
+	}
+	if rows[entity] == nil {
+		rows[entity] = make(map[string][]interface{})
+	}
+	if _, ok := rows[entity][key]; !ok {
+		b.size++
+	}
+	rows[entity][key] = values
+
+	if b.size < b.limit {
+		return nil
+	}
+	return b.Flush()
+}
+
+// prune buffers prunes of an entity, which are written before the rows. Rows
+// buffered earlier for it in the pruned tables are dropped, as the prune
+// would have removed them and they are stored again after it.
+func (b *Batch) prune(entity string, prunes ...prune) {
+	for _, p := range prunes {
+		b.size -= len(b.rows[p.table][entity])
+		delete(b.rows[p.table], entity)
+	}
+	b.prunes = append(b.prunes, prunes...)
+}
+
+// write writes the batch, and the last collection time unless it is nil
+func (b *Batch) write(repository string, collectedAt *time.Time) error {
+	if len(b.prunes) == 0 && len(b.updates) == 0 && b.size == 0 && collectedAt == nil {
+		return nil
+	}
+
+	tx, err := b.store.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, p := range b.prunes {
+		if err := p.exec(tx); err != nil {
+			return err
+		}
+	}
+	for _, update := range b.updates {
+		if err := update(tx); err != nil {
+			return err
+		}
+	}
+	for _, table := range batchTables {
+		var rows [][]interface{}
+		for _, entityRows := range b.rows[table.table] {
+			for _, values := range entityRows {
+				rows = append(rows, values)
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		if b.store.db.Driver() == "postgres" {
+			err = copyRows(tx, table, rows)
+		} else {
+			err = insertRows(tx, table, rows)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", table.table, err)
+		}
+	}
+	if collectedAt != nil {
+		if err := updateLastCollectionTime(tx, repository, *collectedAt); err != nil {
+			return err
+		}
+		if b.gitHead != "" {
+			if err := recordGitHead(tx, repository, b.gitHead); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit batch: %w", err)
+	}
+	b.prunes, b.updates, b.rows, b.size = nil, nil, make(map[string]batchRows), 0
+	if collectedAt != nil {
+		b.gitHead = ""
+	}
+	return nil
+}
+
+// insertRows upserts rows with as few multi-row inserts as the bind limit allows
+func insertRows(tx *sqlx.Tx, table upsert, rows [][]interface{}) error {
+	perStatement := maxBindVars / len(table.columns)
+	for start := 0; start < len(rows); start += perStatement {
+		chunk := rows[start:min(start+perStatement, len(rows))]
+		args := make([]interface{}, 0, len(chunk)*len(table.columns))
+		for _, values := range chunk {
+			args = append(args, values...)
+		}
+		if _, err := tx.Exec(tx.Rebind(table.query(len(chunk))), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyRows upserts rows by copying them into a staging table dropped at commit,
+// then inserting from it
+func copyRows(tx *sqlx.Tx, table upsert, rows [][]interface{}) error {
+	staging := "staging_" + table.table
+	columns := strings.Join(table.columns, ", ")
+	create := fmt.Sprintf("CREATE TEMP TABLE %s ON COMMIT DROP AS SELECT %s FROM %s WITH NO DATA", staging, columns, table.table)
+	if _, err := tx.Exec(create); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn(staging, table.columns...))
+	if err != nil {
+		return err
+	}
+	for _, values := range rows {
+		if _, err := stmt.Exec(values...); err != nil {
+			stmt.Close()
+			return err
+		}
+	}
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return err
+	}
+	if err := stmt.Close(); err != nil {
+		return err
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s %s", table.table, columns, columns, staging, table.conflict)
+	_, err = tx.Exec(insert)
+	return err
+}
+
+// prEntity identifies a PR among buffered rows
+func prEntity(repository string, prNumber int) string {
+	return fmt.Sprintf("%s#%d", repository, prNumber)
+}
+
+// issueEntity identifies an issue among buffered rows
+func issueEntity(repository string, issueNumber int) string {
+	return fmt.Sprintf("%s#%d", repository, issueNumber)
+}
+
+// deploymentEntity identifies a deployment among buffered rows
+func deploymentEntity(repository string, deploymentID int64) string {
+	return fmt.Sprintf("%s/deployment/%d", repository, deploymentID)
+}
+
+// teamKey identifies a team's row of an entity among buffered rows
+func teamKey(teamID int) string {
+	return strconv.Itoa(teamID)
+}
+
+// commentEntity identifies a comment among buffered rows
+func commentEntity(repository string, commentID int64, commentType string) string {
+	return fmt.Sprintf("%s/%s/%d", repository, commentType, commentID)
+}
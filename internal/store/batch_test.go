@@ -0,0 +1,287 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/dothanhlam/go-github-tracker/internal/worktype"
+)
+
+// newTestStore creates a store on a migrated SQLite database with two teams
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	db, err := database.Connect("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// Migrations are read relative to the repository root
+	originalDir, _ := os.Getwd()
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatalf("failed to change to repository root: %v", err)
+	}
+	defer os.Chdir(originalDir)
+
+	if err := db.RunMigrations(); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO teams (id, name) VALUES (1, 'Platform'), (2, 'Payments')"); err != nil {
+		t.Fatal(err)
+	}
+	return New(db)
+}
+
+// testPR returns a PR metric attributed to a team
+func testPR(number, teamID int, title string) *database.PRMetric {
+	return &database.PRMetric{
+		TeamID: teamID, PRNumber: number, Repository: "acme/api", Author: "alice", Title: title,
+		CreatedAt: time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC), State: "open", WorkType: "feature", AttributionRole: "author",
+	}
+}
+
+// count returns the number of rows a query counts
+func count(t *testing.T, s *Store, query string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	if err := s.db.Get(&n, query, args...); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// TestBatchCommit tests that rows and the last collection time are written together or not at all
+func TestBatchCommit(t *testing.T) {
+	s := newTestStore(t)
+	collectedAt := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)
+
+	batch := s.NewBatch()
+	for _, metric := range []*database.PRMetric{testPR(1, 1, "Add billing"), testPR(1, 2, "Add billing"), testPR(2, 1, "Fix login")} {
+		if err := batch.UpsertPRMetric(metric); err != nil {
+			t.Fatalf("UpsertPRMetric() error = %v", err)
+		}
+	}
+	createdAt := time.Date(2026, 3, 2, 6, 30, 0, 0, time.UTC)
+	if err := batch.UpsertCommitMetric(&database.CommitMetric{TeamID: 1, Repository: "acme/api", CommitHash: "abc123", Author: "alice", Message: "feat: billing", CreatedAt: createdAt, CreatedDate: &createdAt}); err != nil {
+		t.Fatalf("UpsertCommitMetric() error = %v", err)
+	}
+	if err := batch.UpsertCommentMetric(&database.CommentMetric{TeamID: 1, Repository: "acme/api", CommentID: 7, Author: "alice", Body: "LGTM", CreatedAt: createdAt, CreatedDate: &createdAt, CommentType: "issue"}); err != nil {
+		t.Fatalf("UpsertCommentMetric() error = %v", err)
+	}
+	if got := count(t, s, "SELECT COUNT(*) FROM pr_metrics"); got != 0 {
+		t.Fatalf("%d PR rows written before Commit(), want 0", got)
+	}
+
+	// A failed commit writes nothing and keeps the rows buffered
+	if _, err := s.db.Exec("ALTER TABLE collection_metadata RENAME TO collection_metadata_old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Commit("acme/api", collectedAt); err == nil {
+		t.Fatal("Commit() error = nil, want an error without collection_metadata")
+	}
+	if got := count(t, s, "SELECT COUNT(*) FROM pr_metrics"); got != 0 {
+		t.Errorf("%d PR rows written by a failed Commit(), want 0", got)
+	}
+	if batch.Len() != 5 {
+		t.Errorf("Len() after a failed Commit() = %d, want 5", batch.Len())
+	}
+	if _, err := s.db.Exec("ALTER TABLE collection_metadata_old RENAME TO collection_metadata"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := batch.Commit("acme/api", collectedAt); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	for table, want := range map[string]int{"pr_metrics": 3, "commit_metrics": 1, "comment_metrics": 1} {
+		if got := count(t, s, "SELECT COUNT(*) FROM "+table); got != want {
+			t.Errorf("%d rows in %s, want %d", got, table, want)
+		}
+	}
+	last, err := s.GetLastCollectionTime("acme/api")
+	if err != nil {
+		t.Fatalf("GetLastCollectionTime() error = %v", err)
+	}
+	if !last.Equal(collectedAt) {
+		t.Errorf("GetLastCollectionTime() = %v, want %v", last, collectedAt)
+	}
+	if batch.Len() != 0 {
+		t.Errorf("Len() after Commit() = %d, want 0", batch.Len())
+	}
+}
+
+// TestBatchPrune tests that prunes apply to stored rows and replace buffered ones, as if written in order
+func TestBatchPrune(t *testing.T) {
+	s := newTestStore(t)
+	if err := s.UpsertPRMetric(testPR(1, 1, "Add billing")); err != nil {
+		t.Fatalf("UpsertPRMetric() error = %v", err)
+	}
+
+	batch := s.NewBatch()
+	batch.UpsertPRMetric(testPR(1, 1, "Add billing v2"))
+	batch.UpsertPRMetric(testPR(1, 2, "Add billing v2"))
+	batch.UpsertPRMetric(testPR(2, 1, "Fix login"))
+	batch.UpsertPRMetric(testPR(2, 1, "Fix login v2"))
+
+	// PR #1 is collected again, now only for team 2
+	batch.PrunePRMetrics("acme/api", 1, []int{2})
+	batch.UpsertPRMetric(testPR(1, 2, "Add billing v3"))
+	if batch.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", batch.Len())
+	}
+	if err := batch.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	var rows []struct {
+		TeamID   int    `db:"team_id"`
+		PRNumber int    `db:"pr_number"`
+		Title    string `db:"title"`
+	}
+	if err := s.db.Select(&rows, "SELECT team_id, pr_number, title FROM pr_metrics ORDER BY pr_number, team_id"); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].TeamID != 2 || rows[0].Title != "Add billing v3" || rows[1].Title != "Fix login v2" {
+		t.Errorf("pr_metrics = %+v, want PR #1 for team 2 only and the last titles", rows)
+	}
+	if got := count(t, s, "SELECT COUNT(*) FROM collection_metadata"); got != 0 {
+		t.Errorf("Flush() recorded a collection time")
+	}
+}
+
+// TestBatchFlushesWhenFull tests writing a full batch across several multi-row inserts
+func TestBatchFlushesWhenFull(t *testing.T) {
+	s := newTestStore(t)
+	batch := s.NewBatch()
+	batch.limit = 100
+
+	for number := 1; number <= 150; number++ {
+		if err := batch.UpsertPRMetric(testPR(number, 1, "PR")); err != nil {
+			t.Fatalf("UpsertPRMetric() error = %v", err)
+		}
+	}
+	if got := count(t, s, "SELECT COUNT(*) FROM pr_metrics"); got != 100 {
+		t.Errorf("%d PR rows written once the batch was full, want 100", got)
+	}
+	if batch.Len() != 50 {
+		t.Errorf("Len() = %d, want 50", batch.Len())
+	}
+}
+
+// TestBatchCoAuthorsAndLabels tests that co-author credit, labels and issue
+// links are written with the PR's rows, and that a failed commit leaves the
+// ones stored earlier alone
+func TestBatchCoAuthorsAndLabels(t *testing.T) {
+	s := newTestStore(t)
+	collectedAt := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)
+
+	first := s.NewBatch()
+	first.UpsertPRMetric(testPR(1, 1, "Add billing"))
+	first.UpsertPRCoAuthor(&database.PRCoAuthor{TeamID: 1, Repository: "acme/api", PRNumber: 1, GitHubUsername: "bob", Weight: 0.5})
+	first.SetPRLabels("acme/api", 1, []string{"bug", "payments"})
+	first.SetPRIssueLinks("acme/api", 1, []database.PRIssueLink{{IssueKey: "PAY-1", Tracker: "jira", Source: "title"}})
+	if err := first.Commit("acme/api", collectedAt); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	// PR #1 is collected again for team 2 only, with new labels, but the commit fails
+	second := s.NewBatch()
+	second.PrunePRMetrics("acme/api", 1, []int{2})
+	second.UpsertPRMetric(testPR(1, 2, "Add billing"))
+	second.UpsertPRCoAuthor(&database.PRCoAuthor{TeamID: 2, Repository: "acme/api", PRNumber: 1, GitHubUsername: "carol", Weight: 0.5})
+	second.SetPRLabels("acme/api", 1, []string{"feature"})
+	second.SetPRIssueLinks("acme/api", 1, nil)
+	if _, err := s.db.Exec("ALTER TABLE collection_metadata RENAME TO collection_metadata_old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Commit("acme/api", collectedAt.Add(time.Hour)); err == nil {
+		t.Fatal("Commit() error = nil, want an error without collection_metadata")
+	}
+	for table, want := range map[string]int{"pr_metrics": 1, "pr_coauthors": 1, "pr_labels": 2, "pr_issue_links": 1} {
+		if got := count(t, s, "SELECT COUNT(*) FROM "+table); got != want {
+			t.Errorf("%d rows in %s after a failed Commit(), want the %d stored before", got, table, want)
+		}
+	}
+	if _, err := s.db.Exec("ALTER TABLE collection_metadata_old RENAME TO collection_metadata"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := second.Commit("acme/api", collectedAt.Add(time.Hour)); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	var coAuthors []string
+	if err := s.db.Select(&coAuthors, "SELECT github_username FROM pr_coauthors"); err != nil {
+		t.Fatal(err)
+	}
+	if len(coAuthors) != 1 || coAuthors[0] != "carol" {
+		t.Errorf("pr_coauthors = %v, want carol only", coAuthors)
+	}
+	var labels []string
+	if err := s.db.Select(&labels, "SELECT label FROM pr_labels"); err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 1 || labels[0] != "feature" {
+		t.Errorf("pr_labels = %v, want feature only", labels)
+	}
+	if got := count(t, s, "SELECT COUNT(*) FROM pr_issue_links"); got != 0 {
+		t.Errorf("%d issue links, want 0", got)
+	}
+}
+
+// TestBatchClassifyWorkTypes tests that stored PRs are only re-classified
+// when the work type mapping changed since they were last classified
+func TestBatchClassifyWorkTypes(t *testing.T) {
+	s := newTestStore(t)
+	collectedAt := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)
+
+	batch := s.NewBatch()
+	batch.UpsertPRMetric(testPR(1, 1, "fix: login"))
+	batch.UpsertPRMetric(testPR(1, 2, "fix: login"))
+	batch.UpsertPRMetric(testPR(2, 1, "Add billing"))
+	if err := batch.Commit("acme/api", collectedAt); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	bugs := worktype.New([]config.WorkTypeConfig{{Name: "bug", CommitTypes: []string{"fix"}}})
+	classify := func(classifier *worktype.Classifier) {
+		t.Helper()
+		batch := s.NewBatch()
+		batch.ClassifyWorkTypes("acme/api", classifier)
+		collectedAt = collectedAt.Add(time.Hour)
+		if err := batch.Commit("acme/api", collectedAt); err != nil {
+			t.Fatalf("Commit() error = %v", err)
+		}
+	}
+	workTypes := func() string {
+		t.Helper()
+		var workTypes []string
+		if err := s.db.Select(&workTypes, "SELECT work_type FROM pr_metrics ORDER BY pr_number, team_id"); err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(workTypes, ",")
+	}
+
+	classify(bugs)
+	if got := workTypes(); got != "bug,bug,other" {
+		t.Errorf("work types after the mapping changed = %s, want bug,bug,other", got)
+	}
+
+	// With the same mapping, stored PRs are left alone
+	if _, err := s.db.Exec("UPDATE pr_metrics SET work_type = 'feature'"); err != nil {
+		t.Fatal(err)
+	}
+	classify(worktype.New([]config.WorkTypeConfig{{Name: "bug", CommitTypes: []string{"FIX"}}}))
+	if got := workTypes(); got != "feature,feature,feature" {
+		t.Errorf("work types with the same mapping = %s, want them unchanged", got)
+	}
+
+	classify(worktype.New([]config.WorkTypeConfig{{Name: "bug", CommitTypes: []string{"fix", "bugfix"}}}))
+	if got := workTypes(); got != "bug,bug,other" {
+		t.Errorf("work types after the mapping changed = %s, want bug,bug,other", got)
+	}
+}
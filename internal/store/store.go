@@ -1,10 +1,13 @@
 package store
 
 import (
+	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/dothanhlam/go-github-tracker/internal/worktype"
+	"github.com/jmoiron/sqlx"
 )
 
 // Store handles database persistence
@@ -19,59 +22,186 @@ func New(db *database.DB) *Store {
 
 // UpsertPRMetric inserts or updates a PR metric (idempotent)
 func (s *Store) UpsertPRMetric(metric *database.PRMetric) error {
-	query := `
-		INSERT INTO pr_metrics (
-			team_id, pr_number, repository, author, title,
-			created_at, merged_at, closed_at, cycle_time_hours, state, created_date,
-			first_review_at, review_turnaround_hours,
-			review_comments_count, conversation_count,
-			changes_requested_count, approved_count,
-			reviewers_count, external_reviewers_count, reviewers_list
-		) VALUES (
-			?, ?, ?, ?, ?,
-			?, ?, ?, ?, ?, ?,
-			?, ?,
-			?, ?,
-			?, ?,
-			?, ?, ?
-		)
-		ON CONFLICT(team_id, repository, pr_number) DO UPDATE SET
-			title = excluded.title,
-			merged_at = excluded.merged_at,
-			closed_at = excluded.closed_at,
-			cycle_time_hours = excluded.cycle_time_hours,
-			state = excluded.state,
-			first_review_at = excluded.first_review_at,
-			review_turnaround_hours = excluded.review_turnaround_hours,
-			review_comments_count = excluded.review_comments_count,
-			conversation_count = excluded.conversation_count,
-			changes_requested_count = excluded.changes_requested_count,
-			approved_count = excluded.approved_count,
-			reviewers_count = excluded.reviewers_count,
-			external_reviewers_count = excluded.external_reviewers_count,
-			reviewers_list = excluded.reviewers_list
+	if _, err := s.db.Exec(prMetrics.query(1), prMetricRow(metric)...); err != nil {
+		return fmt.Errorf("failed to upsert PR metric: %w", err)
+	}
+	return nil
+}
+
+// matchBaseRefs re-evaluates which of a repository's stored PRs count toward
+// delivery metrics, so changed base branch rules also apply to PRs collected earlier
+func matchBaseRefs(tx *sqlx.Tx, repository string, matches func(baseRef string) bool) error {
+	var baseRefs []string
+	query := "SELECT DISTINCT base_ref FROM pr_metrics WHERE repository = ? AND base_ref IS NOT NULL"
+	if err := tx.Select(&baseRefs, tx.Rebind(query), repository); err != nil {
+		return fmt.Errorf("failed to load base refs: %w", err)
+	}
+
+	for _, baseRef := range baseRefs {
+		query := "UPDATE pr_metrics SET base_matched = ? WHERE repository = ? AND base_ref = ? AND base_matched <> ?"
+		matched := matches(baseRef)
+		if _, err := tx.Exec(tx.Rebind(query), matched, repository, baseRef, matched); err != nil {
+			return fmt.Errorf("failed to update PRs into %s: %w", baseRef, err)
+		}
+	}
+	return nil
+}
+
+// JiraIssueStartedAt returns when work started on a stored Jira issue, or nil
+// if it isn't stored or hasn't started
+func (s *Store) JiraIssueStartedAt(issueKey string) (*time.Time, error) {
+	var startedAt []time.Time
+	query := "SELECT started_at FROM jira_issues WHERE issue_key = ? AND started_at IS NOT NULL"
+	if err := s.db.Select(&startedAt, query, issueKey); err != nil {
+		return nil, fmt.Errorf("failed to check Jira issue %s: %w", issueKey, err)
+	}
+	if len(startedAt) == 0 {
+		return nil, nil
+	}
+	return &startedAt[0], nil
+}
+
+// classifyWorkTypes re-classifies a repository's stored PRs from their stored
+// labels, titles and head branches when the work type mapping changed since
+// they were last classified, so the new mapping also applies to PRs collected
+// earlier. PRs classified by their commit messages, which are not stored per
+// PR, keep that work type unless something else now matches.
+// It also checks the commits collected before the Conventional Commits check existed.
+func classifyWorkTypes(tx *sqlx.Tx, repository string, classifier *worktype.Classifier) error {
+	if err := checkConventionalCommits(tx, repository); err != nil {
+		return err
+	}
+
+	var classifiedWith []string
+	query := "SELECT work_types_fingerprint FROM collection_metadata WHERE repository = ? AND work_types_fingerprint IS NOT NULL"
+	if err := tx.Select(&classifiedWith, tx.Rebind(query), repository); err != nil {
+		return fmt.Errorf("failed to load work type mapping fingerprint: %w", err)
+	}
+	if len(classifiedWith) > 0 && classifiedWith[0] == classifier.Fingerprint() {
+		return nil
+	}
+
+	var prs []struct {
+		PRNumber       int     `db:"pr_number"`
+		Title          string  `db:"title"`
+		HeadRef        *string `db:"head_ref"`
+		WorkType       string  `db:"work_type"`
+		WorkTypeSource *string `db:"work_type_source"`
+		WorkScope      *string `db:"work_scope"`
+		Conventional   *bool   `db:"conventional"`
+	}
+	query = `
+		SELECT pr_number, COALESCE(title, '') as title, head_ref, work_type, work_type_source, work_scope, conventional
+		FROM pr_metrics WHERE repository = ?
 	`
+	if err := tx.Select(&prs, tx.Rebind(query), repository); err != nil {
+		return fmt.Errorf("failed to load PRs: %w", err)
+	}
 
-	_, err := s.db.Exec(query,
-		metric.TeamID, metric.PRNumber, metric.Repository, metric.Author, metric.Title,
-		metric.CreatedAt, metric.MergedAt, metric.ClosedAt, metric.CycleTimeHours, metric.State, metric.CreatedAt,
-		metric.FirstReviewAt, metric.ReviewTurnaroundHours,
-		metric.ReviewCommentsCount, metric.ConversationCount,
-		metric.ChangesRequestedCount, metric.ApprovedCount,
-		metric.ReviewersCount, metric.ExternalReviewersCount, metric.ReviewersList,
-	)
+	var rows []struct {
+		PRNumber int    `db:"pr_number"`
+		Label    string `db:"label"`
+	}
+	if err := tx.Select(&rows, tx.Rebind("SELECT pr_number, label FROM pr_labels WHERE repository = ?"), repository); err != nil {
+		return fmt.Errorf("failed to load PR labels: %w", err)
+	}
+	labels := make(map[int][]string)
+	for _, row := range rows {
+		labels[row.PRNumber] = append(labels[row.PRNumber], row.Label)
+	}
 
-	if err != nil {
-		return fmt.Errorf("failed to upsert PR metric: %w", err)
+	// A PR has a row per attributed team; update them all when any is out of
+	// date, with one statement per classification
+	type classification struct {
+		workType, source, scope string
+		conventional            bool
 	}
+	outdated := make(map[classification][]int)
+	checked := make(map[int]bool)
+	for _, pr := range prs {
+		if checked[pr.PRNumber] {
+			continue
+		}
+		result := classifier.Classify(worktype.PR{Labels: labels[pr.PRNumber], Title: pr.Title, HeadRef: stringValue(pr.HeadRef)})
+		source, scope := result.Source, result.Scope
+		if result.WorkType == worktype.Other && stringValue(pr.WorkTypeSource) == worktype.SourceCommit {
+			result.WorkType, source = pr.WorkType, worktype.SourceCommit
+			if scope == "" {
+				scope = stringValue(pr.WorkScope)
+			}
+		}
+		if result.WorkType == pr.WorkType && source == stringValue(pr.WorkTypeSource) && scope == stringValue(pr.WorkScope) &&
+			pr.Conventional != nil && *pr.Conventional == result.Conventional {
+			continue
+		}
 
+		key := classification{result.WorkType, source, scope, result.Conventional}
+		outdated[key] = append(outdated[key], pr.PRNumber)
+		checked[pr.PRNumber] = true
+	}
+	for c, prNumbers := range outdated {
+		query := "UPDATE pr_metrics SET work_type = ?, work_type_source = ?, work_scope = ?, conventional = ? WHERE repository = ? AND pr_number IN (?)"
+		query, args, err := sqlx.In(query, c.workType, nullString(c.source), nullString(c.scope), c.conventional, repository, prNumbers)
+		if err != nil {
+			return fmt.Errorf("failed to build work type update: %w", err)
+		}
+		if _, err := tx.Exec(tx.Rebind(query), args...); err != nil {
+			return fmt.Errorf("failed to classify PRs as %s: %w", c.workType, err)
+		}
+	}
+
+	query = `
+		INSERT INTO collection_metadata (repository, work_types_fingerprint)
+		VALUES (?, ?)
+		ON CONFLICT(repository) DO UPDATE SET
+			work_types_fingerprint = excluded.work_types_fingerprint
+	`
+	if _, err := tx.Exec(tx.Rebind(query), repository, classifier.Fingerprint()); err != nil {
+		return fmt.Errorf("failed to record work type mapping fingerprint: %w", err)
+	}
 	return nil
 }
 
+// checkConventionalCommits checks whether the commits collected before the
+// Conventional Commits check existed follow Conventional Commits
+func checkConventionalCommits(tx *sqlx.Tx, repository string) error {
+	var commits []struct {
+		CommitHash string `db:"commit_hash"`
+		Message    string `db:"message"`
+	}
+	query := "SELECT DISTINCT commit_hash, message FROM commit_metrics WHERE repository = ? AND conventional IS NULL"
+	if err := tx.Select(&commits, tx.Rebind(query), repository); err != nil {
+		return fmt.Errorf("failed to load unchecked commits: %w", err)
+	}
+	for _, commit := range commits {
+		query := "UPDATE commit_metrics SET conventional = ? WHERE repository = ? AND commit_hash = ?"
+		if _, err := tx.Exec(tx.Rebind(query), worktype.IsConventional(commit.Message), repository, commit.CommitHash); err != nil {
+			return fmt.Errorf("failed to check commit %s: %w", commit.CommitHash, err)
+		}
+	}
+	return nil
+}
+
+// stringValue returns the string a nullable column holds, "" for NULL
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// nullString stores "" as NULL
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
 // GetLastCollectionTime returns the last time a repository was collected.
 // Returns zero time if the repository has never been collected.
 func (s *Store) GetLastCollectionTime(repository string) (time.Time, error) {
-	var lastCollectedAt time.Time
+	var lastCollectedAt sql.NullTime // NULL if only failed runs were recorded
 	query := `SELECT last_collected_at FROM collection_metadata WHERE repository = ?`
 	err := s.db.Get(&lastCollectedAt, query, repository)
 	if err != nil {
@@ -81,70 +211,114 @@ func (s *Store) GetLastCollectionTime(repository string) (time.Time, error) {
 		}
 		return time.Time{}, fmt.Errorf("failed to get last collection time: %w", err)
 	}
-	return lastCollectedAt, nil
+	return lastCollectedAt.Time, nil
 }
 
 // UpdateLastCollectionTime upserts the last collection timestamp for a repository.
+// It never moves the timestamp back, so a run that finishes after a later one
+// can't make the next run collect again what the later one already did.
 func (s *Store) UpdateLastCollectionTime(repository string, timestamp time.Time) error {
+	return updateLastCollectionTime(s.db, repository, timestamp)
+}
+
+// updateLastCollectionTime moves the last collection time forward with db or a transaction
+func updateLastCollectionTime(db sqlx.Ext, repository string, timestamp time.Time) error {
 	query := `
 		INSERT INTO collection_metadata (repository, last_collected_at)
 		VALUES (?, ?)
 		ON CONFLICT(repository) DO UPDATE SET
 			last_collected_at = excluded.last_collected_at
+		WHERE collection_metadata.last_collected_at IS NULL
+			OR excluded.last_collected_at > collection_metadata.last_collected_at
 	`
-	_, err := s.db.Exec(query, repository, timestamp)
+	_, err := db.Exec(db.Rebind(query), repository, timestamp)
 	if err != nil {
 		return fmt.Errorf("failed to update last collection time: %w", err)
 	}
 	return nil
 }
 
-// UpsertCommitMetric inserts or updates a Commit metric (idempotent)
-func (s *Store) UpsertCommitMetric(metric *database.CommitMetric) error {
+// GetLastGitHead returns the commit a repository's git clone was last collected
+// up to, or "" if it never was
+func (s *Store) GetLastGitHead(repository string) (string, error) {
+	var heads []string
+	query := "SELECT last_git_head FROM collection_metadata WHERE repository = ? AND last_git_head IS NOT NULL"
+	if err := s.db.Select(&heads, query, repository); err != nil {
+		return "", fmt.Errorf("failed to get last git head: %w", err)
+	}
+	if len(heads) == 0 {
+		return "", nil
+	}
+	return heads[0], nil
+}
+
+// recordGitHead records the commit a repository's git clone was collected up to
+func recordGitHead(tx *sqlx.Tx, repository, head string) error {
 	query := `
-		INSERT INTO commit_metrics (
-			team_id, repository, commit_hash, author, message, created_at, created_date
-		) VALUES (
-			?, ?, ?, ?, ?, ?, ?
-		)
-		ON CONFLICT(team_id, repository, commit_hash) DO UPDATE SET
-			message = excluded.message,
-			author = excluded.author
+		INSERT INTO collection_metadata (repository, last_git_head)
+		VALUES (?, ?)
+		ON CONFLICT(repository) DO UPDATE SET
+			last_git_head = excluded.last_git_head
 	`
+	if _, err := tx.Exec(tx.Rebind(query), repository, head); err != nil {
+		return fmt.Errorf("failed to record git head: %w", err)
+	}
+	return nil
+}
 
-	_, err := s.db.Exec(query,
-		metric.TeamID, metric.Repository, metric.CommitHash, metric.Author,
-		metric.Message, metric.CreatedAt, metric.CreatedDate,
-	)
-
+// RecordRun upserts how a repository's last collection run went, for health
+// checks. It leaves the last collection time alone.
+func (s *Store) RecordRun(repository, status, runError string, startedAt, finishedAt time.Time) error {
+	query := `
+		INSERT INTO collection_metadata (repository, last_run_started_at, last_run_finished_at, last_run_status, last_run_error)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(repository) DO UPDATE SET
+			last_run_started_at = excluded.last_run_started_at,
+			last_run_finished_at = excluded.last_run_finished_at,
+			last_run_status = excluded.last_run_status,
+			last_run_error = excluded.last_run_error
+	`
+	_, err := s.db.Exec(query, repository, startedAt, finishedAt, status, nullString(runError))
 	if err != nil {
-		return fmt.Errorf("failed to upsert commit metric: %w", err)
+		return fmt.Errorf("failed to record collection run: %w", err)
 	}
+	return nil
+}
 
+// UpsertCommitMetric inserts or updates a Commit metric (idempotent)
+func (s *Store) UpsertCommitMetric(metric *database.CommitMetric) error {
+	if _, err := s.db.Exec(commitMetrics.query(1), commitMetricRow(metric)...); err != nil {
+		return fmt.Errorf("failed to upsert commit metric: %w", err)
+	}
 	return nil
 }
 
 // UpsertCommentMetric inserts or updates a Comment metric (idempotent)
 func (s *Store) UpsertCommentMetric(metric *database.CommentMetric) error {
-	query := `
-		INSERT INTO comment_metrics (
-			team_id, repository, comment_id, author, body, created_at, created_date, comment_type
-		) VALUES (
-			?, ?, ?, ?, ?, ?, ?, ?
-		)
-		ON CONFLICT(team_id, repository, comment_id, comment_type) DO UPDATE SET
-			body = excluded.body,
-			author = excluded.author
-	`
-
-	_, err := s.db.Exec(query,
-		metric.TeamID, metric.Repository, metric.CommentID, metric.Author,
-		metric.Body, metric.CreatedAt, metric.CreatedDate, metric.CommentType,
-	)
-
-	if err != nil {
+	if _, err := s.db.Exec(commentMetrics.query(1), commentMetricRow(metric)...); err != nil {
 		return fmt.Errorf("failed to upsert comment metric: %w", err)
 	}
+	return nil
+}
 
+// PrunePRMetrics removes a PR's attributions (and co-author credit) for teams
+// outside keepTeamIDs, so a re-collected PR only counts for the teams its
+// people belonged to at the time
+func (s *Store) PrunePRMetrics(repository string, prNumber int, keepTeamIDs []int) error {
+	for _, p := range prPrunes(repository, prNumber, keepTeamIDs) {
+		if err := p.exec(s.db); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// PruneCommitMetrics removes a commit's attributions for teams outside keepTeamIDs
+func (s *Store) PruneCommitMetrics(repository, commitHash string, keepTeamIDs []int) error {
+	return commitPrune(repository, commitHash, keepTeamIDs).exec(s.db)
+}
+
+// PruneCommentMetrics removes a comment's attributions for teams outside keepTeamIDs
+func (s *Store) PruneCommentMetrics(repository string, commentID int64, commentType string, keepTeamIDs []int) error {
+	return commentPrune(repository, commentID, commentType, keepTeamIDs).exec(s.db)
+}
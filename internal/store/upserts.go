@@ -0,0 +1,285 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// upsert describes how rows are inserted into a metrics table, one at a time or many at once
+type upsert struct {
+	table    string
+	columns  []string
+	conflict string // ON CONFLICT clause updating an existing row
+}
+
+// query returns a statement upserting the given number of rows
+func (u upsert) query(rows int) string {
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(u.columns)), ", ") + ")"
+	values := make([]string, rows)
+	for i := range values {
+		values[i] = row
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s %s", u.table, strings.Join(u.columns, ", "), strings.Join(values, ", "), u.conflict)
+}
+
+var prMetrics = upsert{
+	table: "pr_metrics",
+	columns: []string{
+		"team_id", "pr_number", "repository", "author", "title",
+		"created_at", "merged_at", "closed_at", "cycle_time_hours", "state", "created_date",
+		"first_review_at", "review_turnaround_hours",
+		"review_comments_count", "conversation_count",
+		"changes_requested_count", "approved_count",
+		"reviewers_count", "external_reviewers_count", "reviewers_list",
+		"attribution_role", "owned",
+		"base_ref", "head_ref", "base_matched",
+		"work_type", "work_type_source", "work_scope", "conventional",
+		"ticket_started_at", "ticket_lead_time_hours",
+	},
+	conflict: `
+		ON CONFLICT(team_id, repository, pr_number) DO UPDATE SET
+			title = excluded.title,
+			merged_at = excluded.merged_at,
+			closed_at = excluded.closed_at,
+			cycle_time_hours = excluded.cycle_time_hours,
+			state = excluded.state,
+			first_review_at = excluded.first_review_at,
+			review_turnaround_hours = excluded.review_turnaround_hours,
+			review_comments_count = excluded.review_comments_count,
+			conversation_count = excluded.conversation_count,
+			changes_requested_count = excluded.changes_requested_count,
+			approved_count = excluded.approved_count,
+			reviewers_count = excluded.reviewers_count,
+			external_reviewers_count = excluded.external_reviewers_count,
+			reviewers_list = excluded.reviewers_list,
+			attribution_role = excluded.attribution_role,
+			owned = excluded.owned,
+			base_ref = excluded.base_ref,
+			head_ref = excluded.head_ref,
+			base_matched = excluded.base_matched,
+			work_type = excluded.work_type,
+			work_type_source = excluded.work_type_source,
+			work_scope = excluded.work_scope,
+			conventional = excluded.conventional,
+			ticket_started_at = excluded.ticket_started_at,
+			ticket_lead_time_hours = excluded.ticket_lead_time_hours
+	`,
+}
+
+// prMetricRow returns a PR metric's values in prMetrics column order
+func prMetricRow(metric *database.PRMetric) []interface{} {
+	return []interface{}{
+		metric.TeamID, metric.PRNumber, metric.Repository, metric.Author, metric.Title,
+		metric.CreatedAt, metric.MergedAt, metric.ClosedAt, metric.CycleTimeHours, metric.State, metric.CreatedAt,
+		metric.FirstReviewAt, metric.ReviewTurnaroundHours,
+		metric.ReviewCommentsCount, metric.ConversationCount,
+		metric.ChangesRequestedCount, metric.ApprovedCount,
+		metric.ReviewersCount, metric.ExternalReviewersCount, metric.ReviewersList,
+		metric.AttributionRole, metric.Owned,
+		metric.BaseRef, metric.HeadRef, metric.BaseMatched,
+		metric.WorkType, metric.WorkTypeSource, metric.WorkScope, metric.Conventional,
+		metric.TicketStartedAt, metric.TicketLeadTimeHours,
+	}
+}
+
+var commitMetrics = upsert{
+	table: "commit_metrics",
+	columns: []string{
+		"team_id", "repository", "commit_hash", "author", "message", "created_at", "created_date", "conventional",
+		"author_email", "is_merge", "additions", "deletions", "files_changed",
+	},
+	conflict: `
+		ON CONFLICT(team_id, repository, commit_hash) DO UPDATE SET
+			message = excluded.message,
+			author = excluded.author,
+			conventional = excluded.conventional,
+			author_email = COALESCE(excluded.author_email, commit_metrics.author_email),
+			is_merge = COALESCE(excluded.is_merge, commit_metrics.is_merge),
+			additions = COALESCE(excluded.additions, commit_metrics.additions),
+			deletions = COALESCE(excluded.deletions, commit_metrics.deletions),
+			files_changed = COALESCE(excluded.files_changed, commit_metrics.files_changed)
+	`,
+}
+
+// commitMetricRow returns a commit metric's values in commitMetrics column order
+func commitMetricRow(metric *database.CommitMetric) []interface{} {
+	return []interface{}{
+		metric.TeamID, metric.Repository, metric.CommitHash, metric.Author,
+		metric.Message, metric.CreatedAt, metric.CreatedDate, metric.Conventional,
+		metric.AuthorEmail, metric.IsMerge, metric.Additions, metric.Deletions, metric.FilesChanged,
+	}
+}
+
+var commentMetrics = upsert{
+	table: "comment_metrics",
+	columns: []string{
+		"team_id", "repository", "comment_id", "author", "body", "created_at", "created_date", "comment_type", "issue_number",
+	},
+	conflict: `
+		ON CONFLICT(team_id, repository, comment_id, comment_type) DO UPDATE SET
+			body = excluded.body,
+			author = excluded.author,
+			issue_number = excluded.issue_number
+	`,
+}
+
+// commentMetricRow returns a comment metric's values in commentMetrics column order
+func commentMetricRow(metric *database.CommentMetric) []interface{} {
+	return []interface{}{
+		metric.TeamID, metric.Repository, metric.CommentID, metric.Author,
+		metric.Body, metric.CreatedAt, metric.CreatedDate, metric.CommentType, metric.IssueNumber,
+	}
+}
+
+var issueMetrics = upsert{
+	table: "issue_metrics",
+	columns: []string{
+		"team_id", "repository", "issue_number", "title", "author", "state",
+		"created_at", "closed_at", "time_to_close_hours", "milestone", "assignees_list",
+		"work_type", "attribution_role",
+	},
+	conflict: `
+		ON CONFLICT(team_id, repository, issue_number) DO UPDATE SET
+			title = excluded.title,
+			state = excluded.state,
+			closed_at = excluded.closed_at,
+			time_to_close_hours = excluded.time_to_close_hours,
+			milestone = excluded.milestone,
+			assignees_list = excluded.assignees_list,
+			work_type = excluded.work_type,
+			attribution_role = excluded.attribution_role
+	`,
+}
+
+// issueMetricRow returns an issue metric's values in issueMetrics column order
+func issueMetricRow(metric *database.IssueMetric) []interface{} {
+	return []interface{}{
+		metric.TeamID, metric.Repository, metric.IssueNumber, metric.Title, metric.Author, metric.State,
+		metric.CreatedAt, metric.ClosedAt, metric.TimeToCloseHours, metric.Milestone, metric.AssigneesList,
+		metric.WorkType, metric.AttributionRole,
+	}
+}
+
+var commitCoAuthors = upsert{
+	table:    "commit_coauthors",
+	columns:  []string{"team_id", "repository", "commit_hash", "github_username", "weight", "created_at", "created_date"},
+	conflict: "ON CONFLICT(team_id, repository, commit_hash, github_username) DO UPDATE SET weight = excluded.weight",
+}
+
+var prCoAuthors = upsert{
+	table:    "pr_coauthors",
+	columns:  []string{"team_id", "repository", "pr_number", "github_username", "weight"},
+	conflict: "ON CONFLICT(team_id, repository, pr_number, github_username) DO UPDATE SET weight = excluded.weight",
+}
+
+var prLabels = upsert{
+	table:    "pr_labels",
+	columns:  []string{"repository", "pr_number", "label"},
+	conflict: "ON CONFLICT DO NOTHING",
+}
+
+var issueLabels = upsert{
+	table:    "issue_labels",
+	columns:  []string{"repository", "issue_number", "label"},
+	conflict: "ON CONFLICT DO NOTHING",
+}
+
+var prCommits = upsert{
+	table:    "pr_commits",
+	columns:  []string{"repository", "pr_number", "commit_hash"},
+	conflict: "ON CONFLICT DO NOTHING",
+}
+
+var prIssueLinks = upsert{
+	table:    "pr_issue_links",
+	columns:  []string{"repository", "pr_number", "issue_key", "tracker", "source"},
+	conflict: "ON CONFLICT DO NOTHING",
+}
+
+var jiraIssues = upsert{
+	table:   "jira_issues",
+	columns: []string{"issue_key", "summary", "status", "created_at", "resolved_at", "started_at", "fetched_at"},
+	conflict: `
+		ON CONFLICT(issue_key) DO UPDATE SET
+			summary = excluded.summary,
+			status = excluded.status,
+			created_at = excluded.created_at,
+			resolved_at = excluded.resolved_at,
+			started_at = excluded.started_at,
+			fetched_at = excluded.fetched_at
+	`,
+}
+
+var deployments = upsert{
+	table:   "deployments",
+	columns: []string{"repository", "deployment_id", "environment", "ref", "sha", "creator", "created_at"},
+	conflict: `
+		ON CONFLICT(repository, deployment_id) DO UPDATE SET
+			environment = excluded.environment,
+			ref = excluded.ref,
+			sha = excluded.sha,
+			creator = excluded.creator
+	`,
+}
+
+var jiraTransitions = upsert{
+	table:    "jira_transitions",
+	columns:  []string{"issue_key", "from_status", "to_status", "transitioned_at"},
+	conflict: "ON CONFLICT DO NOTHING",
+}
+
+// prune deletes the rows of one PR, commit, comment or issue from table
+// unless their team is kept
+type prune struct {
+	table       string
+	where       string
+	args        []interface{}
+	keepTeamIDs []int
+}
+
+// prPrunes returns the prunes of a PR's attributions and co-author credit
+func prPrunes(repository string, prNumber int, keepTeamIDs []int) []prune {
+	var prunes []prune
+	for _, table := range []string{"pr_metrics", "pr_coauthors"} {
+		prunes = append(prunes, prune{table: table, where: "repository = ? AND pr_number = ?", args: []interface{}{repository, prNumber}, keepTeamIDs: keepTeamIDs})
+	}
+	return prunes
+}
+
+// commitPrune returns the prune of a commit's attributions
+func commitPrune(repository, commitHash string, keepTeamIDs []int) prune {
+	return prune{table: "commit_metrics", where: "repository = ? AND commit_hash = ?", args: []interface{}{repository, commitHash}, keepTeamIDs: keepTeamIDs}
+}
+
+// commentPrune returns the prune of a comment's attributions
+func commentPrune(repository string, commentID int64, commentType string, keepTeamIDs []int) prune {
+	return prune{table: "comment_metrics", where: "repository = ? AND comment_id = ? AND comment_type = ?", args: []interface{}{repository, commentID, commentType}, keepTeamIDs: keepTeamIDs}
+}
+
+// issuePrune returns the prune of an issue's attributions
+func issuePrune(repository string, issueNumber int, keepTeamIDs []int) prune {
+	return prune{table: "issue_metrics", where: "repository = ? AND issue_number = ?", args: []interface{}{repository, issueNumber}, keepTeamIDs: keepTeamIDs}
+}
+
+// exec runs the prune with db or a transaction
+func (p prune) exec(db sqlx.Ext) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s", p.table, p.where)
+	args := p.args
+	if len(p.keepTeamIDs) > 0 {
+		query += " AND team_id NOT IN (?)"
+		args = append(args[:len(args):len(args)], p.keepTeamIDs)
+	}
+
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to build prune query for %s: %w", p.table, err)
+	}
+
+	if _, err := db.Exec(db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("failed to prune %s: %w", p.table, err)
+	}
+	return nil
+}
@@ -0,0 +1,407 @@
+package team
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// Errors returned by the admin operations
+var (
+	ErrTeamNotFound   = errors.New("team not found")
+	ErrMemberNotFound = errors.New("member not found")
+	ErrTeamExists     = errors.New("team already exists")
+	ErrAlreadyMember  = errors.New("already a member of the team")
+	ErrConfigManaged  = errors.New("team is managed by the team config")
+	ErrInvalid        = errors.New("invalid request")
+)
+
+// Audit log actions
+const (
+	AuditTeamCreate     = "team.create"
+	AuditTeamUpdate     = "team.update"
+	AuditMemberAdd      = "member.add"
+	AuditMemberRemove   = "member.remove"
+	AuditMemberAllocate = "member.allocation"
+)
+
+// defaultAuditLimit is the number of audit entries returned when no limit is given
+const defaultAuditLimit = 100
+
+// TeamChanges lists the team fields to update; nil fields are left unchanged.
+// A ParentID of 0 makes the team a top-level team.
+type TeamChanges struct {
+	Name        *string
+	Description *string
+	ParentID    *int
+}
+
+// LoadManager creates a team manager from the teams already in the database,
+// without syncing the team config. It is used by the admin API.
+func LoadManager(db *database.DB) (*Manager, error) {
+	m := &Manager{db: db, identities: make(map[string]string), codeOwners: make(map[string]int)}
+	if err := m.loadTeams(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// CreateTeam creates a team managed through the admin API
+func (m *Manager) CreateTeam(actor, name string, description *string, parentID *int) (*database.Team, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: team name is required", ErrInvalid)
+	}
+
+	var team database.Team
+	err := m.inTx(func(tx *sqlx.Tx) error {
+		var exists int
+		if err := tx.Get(&exists, "SELECT COUNT(*) FROM teams WHERE name = ?", name); err != nil {
+			return fmt.Errorf("failed to check team name: %w", err)
+		}
+		if exists > 0 {
+			return ErrTeamExists
+		}
+		if parentID != nil {
+			if _, err := getTeam(tx, *parentID); err != nil {
+				return parentError(err)
+			}
+		}
+
+		now := time.Now()
+		query := `
+			INSERT INTO teams (name, description, parent_id, source, created_at, updated_at)
+			VALUES (?, ?, ?, 'api', ?, ?)
+			RETURNING id
+		`
+		var teamID int
+		if err := tx.QueryRow(query, name, description, parentID, now, now).Scan(&teamID); err != nil {
+			return fmt.Errorf("failed to create team '%s': %w", name, err)
+		}
+
+		details := map[string]interface{}{"name": name, "description": description, "parent_id": parentID}
+		if err := writeAudit(tx, actor, AuditTeamCreate, &teamID, nil, details, now); err != nil {
+			return err
+		}
+
+		created, err := getTeam(tx, teamID)
+		team = created
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// UpdateTeam renames, describes or re-parents a team. Teams from the team
+// config can only have their description changed, since the next sync would
+// undo anything else.
+func (m *Manager) UpdateTeam(actor string, id int, changes TeamChanges) (*database.Team, error) {
+	var team database.Team
+	err := m.inTx(func(tx *sqlx.Tx) error {
+		current, err := getTeam(tx, id)
+		if err != nil {
+			return err
+		}
+		if current.Source != "api" && (changes.Name != nil || changes.ParentID != nil) {
+			return fmt.Errorf("%w: only the description of '%s' can be changed", ErrConfigManaged, current.Name)
+		}
+
+		details := make(map[string]interface{})
+		if changes.Name != nil {
+			name := strings.TrimSpace(*changes.Name)
+			if name == "" {
+				return fmt.Errorf("%w: team name is required", ErrInvalid)
+			}
+			var exists int
+			if err := tx.Get(&exists, "SELECT COUNT(*) FROM teams WHERE name = ? AND id <> ?", name, id); err != nil {
+				return fmt.Errorf("failed to check team name: %w", err)
+			}
+			if exists > 0 {
+				return ErrTeamExists
+			}
+			details["name"] = map[string]string{"from": current.Name, "to": name}
+			current.Name = name
+		}
+		if changes.Description != nil {
+			details["description"] = map[string]*string{"from": current.Description, "to": changes.Description}
+			current.Description = changes.Description
+		}
+		if changes.ParentID != nil {
+			var parentID *int
+			if *changes.ParentID != 0 {
+				parentID = changes.ParentID
+				if err := checkParent(tx, id, *parentID); err != nil {
+					return err
+				}
+			}
+			details["parent_id"] = map[string]*int{"from": current.ParentID, "to": parentID}
+			current.ParentID = parentID
+		}
+		if len(details) == 0 {
+			team = current
+			return nil
+		}
+
+		now := time.Now()
+		query := "UPDATE teams SET name = ?, description = ?, parent_id = ?, updated_at = ? WHERE id = ?"
+		if _, err := tx.Exec(query, current.Name, current.Description, current.ParentID, now, id); err != nil {
+			return fmt.Errorf("failed to update team %d: %w", id, err)
+		}
+		if err := writeAudit(tx, actor, AuditTeamUpdate, &id, nil, details, now); err != nil {
+			return err
+		}
+
+		team, err = getTeam(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// AddMember adds a user to a team from joinedAt (now if zero)
+func (m *Manager) AddMember(actor string, teamID int, username string, allocation float64, joinedAt time.Time) error {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return fmt.Errorf("%w: username is required", ErrInvalid)
+	}
+	if err := checkAllocation(allocation); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if joinedAt.IsZero() {
+		joinedAt = now
+	}
+
+	return m.inTx(func(tx *sqlx.Tx) error {
+		if err := checkAPIManaged(tx, teamID); err != nil {
+			return err
+		}
+		if _, err := openMembership(tx, teamID, username); err == nil {
+			return ErrAlreadyMember
+		} else if !errors.Is(err, ErrMemberNotFound) {
+			return err
+		}
+
+		// A new period must not overlap the user's earlier ones
+		var overlapping int
+		query := "SELECT COUNT(*) FROM team_memberships WHERE team_id = ? AND github_username = ? AND left_at > ?"
+		if err := tx.Get(&overlapping, query, teamID, username, joinedAt); err != nil {
+			return fmt.Errorf("failed to load memberships of %s in team %d: %w", username, teamID, err)
+		}
+		if overlapping > 0 {
+			return fmt.Errorf("%w: %s was still on the team at %s", ErrInvalid, username, joinedAt.Format("2006-01-02"))
+		}
+
+		if err := upsertMembership(tx, teamID, username, allocation, joinedAt, nil, now); err != nil {
+			return fmt.Errorf("failed to add %s to team %d: %w", username, teamID, err)
+		}
+		details := map[string]interface{}{"allocation": allocation, "joined_at": joinedAt}
+		return writeAudit(tx, actor, AuditMemberAdd, &teamID, &username, details, now)
+	})
+}
+
+// RemoveMember ends a user's current membership of a team at leftAt (now if zero)
+func (m *Manager) RemoveMember(actor string, teamID int, username string, leftAt time.Time) error {
+	now := time.Now()
+	if leftAt.IsZero() {
+		leftAt = now
+	}
+
+	return m.inTx(func(tx *sqlx.Tx) error {
+		if err := checkAPIManaged(tx, teamID); err != nil {
+			return err
+		}
+		membership, err := openMembership(tx, teamID, username)
+		if err != nil {
+			return err
+		}
+		if !leftAt.After(membership.JoinedAt) {
+			return fmt.Errorf("%w: left_at must be after the join date %s", ErrInvalid, membership.JoinedAt.Format("2006-01-02"))
+		}
+
+		if err := updateMembership(tx, membership.ID, membership.AllocationWeight, &leftAt); err != nil {
+			return fmt.Errorf("failed to remove %s from team %d: %w", username, teamID, err)
+		}
+		details := map[string]interface{}{"left_at": leftAt}
+		return writeAudit(tx, actor, AuditMemberRemove, &teamID, &username, details, now)
+	})
+}
+
+// SetAllocation changes a member's allocation from effectiveAt (now if zero).
+// The current membership period is closed and a new one started, so earlier
+// activity keeps the allocation it had at the time.
+func (m *Manager) SetAllocation(actor string, teamID int, username string, allocation float64, effectiveAt time.Time) error {
+	if err := checkAllocation(allocation); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if effectiveAt.IsZero() {
+		effectiveAt = now
+	}
+
+	return m.inTx(func(tx *sqlx.Tx) error {
+		if err := checkAPIManaged(tx, teamID); err != nil {
+			return err
+		}
+		membership, err := openMembership(tx, teamID, username)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case effectiveAt.Before(membership.JoinedAt):
+			return fmt.Errorf("%w: effective_at must not be before the join date %s", ErrInvalid, membership.JoinedAt.Format("2006-01-02"))
+		case effectiveAt.Equal(membership.JoinedAt):
+			// Nothing to preserve; correct the period in place
+			if err := updateMembership(tx, membership.ID, allocation, nil); err != nil {
+				return fmt.Errorf("failed to update allocation of %s in team %d: %w", username, teamID, err)
+			}
+		default:
+			if err := updateMembership(tx, membership.ID, membership.AllocationWeight, &effectiveAt); err != nil {
+				return fmt.Errorf("failed to close membership of %s in team %d: %w", username, teamID, err)
+			}
+			if err := upsertMembership(tx, teamID, username, allocation, effectiveAt, nil, now); err != nil {
+				return fmt.Errorf("failed to update allocation of %s in team %d: %w", username, teamID, err)
+			}
+		}
+
+		details := map[string]interface{}{
+			"allocation":   map[string]float64{"from": membership.AllocationWeight, "to": allocation},
+			"effective_at": effectiveAt,
+		}
+		return writeAudit(tx, actor, AuditMemberAllocate, &teamID, &username, details, now)
+	})
+}
+
+// ListAudit returns the most recent audit log entries, newest first
+func (m *Manager) ListAudit(limit int) ([]database.TeamAuditEntry, error) {
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+
+	var entries []database.TeamAuditEntry
+	query := "SELECT * FROM team_audit_log ORDER BY created_at DESC, id DESC LIMIT ?"
+	if err := m.db.Select(&entries, query, limit); err != nil {
+		return nil, fmt.Errorf("failed to load audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// inTx runs fn in a transaction and refreshes the in-memory teams once it commits
+func (m *Manager) inTx(fn func(tx *sqlx.Tx) error) error {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return m.loadTeams()
+}
+
+// writeAudit records an admin change in the audit log
+func writeAudit(db sqlx.Execer, actor, action string, teamID *int, username *string, details map[string]interface{}, now time.Time) error {
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit details: %w", err)
+	}
+	detailsJSON := string(encoded)
+
+	query := `
+		INSERT INTO team_audit_log (actor, action, team_id, github_username, details, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	if _, err := db.Exec(query, actor, action, teamID, username, detailsJSON, now); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// getTeam loads a team by ID
+func getTeam(db sqlx.Queryer, id int) (database.Team, error) {
+	var team database.Team
+	err := sqlx.Get(db, &team, "SELECT * FROM teams WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return team, ErrTeamNotFound
+	}
+	if err != nil {
+		return team, fmt.Errorf("failed to load team %d: %w", id, err)
+	}
+	return team, nil
+}
+
+// checkAPIManaged ensures a team exists and its members are managed through the admin API
+func checkAPIManaged(db sqlx.Queryer, id int) error {
+	team, err := getTeam(db, id)
+	if err != nil {
+		return err
+	}
+	if team.Source != "api" {
+		return fmt.Errorf("%w: change the members of '%s' in the team config", ErrConfigManaged, team.Name)
+	}
+	return nil
+}
+
+// checkParent ensures parentID exists and isn't the team itself or one of its descendants
+func checkParent(db sqlx.Queryer, teamID, parentID int) error {
+	for id := parentID; ; {
+		if id == teamID {
+			return fmt.Errorf("%w: team %d can't be nested under itself", ErrInvalid, teamID)
+		}
+		parent, err := getTeam(db, id)
+		if err != nil {
+			return parentError(err)
+		}
+		if parent.ParentID == nil {
+			return nil
+		}
+		id = *parent.ParentID
+	}
+}
+
+// parentError reports a missing parent team as an invalid request
+func parentError(err error) error {
+	if errors.Is(err, ErrTeamNotFound) {
+		return fmt.Errorf("%w: parent team not found", ErrInvalid)
+	}
+	return err
+}
+
+// checkAllocation ensures an allocation is in (0, 1]
+func checkAllocation(allocation float64) error {
+	if allocation <= 0 || allocation > 1 {
+		return fmt.Errorf("%w: allocation must be greater than 0 and at most 1", ErrInvalid)
+	}
+	return nil
+}
+
+// openMembership returns a user's current membership period of a team
+func openMembership(db sqlx.Queryer, teamID int, username string) (database.TeamMembership, error) {
+	var membership database.TeamMembership
+	query := "SELECT * FROM team_memberships WHERE team_id = ? AND github_username = ? AND left_at IS NULL"
+	err := sqlx.Get(db, &membership, query, teamID, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return membership, ErrMemberNotFound
+	}
+	if err != nil {
+		return membership, fmt.Errorf("failed to load membership of %s in team %d: %w", username, teamID, err)
+	}
+	return membership, nil
+}
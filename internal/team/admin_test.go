@@ -0,0 +1,225 @@
+package team
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+)
+
+// TestAdminTeams tests creating and updating teams through the admin operations
+func TestAdminTeams(t *testing.T) {
+	db := newTestDB(t)
+	cfg := &config.Config{Teams: []config.TeamConfig{{
+		Name:    "Platform",
+		Members: []config.TeamMemberConfig{{Username: "alice", Allocation: 1.0}},
+	}}}
+	if _, err := NewManager(db, cfg); err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	m, err := LoadManager(db)
+	if err != nil {
+		t.Fatalf("LoadManager() error = %v", err)
+	}
+
+	tribe, err := m.CreateTeam("alice", "Tribe", nil, nil)
+	if err != nil {
+		t.Fatalf("CreateTeam() error = %v", err)
+	}
+	if tribe.Source != "api" {
+		t.Errorf("created team source = %q, want api", tribe.Source)
+	}
+	web, err := m.CreateTeam("alice", "Web", nil, &tribe.ID)
+	if err != nil {
+		t.Fatalf("CreateTeam() error = %v", err)
+	}
+
+	var platformID int
+	if err := db.Get(&platformID, "SELECT id FROM teams WHERE name = 'Platform'"); err != nil {
+		t.Fatalf("failed to load Platform: %v", err)
+	}
+
+	name := func(s string) *string { return &s }
+	id := func(i int) *int { return &i }
+	missing := 9999
+
+	tests := []struct {
+		name    string
+		run     func() error
+		wantErr error
+	}{
+		{"duplicate name", func() error { _, err := m.CreateTeam("alice", "Platform", nil, nil); return err }, ErrTeamExists},
+		{"empty name", func() error { _, err := m.CreateTeam("alice", " ", nil, nil); return err }, ErrInvalid},
+		{"unknown parent", func() error { _, err := m.CreateTeam("alice", "Ops", nil, &missing); return err }, ErrInvalid},
+		{"rename", func() error { _, err := m.UpdateTeam("alice", web.ID, TeamChanges{Name: name("Frontend")}); return err }, nil},
+		{"parent cycle", func() error {
+			_, err := m.UpdateTeam("alice", tribe.ID, TeamChanges{ParentID: id(web.ID)})
+			return err
+		}, ErrInvalid},
+		{"move to top level", func() error { _, err := m.UpdateTeam("alice", web.ID, TeamChanges{ParentID: id(0)}); return err }, nil},
+		{"rename config team", func() error {
+			_, err := m.UpdateTeam("alice", platformID, TeamChanges{Name: name("Infra")})
+			return err
+		}, ErrConfigManaged},
+		{"describe config team", func() error {
+			_, err := m.UpdateTeam("alice", platformID, TeamChanges{Description: name("Shared services")})
+			return err
+		}, nil},
+		{"unknown team", func() error { _, err := m.UpdateTeam("alice", missing, TeamChanges{Name: name("X")}); return err }, ErrTeamNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// API-managed teams survive the next config sync untouched
+	if _, err := m.CreateTeam("alice", "Data", nil, nil); err != nil {
+		t.Fatalf("CreateTeam() error = %v", err)
+	}
+	if _, err := NewManager(db, cfg); err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	var source string
+	if err := db.Get(&source, "SELECT source FROM teams WHERE name = 'Data'"); err != nil || source != "api" {
+		t.Errorf("Data source after sync = %q (%v), want api", source, err)
+	}
+
+	// Every successful change is audited
+	entries, err := m.ListAudit(0)
+	if err != nil {
+		t.Fatalf("ListAudit() error = %v", err)
+	}
+	if len(entries) != 6 {
+		t.Fatalf("ListAudit() returned %d entries, want 6", len(entries))
+	}
+	if entries[0].Action != AuditTeamCreate || entries[0].Actor != "alice" {
+		t.Errorf("latest audit entry = %s by %s, want %s by alice", entries[0].Action, entries[0].Actor, AuditTeamCreate)
+	}
+}
+
+// TestAdminMembers tests adding, reallocating and removing members with effective dates
+func TestAdminMembers(t *testing.T) {
+	db := newTestDB(t)
+	m, err := LoadManager(db)
+	if err != nil {
+		t.Fatalf("LoadManager() error = %v", err)
+	}
+	web, err := m.CreateTeam("admin", "Web", nil, nil)
+	if err != nil {
+		t.Fatalf("CreateTeam() error = %v", err)
+	}
+
+	day := func(value string) time.Time { return date(value).Time }
+
+	if err := m.AddMember("admin", web.ID, "bob", 1.0, day("2024-01-01")); err != nil {
+		t.Fatalf("AddMember() error = %v", err)
+	}
+	if err := m.AddMember("admin", web.ID, "bob", 1.0, day("2024-02-01")); !errors.Is(err, ErrAlreadyMember) {
+		t.Errorf("AddMember() twice error = %v, want %v", err, ErrAlreadyMember)
+	}
+	if err := m.AddMember("admin", web.ID, "carol", 1.5, time.Time{}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("AddMember() with allocation 1.5 error = %v, want %v", err, ErrInvalid)
+	}
+	if err := m.SetAllocation("admin", web.ID, "bob", 0.5, day("2024-03-01")); err != nil {
+		t.Fatalf("SetAllocation() error = %v", err)
+	}
+	if err := m.SetAllocation("admin", web.ID, "bob", 0.5, day("2024-02-01")); !errors.Is(err, ErrInvalid) {
+		t.Errorf("SetAllocation() before the current period error = %v, want %v", err, ErrInvalid)
+	}
+	if err := m.RemoveMember("admin", web.ID, "bob", day("2024-06-01")); err != nil {
+		t.Fatalf("RemoveMember() error = %v", err)
+	}
+	if err := m.RemoveMember("admin", web.ID, "bob", time.Time{}); !errors.Is(err, ErrMemberNotFound) {
+		t.Errorf("RemoveMember() twice error = %v, want %v", err, ErrMemberNotFound)
+	}
+	if err := m.AddMember("admin", web.ID, "bob", 1.0, day("2024-05-01")); !errors.Is(err, ErrInvalid) {
+		t.Errorf("AddMember() overlapping an earlier period error = %v, want %v", err, ErrInvalid)
+	}
+
+	tests := []struct {
+		name   string
+		at     string
+		member bool
+		weight float64
+	}{
+		{"before joining", "2023-12-31", false, 0},
+		{"full time", "2024-02-15", true, 1.0},
+		{"half time", "2024-04-15", true, 0.5},
+		{"after leaving", "2024-07-01", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := day(tt.at)
+			if got := m.InTeamAt("bob", web.ID, at); got != tt.member {
+				t.Errorf("InTeamAt(%s) = %v, want %v", tt.at, got, tt.member)
+			}
+			for _, period := range m.memberships["bob"] {
+				if period.activeAt(at) && period.allocation != tt.weight {
+					t.Errorf("allocation at %s = %v, want %v", tt.at, period.allocation, tt.weight)
+				}
+			}
+		})
+	}
+
+	// Config-managed teams can't be edited member by member
+	cfg := &config.Config{Teams: []config.TeamConfig{{Name: "Platform"}}}
+	if _, err := NewManager(db, cfg); err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	var platformID int
+	if err := db.Get(&platformID, "SELECT id FROM teams WHERE name = 'Platform'"); err != nil {
+		t.Fatalf("failed to load Platform: %v", err)
+	}
+	if err := m.AddMember("admin", platformID, "dave", 1.0, time.Time{}); !errors.Is(err, ErrConfigManaged) {
+		t.Errorf("AddMember() on a config team error = %v, want %v", err, ErrConfigManaged)
+	}
+}
+
+// TestAdminConcurrentReloads tests that admin writes, which reload the
+// in-memory teams, can run alongside each other and alongside lookups
+func TestAdminConcurrentReloads(t *testing.T) {
+	db := newTestDB(t)
+	cfg := &config.Config{Teams: []config.TeamConfig{{
+		Name:    "Platform",
+		Members: []config.TeamMemberConfig{{Username: "alice", Allocation: 1.0}},
+	}}}
+	if _, err := NewManager(db, cfg); err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	m, err := LoadManager(db)
+	if err != nil {
+		t.Fatalf("LoadManager() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := m.loadTeams(); err != nil {
+					errs <- err
+					return
+				}
+				if !m.IsMember("alice") || len(m.GetTeamsForUser("alice")) != 1 {
+					errs <- errors.New("alice isn't on Platform during a reload")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
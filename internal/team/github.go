@@ -0,0 +1,201 @@
+package team
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+	gh "github.com/google/go-github/v58/github"
+)
+
+// OrgTeamsClient lists GitHub organization teams and their members
+type OrgTeamsClient interface {
+	FetchOrgTeams(org string) ([]*gh.Team, error)
+	FetchTeamMembers(org, slug string) ([]*gh.User, error)
+}
+
+// resolveTeams returns the teams to sync for the configured team source
+func resolveTeams(cfg *config.Config, client OrgTeamsClient) ([]config.TeamConfig, error) {
+	switch cfg.TeamSource {
+	case "", "config":
+		return cfg.Teams, nil
+	case "github", "merged":
+		if client == nil {
+			return nil, fmt.Errorf("team source '%s' needs a GitHub client", cfg.TeamSource)
+		}
+		orgTeams, err := loadOrgTeams(client, cfg.GitHubOrg, cfg.GitHubTeamMap)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.TeamSource == "github" {
+			return orgTeams, nil
+		}
+		return mergeTeams(cfg.Teams, orgTeams), nil
+	default:
+		return nil, fmt.Errorf("unknown team source: %s", cfg.TeamSource)
+	}
+}
+
+// loadOrgTeams builds team configs from an organization's GitHub teams.
+// When slugMap is set only the mapped teams are loaded, under their mapped names.
+// Nested teams keep their parent, and since GitHub lists child team members on
+// the parent as well, each person is only placed on their most specific team.
+// A person on several teams has their allocation split evenly between them.
+func loadOrgTeams(client OrgTeamsClient, org string, slugMap map[string]string) ([]config.TeamConfig, error) {
+	ghTeams, err := client.FetchOrgTeams(org)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string) // slug -> team name
+	var slugs []string
+	for _, ghTeam := range ghTeams {
+		slug := ghTeam.GetSlug()
+		name := ghTeam.GetName()
+		if len(slugMap) > 0 {
+			mapped, ok := slugMap[slug]
+			if !ok {
+				continue
+			}
+			name = mapped
+		}
+		names[slug] = name
+		slugs = append(slugs, slug)
+	}
+
+	for slug := range slugMap {
+		if _, ok := names[slug]; !ok {
+			return nil, fmt.Errorf("GitHub team '%s' not found in org %s", slug, org)
+		}
+	}
+
+	parents := make(map[string]string) // slug -> parent slug, for loaded parents only
+	for _, ghTeam := range ghTeams {
+		parentSlug := ghTeam.GetParent().GetSlug()
+		if _, ok := names[ghTeam.GetSlug()]; ok && names[parentSlug] != "" {
+			parents[ghTeam.GetSlug()] = parentSlug
+		}
+	}
+
+	members := make(map[string]map[string]bool) // slug -> usernames, including child teams
+	for _, slug := range slugs {
+		users, err := client.FetchTeamMembers(org, slug)
+		if err != nil {
+			return nil, err
+		}
+		members[slug] = make(map[string]bool)
+		for _, user := range users {
+			members[slug][user.GetLogin()] = true
+		}
+	}
+
+	// Drop members who are listed on a parent only because they are on a child team
+	direct := make(map[string][]string)
+	teamCount := make(map[string]int)
+	for _, slug := range slugs {
+		for _, user := range sortedKeys(members[slug]) {
+			if onChildTeam(slug, user, parents, members) {
+				continue
+			}
+			direct[slug] = append(direct[slug], user)
+			teamCount[user]++
+		}
+	}
+
+	teams := make([]config.TeamConfig, 0, len(slugs))
+	for _, slug := range slugs {
+		teamCfg := config.TeamConfig{Name: names[slug], CodeOwners: []string{fmt.Sprintf("@%s/%s", org, slug)}}
+		if parentSlug, ok := parents[slug]; ok {
+			teamCfg.Parent = names[parentSlug]
+		}
+		for _, user := range direct[slug] {
+			teamCfg.Members = append(teamCfg.Members, config.TeamMemberConfig{
+				Username:   user,
+				Allocation: 1.0 / float64(teamCount[user]),
+			})
+		}
+		teams = append(teams, teamCfg)
+	}
+
+	return teams, nil
+}
+
+// onChildTeam reports whether user is a member of any loaded descendant of slug
+func onChildTeam(slug, user string, parents map[string]string, members map[string]map[string]bool) bool {
+	for child, parent := range parents {
+		if parent != slug {
+			continue
+		}
+		if members[child][user] || onChildTeam(child, user, parents, members) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeTeams combines configured teams with GitHub org teams by name.
+// Configured settings (parent, allocation, dates, emails) take precedence;
+// GitHub adds any teams and members the config doesn't mention.
+func mergeTeams(configured, orgTeams []config.TeamConfig) []config.TeamConfig {
+	merged := make([]config.TeamConfig, 0, len(configured)+len(orgTeams))
+	index := make(map[string]int)
+	for _, teamCfg := range configured {
+		index[teamCfg.Name] = len(merged)
+		teamCfg.Members = append([]config.TeamMemberConfig(nil), teamCfg.Members...)
+		merged = append(merged, teamCfg)
+	}
+
+	for _, orgTeam := range orgTeams {
+		i, ok := index[orgTeam.Name]
+		if !ok {
+			index[orgTeam.Name] = len(merged)
+			merged = append(merged, orgTeam)
+			continue
+		}
+
+		if merged[i].Parent == "" {
+			merged[i].Parent = orgTeam.Parent
+		}
+		merged[i].CodeOwners = uniqueAppend(merged[i].CodeOwners, orgTeam.CodeOwners...)
+		known := make(map[string]bool)
+		for _, member := range merged[i].Members {
+			known[member.Username] = true
+		}
+		for _, member := range orgTeam.Members {
+			if !known[member.Username] {
+				merged[i].Members = append(merged[i].Members, member)
+			}
+		}
+	}
+
+	return merged
+}
+
+// uniqueAppend appends the values that aren't in list yet
+func uniqueAppend(list []string, values ...string) []string {
+	list = append([]string(nil), list...)
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if strings.EqualFold(existing, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// sortedKeys returns the keys of a set in sorted order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
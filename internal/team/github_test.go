@@ -0,0 +1,203 @@
+package team
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+	"github.com/dothanhlam/go-github-tracker/internal/github"
+)
+
+// fakeOrg serves the GitHub Teams API for a single organization
+type fakeOrg struct {
+	teams   []map[string]interface{}
+	members map[string][]string // slug -> logins
+}
+
+func (f *fakeOrg) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/orgs/acme/teams", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(f.teams)
+	})
+	mux.HandleFunc("/api/v3/orgs/acme/teams/{slug}/members", func(w http.ResponseWriter, r *http.Request) {
+		var users []map[string]string
+		for _, login := range f.members[r.PathValue("slug")] {
+			users = append(users, map[string]string{"login": login})
+		}
+		json.NewEncoder(w).Encode(users)
+	})
+	return mux
+}
+
+func newFakeOrgClient(t *testing.T, org *fakeOrg) *github.Client {
+	t.Helper()
+
+	server := httptest.NewServer(org.handler())
+	t.Cleanup(server.Close)
+
+	client, err := github.NewClientWithBaseURL("test-token", server.URL+"/api/v3/")
+	if err != nil {
+		t.Fatalf("NewClientWithBaseURL() error = %v", err)
+	}
+	return client
+}
+
+// TestLoadOrgTeams tests building teams from nested GitHub organization teams
+func TestLoadOrgTeams(t *testing.T) {
+	org := &fakeOrg{
+		teams: []map[string]interface{}{
+			{"slug": "platform", "name": "Platform"},
+			{"slug": "platform-api", "name": "Platform API", "parent": map[string]string{"slug": "platform"}},
+			{"slug": "web", "name": "Web"},
+			{"slug": "everyone", "name": "Everyone"},
+		},
+		members: map[string][]string{
+			// GitHub lists child team members on the parent too
+			"platform":     {"alice", "bob", "carol"},
+			"platform-api": {"bob", "carol"},
+			"web":          {"alice", "dave"},
+		},
+	}
+	client := newFakeOrgClient(t, org)
+
+	slugMap := map[string]string{"platform": "Platform", "platform-api": "API", "web": "Frontend"}
+	teams, err := loadOrgTeams(client, "acme", slugMap)
+	if err != nil {
+		t.Fatalf("loadOrgTeams() error = %v", err)
+	}
+
+	want := []config.TeamConfig{
+		{Name: "Platform", Members: []config.TeamMemberConfig{{Username: "alice", Allocation: 0.5}}},
+		{Name: "API", Parent: "Platform", Members: []config.TeamMemberConfig{
+			{Username: "bob", Allocation: 1.0},
+			{Username: "carol", Allocation: 1.0},
+		}},
+		{Name: "Frontend", Members: []config.TeamMemberConfig{
+			{Username: "alice", Allocation: 0.5},
+			{Username: "dave", Allocation: 1.0},
+		}},
+	}
+	if len(teams) != len(want) {
+		t.Fatalf("loadOrgTeams() returned %d teams, want %d: %+v", len(teams), len(want), teams)
+	}
+	for i := range want {
+		if teams[i].Name != want[i].Name || teams[i].Parent != want[i].Parent {
+			t.Errorf("team %d = %s (parent %q), want %s (parent %q)", i, teams[i].Name, teams[i].Parent, want[i].Name, want[i].Parent)
+		}
+		if len(teams[i].Members) != len(want[i].Members) {
+			t.Errorf("team %s members = %+v, want %+v", want[i].Name, teams[i].Members, want[i].Members)
+			continue
+		}
+		for j, member := range want[i].Members {
+			if teams[i].Members[j].Username != member.Username || teams[i].Members[j].Allocation != member.Allocation {
+				t.Errorf("team %s member %d = %+v, want %+v", want[i].Name, j, teams[i].Members[j], member)
+			}
+		}
+	}
+
+	if len(teams[1].CodeOwners) != 1 || teams[1].CodeOwners[0] != "@acme/platform-api" {
+		t.Errorf("API CODEOWNERS handles = %v, want [@acme/platform-api]", teams[1].CodeOwners)
+	}
+
+	if _, err := loadOrgTeams(client, "acme", map[string]string{"missing": "Missing"}); err == nil {
+		t.Error("loadOrgTeams() with an unknown slug should fail")
+	}
+}
+
+// TestMergeTeams tests that configured settings win over GitHub teams
+func TestMergeTeams(t *testing.T) {
+	configured := []config.TeamConfig{
+		{Name: "Platform", Members: []config.TeamMemberConfig{{Username: "alice", Allocation: 0.8}}},
+		{Name: "Contractors", Members: []config.TeamMemberConfig{{Username: "erin", Allocation: 1.0}}},
+	}
+	orgTeams := []config.TeamConfig{
+		{Name: "Platform", Parent: "Engineering", Members: []config.TeamMemberConfig{
+			{Username: "alice", Allocation: 1.0},
+			{Username: "bob", Allocation: 1.0},
+		}},
+		{Name: "Engineering"},
+	}
+
+	merged := mergeTeams(configured, orgTeams)
+
+	if len(merged) != 3 {
+		t.Fatalf("mergeTeams() returned %d teams, want 3", len(merged))
+	}
+	platform := merged[0]
+	if platform.Parent != "Engineering" {
+		t.Errorf("Platform parent = %q, want Engineering", platform.Parent)
+	}
+	if len(platform.Members) != 2 || platform.Members[0].Allocation != 0.8 || platform.Members[1].Username != "bob" {
+		t.Errorf("Platform members = %+v, want configured alice (0.8) plus bob", platform.Members)
+	}
+	if len(configured[0].Members) != 1 {
+		t.Error("mergeTeams() modified the configured teams")
+	}
+}
+
+// TestOrgTeamSync tests syncing GitHub team membership changes with effective dates
+func TestOrgTeamSync(t *testing.T) {
+	db := newTestDB(t)
+	org := &fakeOrg{
+		teams: []map[string]interface{}{
+			{"slug": "platform", "name": "Platform"},
+			{"slug": "platform-api", "name": "Platform API", "parent": map[string]string{"slug": "platform"}},
+		},
+		members: map[string][]string{
+			"platform":     {"alice", "bob"},
+			"platform-api": {"bob"},
+		},
+	}
+	client := newFakeOrgClient(t, org)
+	cfg := &config.Config{TeamSource: "github", GitHubOrg: "acme"}
+
+	m, err := NewManagerWithOrg(db, cfg, client)
+	if err != nil {
+		t.Fatalf("NewManagerWithOrg() error = %v", err)
+	}
+
+	var parent string
+	query := "SELECT p.name FROM teams t JOIN teams p ON p.id = t.parent_id WHERE t.name = 'Platform API'"
+	if err := db.Get(&parent, query); err != nil {
+		t.Fatalf("failed to load parent: %v", err)
+	}
+	if parent != "Platform" {
+		t.Errorf("Platform API parent = %q, want Platform", parent)
+	}
+	if teams := m.GetTeamsForUser("bob"); len(teams) != 1 {
+		t.Errorf("bob teams = %v, want only Platform API", teams)
+	}
+
+	// bob leaves the API team, carol joins it
+	org.members["platform"] = []string{"alice", "carol"}
+	org.members["platform-api"] = []string{"carol"}
+	m, err = NewManagerWithOrg(db, cfg, client)
+	if err != nil {
+		t.Fatalf("NewManagerWithOrg() error = %v", err)
+	}
+
+	historic := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := time.Now().Add(time.Minute)
+	tests := []struct {
+		name     string
+		username string
+		at       time.Time
+		want     int
+	}{
+		{"bob counts historically", "bob", historic, 1},
+		{"bob has left", "bob", later, 0},
+		{"carol is not credited for old work", "carol", historic, 0},
+		{"carol counts from now", "carol", later, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.GetTeamsForUserAt(tt.username, tt.at); len(got) != tt.want {
+				t.Errorf("GetTeamsForUserAt(%s) = %v, want %d teams", tt.username, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,30 +2,90 @@ package team
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/dothanhlam/go-github-tracker/internal/config"
 	"github.com/dothanhlam/go-github-tracker/internal/database"
+	"github.com/jmoiron/sqlx"
 )
 
 // Manager handles team membership lookups
 type Manager struct {
-	db            *database.DB
-	teams         map[int]*database.Team
-	membershipMap map[string][]int // username -> team IDs
+	db *database.DB
+
+	// mu guards teams, memberships and identities, which admin writes reload
+	// while API requests read them
+	mu          sync.RWMutex
+	teams       map[int]*database.Team
+	memberships map[string][]membershipPeriod // username -> membership history
+	identities  map[string]string             // lowercased username/email -> username
+	codeOwners  map[string]int                // lowercased CODEOWNERS team handle -> team ID
+	issueLabels map[string][]int              // lowercased issue label -> team IDs
+}
+
+// membershipPeriod is one stint of a user on a team
+type membershipPeriod struct {
+	teamID     int
+	allocation float64
+	joinedAt   time.Time
+	leftAt     *time.Time
+}
+
+// activeAt reports whether the membership covers the given time
+func (p membershipPeriod) activeAt(t time.Time) bool {
+	return !t.Before(p.joinedAt) && (p.leftAt == nil || t.Before(*p.leftAt))
 }
 
-// NewManager creates a new team manager
+// membershipEpoch is the join date for members of the initial import (the
+// first sync into a database without memberships): they were on their teams
+// before tracking began, so their history counts
+var membershipEpoch = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// noreplyEmail matches GitHub noreply addresses, optionally prefixed with the user ID
+var noreplyEmail = regexp.MustCompile(`^(?:\d+\+)?([^@]+)@users\.noreply\.github\.com$`)
+
+// NewManager creates a new team manager from the configured teams
 func NewManager(db *database.DB, cfg *config.Config) (*Manager, error) {
+	return NewManagerWithOrg(db, cfg, nil)
+}
+
+// NewManagerWithOrg creates a new team manager, loading teams from GitHub
+// organization teams as well when cfg.TeamSource asks for it
+func NewManagerWithOrg(db *database.DB, cfg *config.Config, org OrgTeamsClient) (*Manager, error) {
+	return newManager(db, cfg, org, true)
+}
+
+// LoadManagerWithOrg creates a team manager from the teams and memberships as
+// last synced, without syncing the configured ones, for runs that must not
+// write to the database
+func LoadManagerWithOrg(db *database.DB, cfg *config.Config, org OrgTeamsClient) (*Manager, error) {
+	return newManager(db, cfg, org, false)
+}
+
+// newManager creates a team manager, syncing the configured teams first if sync is set
+func newManager(db *database.DB, cfg *config.Config, org OrgTeamsClient, sync bool) (*Manager, error) {
 	m := &Manager{
-		db:            db,
-		teams:         make(map[int]*database.Team),
-		membershipMap: make(map[string][]int),
+		db:          db,
+		teams:       make(map[int]*database.Team),
+		memberships: make(map[string][]membershipPeriod),
+		identities:  make(map[string]string),
+		codeOwners:  make(map[string]int),
+		issueLabels: make(map[string][]int),
+	}
+
+	teams, err := resolveTeams(cfg, org)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load teams: %w", err)
 	}
 
-	// Sync teams from config to database
-	if err := m.syncTeams(cfg); err != nil {
-		return nil, fmt.Errorf("failed to sync teams: %w", err)
+	// Sync teams to database
+	if sync {
+		if err := m.syncTeams(teams); err != nil {
+			return nil, fmt.Errorf("failed to sync teams: %w", err)
+		}
 	}
 
 	// Load teams into memory
@@ -33,48 +93,231 @@ func NewManager(db *database.DB, cfg *config.Config) (*Manager, error) {
 		return nil, fmt.Errorf("failed to load teams: %w", err)
 	}
 
+	// Configured emails and aliases let commit identities resolve to members
+	for _, teamCfg := range teams {
+		for _, member := range teamCfg.Members {
+			for _, email := range member.Emails {
+				m.identities[strings.ToLower(email)] = member.Username
+			}
+		}
+	}
+	teamIDs := make(map[string]int)
+	for id, t := range m.teams {
+		teamIDs[t.Name] = id
+	}
+	for _, teamCfg := range teams {
+		for _, handle := range teamCfg.CodeOwners {
+			m.codeOwners[strings.ToLower(handle)] = teamIDs[teamCfg.Name]
+		}
+		for _, label := range teamCfg.IssueLabels {
+			key := strings.ToLower(strings.TrimSpace(label))
+			m.issueLabels[key] = append(m.issueLabels[key], teamIDs[teamCfg.Name])
+		}
+	}
+
+	for alias, username := range cfg.Aliases {
+		if canonical, ok := m.identities[strings.ToLower(username)]; ok {
+			username = canonical
+		}
+		m.identities[strings.ToLower(strings.TrimSpace(alias))] = username
+	}
+
 	return m, nil
 }
 
-// syncTeams syncs teams from config to database
+// syncTeams syncs teams to the database in one transaction, so a sync that
+// fails partway leaves the teams and memberships as they were.
 // Teams are identified by their unique name, and IDs are auto-generated by the database
-func (m *Manager) syncTeams(cfg *config.Config) error {
-	for _, teamCfg := range cfg.Teams {
-		// Upsert team using name as the unique identifier
-		// The database will auto-generate the ID for new teams
-		query := `
-			INSERT INTO teams (name, created_at, updated_at)
-			VALUES (?, ?, ?)
-			ON CONFLICT(name) DO UPDATE SET
-				updated_at = excluded.updated_at
-			RETURNING id
-		`
-		now := time.Now()
-		
-		var teamID int
-		err := m.db.QueryRow(query, teamCfg.Name, now, now).Scan(&teamID)
+func (m *Manager) syncTeams(teams []config.TeamConfig) error {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	configured := make(map[int]bool)
+	teamIDs := make(map[string]int)
+
+	// Members of the initial import predate tracking. Anyone added later, on a
+	// new team or an existing one, joins when first synced unless config says when.
+	var memberships int
+	if err := tx.Get(&memberships, "SELECT COUNT(*) FROM team_memberships"); err != nil {
+		return fmt.Errorf("failed to count memberships: %w", err)
+	}
+	defaultJoinedAt := now
+	if memberships == 0 {
+		defaultJoinedAt = membershipEpoch
+	}
+
+	for _, teamCfg := range teams {
+		teamID, err := upsertTeam(tx, teamCfg.Name, now)
 		if err != nil {
-			return fmt.Errorf("failed to upsert team '%s': %w", teamCfg.Name, err)
+			return err
 		}
+		configured[teamID] = true
+		teamIDs[teamCfg.Name] = teamID
 
-		// Upsert team memberships using the returned team ID
-		for _, member := range teamCfg.Members {
-			query := `
-				INSERT INTO team_memberships (team_id, github_username, allocation_weight, joined_at, created_at)
-				VALUES (?, ?, ?, ?, ?)
-				ON CONFLICT(team_id, github_username, joined_at) DO UPDATE SET
-					allocation_weight = excluded.allocation_weight
-			`
-			if _, err := m.db.Exec(query, teamID, member.Username, member.Allocation, now, now); err != nil {
+		if err := syncMemberships(tx, teamID, teamCfg, defaultJoinedAt, now); err != nil {
+			return err
+		}
+	}
+
+	// Link parents once every team exists, so config order doesn't matter
+	for _, teamCfg := range teams {
+		var parentID *int
+		if teamCfg.Parent != "" {
+			id, ok := teamIDs[teamCfg.Parent]
+			if !ok {
+				return fmt.Errorf("team '%s': parent team '%s' is not configured", teamCfg.Name, teamCfg.Parent)
+			}
+			parentID = &id
+		}
+		query := "UPDATE teams SET parent_id = ? WHERE id = ?"
+		if _, err := tx.Exec(query, parentID, teamIDs[teamCfg.Name]); err != nil {
+			return fmt.Errorf("failed to set parent of team '%s': %w", teamCfg.Name, err)
+		}
+	}
+
+	// Teams dropped from config keep their history, but nobody is on them any more.
+	// Teams managed through the admin API are left alone.
+	// An empty config is treated as "not configured" rather than "disband everything".
+	if len(teams) > 0 {
+		var allTeamIDs []int
+		if err := tx.Select(&allTeamIDs, "SELECT id FROM teams WHERE source = 'config'"); err != nil {
+			return fmt.Errorf("failed to list teams: %w", err)
+		}
+		for _, teamID := range allTeamIDs {
+			if configured[teamID] {
+				continue
+			}
+			query := "UPDATE team_memberships SET left_at = ? WHERE team_id = ? AND left_at IS NULL"
+			if _, err := tx.Exec(query, now, teamID); err != nil {
+				return fmt.Errorf("failed to close memberships for team %d: %w", teamID, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit team sync: %w", err)
+	}
+	return nil
+}
+
+// upsertTeam creates a team by name (or touches it) and returns its ID
+func upsertTeam(tx *sqlx.Tx, name string, now time.Time) (int, error) {
+	// Upsert team using name as the unique identifier
+	// The database will auto-generate the ID for new teams
+	query := `
+		INSERT INTO teams (name, source, created_at, updated_at)
+		VALUES (?, 'config', ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			source = 'config',
+			updated_at = excluded.updated_at
+		RETURNING id
+	`
+
+	var teamID int
+	if err := tx.QueryRow(query, name, now, now).Scan(&teamID); err != nil {
+		return 0, fmt.Errorf("failed to upsert team '%s': %w", name, err)
+	}
+	return teamID, nil
+}
+
+// syncMemberships reconciles a team's membership periods with its config.
+// Open memberships are kept open across runs (only the allocation changes),
+// members missing from config are closed as of now, new members join at
+// defaultJoinedAt, and explicit joined_at/left_at dates in config take
+// precedence over inferred ones.
+func syncMemberships(tx *sqlx.Tx, teamID int, teamCfg config.TeamConfig, defaultJoinedAt, now time.Time) error {
+	var existing []database.TeamMembership
+	if err := tx.Select(&existing, "SELECT * FROM team_memberships WHERE team_id = ? ORDER BY joined_at", teamID); err != nil {
+		return fmt.Errorf("failed to load memberships for team '%s': %w", teamCfg.Name, err)
+	}
+
+	open := make(map[string]database.TeamMembership)
+	latest := make(map[string]database.TeamMembership)
+	for _, membership := range existing {
+		latest[membership.GitHubUsername] = membership
+		if membership.LeftAt == nil {
+			open[membership.GitHubUsername] = membership
+		}
+	}
+
+	configured := make(map[string]bool)
+	for _, member := range teamCfg.Members {
+		configured[member.Username] = true
+
+		var leftAt *time.Time
+		if member.LeftAt != nil {
+			leftAt = &member.LeftAt.Time
+		}
+
+		current, isOpen := open[member.Username]
+		switch {
+		case member.JoinedAt != nil:
+			// Explicit dates replace an open period that started on a different date
+			if isOpen && !current.JoinedAt.Equal(member.JoinedAt.Time) {
+				if _, err := tx.Exec("DELETE FROM team_memberships WHERE id = ?", current.ID); err != nil {
+					return fmt.Errorf("failed to replace membership for %s in team '%s': %w", member.Username, teamCfg.Name, err)
+				}
+			}
+			if err := upsertMembership(tx, teamID, member.Username, member.Allocation, member.JoinedAt.Time, leftAt, now); err != nil {
+				return fmt.Errorf("failed to upsert membership for %s in team '%s': %w", member.Username, teamCfg.Name, err)
+			}
+
+		case isOpen:
+			if err := updateMembership(tx, current.ID, member.Allocation, leftAt); err != nil {
+				return fmt.Errorf("failed to update membership for %s in team '%s': %w", member.Username, teamCfg.Name, err)
+			}
+
+		case leftAt != nil && latest[member.Username].ID != 0:
+			// Already closed; keep the last period in line with config
+			if err := updateMembership(tx, latest[member.Username].ID, member.Allocation, leftAt); err != nil {
+				return fmt.Errorf("failed to update membership for %s in team '%s': %w", member.Username, teamCfg.Name, err)
+			}
+
+		default:
+			if err := upsertMembership(tx, teamID, member.Username, member.Allocation, defaultJoinedAt, leftAt, now); err != nil {
 				return fmt.Errorf("failed to upsert membership for %s in team '%s': %w", member.Username, teamCfg.Name, err)
 			}
 		}
 	}
 
+	// Anyone still open but no longer configured has left the team
+	for username, membership := range open {
+		if configured[username] {
+			continue
+		}
+		if err := updateMembership(tx, membership.ID, membership.AllocationWeight, &now); err != nil {
+			return fmt.Errorf("failed to close membership for %s in team '%s': %w", username, teamCfg.Name, err)
+		}
+	}
+
 	return nil
 }
 
-// loadTeams loads teams and memberships into memory
+// upsertMembership records a membership period keyed by its join date
+func upsertMembership(db sqlx.Execer, teamID int, username string, allocation float64, joinedAt time.Time, leftAt *time.Time, now time.Time) error {
+	query := `
+		INSERT INTO team_memberships (team_id, github_username, allocation_weight, joined_at, left_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(team_id, github_username, joined_at) DO UPDATE SET
+			allocation_weight = excluded.allocation_weight,
+			left_at = excluded.left_at
+	`
+	_, err := db.Exec(query, teamID, username, allocation, joinedAt, leftAt, now)
+	return err
+}
+
+// updateMembership changes the allocation and end date of an existing membership period
+func updateMembership(db sqlx.Execer, id int, allocation float64, leftAt *time.Time) error {
+	query := "UPDATE team_memberships SET allocation_weight = ?, left_at = ? WHERE id = ?"
+	_, err := db.Exec(query, allocation, leftAt, id)
+	return err
+}
+
+// loadTeams loads teams and memberships into memory, replacing those loaded before
 func (m *Manager) loadTeams() error {
 	// Load teams
 	var teams []database.Team
@@ -82,40 +325,144 @@ func (m *Manager) loadTeams() error {
 		return fmt.Errorf("failed to load teams: %w", err)
 	}
 
-	for i := range teams {
-		m.teams[teams[i].ID] = &teams[i]
-	}
-
-	// Load memberships
+	// Load memberships, including past ones, so historical events are attributed
+	// to the teams people belonged to at the time
 	var memberships []database.TeamMembership
-	query := "SELECT * FROM team_memberships WHERE left_at IS NULL"
+	query := "SELECT * FROM team_memberships"
 	if err := m.db.Select(&memberships, query); err != nil {
 		return fmt.Errorf("failed to load memberships: %w", err)
 	}
 
+	// Build the maps before taking the lock, so readers only wait for the swap
+	teamsByID := make(map[int]*database.Team, len(teams))
+	for i := range teams {
+		teamsByID[teams[i].ID] = &teams[i]
+	}
+	periods := make(map[string][]membershipPeriod)
 	for _, membership := range memberships {
-		m.membershipMap[membership.GitHubUsername] = append(
-			m.membershipMap[membership.GitHubUsername],
-			membership.TeamID,
+		periods[membership.GitHubUsername] = append(
+			periods[membership.GitHubUsername],
+			membershipPeriod{
+				teamID:     membership.TeamID,
+				allocation: membership.AllocationWeight,
+				joinedAt:   membership.JoinedAt,
+				leftAt:     membership.LeftAt,
+			},
 		)
 	}
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.teams = teamsByID
+	m.memberships = periods
+	for _, membership := range memberships {
+		m.identities[strings.ToLower(membership.GitHubUsername)] = membership.GitHubUsername
+	}
 	return nil
 }
 
-// IsMember checks if a username is a member of any team
+// IsMember checks if a username has ever been a member of any team
 func (m *Manager) IsMember(username string) bool {
-	_, exists := m.membershipMap[username]
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, exists := m.memberships[username]
 	return exists
 }
 
-// GetTeamsForUser returns all team IDs for a username
+// GetTeamsForUser returns the IDs of the teams a username currently belongs to
 func (m *Manager) GetTeamsForUser(username string) []int {
-	return m.membershipMap[username]
+	return m.GetTeamsForUserAt(username, time.Now())
+}
+
+// GetTeamsForUserAt returns the IDs of the teams a username belonged to at
+// the given time. A zero time means "now".
+func (m *Manager) GetTeamsForUserAt(username string, at time.Time) []int {
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var teamIDs []int
+	seen := make(map[int]bool)
+	for _, period := range m.memberships[username] {
+		if period.activeAt(at) && !seen[period.teamID] {
+			seen[period.teamID] = true
+			teamIDs = append(teamIDs, period.teamID)
+		}
+	}
+	return teamIDs
+}
+
+// ResolveMember maps a commit identity (name and email, as found in a
+// Co-authored-by trailer) to a team member's GitHub username. It tries a
+// GitHub noreply address first, then configured member emails, then a name
+// that matches a username.
+func (m *Manager) ResolveMember(name, email string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	email = strings.ToLower(strings.TrimSpace(email))
+	if match := noreplyEmail.FindStringSubmatch(email); match != nil {
+		if username, ok := m.identities[match[1]]; ok {
+			return username, true
+		}
+	}
+
+	if username, ok := m.identities[email]; ok && email != "" {
+		return username, true
+	}
+
+	if username, ok := m.identities[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return username, true
+	}
+
+	return "", false
+}
+
+// TeamsForCodeOwner returns the IDs of the teams a CODEOWNERS owner stands for
+// at the given time: the team behind an @org/team-slug handle, or the teams a
+// member named by @username or email belonged to
+func (m *Manager) TeamsForCodeOwner(owner string, at time.Time) []int {
+	owner = strings.TrimSpace(owner)
+	if strings.HasPrefix(owner, "@") && strings.Contains(owner, "/") {
+		if teamID, ok := m.codeOwners[strings.ToLower(owner)]; ok {
+			return []int{teamID}
+		}
+		return nil
+	}
+
+	var username string
+	var ok bool
+	if strings.HasPrefix(owner, "@") {
+		username, ok = m.ResolveMember(strings.TrimPrefix(owner, "@"), "")
+	} else {
+		username, ok = m.ResolveMember("", owner)
+	}
+	if !ok {
+		return nil
+	}
+	return m.GetTeamsForUserAt(username, at)
+}
+
+// TeamsForIssueLabels returns the IDs of the teams that claim issues with any of the labels
+func (m *Manager) TeamsForIssueLabels(labels []string) []int {
+	seen := make(map[int]bool)
+	var teamIDs []int
+	for _, label := range labels {
+		for _, teamID := range m.issueLabels[strings.ToLower(strings.TrimSpace(label))] {
+			if !seen[teamID] {
+				seen[teamID] = true
+				teamIDs = append(teamIDs, teamID)
+			}
+		}
+	}
+	return teamIDs
 }
 
 // GetAllTeamIDs returns all team IDs
 func (m *Manager) GetAllTeamIDs() []int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	var ids []int
 	for id := range m.teams {
 		ids = append(ids, id)
@@ -123,13 +470,17 @@ func (m *Manager) GetAllTeamIDs() []int {
 	return ids
 }
 
-// IsExternalReviewer checks if a reviewer is external to a team
-func (m *Manager) IsExternalReviewer(reviewer string, teamID int) bool {
-	teams := m.GetTeamsForUser(reviewer)
-	for _, tid := range teams {
+// InTeamAt checks if a username belonged to a team at the given time
+func (m *Manager) InTeamAt(username string, teamID int, at time.Time) bool {
+	for _, tid := range m.GetTeamsForUserAt(username, at) {
 		if tid == teamID {
-			return false
+			return true
 		}
 	}
-	return true
+	return false
+}
+
+// IsExternalReviewer checks if a reviewer was outside a team at the given time
+func (m *Manager) IsExternalReviewer(reviewer string, teamID int, at time.Time) bool {
+	return !m.InTeamAt(reviewer, teamID, at)
 }
@@ -0,0 +1,308 @@
+package team
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+	"github.com/dothanhlam/go-github-tracker/internal/database"
+)
+
+// newTestDB creates a migrated SQLite database in a temp directory
+func newTestDB(t *testing.T) *database.DB {
+	t.Helper()
+
+	db, err := database.Connect("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// Migrations are read relative to the repository root
+	originalDir, _ := os.Getwd()
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatalf("failed to change to repository root: %v", err)
+	}
+	defer os.Chdir(originalDir)
+
+	if err := db.RunMigrations(); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+	return db
+}
+
+func date(value string) *config.Date {
+	t, _ := time.Parse("2006-01-02", value)
+	return &config.Date{Time: t}
+}
+
+// TestMembershipSyncLifecycle tests joining, leaving and explicit dates across syncs
+func TestMembershipSyncLifecycle(t *testing.T) {
+	db := newTestDB(t)
+	historic := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// First sync: members of the initial import count from the start of history
+	cfg := &config.Config{Teams: []config.TeamConfig{{
+		Name: "Platform",
+		Members: []config.TeamMemberConfig{
+			{Username: "alice", Allocation: 1.0},
+			{Username: "bob", Allocation: 0.5},
+		},
+	}}}
+	m, err := NewManager(db, cfg)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if teams := m.GetTeamsForUserAt("alice", historic); len(teams) != 1 {
+		t.Errorf("alice teams in 2020 = %v, want 1 team", teams)
+	}
+
+	// Second sync: bob leaves, carol joins, dave has explicit dates, and a new team starts
+	cfg.Teams[0].Members = []config.TeamMemberConfig{
+		{Username: "alice", Allocation: 0.8},
+		{Username: "carol", Allocation: 1.0},
+		{Username: "dave", Allocation: 1.0, JoinedAt: date("2021-01-01"), LeftAt: date("2022-01-01")},
+	}
+	cfg.Teams = append(cfg.Teams, config.TeamConfig{
+		Name:    "Data",
+		Members: []config.TeamMemberConfig{{Username: "erin", Allocation: 1.0}},
+	})
+	m, err = NewManager(db, cfg)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		at       time.Time
+		want     int
+	}{
+		{"alice stays on the team", "alice", time.Now(), 1},
+		{"bob has left", "bob", time.Now().Add(time.Minute), 0},
+		{"bob still counts historically", "bob", historic, 1},
+		{"carol is not credited for old work", "carol", historic, 0},
+		{"carol counts from now", "carol", time.Now().Add(time.Minute), 1},
+		{"a new team's member is not credited for old work", "erin", historic, 0},
+		{"a new team's member counts from now", "erin", time.Now().Add(time.Minute), 1},
+		{"dave inside explicit period", "dave", time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), 1},
+		{"dave after explicit period", "dave", time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.GetTeamsForUserAt(tt.username, tt.at); len(got) != tt.want {
+				t.Errorf("GetTeamsForUserAt(%s) = %v, want %d teams", tt.username, got, tt.want)
+			}
+		})
+	}
+
+	// Re-running the same config must not open new membership rows
+	if _, err := NewManager(db, cfg); err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	var openRows int
+	if err := db.Get(&openRows, "SELECT COUNT(*) FROM team_memberships WHERE github_username = 'alice'"); err != nil {
+		t.Fatalf("failed to count memberships: %v", err)
+	}
+	if openRows != 1 {
+		t.Errorf("alice has %d membership rows, want 1", openRows)
+	}
+
+	// A sync that fails partway changes nothing
+	failing := &config.Config{Teams: []config.TeamConfig{
+		{Name: "Platform", Members: []config.TeamMemberConfig{{Username: "frank", Allocation: 1.0}}},
+		{Name: "Ghost", Parent: "Missing"},
+	}}
+	if _, err := NewManager(db, failing); err == nil {
+		t.Fatal("NewManager() error = nil, want an error for the missing parent")
+	}
+	var written int
+	query := "SELECT COUNT(*) FROM team_memberships WHERE github_username = 'frank' OR (github_username = 'alice' AND left_at IS NOT NULL)"
+	if err := db.Get(&written, query); err != nil {
+		t.Fatalf("failed to count memberships: %v", err)
+	}
+	if written != 0 {
+		t.Errorf("a failed sync left %d membership changes, want 0", written)
+	}
+}
+
+// TestMembershipMigrationUpgrade tests that migration 011 dates the open
+// memberships of an upgraded database from the start of history, as the
+// initial import of a fresh one does
+func TestMembershipMigrationUpgrade(t *testing.T) {
+	db := newTestDB(t)
+	if _, err := db.Exec("INSERT INTO teams (id, name) VALUES (1, 'Platform')"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Before 011, every sync added an open row stamped with the run time
+	upgraded := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, synced := range []time.Time{upgraded, upgraded.Add(time.Hour)} {
+		query := "INSERT INTO team_memberships (team_id, github_username, allocation_weight, joined_at) VALUES (1, 'alice', 1, ?)"
+		if _, err := db.Exec(query, synced); err != nil {
+			t.Fatal(err)
+		}
+	}
+	migration, err := os.ReadFile("../../migrations/sqlite/011_time_bounded_memberships.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(string(migration)); err != nil {
+		t.Fatalf("failed to run migration 011: %v", err)
+	}
+
+	m, err := NewManager(db, &config.Config{Teams: []config.TeamConfig{{
+		Name:    "Platform",
+		Members: []config.TeamMemberConfig{{Username: "alice", Allocation: 1.0}},
+	}}})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	historic := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	if teams := m.GetTeamsForUserAt("alice", historic); len(teams) != 1 {
+		t.Errorf("alice teams in 2020 = %v, want 1 team", teams)
+	}
+	var rows int
+	if err := db.Get(&rows, "SELECT COUNT(*) FROM team_memberships WHERE github_username = 'alice'"); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 1 {
+		t.Errorf("alice has %d membership rows, want 1", rows)
+	}
+}
+
+// TestTeamHierarchySync tests linking teams to their configured parents
+func TestTeamHierarchySync(t *testing.T) {
+	db := newTestDB(t)
+
+	// Children are listed before their parent to check config order doesn't matter
+	cfg := &config.Config{Teams: []config.TeamConfig{
+		{Name: "Checkout", Parent: "Payments"},
+		{Name: "Payments"},
+	}}
+	if _, err := NewManager(db, cfg); err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	var parent string
+	query := "SELECT p.name FROM teams t JOIN teams p ON p.id = t.parent_id WHERE t.name = 'Checkout'"
+	if err := db.Get(&parent, query); err != nil {
+		t.Fatalf("failed to load parent: %v", err)
+	}
+	if parent != "Payments" {
+		t.Errorf("Checkout parent = %q, want Payments", parent)
+	}
+
+	// Dropping the parent from config detaches the team
+	cfg.Teams[0].Parent = ""
+	if _, err := NewManager(db, cfg); err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	var linked int
+	if err := db.Get(&linked, "SELECT COUNT(*) FROM teams WHERE parent_id IS NOT NULL"); err != nil {
+		t.Fatalf("failed to count linked teams: %v", err)
+	}
+	if linked != 0 {
+		t.Errorf("%d teams still have a parent, want 0", linked)
+	}
+}
+
+// TestTeamsForCodeOwner tests resolving CODEOWNERS owners to teams
+func TestTeamsForCodeOwner(t *testing.T) {
+	db := newTestDB(t)
+	cfg := &config.Config{Teams: []config.TeamConfig{
+		{
+			Name:       "Payments",
+			CodeOwners: []string{"@acme/payments"},
+			Members: []config.TeamMemberConfig{
+				{Username: "alice", Allocation: 1.0, Emails: []string{"alice@acme.com"}, JoinedAt: date("2024-01-01")},
+			},
+		},
+		{Name: "Platform", CodeOwners: []string{"@acme/platform"}},
+	}}
+	m, err := NewManager(db, cfg)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	var paymentsID int
+	if err := db.Get(&paymentsID, "SELECT id FROM teams WHERE name = 'Payments'"); err != nil {
+		t.Fatalf("failed to load Payments: %v", err)
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name  string
+		owner string
+		at    time.Time
+		want  []int
+	}{
+		{"team handle", "@acme/payments", now, []int{paymentsID}},
+		{"team handle is case insensitive", "@ACME/Payments", now, []int{paymentsID}},
+		{"unknown team handle", "@acme/search", now, nil},
+		{"member handle", "@alice", now, []int{paymentsID}},
+		{"member email", "alice@acme.com", now, []int{paymentsID}},
+		{"member before joining", "@alice", time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), nil},
+		{"unknown user", "@mallory", now, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.TeamsForCodeOwner(tt.owner, tt.at)
+			if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+				t.Errorf("TeamsForCodeOwner(%s) = %v, want %v", tt.owner, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTeamsForIssueLabels tests resolving issue labels to the teams that claim them
+func TestTeamsForIssueLabels(t *testing.T) {
+	db := newTestDB(t)
+	cfg := &config.Config{Teams: []config.TeamConfig{
+		{Name: "Payments", IssueLabels: []string{"area:payments", "billing"}},
+		{Name: "Platform", IssueLabels: []string{"area:platform", "billing"}},
+	}}
+	m, err := NewManager(db, cfg)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	ids := make(map[string]int)
+	for _, name := range []string{"Payments", "Platform"} {
+		var id int
+		if err := db.Get(&id, "SELECT id FROM teams WHERE name = ?", name); err != nil {
+			t.Fatalf("failed to load %s: %v", name, err)
+		}
+		ids[name] = id
+	}
+
+	tests := []struct {
+		name   string
+		labels []string
+		want   []int
+	}{
+		{"no labels", nil, nil},
+		{"unclaimed label", []string{"bug"}, nil},
+		{"one team", []string{"bug", "Area:Payments"}, []int{ids["Payments"]}},
+		{"shared label", []string{"billing"}, []int{ids["Payments"], ids["Platform"]}},
+		{"each team once", []string{"area:payments", "billing"}, []int{ids["Payments"], ids["Platform"]}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.TeamsForIssueLabels(tt.labels)
+			if len(got) != len(tt.want) {
+				t.Fatalf("TeamsForIssueLabels(%v) = %v, want %v", tt.labels, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("TeamsForIssueLabels(%v) = %v, want %v", tt.labels, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+// Package worktype classifies PRs into work types (bug, feature, ...) by their
+// labels, Conventional Commit titles, branch names and commit messages.
+package worktype
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+)
+
+// Other is the work type of PRs no mapping matches
+const Other = "other"
+
+// Where a PR's work type came from, in the order they are tried
+const (
+	SourceLabel  = "label"
+	SourceTitle  = "title"
+	SourceBranch = "branch"
+	SourceCommit = "commit"
+)
+
+// conventionalPattern matches a Conventional Commit subject: type(scope)!: description
+var conventionalPattern = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^()]*)\))?!?: \S`)
+
+// PR holds what a PR is classified by
+type PR struct {
+	Labels         []string
+	Title          string
+	HeadRef        string
+	CommitMessages []string // Only needed when nothing else classifies the PR
+}
+
+// Classification is a PR's work type and where it came from
+type Classification struct {
+	WorkType     string
+	Source       string // One of the Source constants, "" for Other
+	Scope        string // From the Conventional Commit title, or else its commits
+	Conventional bool   // Whether the title follows Conventional Commits
+}
+
+// Classifier maps labels, commit types and branch prefixes to work types
+type Classifier struct {
+	priority       map[string]int    // work type -> position in config
+	labels         map[string]string // lowercased label -> work type
+	commitTypes    map[string]string // lowercased commit type -> work type
+	branchPrefixes map[string]string // lowercased branch prefix -> work type
+	fingerprint    string
+}
+
+// New creates a classifier from the configured work types, in priority order
+func New(workTypes []config.WorkTypeConfig) *Classifier {
+	c := &Classifier{
+		priority:       make(map[string]int),
+		labels:         make(map[string]string),
+		commitTypes:    make(map[string]string),
+		branchPrefixes: make(map[string]string),
+	}
+	add := func(mapping map[string]string, values []string, workType string) {
+		for _, value := range values {
+			key := strings.ToLower(strings.TrimSpace(value))
+			if _, ok := mapping[key]; !ok {
+				mapping[key] = workType
+			}
+		}
+	}
+	for i, workType := range workTypes {
+		c.priority[workType.Name] = i
+		add(c.labels, workType.Labels, workType.Name)
+		add(c.commitTypes, workType.CommitTypes, workType.Name)
+		add(c.branchPrefixes, workType.BranchPrefixes, workType.Name)
+	}
+
+	hash := sha256.New()
+	for _, workType := range workTypes {
+		fmt.Fprintf(hash, "%q\n", workType.Name)
+	}
+	for _, mapping := range []map[string]string{c.labels, c.commitTypes, c.branchPrefixes} {
+		for _, key := range slices.Sorted(maps.Keys(mapping)) {
+			fmt.Fprintf(hash, "%q=%q\n", key, mapping[key])
+		}
+		fmt.Fprintln(hash)
+	}
+	c.fingerprint = hex.EncodeToString(hash.Sum(nil))
+	return c
+}
+
+// Fingerprint identifies the mapping: classifiers from mappings that classify
+// alike, down to case and whitespace, have the same fingerprint
+func (c *Classifier) Fingerprint() string {
+	return c.fingerprint
+}
+
+// Classify returns a PR's work type from, in order: its labels, its
+// Conventional Commit title, its head branch prefix and its commit messages.
+func (c *Classifier) Classify(pr PR) Classification {
+	commitType, scope, conventional := ParseConventional(pr.Title)
+	result := Classification{WorkType: Other, Scope: scope, Conventional: conventional}
+
+	if workType := c.classifyLabels(pr.Labels); workType != Other {
+		result.WorkType, result.Source = workType, SourceLabel
+	} else if workType, ok := c.commitTypes[commitType]; ok {
+		result.WorkType, result.Source = workType, SourceTitle
+	} else if workType := c.classifyBranch(pr.HeadRef); workType != Other {
+		result.WorkType, result.Source = workType, SourceBranch
+	} else if workType, commitScope := c.classifyCommits(pr.CommitMessages); workType != Other {
+		result.WorkType, result.Source = workType, SourceCommit
+		if result.Scope == "" {
+			result.Scope = commitScope
+		}
+	}
+	return result
+}
+
+// classifyLabels returns the highest priority work type of the labels
+func (c *Classifier) classifyLabels(labels []string) string {
+	result := Other
+	for _, label := range labels {
+		if workType, ok := c.labels[strings.ToLower(strings.TrimSpace(label))]; ok {
+			result = c.first(result, workType)
+		}
+	}
+	return result
+}
+
+// classifyBranch matches the leading segments of a branch name ("alice/fix/login" tries "alice", then "fix")
+func (c *Classifier) classifyBranch(branch string) string {
+	segments := strings.Split(strings.ToLower(branch), "/")
+	for _, segment := range segments[:len(segments)-1] {
+		if workType, ok := c.branchPrefixes[segment]; ok {
+			return workType
+		}
+	}
+	return Other
+}
+
+// classifyCommits returns the most common work type among Conventional Commit
+// messages (the highest priority one on a tie) and the first scope given
+func (c *Classifier) classifyCommits(messages []string) (string, string) {
+	counts := make(map[string]int)
+	result, scope := Other, ""
+	for _, message := range messages {
+		subject, _, _ := strings.Cut(message, "\n")
+		commitType, commitScope, ok := ParseConventional(subject)
+		workType, mapped := c.commitTypes[commitType]
+		if !ok || !mapped {
+			continue
+		}
+		counts[workType]++
+		if scope == "" {
+			scope = commitScope
+		}
+		if result == Other || counts[workType] > counts[result] ||
+			(counts[workType] == counts[result] && c.priority[workType] < c.priority[result]) {
+			result = workType
+		}
+	}
+	return result, scope
+}
+
+// first returns whichever work type is configured first, treating Other as last
+func (c *Classifier) first(a, b string) string {
+	if a == Other || (b != Other && c.priority[b] < c.priority[a]) {
+		return b
+	}
+	return a
+}
+
+// ParseConventional parses a Conventional Commit subject such as
+// "feat(api)!: add pagination" into its lowercased type and scope
+func ParseConventional(subject string) (commitType, scope string, ok bool) {
+	match := conventionalPattern.FindStringSubmatch(strings.TrimSpace(subject))
+	if match == nil {
+		return "", "", false
+	}
+	return strings.ToLower(match[1]), strings.TrimSpace(match[2]), true
+}
+
+// IsConventional returns whether a commit message's subject line follows Conventional Commits
+func IsConventional(message string) bool {
+	subject, _, _ := strings.Cut(message, "\n")
+	_, _, ok := ParseConventional(subject)
+	return ok
+}
@@ -0,0 +1,115 @@
+package worktype
+
+import (
+	"testing"
+
+	"github.com/dothanhlam/go-github-tracker/internal/config"
+)
+
+// TestClassify tests classifying PRs by labels, title, branch and commits, in that order
+func TestClassify(t *testing.T) {
+	c := New([]config.WorkTypeConfig{
+		{Name: "bug", Labels: []string{"type:bug", "bug"}, CommitTypes: []string{"fix"}, BranchPrefixes: []string{"fix", "hotfix"}},
+		{Name: "feature", Labels: []string{"type:feature", "enhancement"}, CommitTypes: []string{"feat"}, BranchPrefixes: []string{"feature"}},
+		{Name: "maintenance", CommitTypes: []string{"chore", "docs"}, BranchPrefixes: []string{"chore"}},
+	})
+
+	tests := []struct {
+		name string
+		pr   PR
+		want Classification
+	}{
+		{"nothing to go by", PR{Title: "Update things", HeadRef: "patch-1"}, Classification{WorkType: Other}},
+		{"unmapped labels", PR{Labels: []string{"area:payments"}}, Classification{WorkType: Other}},
+		{"mapped label", PR{Labels: []string{"area:payments", "type:feature"}}, Classification{WorkType: "feature", Source: SourceLabel}},
+		{"case-insensitive label", PR{Labels: []string{"Type:Bug"}}, Classification{WorkType: "bug", Source: SourceLabel}},
+		{"first configured label wins", PR{Labels: []string{"enhancement", "bug"}}, Classification{WorkType: "bug", Source: SourceLabel}},
+		{"label over title", PR{Labels: []string{"bug"}, Title: "feat(api): add pagination"},
+			Classification{WorkType: "bug", Source: SourceLabel, Scope: "api", Conventional: true}},
+		{"conventional title", PR{Title: "feat(api)!: drop v1 endpoints", HeadRef: "fix/v1"},
+			Classification{WorkType: "feature", Source: SourceTitle, Scope: "api", Conventional: true}},
+		{"unmapped conventional type", PR{Title: "wip: try things", HeadRef: "chore/cleanup"},
+			Classification{WorkType: "maintenance", Source: SourceBranch, Conventional: true}},
+		{"branch prefix", PR{Title: "Fix login", HeadRef: "hotfix/login"}, Classification{WorkType: "bug", Source: SourceBranch}},
+		{"nested branch prefix", PR{HeadRef: "alice/feature/search"}, Classification{WorkType: "feature", Source: SourceBranch}},
+		{"prefix is not the whole branch", PR{HeadRef: "fix"}, Classification{WorkType: Other}},
+		{"commit messages", PR{Title: "Sprint 12", HeadRef: "sprint-12", CommitMessages: []string{
+			"docs: readme\n\nMore details",
+			"fix(auth): token refresh",
+			"Merge branch 'main'",
+			"fix: typo",
+		}}, Classification{WorkType: "bug", Source: SourceCommit, Scope: "auth"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Classify(tt.pr); got != tt.want {
+				t.Errorf("Classify() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFingerprint tests that only mapping changes change the fingerprint
+func TestFingerprint(t *testing.T) {
+	workTypes := []config.WorkTypeConfig{
+		{Name: "bug", Labels: []string{"bug"}, CommitTypes: []string{"fix"}},
+		{Name: "feature", Labels: []string{"enhancement"}, BranchPrefixes: []string{"feature"}},
+	}
+	fingerprint := New(workTypes).Fingerprint()
+
+	tests := []struct {
+		name      string
+		workTypes []config.WorkTypeConfig
+		same      bool
+	}{
+		{"same mapping", []config.WorkTypeConfig{
+			{Name: "bug", Labels: []string{" Bug "}, CommitTypes: []string{"FIX"}},
+			{Name: "feature", Labels: []string{"enhancement"}, BranchPrefixes: []string{"feature"}},
+		}, true},
+		{"label added", []config.WorkTypeConfig{
+			{Name: "bug", Labels: []string{"bug", "type:bug"}, CommitTypes: []string{"fix"}},
+			{Name: "feature", Labels: []string{"enhancement"}, BranchPrefixes: []string{"feature"}},
+		}, false},
+		{"priority swapped", []config.WorkTypeConfig{workTypes[1], workTypes[0]}, false},
+		{"label moved to a commit type", []config.WorkTypeConfig{
+			{Name: "bug", CommitTypes: []string{"fix", "bug"}},
+			{Name: "feature", Labels: []string{"enhancement"}, BranchPrefixes: []string{"feature"}},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.workTypes).Fingerprint() == fingerprint; got != tt.same {
+				t.Errorf("same fingerprint = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
+
+// TestParseConventional tests parsing Conventional Commit subjects
+func TestParseConventional(t *testing.T) {
+	tests := []struct {
+		subject   string
+		wantType  string
+		wantScope string
+		wantOK    bool
+	}{
+		{"feat: add search", "feat", "", true},
+		{"Fix(Auth): refresh tokens", "fix", "Auth", true},
+		{"refactor(api)!: rename fields", "refactor", "api", true},
+		{"feat:missing space", "", "", false},
+		{"Add search", "", "", false},
+		{"Merge pull request #12 from acme/fix", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			commitType, scope, ok := ParseConventional(tt.subject)
+			if commitType != tt.wantType || scope != tt.wantScope || ok != tt.wantOK {
+				t.Errorf("ParseConventional(%q) = %q, %q, %v, want %q, %q, %v",
+					tt.subject, commitType, scope, ok, tt.wantType, tt.wantScope, tt.wantOK)
+			}
+		})
+	}
+}
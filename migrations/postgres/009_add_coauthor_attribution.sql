@@ -0,0 +1,29 @@
+-- Co-author credit for commits, parsed from Co-authored-by trailers
+CREATE TABLE IF NOT EXISTS commit_coauthors (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    repository VARCHAR(255) NOT NULL,
+    commit_hash VARCHAR(64) NOT NULL,
+    github_username VARCHAR(255) NOT NULL,
+    weight DECIMAL(3,2) NOT NULL DEFAULT 1.00,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_date DATE NOT NULL,
+    UNIQUE(team_id, repository, commit_hash, github_username)
+);
+
+CREATE INDEX IF NOT EXISTS idx_commit_coauthors_team_id ON commit_coauthors(team_id);
+CREATE INDEX IF NOT EXISTS idx_commit_coauthors_username ON commit_coauthors(github_username);
+
+-- Co-author credit for pull requests, derived from the PR's commits
+CREATE TABLE IF NOT EXISTS pr_coauthors (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    repository VARCHAR(255) NOT NULL,
+    pr_number INTEGER NOT NULL,
+    github_username VARCHAR(255) NOT NULL,
+    weight DECIMAL(3,2) NOT NULL DEFAULT 1.00,
+    UNIQUE(team_id, repository, pr_number, github_username)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pr_coauthors_team_id ON pr_coauthors(team_id);
+CREATE INDEX IF NOT EXISTS idx_pr_coauthors_username ON pr_coauthors(github_username);
@@ -0,0 +1,19 @@
+-- Current allocation per team member (memberships that haven't ended yet)
+-- A person may have several current rows in a team, so the highest allocation wins
+CREATE OR REPLACE VIEW view_member_allocation AS
+SELECT 
+    team_id,
+    github_username,
+    MAX(allocation_weight) as allocation_weight
+FROM team_memberships
+WHERE left_at IS NULL OR left_at > NOW()
+GROUP BY team_id, github_username;
+
+-- Full-time equivalents per team
+CREATE OR REPLACE VIEW view_team_fte AS
+SELECT 
+    team_id,
+    SUM(allocation_weight) as fte,
+    COUNT(*) as member_count
+FROM view_member_allocation
+GROUP BY team_id;
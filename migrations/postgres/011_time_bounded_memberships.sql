@@ -0,0 +1,16 @@
+-- Membership sync used to insert a new open row on every run, stamped with the
+-- run time. Keep one open row per person and team, and date it from the start
+-- of history, as the initial import of a fresh database does: the real join
+-- date was never recorded.
+DELETE FROM team_memberships
+WHERE left_at IS NULL
+    AND id NOT IN (
+        SELECT MIN(id) FROM team_memberships
+        WHERE left_at IS NULL
+        GROUP BY team_id, github_username
+    );
+
+UPDATE team_memberships SET joined_at = '1970-01-01 00:00:00' WHERE left_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_team_memberships_period
+    ON team_memberships(team_id, github_username, joined_at, left_at);
@@ -0,0 +1,5 @@
+-- Teams can belong to a parent team (group, tribe, department, ...).
+-- Metrics for a team roll up its whole subtree.
+ALTER TABLE teams ADD COLUMN parent_id INTEGER REFERENCES teams(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_teams_parent_id ON teams(parent_id);
@@ -0,0 +1,17 @@
+-- Where a team is managed: 'config' (team config / GitHub sync) or 'api' (admin API).
+-- Config sync never closes memberships of API-managed teams.
+ALTER TABLE teams ADD COLUMN source VARCHAR(16) NOT NULL DEFAULT 'config' CHECK(source IN ('config', 'api'));
+
+-- Audit trail of changes made through the admin API
+CREATE TABLE IF NOT EXISTS team_audit_log (
+    id SERIAL PRIMARY KEY,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(64) NOT NULL,
+    team_id INTEGER,
+    github_username VARCHAR(255),
+    details TEXT, -- JSON object describing the change
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_audit_log_team_id ON team_audit_log(team_id);
+CREATE INDEX IF NOT EXISTS idx_team_audit_log_created_at ON team_audit_log(created_at);
@@ -0,0 +1,21 @@
+-- How a team is credited with a PR: its members authored it ('author'), reviewed
+-- it ('reviewer'), both, or neither but the team owns changed files ('ownership').
+ALTER TABLE pr_metrics ADD COLUMN attribution_role VARCHAR(16) NOT NULL DEFAULT 'author' CHECK(attribution_role IN ('author', 'reviewer', 'both', 'ownership'));
+
+-- Set when CODEOWNERS makes the team an owner of any file the PR changed
+ALTER TABLE pr_metrics ADD COLUMN owned BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Existing rows whose author (or co-author) was never on the team came from reviews
+UPDATE pr_metrics SET attribution_role = 'reviewer'
+WHERE NOT EXISTS (
+    SELECT 1 FROM team_memberships tm
+    WHERE tm.team_id = pr_metrics.team_id AND tm.github_username = pr_metrics.author
+)
+AND NOT EXISTS (
+    SELECT 1 FROM pr_coauthors pc
+    WHERE pc.team_id = pr_metrics.team_id
+      AND pc.repository = pr_metrics.repository
+      AND pc.pr_number = pr_metrics.pr_number
+);
+
+CREATE INDEX IF NOT EXISTS idx_pr_metrics_attribution_role ON pr_metrics(attribution_role);
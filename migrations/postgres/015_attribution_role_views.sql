@@ -0,0 +1,78 @@
+-- PRs reviewed by a team are stored with attribution_role 'reviewer'. Delivery
+-- views (velocity, lead time) only count PRs the team authored, review views
+-- count PRs it authored or reviewed; ownership-only rows are left out of both.
+
+CREATE OR REPLACE VIEW view_team_velocity AS
+SELECT 
+    team_id,
+    DATE_TRUNC('week', merged_at) as week,
+    COUNT(*) as prs_merged,
+    AVG(cycle_time_hours) as avg_cycle_time_hours
+FROM pr_metrics
+WHERE merged_at IS NOT NULL
+  AND attribution_role IN ('author', 'both')
+GROUP BY team_id, week
+ORDER BY team_id, week DESC;
+
+CREATE OR REPLACE VIEW view_dora_lead_time AS
+SELECT 
+    team_id,
+    DATE_TRUNC('month', merged_at) as month,
+    PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY cycle_time_hours) as median_lead_time_hours,
+    PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY cycle_time_hours) as p95_lead_time_hours,
+    COUNT(*) as pr_count
+FROM pr_metrics
+WHERE merged_at IS NOT NULL
+  AND attribution_role IN ('author', 'both')
+GROUP BY team_id, month
+ORDER BY team_id, month DESC;
+
+-- Review views: PRs the team authored or reviewed
+CREATE OR REPLACE VIEW view_review_turnaround AS
+SELECT 
+    team_id,
+    DATE_TRUNC('month', created_at) as month,
+    AVG(review_turnaround_hours) as avg_turnaround_hours,
+    MIN(review_turnaround_hours) as min_turnaround_hours,
+    MAX(review_turnaround_hours) as max_turnaround_hours,
+    PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY review_turnaround_hours) as median_turnaround_hours,
+    PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY review_turnaround_hours) as p95_turnaround_hours,
+    COUNT(*) as pr_count,
+    COUNT(*) FILTER (WHERE review_turnaround_hours <= 24) as within_24h_count,
+    COUNT(*) FILTER (WHERE review_turnaround_hours > 24) as over_24h_count
+FROM pr_metrics
+WHERE first_review_at IS NOT NULL
+  AND attribution_role IN ('author', 'reviewer', 'both')
+GROUP BY team_id, month
+ORDER BY team_id, month DESC;
+
+CREATE OR REPLACE VIEW view_review_engagement AS
+SELECT 
+    team_id,
+    DATE_TRUNC('month', created_at) as month,
+    AVG(review_comments_count) as avg_comments_per_pr,
+    AVG(conversation_count) as avg_conversations_per_pr,
+    AVG(reviewers_count) as avg_reviewers_per_pr,
+    SUM(changes_requested_count)::DECIMAL * 100.0 / NULLIF(SUM(changes_requested_count + approved_count), 0) as changes_requested_rate,
+    SUM(approved_count)::DECIMAL * 100.0 / NULLIF(SUM(changes_requested_count + approved_count), 0) as approval_rate,
+    COUNT(*) as pr_count
+FROM pr_metrics
+WHERE attribution_role IN ('author', 'reviewer', 'both')
+GROUP BY team_id, month
+ORDER BY team_id, month DESC;
+
+CREATE OR REPLACE VIEW view_knowledge_sharing AS
+SELECT 
+    team_id,
+    DATE_TRUNC('month', created_at) as month,
+    AVG(reviewers_count) as avg_reviewers,
+    AVG(external_reviewers_count) as avg_external_reviewers,
+    AVG(external_reviewers_count::DECIMAL * 100.0 / NULLIF(reviewers_count, 0)) as external_reviewer_rate,
+    SUM(external_reviewers_count) as total_external_reviews,
+    COUNT(*) FILTER (WHERE external_reviewers_count > 0) as prs_with_external_reviews,
+    COUNT(*) as pr_count
+FROM pr_metrics
+WHERE reviewers_count > 0
+  AND attribution_role IN ('author', 'reviewer', 'both')
+GROUP BY team_id, month
+ORDER BY team_id, month DESC;
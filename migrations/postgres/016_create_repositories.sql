@@ -0,0 +1,35 @@
+-- Tracked repositories and their settings, managed through the team config ('config') or the admin API ('api')
+CREATE TABLE IF NOT EXISTS repositories (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE, -- owner/repo
+    default_branch VARCHAR(255),
+    team_id INTEGER REFERENCES teams(id) ON DELETE SET NULL, -- owning team
+    service_group VARCHAR(255),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    lookback_days INTEGER, -- overrides COLLECTION_LOOKBACK_DAYS
+    deployment_source VARCHAR(16) CHECK(deployment_source IN ('releases', 'deployments', 'tags')),
+    deployment_environment VARCHAR(255),
+    source VARCHAR(16) NOT NULL DEFAULT 'config' CHECK(source IN ('config', 'api')),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_repositories_team_id ON repositories(team_id);
+
+CREATE TABLE IF NOT EXISTS repository_tags (
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    tag VARCHAR(255) NOT NULL,
+    PRIMARY KEY (repository_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_repository_tags_tag ON repository_tags(tag);
+
+-- Repositories collected so far become config-managed entries; the next sync fills in their settings
+INSERT INTO repositories (name)
+SELECT repository FROM collection_metadata
+UNION
+SELECT DISTINCT repository FROM pr_metrics
+ON CONFLICT (name) DO NOTHING;
+
+-- Admin changes to repositories are audited alongside team changes
+ALTER TABLE team_audit_log ADD COLUMN repository VARCHAR(255);
@@ -0,0 +1,11 @@
+-- Where a PR's work type came from ("label", "title", "branch" or "commit"), the
+-- Conventional Commit scope, and whether its title follows Conventional Commits
+ALTER TABLE pr_metrics ADD COLUMN work_type_source VARCHAR(16);
+ALTER TABLE pr_metrics ADD COLUMN work_scope VARCHAR(255);
+ALTER TABLE pr_metrics ADD COLUMN conventional BOOLEAN;
+
+-- Work types so far came from labels only
+UPDATE pr_metrics SET work_type_source = 'label' WHERE work_type <> 'other';
+
+-- Whether the commit message follows Conventional Commits (NULL until checked)
+ALTER TABLE commit_metrics ADD COLUMN conventional BOOLEAN;
@@ -0,0 +1,29 @@
+-- Issues of tracked repositories, one row per team they are attributed to
+-- (through their assignees or the team's issue_labels)
+CREATE TABLE IF NOT EXISTS issue_metrics (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    repository VARCHAR(255) NOT NULL,
+    issue_number INTEGER NOT NULL,
+    title TEXT,
+    author VARCHAR(255),
+    state VARCHAR(20) NOT NULL, -- 'open' or 'closed'
+    created_at TIMESTAMP NOT NULL,
+    closed_at TIMESTAMP,
+    time_to_close_hours INTEGER,
+    milestone VARCHAR(255),
+    assignees_list TEXT, -- JSON array
+    work_type VARCHAR(64) NOT NULL DEFAULT 'other',
+    attribution_role VARCHAR(20) NOT NULL CHECK(attribution_role IN ('assignee', 'label', 'both')),
+    UNIQUE(team_id, repository, issue_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_issue_metrics_team_id ON issue_metrics(team_id);
+CREATE INDEX IF NOT EXISTS idx_issue_metrics_created_at ON issue_metrics(created_at);
+CREATE INDEX IF NOT EXISTS idx_issue_metrics_closed_at ON issue_metrics(closed_at);
+CREATE INDEX IF NOT EXISTS idx_issue_metrics_work_type ON issue_metrics(work_type);
+
+-- The issue or PR a conversation comment belongs to. Comments on PRs are now
+-- stored as 'pull_request' rather than 'issue'; ones collected earlier are
+-- retyped when they are collected again.
+ALTER TABLE comment_metrics ADD COLUMN issue_number INTEGER;
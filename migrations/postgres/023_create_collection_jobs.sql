@@ -0,0 +1,24 @@
+-- Collection jobs: a planner enqueues one per repository (or per repository and
+-- backfill window) and collector workers lease them. A job is 'queued' until
+-- leased, 'running' while its lease lasts, and 'succeeded' or 'dead' (out of
+-- attempts) when done. Running jobs whose lease expired can be leased again.
+CREATE TABLE IF NOT EXISTS collection_jobs (
+    id SERIAL PRIMARY KEY,
+    job_key VARCHAR(255) NOT NULL, -- repository and window; one pending job per key
+    repository VARCHAR(255) NOT NULL,
+    window_start TIMESTAMP, -- NULL for incremental collection
+    window_end TIMESTAMP,
+    status VARCHAR(16) NOT NULL DEFAULT 'queued' CHECK(status IN ('queued', 'running', 'succeeded', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    available_at TIMESTAMP NOT NULL, -- not leased before (retry backoff)
+    leased_by VARCHAR(255),
+    lease_expires_at TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_collection_jobs_pending_key ON collection_jobs(job_key) WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS idx_collection_jobs_status ON collection_jobs(status, available_at);
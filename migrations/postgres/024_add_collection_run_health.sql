@@ -0,0 +1,7 @@
+-- Run health per repository: how its last collection run went. A repository
+-- whose first run failed has no last collection time yet.
+ALTER TABLE collection_metadata ALTER COLUMN last_collected_at DROP NOT NULL;
+ALTER TABLE collection_metadata ADD COLUMN last_run_started_at TIMESTAMP;
+ALTER TABLE collection_metadata ADD COLUMN last_run_finished_at TIMESTAMP;
+ALTER TABLE collection_metadata ADD COLUMN last_run_status VARCHAR(16); -- collected, skipped, failed or interrupted
+ALTER TABLE collection_metadata ADD COLUMN last_run_error TEXT;
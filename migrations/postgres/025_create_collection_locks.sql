@@ -0,0 +1,12 @@
+-- Named locks held by collector runs, such as 'repository:acme/api', so two
+-- runs never collect the same repository at once. The lock itself is a session
+-- advisory lock (pg_try_advisory_lock), released when its holder's connection
+-- ends; this table records who holds it and until when it was last refreshed.
+-- On SQLite the row is the lock. token identifies one acquisition.
+CREATE TABLE IF NOT EXISTS collection_locks (
+    name VARCHAR(512) PRIMARY KEY,
+    owner VARCHAR(255) NOT NULL, -- host-pid of the holding process
+    token VARCHAR(64) NOT NULL,
+    acquired_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
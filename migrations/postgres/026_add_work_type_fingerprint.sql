@@ -0,0 +1,3 @@
+-- The work type mapping a repository's stored PRs were last classified with,
+-- so they are only re-classified when the mapping changes
+ALTER TABLE collection_metadata ADD COLUMN work_types_fingerprint VARCHAR(64);
@@ -0,0 +1,3 @@
+-- The commit HEAD pointed to when a repository collected from a git clone was
+-- last collected; the next run reads the commits since it rather than by date
+ALTER TABLE collection_metadata ADD COLUMN last_git_head VARCHAR(64);
@@ -0,0 +1,16 @@
+-- Deployments of repositories whose deployment_source is 'deployments', read
+-- from their code host's deployments API (GitHub deployments, GitLab
+-- environments), for deployment frequency
+CREATE TABLE IF NOT EXISTS deployments (
+    id SERIAL PRIMARY KEY,
+    repository VARCHAR(255) NOT NULL,
+    deployment_id BIGINT NOT NULL, -- The code host's ID
+    environment VARCHAR(255) NOT NULL,
+    ref VARCHAR(255),
+    sha VARCHAR(64),
+    creator VARCHAR(255),
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE(repository, deployment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_deployments_repository_created_at ON deployments(repository, created_at);
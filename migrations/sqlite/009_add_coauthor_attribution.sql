@@ -0,0 +1,31 @@
+-- Co-author credit for commits, parsed from Co-authored-by trailers
+CREATE TABLE IF NOT EXISTS commit_coauthors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    team_id INTEGER NOT NULL,
+    repository TEXT NOT NULL,
+    commit_hash TEXT NOT NULL,
+    github_username TEXT NOT NULL,
+    weight REAL NOT NULL DEFAULT 1.0,
+    created_at DATETIME NOT NULL,
+    created_date DATE,
+    FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE,
+    UNIQUE(team_id, repository, commit_hash, github_username)
+);
+
+CREATE INDEX IF NOT EXISTS idx_commit_coauthors_team_id ON commit_coauthors(team_id);
+CREATE INDEX IF NOT EXISTS idx_commit_coauthors_username ON commit_coauthors(github_username);
+
+-- Co-author credit for pull requests, derived from the PR's commits
+CREATE TABLE IF NOT EXISTS pr_coauthors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    team_id INTEGER NOT NULL,
+    repository TEXT NOT NULL,
+    pr_number INTEGER NOT NULL,
+    github_username TEXT NOT NULL,
+    weight REAL NOT NULL DEFAULT 1.0,
+    FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE,
+    UNIQUE(team_id, repository, pr_number, github_username)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pr_coauthors_team_id ON pr_coauthors(team_id);
+CREATE INDEX IF NOT EXISTS idx_pr_coauthors_username ON pr_coauthors(github_username);
@@ -0,0 +1,82 @@
+-- PRs reviewed by a team are stored with attribution_role 'reviewer'. Delivery
+-- views (velocity, lead time) only count PRs the team authored, review views
+-- count PRs it authored or reviewed; ownership-only rows are left out of both.
+
+DROP VIEW IF EXISTS view_team_velocity;
+CREATE VIEW view_team_velocity AS
+SELECT 
+    team_id,
+    DATE(merged_at, 'weekday 0', '-6 days') as week,
+    COUNT(*) as prs_merged,
+    AVG(cycle_time_hours) as avg_cycle_time_hours
+FROM pr_metrics
+WHERE merged_at IS NOT NULL
+  AND attribution_role IN ('author', 'both')
+GROUP BY team_id, week
+ORDER BY team_id, week DESC;
+
+DROP VIEW IF EXISTS view_dora_lead_time;
+CREATE VIEW view_dora_lead_time AS
+SELECT 
+    team_id,
+    strftime('%Y-%m', merged_at) as month,
+    AVG(cycle_time_hours) as avg_lead_time_hours,
+    MIN(cycle_time_hours) as min_lead_time_hours,
+    MAX(cycle_time_hours) as max_lead_time_hours,
+    COUNT(*) as pr_count
+FROM pr_metrics
+WHERE merged_at IS NOT NULL
+  AND attribution_role IN ('author', 'both')
+GROUP BY team_id, month
+ORDER BY team_id, month DESC;
+
+-- Review views: PRs the team authored or reviewed
+DROP VIEW IF EXISTS view_review_turnaround;
+CREATE VIEW view_review_turnaround AS
+SELECT 
+    team_id,
+    strftime('%Y-%m', created_at) as month,
+    AVG(review_turnaround_hours) as avg_turnaround_hours,
+    MIN(review_turnaround_hours) as min_turnaround_hours,
+    MAX(review_turnaround_hours) as max_turnaround_hours,
+    COUNT(*) as pr_count,
+    COUNT(CASE WHEN review_turnaround_hours <= 24 THEN 1 END) as within_24h_count,
+    COUNT(CASE WHEN review_turnaround_hours > 24 THEN 1 END) as over_24h_count
+FROM pr_metrics
+WHERE first_review_at IS NOT NULL
+  AND attribution_role IN ('author', 'reviewer', 'both')
+GROUP BY team_id, month
+ORDER BY team_id, month DESC;
+
+DROP VIEW IF EXISTS view_review_engagement;
+CREATE VIEW view_review_engagement AS
+SELECT 
+    team_id,
+    strftime('%Y-%m', created_at) as month,
+    AVG(review_comments_count) as avg_comments_per_pr,
+    AVG(conversation_count) as avg_conversations_per_pr,
+    AVG(reviewers_count) as avg_reviewers_per_pr,
+    SUM(changes_requested_count) * 100.0 / NULLIF(SUM(changes_requested_count + approved_count), 0) as changes_requested_rate,
+    SUM(approved_count) * 100.0 / NULLIF(SUM(changes_requested_count + approved_count), 0) as approval_rate,
+    COUNT(*) as pr_count
+FROM pr_metrics
+WHERE attribution_role IN ('author', 'reviewer', 'both')
+GROUP BY team_id, month
+ORDER BY team_id, month DESC;
+
+DROP VIEW IF EXISTS view_knowledge_sharing;
+CREATE VIEW view_knowledge_sharing AS
+SELECT 
+    team_id,
+    strftime('%Y-%m', created_at) as month,
+    AVG(reviewers_count) as avg_reviewers,
+    AVG(external_reviewers_count) as avg_external_reviewers,
+    AVG(external_reviewers_count * 100.0 / NULLIF(reviewers_count, 0)) as external_reviewer_rate,
+    SUM(external_reviewers_count) as total_external_reviews,
+    COUNT(CASE WHEN external_reviewers_count > 0 THEN 1 END) as prs_with_external_reviews,
+    COUNT(*) as pr_count
+FROM pr_metrics
+WHERE reviewers_count > 0
+  AND attribution_role IN ('author', 'reviewer', 'both')
+GROUP BY team_id, month
+ORDER BY team_id, month DESC;
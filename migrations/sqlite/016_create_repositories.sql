@@ -0,0 +1,34 @@
+-- Tracked repositories and their settings, managed through the team config ('config') or the admin API ('api')
+CREATE TABLE IF NOT EXISTS repositories (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE, -- owner/repo
+    default_branch TEXT,
+    team_id INTEGER REFERENCES teams(id) ON DELETE SET NULL, -- owning team
+    service_group TEXT,
+    active INTEGER NOT NULL DEFAULT 1,
+    lookback_days INTEGER, -- overrides COLLECTION_LOOKBACK_DAYS
+    deployment_source TEXT CHECK(deployment_source IN ('releases', 'deployments', 'tags')),
+    deployment_environment TEXT,
+    source TEXT NOT NULL DEFAULT 'config' CHECK(source IN ('config', 'api')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_repositories_team_id ON repositories(team_id);
+
+CREATE TABLE IF NOT EXISTS repository_tags (
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (repository_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_repository_tags_tag ON repository_tags(tag);
+
+-- Repositories collected so far become config-managed entries; the next sync fills in their settings
+INSERT INTO repositories (name)
+SELECT repository FROM collection_metadata
+UNION
+SELECT DISTINCT repository FROM pr_metrics;
+
+-- Admin changes to repositories are audited alongside team changes
+ALTER TABLE team_audit_log ADD COLUMN repository TEXT;
@@ -0,0 +1,50 @@
+-- The branch a PR merges into (base) and the branch it was opened from (head)
+ALTER TABLE pr_metrics ADD COLUMN base_ref TEXT;
+ALTER TABLE pr_metrics ADD COLUMN head_ref TEXT;
+
+-- Cleared when the base doesn't match the repository's base branch rules, such as
+-- PRs into feature branches or further down a stack, whose work is counted again
+-- when the branch they merged into is merged itself
+ALTER TABLE pr_metrics ADD COLUMN base_matched INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_pr_metrics_base_ref ON pr_metrics(repository, base_ref);
+
+-- Base branch rules: glob patterns, or 'default' for the repository's default branch.
+-- PRs into any base count for repositories without rules.
+CREATE TABLE IF NOT EXISTS repository_base_branches (
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    pattern TEXT NOT NULL,
+    PRIMARY KEY (repository_id, pattern)
+);
+
+-- Delivery views only count PRs merged into a matching base; review views still
+-- include every PR, since reviewing a stacked PR is work all the same
+DROP VIEW IF EXISTS view_team_velocity;
+CREATE VIEW view_team_velocity AS
+SELECT 
+    team_id,
+    DATE(merged_at, 'weekday 0', '-6 days') as week,
+    COUNT(*) as prs_merged,
+    AVG(cycle_time_hours) as avg_cycle_time_hours
+FROM pr_metrics
+WHERE merged_at IS NOT NULL
+  AND attribution_role IN ('author', 'both')
+  AND base_matched = TRUE
+GROUP BY team_id, week
+ORDER BY team_id, week DESC;
+
+DROP VIEW IF EXISTS view_dora_lead_time;
+CREATE VIEW view_dora_lead_time AS
+SELECT 
+    team_id,
+    strftime('%Y-%m', merged_at) as month,
+    AVG(cycle_time_hours) as avg_lead_time_hours,
+    MIN(cycle_time_hours) as min_lead_time_hours,
+    MAX(cycle_time_hours) as max_lead_time_hours,
+    COUNT(*) as pr_count
+FROM pr_metrics
+WHERE merged_at IS NOT NULL
+  AND attribution_role IN ('author', 'both')
+  AND base_matched = TRUE
+GROUP BY team_id, month
+ORDER BY team_id, month DESC;
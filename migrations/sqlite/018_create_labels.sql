@@ -0,0 +1,23 @@
+-- Labels on collected PRs and on the repository's issues
+CREATE TABLE IF NOT EXISTS pr_labels (
+    repository TEXT NOT NULL,
+    pr_number INTEGER NOT NULL,
+    label TEXT NOT NULL,
+    PRIMARY KEY (repository, pr_number, label)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pr_labels_label ON pr_labels(label);
+
+CREATE TABLE IF NOT EXISTS issue_labels (
+    repository TEXT NOT NULL,
+    issue_number INTEGER NOT NULL,
+    label TEXT NOT NULL,
+    PRIMARY KEY (repository, issue_number, label)
+);
+
+CREATE INDEX IF NOT EXISTS idx_issue_labels_label ON issue_labels(label);
+
+-- Work type (bug, feature, ...) from the PR's labels and the configured label mapping
+ALTER TABLE pr_metrics ADD COLUMN work_type TEXT NOT NULL DEFAULT 'other';
+
+CREATE INDEX IF NOT EXISTS idx_pr_metrics_work_type ON pr_metrics(work_type);
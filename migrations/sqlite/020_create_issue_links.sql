@@ -0,0 +1,35 @@
+-- Issues PRs reference: tracker keys (PROJ-123) in their titles, branch names
+-- and bodies, and the GitHub issues they close ("closes #12")
+CREATE TABLE IF NOT EXISTS pr_issue_links (
+    repository TEXT NOT NULL,
+    pr_number INTEGER NOT NULL,
+    issue_key TEXT NOT NULL, -- "PROJ-123", or "owner/repo#12" for GitHub issues
+    tracker TEXT NOT NULL CHECK(tracker IN ('jira', 'github')),
+    source TEXT NOT NULL CHECK(source IN ('title', 'branch', 'body')),
+    PRIMARY KEY (repository, pr_number, issue_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pr_issue_links_issue_key ON pr_issue_links(issue_key);
+
+-- Linked Jira issues and their status history
+CREATE TABLE IF NOT EXISTS jira_issues (
+    issue_key TEXT PRIMARY KEY,
+    summary TEXT,
+    status TEXT,
+    created_at DATETIME,
+    resolved_at DATETIME,
+    started_at DATETIME, -- First move into one of JIRA_START_STATUSES
+    fetched_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS jira_transitions (
+    issue_key TEXT NOT NULL REFERENCES jira_issues(issue_key) ON DELETE CASCADE,
+    from_status TEXT,
+    to_status TEXT NOT NULL,
+    transitioned_at DATETIME NOT NULL,
+    PRIMARY KEY (issue_key, transitioned_at, to_status)
+);
+
+-- When work started on the PR's earliest linked ticket, and the hours from then until the PR merged
+ALTER TABLE pr_metrics ADD COLUMN ticket_started_at DATETIME;
+ALTER TABLE pr_metrics ADD COLUMN ticket_lead_time_hours INTEGER;
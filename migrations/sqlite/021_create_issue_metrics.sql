@@ -0,0 +1,30 @@
+-- Issues of tracked repositories, one row per team they are attributed to
+-- (through their assignees or the team's issue_labels)
+CREATE TABLE IF NOT EXISTS issue_metrics (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    team_id INTEGER NOT NULL,
+    repository TEXT NOT NULL,
+    issue_number INTEGER NOT NULL,
+    title TEXT,
+    author TEXT,
+    state TEXT NOT NULL, -- 'open' or 'closed'
+    created_at DATETIME NOT NULL,
+    closed_at DATETIME,
+    time_to_close_hours INTEGER,
+    milestone TEXT,
+    assignees_list TEXT, -- JSON array
+    work_type TEXT NOT NULL DEFAULT 'other',
+    attribution_role TEXT NOT NULL CHECK(attribution_role IN ('assignee', 'label', 'both')),
+    FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE,
+    UNIQUE(team_id, repository, issue_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_issue_metrics_team_id ON issue_metrics(team_id);
+CREATE INDEX IF NOT EXISTS idx_issue_metrics_created_at ON issue_metrics(created_at);
+CREATE INDEX IF NOT EXISTS idx_issue_metrics_closed_at ON issue_metrics(closed_at);
+CREATE INDEX IF NOT EXISTS idx_issue_metrics_work_type ON issue_metrics(work_type);
+
+-- The issue or PR a conversation comment belongs to. Comments on PRs are now
+-- stored as 'pull_request' rather than 'issue'; ones collected earlier are
+-- retyped when they are collected again.
+ALTER TABLE comment_metrics ADD COLUMN issue_number INTEGER;
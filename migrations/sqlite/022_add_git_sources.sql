@@ -0,0 +1,10 @@
+-- Repositories can be read from a local clone with git instead of the GitHub API
+ALTER TABLE repositories ADD COLUMN git_path TEXT;
+
+-- Commit identity and churn. Churn is only known for commits read with git;
+-- merges have none of their own.
+ALTER TABLE commit_metrics ADD COLUMN author_email TEXT;
+ALTER TABLE commit_metrics ADD COLUMN additions INTEGER;
+ALTER TABLE commit_metrics ADD COLUMN deletions INTEGER;
+ALTER TABLE commit_metrics ADD COLUMN files_changed INTEGER;
+ALTER TABLE commit_metrics ADD COLUMN is_merge INTEGER;
@@ -0,0 +1,24 @@
+-- Collection jobs: a planner enqueues one per repository (or per repository and
+-- backfill window) and collector workers lease them. A job is 'queued' until
+-- leased, 'running' while its lease lasts, and 'succeeded' or 'dead' (out of
+-- attempts) when done. Running jobs whose lease expired can be leased again.
+CREATE TABLE IF NOT EXISTS collection_jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_key TEXT NOT NULL, -- repository and window; one pending job per key
+    repository TEXT NOT NULL,
+    window_start DATETIME, -- NULL for incremental collection
+    window_end DATETIME,
+    status TEXT NOT NULL DEFAULT 'queued' CHECK(status IN ('queued', 'running', 'succeeded', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    available_at DATETIME NOT NULL, -- not leased before (retry backoff)
+    leased_by TEXT,
+    lease_expires_at DATETIME,
+    last_error TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    finished_at DATETIME
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_collection_jobs_pending_key ON collection_jobs(job_key) WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS idx_collection_jobs_status ON collection_jobs(status, available_at);
@@ -0,0 +1,30 @@
+-- Run health per repository: how its last collection run went. A repository
+-- whose first run failed has no last collection time yet, so that becomes
+-- nullable; SQLite can't drop NOT NULL, so the table is rebuilt.
+CREATE TABLE collection_metadata_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    repository TEXT UNIQUE NOT NULL,
+    last_collected_at DATETIME, -- NULL until a run succeeds
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_run_started_at DATETIME,
+    last_run_finished_at DATETIME,
+    last_run_status TEXT, -- collected, skipped, failed or interrupted
+    last_run_error TEXT
+);
+
+INSERT INTO collection_metadata_new (id, repository, last_collected_at, created_at, updated_at)
+SELECT id, repository, last_collected_at, created_at, updated_at FROM collection_metadata;
+
+DROP TABLE collection_metadata;
+ALTER TABLE collection_metadata_new RENAME TO collection_metadata;
+
+CREATE INDEX IF NOT EXISTS idx_collection_metadata_repository
+    ON collection_metadata(repository);
+
+CREATE TRIGGER IF NOT EXISTS trg_collection_metadata_updated_at
+BEFORE UPDATE ON collection_metadata
+FOR EACH ROW
+BEGIN
+    UPDATE collection_metadata SET updated_at = CURRENT_TIMESTAMP WHERE id = OLD.id;
+END;
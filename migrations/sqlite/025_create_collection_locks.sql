@@ -0,0 +1,14 @@
+-- Named locks held by collector runs, such as 'repository:acme/api', so two
+-- runs never collect the same repository at once. A lock is held until
+-- expires_at and refreshed while its run goes on; an expired lock is stale
+-- (its holder died) and can be taken over. token identifies one acquisition,
+-- so a holder that lost its lock can't release or refresh the new holder's.
+-- On PostgreSQL an advisory lock is the lock itself and the row only records
+-- who holds it.
+CREATE TABLE IF NOT EXISTS collection_locks (
+    name TEXT PRIMARY KEY,
+    owner TEXT NOT NULL, -- host-pid of the holding process
+    token TEXT NOT NULL,
+    acquired_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL
+);
@@ -0,0 +1,16 @@
+-- Deployments of repositories whose deployment_source is 'deployments', read
+-- from their code host's deployments API (GitHub deployments, GitLab
+-- environments), for deployment frequency
+CREATE TABLE IF NOT EXISTS deployments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    repository TEXT NOT NULL,
+    deployment_id INTEGER NOT NULL, -- The code host's ID
+    environment TEXT NOT NULL,
+    ref TEXT,
+    sha TEXT,
+    creator TEXT,
+    created_at DATETIME NOT NULL,
+    UNIQUE(repository, deployment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_deployments_repository_created_at ON deployments(repository, created_at);
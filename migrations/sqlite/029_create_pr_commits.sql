@@ -0,0 +1,10 @@
+-- The commits of collected PRs, including the one a merged PR landed as, so
+-- commit metrics can be filtered by the labels of the PR they belong to
+CREATE TABLE IF NOT EXISTS pr_commits (
+    repository TEXT NOT NULL,
+    pr_number INTEGER NOT NULL,
+    commit_hash TEXT NOT NULL,
+    PRIMARY KEY (repository, pr_number, commit_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pr_commits_commit ON pr_commits(repository, commit_hash);
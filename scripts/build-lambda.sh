@@ -8,11 +8,13 @@ echo "Compiling Go binary..."
 GOOS=linux GOARCH=arm64 CGO_ENABLED=0 go build \
   -tags lambda.norpc \
   -o bootstrap \
-  cmd/collector/main.go
+  ./cmd/collector
 
-# Create deployment package
+# Create deployment package, with the migrations the handler runs when asked
 echo "Creating deployment package..."
+rm -f lambda-deployment.zip
 zip -j lambda-deployment.zip bootstrap
+zip -r lambda-deployment.zip migrations
 
 # Clean up
 rm bootstrap
@@ -47,7 +47,8 @@ resource "aws_iam_role_policy" "github_actions_deploy" {
         Action = [
           "lambda:UpdateFunctionCode",
           "lambda:GetFunction",
-          "lambda:GetFunctionConfiguration"
+          "lambda:GetFunctionConfiguration",
+          "lambda:InvokeFunction" # Runs migrations after deploying
         ]
         Resource = "arn:aws:lambda:*:*:function:dora-metrics-*-collector"
       }
@@ -116,10 +116,12 @@
       }
 
       teamListEl.innerHTML = '';
-      teams.forEach(team => {
+      // Teams come as a tree; sub-teams are listed under their parent, indented
+      const addTeam = (team, depth) => {
         const item = document.createElement('a');
         item.href = '#';
         item.className = 'list-group-item list-group-item-action';
+        item.style.paddingLeft = `${1 + depth * 1.25}rem`;
         item.textContent = team.name || `Team ${team.id}`;
         item.dataset.teamId = team.id;
         item.dataset.teamName = team.name || `Team ${team.id}`;
@@ -129,7 +131,9 @@
           selectTeam(team.id, team.name || `Team ${team.id}`, team.member_count || 0);
         });
         teamListEl.appendChild(item);
-      });
+        (team.children || []).forEach(child => addTeam(child, depth + 1));
+      };
+      teams.forEach(team => addTeam(team, 0));
 
       // Auto-select first team
       if (teams.length > 0) {